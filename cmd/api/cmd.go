@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/handlerutil"
 	"github.com/julienschmidt/httprouter"
 	"github.com/urfave/cli/v2"
 	"net/http"
@@ -29,19 +30,33 @@ func Command() *cli.Command {
 				router.GET("/ResourceTypes/:id", ResourceTypeByIdHandler(app.userResourceType, app.GroupResourceType()))
 
 				router.GET("/Users/:id", GetHandler(app.UserGetService(), app.Logger()))
-				router.GET("/Users", SearchHandler(app.UserQueryService(), app.Logger()))
+				router.GET("/Users", SearchHandler(app.UserQueryService(), app.Logger(), app.QueryOptions()...))
+				router.POST("/Users/.search", SearchHandler(app.UserQueryService(), app.Logger(), app.QueryOptions()...))
 				router.POST("/Users", CreateHandler(app.UserCreateService(), app.Logger()))
 				router.PUT("/Users/:id", ReplaceHandler(app.UserReplaceService(), app.Logger()))
 				router.PATCH("/Users/:id", PatchHandler(app.UserPatchService(), app.Logger()))
 				router.DELETE("/Users/:id", DeleteHandler(app.UserDeleteService(), app.Logger()))
 
 				router.GET("/Groups/:id", GetHandler(app.GroupGetService(), app.Logger()))
-				router.GET("/Groups", SearchHandler(app.GroupQueryService(), app.Logger()))
+				router.GET("/Groups", SearchHandler(app.GroupQueryService(), app.Logger(), app.QueryOptions()...))
+				router.POST("/Groups/.search", SearchHandler(app.GroupQueryService(), app.Logger(), app.QueryOptions()...))
 				router.POST("/Groups", CreateHandler(app.GroupCreateService(), app.Logger()))
 				router.PUT("/Groups/:id", ReplaceHandler(app.GroupReplaceService(), app.Logger()))
 				router.PATCH("/Groups/:id", PatchHandler(app.GroupPatchService(), app.Logger()))
 				router.DELETE("/Groups/:id", DeleteHandler(app.GroupDeleteService(), app.Logger()))
 
+				me, header := app.UserMeService(), args.MeSubjectHeader
+				router.GET("/Me", MeRoute(header, GetHandler(me.Get, app.Logger())))
+				router.POST("/Me", MeRoute(header, CreateHandler(me.Create, app.Logger())))
+				router.PUT("/Me", MeRoute(header, ReplaceHandler(me.Replace, app.Logger())))
+				router.PATCH("/Me", MeRoute(header, PatchHandler(me.Patch, app.Logger())))
+				router.DELETE("/Me", MeRoute(header, DeleteHandler(me.Delete, app.Logger())))
+
+				router.GET("/", SearchHandler(app.RootQueryService(), app.Logger(), app.QueryOptions()...))
+				router.POST("/.search", SearchHandler(app.RootQueryService(), app.Logger(), app.QueryOptions()...))
+
+				router.POST("/Bulk", BulkHandler(app.BulkService(), app.Logger()))
+
 				router.GET("/health", HealthHandler(app.MongoClient(), app.RabbitMQConnection()))
 			}
 
@@ -49,7 +64,7 @@ func Command() *cli.Command {
 				"port": args.httpPort,
 			}).Msg("Listening for incoming requests.")
 
-			return http.ListenAndServe(fmt.Sprintf(":%d", args.httpPort), router)
+			return http.ListenAndServe(fmt.Sprintf(":%d", args.httpPort), handlerutil.Locate(router, app.LocateOptions()...))
 		},
 	}
 }
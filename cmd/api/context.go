@@ -6,6 +6,7 @@ import (
 	scimmongo "github.com/imulab/go-scim/mongo/v2"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
+	"github.com/imulab/go-scim/pkg/v2/handlerutil"
 	"github.com/imulab/go-scim/pkg/v2/service"
 	"github.com/imulab/go-scim/pkg/v2/service/filter"
 	"github.com/imulab/go-scim/pkg/v2/spec"
@@ -26,6 +27,7 @@ type applicationContext struct {
 	groupResourceType         *spec.ResourceType
 	userDatabase              db.DB
 	groupDatabase             db.DB
+	referenceResolver         *filter.ReferenceResolver
 	mongoClient               *mongo.Client
 	registerMongoMetadataOnce sync.Once
 	rabbitMqConn              *amqp.Connection
@@ -42,6 +44,9 @@ type applicationContext struct {
 	groupGetService           service.Get
 	userQueryService          service.Query
 	groupQueryService         service.Query
+	rootQueryService          service.Query
+	bulkService               service.Bulk
+	userMeService             *service.MeEndpoint
 }
 
 func (ctx *applicationContext) Logger() *zerolog.Logger {
@@ -52,9 +57,31 @@ func (ctx *applicationContext) Logger() *zerolog.Logger {
 	return ctx.logger
 }
 
+// Limits advertised when the service provider config is generated.
+const (
+	maxBulkOperations  = 1000    // maximum number of operations in a bulk request
+	maxBulkPayloadSize = 1048576 // maximum size of a bulk request, in bytes
+)
+
 func (ctx *applicationContext) ServiceProviderConfig() *spec.ServiceProviderConfig {
 	if ctx.serviceProviderConfig == nil {
-		spc, err := ctx.args.ParseServiceProviderConfig()
+		var (
+			spc *spec.ServiceProviderConfig
+			err error
+		)
+		if len(ctx.args.ServiceProviderConfigPath) > 0 {
+			spc, err = ctx.args.ParseServiceProviderConfig()
+		} else {
+			// advertise what the services below are wired with: change password is not implemented
+			spc, err = spec.NewServiceProviderConfigBuilder().
+				DocumentationURI("https://github.com/imulab/go-scim").
+				Patch().
+				Bulk(maxBulkOperations, maxBulkPayloadSize).
+				Filter(ctx.args.MaxPageSize).
+				Sort().
+				ETag().
+				Build()
+		}
 		if err != nil {
 			ctx.logInitFailure("service provider config", err)
 			panic(err)
@@ -65,6 +92,31 @@ func (ctx *applicationContext) ServiceProviderConfig() *spec.ServiceProviderConf
 	return ctx.serviceProviderConfig
 }
 
+// QueryOptions returns the options applying the default page size, and the maxResults of the service provider config
+// as the maximum page size, to the query requests.
+func (ctx *applicationContext) QueryOptions() []handlerutil.QueryOptions {
+	return []handlerutil.QueryOptions{
+		handlerutil.DefaultCount(ctx.args.DefaultPageSize),
+		handlerutil.MaxCount(ctx.ServiceProviderConfig().Filter.MaxResults),
+	}
+}
+
+// LocateOptions returns the options deriving the base URL of the locations rendered in responses, according to the
+// base URL, forwarded headers and path only flags.
+func (ctx *applicationContext) LocateOptions() []handlerutil.LocateOptions {
+	var options []handlerutil.LocateOptions
+	if len(ctx.args.BaseURL) > 0 {
+		options = append(options, handlerutil.BaseURL(ctx.args.BaseURL))
+	}
+	if ctx.args.TrustForwardedHeaders {
+		options = append(options, handlerutil.TrustForwardedHeaders())
+	}
+	if ctx.args.PathOnlyLocations {
+		options = append(options, handlerutil.PathOnly())
+	}
+	return options
+}
+
 func (ctx *applicationContext) UserResourceType() *spec.ResourceType {
 	ctx.ensureSchemaRegistered()
 	if ctx.userResourceType == nil {
@@ -97,6 +149,9 @@ func (ctx *applicationContext) GroupResourceType() *spec.ResourceType {
 
 func (ctx *applicationContext) ensureSchemaRegistered() {
 	ctx.registerSchemaOnce.Do(func() {
+		spec.SetWarningHandler(func(warning error) {
+			ctx.Logger().Warn().Err(warning).Msg("schema warning")
+		})
 		if err := ctx.args.RegisterSchemas(); err != nil {
 			ctx.logInitFailure("schema", err)
 			panic(err)
@@ -158,6 +213,17 @@ func (ctx *applicationContext) GroupDatabase() db.DB {
 	return ctx.groupDatabase
 }
 
+// ReferenceResolver enforces the referenceTypes of group members, which may be users or groups.
+func (ctx *applicationContext) ReferenceResolver() *filter.ReferenceResolver {
+	if ctx.referenceResolver == nil {
+		ctx.referenceResolver = filter.NewReferenceResolver().
+			Register(ctx.UserResourceType(), ctx.UserDatabase()).
+			Register(ctx.GroupResourceType(), ctx.GroupDatabase())
+		ctx.logInitialized("reference resolver")
+	}
+	return ctx.referenceResolver
+}
+
 func (ctx *applicationContext) ensureMongoMetadata() {
 	ctx.registerMongoMetadataOnce.Do(func() {
 		if err := ctx.args.MongoDB.RegisterMetadata(); err != nil {
@@ -173,10 +239,11 @@ func (ctx *applicationContext) UserCreateService() service.Create {
 		ctx.userCreateService = service.CreateService(ctx.UserResourceType(), ctx.UserDatabase(), []filter.ByResource{
 			filter.ByPropertyToByResource(
 				filter.ReadOnlyFilter(),
+				ctx.deprecationFilter(),
 				filter.UUIDFilter(),
 				filter.BCryptFilter(),
 			),
-			filter.MetaFilter(),
+			ctx.metaFilter(),
 			filter.ByPropertyToByResource(filter.ValidationFilter(ctx.UserDatabase())),
 		})
 		ctx.logInitialized("user create service")
@@ -190,10 +257,11 @@ func (ctx *applicationContext) GroupCreateService() service.Create {
 			service: service.CreateService(ctx.GroupResourceType(), ctx.GroupDatabase(), []filter.ByResource{
 				filter.ByPropertyToByResource(
 					filter.ReadOnlyFilter(),
+					ctx.deprecationFilter(),
 					filter.UUIDFilter(),
 				),
-				filter.MetaFilter(),
-				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.GroupDatabase())),
+				ctx.metaFilter(),
+				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.GroupDatabase(), filter.EnforceReferenceTypes(ctx.ReferenceResolver()))),
 			}),
 			sender: &groupSyncSender{
 				channel: ctx.RabbitMQChannel(),
@@ -210,10 +278,11 @@ func (ctx *applicationContext) UserReplaceService() service.Replace {
 		ctx.userReplaceService = service.ReplaceService(ctx.ServiceProviderConfig(), ctx.UserResourceType(), ctx.UserDatabase(), []filter.ByResource{
 			filter.ByPropertyToByResource(
 				filter.ReadOnlyFilter(),
+				ctx.deprecationFilter(),
 				filter.BCryptFilter(),
 			),
 			filter.ByPropertyToByResource(filter.ValidationFilter(ctx.UserDatabase())),
-			filter.MetaFilter(),
+			ctx.metaFilter(),
 		})
 		ctx.logInitialized("user replace service")
 	}
@@ -226,9 +295,10 @@ func (ctx *applicationContext) GroupReplaceService() service.Replace {
 			service: service.ReplaceService(ctx.ServiceProviderConfig(), ctx.GroupResourceType(), ctx.GroupDatabase(), []filter.ByResource{
 				filter.ByPropertyToByResource(
 					filter.ReadOnlyFilter(),
+					ctx.deprecationFilter(),
 				),
-				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.UserDatabase())),
-				filter.MetaFilter(),
+				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.UserDatabase(), filter.EnforceReferenceTypes(ctx.ReferenceResolver()))),
+				ctx.metaFilter(),
 			}),
 			sender: &groupSyncSender{
 				channel: ctx.RabbitMQChannel(),
@@ -245,11 +315,12 @@ func (ctx *applicationContext) UserPatchService() service.Patch {
 		ctx.userPatchService = service.PatchService(ctx.ServiceProviderConfig(), ctx.UserDatabase(), []filter.ByResource{}, []filter.ByResource{
 			filter.ByPropertyToByResource(
 				filter.ReadOnlyFilter(),
+				ctx.deprecationFilter(),
 				filter.BCryptFilter(),
 			),
 			filter.ByPropertyToByResource(filter.ValidationFilter(ctx.UserDatabase())),
-			filter.MetaFilter(),
-		})
+			ctx.metaFilter(),
+		}, ctx.patchOptions()...)
 		ctx.logInitialized("user patch service")
 	}
 	return ctx.userPatchService
@@ -261,10 +332,11 @@ func (ctx *applicationContext) GroupPatchService() service.Patch {
 			service: service.PatchService(ctx.ServiceProviderConfig(), ctx.GroupDatabase(), []filter.ByResource{}, []filter.ByResource{
 				filter.ByPropertyToByResource(
 					filter.ReadOnlyFilter(),
+					ctx.deprecationFilter(),
 				),
-				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.GroupDatabase())),
-				filter.MetaFilter(),
-			}),
+				filter.ByPropertyToByResource(filter.ValidationFilter(ctx.GroupDatabase(), filter.EnforceReferenceTypes(ctx.ReferenceResolver()))),
+				ctx.metaFilter(),
+			}, ctx.patchOptions()...),
 			sender: &groupSyncSender{
 				channel: ctx.RabbitMQChannel(),
 				logger:  ctx.Logger(),
@@ -275,6 +347,35 @@ func (ctx *applicationContext) GroupPatchService() service.Patch {
 	return ctx.groupPatchService
 }
 
+// Returns the filter reporting the assignment of attributes annotated with @Deprecated, which are logged, and returned
+// to clients as Warning headers by the handlers.
+func (ctx *applicationContext) deprecationFilter() filter.ByProperty {
+	return filter.DeprecationFilter(filter.OnDeprecation(func(_ context.Context, deprecation filter.Deprecation) {
+		ctx.Logger().Warn().
+			Str("attribute", deprecation.Attribute.ID()).
+			Msg("deprecated attribute assigned")
+	}))
+}
+
+func (ctx *applicationContext) metaFilter() filter.ByResource {
+	var options []filter.MetaOptions
+	if ctx.args.StrongETag {
+		options = append(options, filter.StrongETag())
+	}
+	return filter.MetaFilter(options...)
+}
+
+func (ctx *applicationContext) patchOptions() []service.PatchOptions {
+	var options []service.PatchOptions
+	if ctx.args.AzurePatchCompat {
+		options = append(options, service.AzureCompat())
+	}
+	if ctx.args.MinimalPatchResponse {
+		options = append(options, service.MinimalResponse())
+	}
+	return options
+}
+
 func (ctx *applicationContext) UserDeleteService() service.Delete {
 	if ctx.userDeleteService == nil {
 		ctx.userDeleteService = service.DeleteService(ctx.ServiceProviderConfig(), ctx.UserDatabase())
@@ -315,7 +416,7 @@ func (ctx *applicationContext) GroupGetService() service.Get {
 
 func (ctx *applicationContext) UserQueryService() service.Query {
 	if ctx.userQueryService == nil {
-		ctx.userQueryService = service.QueryService(ctx.ServiceProviderConfig(), ctx.UserDatabase())
+		ctx.userQueryService = service.QueryService(ctx.ServiceProviderConfig(), ctx.UserResourceType(), ctx.UserDatabase())
 		ctx.logInitialized("user query service")
 	}
 	return ctx.userQueryService
@@ -323,12 +424,74 @@ func (ctx *applicationContext) UserQueryService() service.Query {
 
 func (ctx *applicationContext) GroupQueryService() service.Query {
 	if ctx.groupQueryService == nil {
-		ctx.groupQueryService = service.QueryService(ctx.ServiceProviderConfig(), ctx.GroupDatabase())
+		ctx.groupQueryService = service.QueryService(ctx.ServiceProviderConfig(), ctx.GroupResourceType(), ctx.GroupDatabase())
 		ctx.logInitialized("group query service")
 	}
 	return ctx.groupQueryService
 }
 
+func (ctx *applicationContext) RootQueryService() service.Query {
+	if ctx.rootQueryService == nil {
+		ctx.rootQueryService = service.RootQueryService(ctx.ServiceProviderConfig(),
+			[]service.Query{ctx.UserQueryService(), ctx.GroupQueryService()})
+		ctx.logInitialized("root query service")
+	}
+	return ctx.rootQueryService
+}
+
+func (ctx *applicationContext) BulkService() service.Bulk {
+	if ctx.bulkService == nil {
+		ctx.bulkService = service.BulkService(ctx.ServiceProviderConfig(),
+			service.BulkEndpoint{
+				ResourceType: ctx.UserResourceType(),
+				Create:       ctx.UserCreateService(),
+				Replace:      ctx.UserReplaceService(),
+				Patch:        ctx.UserPatchService(),
+				Delete:       ctx.UserDeleteService(),
+			},
+			service.BulkEndpoint{
+				ResourceType: ctx.GroupResourceType(),
+				Create:       ctx.GroupCreateService(),
+				Replace:      ctx.GroupReplaceService(),
+				Patch:        ctx.GroupPatchService(),
+				Delete:       ctx.GroupDeleteService(),
+			},
+		)
+		ctx.logInitialized("bulk service")
+	}
+	return ctx.bulkService
+}
+
+// UserMeService returns the services of the /Me endpoint, which serve the user whose id is in the request header named
+// by the --me-subject-header flag, or respond 501 if the flag is not set.
+func (ctx *applicationContext) UserMeService() service.MeEndpoint {
+	if ctx.userMeService == nil {
+		var resolver service.SubjectResolver
+		if len(ctx.args.MeSubjectHeader) > 0 {
+			resolver = func(c context.Context) (string, error) {
+				id, _ := c.Value(meSubjectKey{}).(string)
+				return id, nil
+			}
+		}
+
+		var options []service.MeOptions
+		if ctx.args.SelfRegistration {
+			options = append(options, service.SelfRegistration())
+		}
+
+		me := service.MeService(service.MeEndpoint{
+			Get:     ctx.UserGetService(),
+			Create:  ctx.UserCreateService(),
+			Replace: ctx.UserReplaceService(),
+			Patch:   ctx.UserPatchService(),
+			Delete:  ctx.UserDeleteService(),
+		}, resolver, options...)
+		ctx.userMeService = &me
+		ctx.logInitialized("user me service")
+	}
+	return *ctx.userMeService
+}
+
 func (ctx *applicationContext) RabbitMQConnection() *amqp.Connection {
 	if ctx.rabbitMqConn == nil {
 		connectCtx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"context"
 	gojson "encoding/json"
 	"errors"
 	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/handlerutil"
 	"github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/service"
+	"github.com/imulab/go-scim/pkg/v2/service/filter"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/zerolog"
@@ -15,6 +17,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 )
 
 // CreateHandler returns a route handler function for creating SCIM resources.
@@ -23,7 +27,8 @@ func CreateHandler(svc service.Create, log *zerolog.Logger) func(rw http.Respons
 		cr, closer := handlerutil.CreateRequest(r)
 		defer closer()
 
-		resp, err := svc.Do(r.Context(), cr)
+		ctx := filter.WithWarnings(r.Context())
+		resp, err := svc.Do(ctx, cr)
 		if err != nil {
 			log.
 				Err(err).
@@ -33,8 +38,9 @@ func CreateHandler(svc service.Create, log *zerolog.Logger) func(rw http.Respons
 		}
 
 		log.Info().Msg("resource created")
+		handlerutil.WriteWarnings(rw, ctx)
 		rw.WriteHeader(201)
-		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource)
+		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource, json.Locate(spec.LocatorFromContext(r.Context())))
 	}
 }
 
@@ -72,16 +78,14 @@ func GetHandler(svc service.Get, log *zerolog.Logger) func(rw http.ResponseWrite
 			return
 		}
 
-		var opt []json.Options
-		if projection != nil {
-			if len(projection.Attributes) > 0 {
-				opt = append(opt, json.Include(projection.Attributes...))
-			}
-			if len(projection.ExcludedAttributes) > 0 {
-				opt = append(opt, json.Exclude(projection.ExcludedAttributes...))
-			}
+		if handlerutil.NotModified(r, resp.Resource) {
+			rw.Header().Set("ETag", resp.Resource.MetaVersionOrEmpty())
+			rw.WriteHeader(http.StatusNotModified)
+			return
 		}
 
+		opt := append(handlerutil.ProjectionOptions(resp.Projection), json.Locate(spec.LocatorFromContext(r.Context())))
+
 		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource, opt...)
 	}
 }
@@ -128,7 +132,8 @@ func ReplaceHandler(svc service.Replace, log *zerolog.Logger) func(rw http.Respo
 		reqFunc, closer := handlerutil.ReplaceRequest(r)
 		defer closer()
 
-		resp, err := svc.Do(r.Context(), reqFunc(id))
+		ctx := filter.WithWarnings(r.Context())
+		resp, err := svc.Do(ctx, reqFunc(id))
 		if err != nil {
 			log.
 				Err(err).
@@ -137,12 +142,13 @@ func ReplaceHandler(svc service.Replace, log *zerolog.Logger) func(rw http.Respo
 			return
 		}
 
+		handlerutil.WriteWarnings(rw, ctx)
 		if !resp.Replaced {
 			rw.WriteHeader(204)
 			return
 		}
 
-		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource)
+		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource, json.Locate(spec.LocatorFromContext(r.Context())))
 	}
 }
 
@@ -162,7 +168,9 @@ func PatchHandler(svc service.Patch, log *zerolog.Logger) func(rw http.ResponseW
 		reqFunc, closer := handlerutil.PatchRequest(r)
 		defer closer()
 
-		resp, err := svc.Do(r.Context(), reqFunc(id))
+		req := reqFunc(id)
+		ctx := filter.WithWarnings(r.Context())
+		resp, err := svc.Do(ctx, req)
 		if err != nil {
 			log.
 				Err(err).
@@ -171,18 +179,77 @@ func PatchHandler(svc service.Patch, log *zerolog.Logger) func(rw http.ResponseW
 			return
 		}
 
-		if !resp.Patched {
+		if len(resp.Quirks) > 0 {
+			log.Info().
+				Str("id", id).
+				Strs("quirks", resp.Quirks).
+				Msg("normalized non-standard patch payload")
+		}
+
+		handlerutil.WriteWarnings(rw, ctx)
+		if len(req.Return) > 0 {
+			rw.Header().Set("Preference-Applied", "return="+req.Return)
+		}
+		if resp.Minimal {
+			handlerutil.WriteResourceHeaders(rw, resp.Resource, json.Locate(spec.LocatorFromContext(r.Context())))
 			rw.WriteHeader(204)
 			return
 		}
 
-		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource)
+		_ = handlerutil.WriteResourceToResponse(rw, resp.Resource, json.Locate(spec.LocatorFromContext(r.Context())))
+	}
+}
+
+// meSubjectKey is the context key of the id of the authenticated user, read from the request header by MeRoute.
+type meSubjectKey struct{}
+
+// MeRoute adapts a route handler of a resource by id to the /Me endpoint, whose services resolve the id of the
+// authenticated user themselves. The id is read from the request header named subjectHeader, if any, and passed to
+// the services through the request context.
+func MeRoute(subjectHeader string, handle httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if len(subjectHeader) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), meSubjectKey{}, r.Header.Get(subjectHeader)))
+		}
+		handle(rw, r, httprouter.Params{{Key: "id", Value: "Me"}})
+	}
+}
+
+// BulkHandler returns a route handler function for executing the operations of a SCIM bulk request. Failures of single
+// operations are reported in the bulk response, hence only failures of the request as a whole result in an error
+// response.
+func BulkHandler(svc service.Bulk, log *zerolog.Logger) func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	return func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		br, closer := handlerutil.BulkRequest(r)
+		defer closer()
+
+		resp, err := svc.Do(r.Context(), br)
+		if err != nil {
+			log.
+				Err(err).
+				Msg("error when executing bulk request")
+			_ = handlerutil.WriteError(rw, err)
+			return
+		}
+
+		for _, op := range resp.Operations {
+			if op.Err != nil {
+				log.
+					Err(op.Err).
+					Str("method", op.Method).
+					Str("bulkId", op.BulkID).
+					Msg("error when executing bulk operation")
+			}
+		}
+
+		log.Info().Int("operations", len(resp.Operations)).Msg("bulk request executed")
+		_ = handlerutil.WriteBulkResponseToResponse(rw, resp, json.Locate(spec.LocatorFromContext(r.Context())))
 	}
 }
 
 // SearchHandler returns a route handler function for searching SCIM resources. This handler could be used in HTTP GET and
-// HTTP POST scenarios, as defined in the SCIM specification.
-func SearchHandler(svc service.Query, log *zerolog.Logger) func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+// HTTP POST scenarios, as defined in the SCIM specification. The options apply the default and maximum page sizes.
+func SearchHandler(svc service.Query, log *zerolog.Logger, options ...handlerutil.QueryOptions) func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	return func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		var (
 			req    *service.QueryRequest
@@ -192,9 +259,9 @@ func SearchHandler(svc service.Query, log *zerolog.Logger) func(rw http.Response
 
 		switch r.Method {
 		case http.MethodGet:
-			req, err = handlerutil.QueryRequestFromGet(r)
+			req, err = handlerutil.QueryRequestFromGet(r, options...)
 		case http.MethodPost:
-			req, closer, err = handlerutil.QueryRequestFromPost(r)
+			req, closer, err = handlerutil.QueryRequestFromPost(r, options...)
 		default:
 			err = errors.New("invalid method configured for search handler")
 		}
@@ -220,17 +287,9 @@ func SearchHandler(svc service.Query, log *zerolog.Logger) func(rw http.Response
 			return
 		}
 
-		var opt []json.Options
-		if resp.Projection != nil {
-			if len(resp.Projection.Attributes) > 0 {
-				opt = append(opt, json.Include(resp.Projection.Attributes...))
-			}
-			if len(resp.Projection.ExcludedAttributes) > 0 {
-				opt = append(opt, json.Exclude(resp.Projection.ExcludedAttributes...))
-			}
-		}
+		opt := append(handlerutil.ProjectionOptions(resp.Projection), json.Locate(spec.LocatorFromContext(r.Context())))
 
-		_ = handlerutil.WriteSearchResultToResponse(rw, resp)
+		_ = handlerutil.WriteSearchResultToResponse(rw, resp, opt...)
 	}
 }
 
@@ -247,43 +306,62 @@ func ServiceProviderConfigHandler(config *spec.ServiceProviderConfig) func(rw ht
 	}
 }
 
-// ResourceTypesHandler returns a route handler function for getting all defined ResourceType.
+// ResourceTypesHandler returns a route handler function for getting all defined ResourceType. The response is cached,
+// and rendered again whenever a schema is registered at runtime.
 func ResourceTypesHandler(resourceTypes ...*spec.ResourceType) func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	result := &service.QueryResponse{
-		TotalResults: len(resourceTypes),
-		StartIndex:   1,
-		ItemsPerPage: len(resourceTypes),
-		Resources:    []json.Serializable{},
-	}
-	for _, resourceType := range resourceTypes {
-		result.Resources = append(result.Resources, json.ResourceTypeToSerializable(resourceType))
-	}
+	var cache atomic.Value
+	render := func() {
+		result := &service.QueryResponse{
+			TotalResults: len(resourceTypes),
+			StartIndex:   1,
+			ItemsPerPage: len(resourceTypes),
+			Resources:    []json.Serializable{},
+		}
+		for _, resourceType := range resourceTypes {
+			result.Resources = append(result.Resources, json.ResourceTypeToSerializable(resourceType))
+		}
 
-	// use recorder to cache render result
-	recorder := httptest.NewRecorder()
-	if err := handlerutil.WriteSearchResultToResponse(recorder, result); err != nil {
-		panic(err)
+		// use recorder to cache render result
+		recorder := httptest.NewRecorder()
+		if err := handlerutil.WriteSearchResultToResponse(recorder, result); err != nil {
+			panic(err)
+		}
+		cache.Store(recorder)
 	}
+	render()
+	spec.Schemas().Subscribe(func(_ *spec.Schema) {
+		render()
+	})
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		recorder := cache.Load().(*httptest.ResponseRecorder)
 		rw.Header().Set("Content-Type", recorder.Header().Get("Content-Type"))
 		_, _ = rw.Write(recorder.Body.Bytes())
 	}
 }
 
-// ResourceTypeByIdHandler returns a route handler function get ResourceType by its id.
+// ResourceTypeByIdHandler returns a route handler function get ResourceType by its id. The response is cached, and
+// rendered again whenever a schema is registered at runtime.
 func ResourceTypeByIdHandler(resourceTypes ...*spec.ResourceType) func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	cache := map[string]gojson.RawMessage{}
-	for _, resourceType := range resourceTypes {
-		raw, err := json.Serialize(json.ResourceTypeToSerializable(resourceType))
-		if err != nil {
-			panic(err)
+	var cache atomic.Value
+	render := func() {
+		rendered := map[string]gojson.RawMessage{}
+		for _, resourceType := range resourceTypes {
+			raw, err := json.Serialize(json.ResourceTypeToSerializable(resourceType))
+			if err != nil {
+				panic(err)
+			}
+			rendered[resourceType.ID()] = raw
 		}
-		cache[resourceType.ID()] = raw
+		cache.Store(rendered)
 	}
+	render()
+	spec.Schemas().Subscribe(func(_ *spec.Schema) {
+		render()
+	})
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		raw, ok := cache[params.ByName("id")]
+		raw, ok := cache.Load().(map[string]gojson.RawMessage)[params.ByName("id")]
 		if !ok {
 			_ = handlerutil.WriteError(rw, fmt.Errorf("%w: resource type is not found", spec.ErrNotFound))
 			return
@@ -294,61 +372,63 @@ func ResourceTypeByIdHandler(resourceTypes ...*spec.ResourceType) func(rw http.R
 	}
 }
 
-// SchemasHandler returns a route handler function for getting all defined Schema.
+// SchemasHandler returns a route handler function for getting all defined Schema. The response is cached, and
+// rendered again whenever a schema is registered at runtime.
 func SchemasHandler() func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	result := &service.QueryResponse{StartIndex: 1, Resources: []json.Serializable{}}
-	if err := spec.Schemas().ForEachSchema(func(schema *spec.Schema) error {
-		if schema.ID() == spec.CoreSchemaId {
-			return nil
-		}
-		result.Resources = append(result.Resources, json.SchemaToSerializable(schema))
-		return nil
-	}); err != nil {
-		panic(err)
-	}
-	result.TotalResults = len(result.Resources)
-	result.ItemsPerPage = len(result.Resources)
+	var cache atomic.Value
+	render := func() {
+		result := handlerutil.ListSchemas()
 
-	// use recorder to cache render result
-	recorder := httptest.NewRecorder()
-	if err := handlerutil.WriteSearchResultToResponse(recorder, result); err != nil {
-		panic(err)
+		// use recorder to cache render result
+		recorder := httptest.NewRecorder()
+		if err := handlerutil.WriteSearchResultToResponse(recorder, result); err != nil {
+			panic(err)
+		}
+		cache.Store(recorder)
 	}
+	render()
+	spec.Schemas().Subscribe(func(_ *spec.Schema) {
+		render()
+	})
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		recorder := cache.Load().(*httptest.ResponseRecorder)
 		rw.Header().Set("Content-Type", recorder.Header().Get("Content-Type"))
 		_, _ = rw.Write(recorder.Body.Bytes())
 	}
 }
 
-// SchemaByIdHandler returns a route handler function get Schema by its id.
+// SchemaByIdHandler returns a route handler function get Schema by its id. The response is cached, and rendered for
+// the schemas registered at runtime as well.
 func SchemaByIdHandler() func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	cache := map[string]gojson.RawMessage{}
-	if err := spec.Schemas().ForEachSchema(func(schema *spec.Schema) error {
-		if schema.ID() == spec.CoreSchemaId {
-			return nil
+	var cache sync.Map
+	render := func(schema *spec.Schema) {
+		serializable, err := handlerutil.GetSchema(schema.ID())
+		if err != nil {
+			return
 		}
 
-		raw, err := json.Serialize(json.SchemaToSerializable(schema))
+		raw, err := json.Serialize(serializable)
 		if err != nil {
-			return err
+			panic(err)
 		}
-		cache[schema.ID()] = raw
-
-		return nil
-	}); err != nil {
-		panic(err)
+		cache.Store(schema.ID(), gojson.RawMessage(raw))
 	}
+	_ = spec.Schemas().ForEachSchema(func(schema *spec.Schema) error {
+		render(schema)
+		return nil
+	})
+	spec.Schemas().Subscribe(render)
 
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		raw, ok := cache[params.ByName("id")]
+		raw, ok := cache.Load(params.ByName("id"))
 		if !ok {
 			_ = handlerutil.WriteError(rw, fmt.Errorf("%w: schema is not found", spec.ErrNotFound))
 			return
 		}
 
 		rw.Header().Set("Content-Type", spec.ApplicationScimJson)
-		_, _ = rw.Write(raw)
+		_, _ = rw.Write(raw.(gojson.RawMessage))
 	}
 }
 
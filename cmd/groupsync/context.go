@@ -84,6 +84,9 @@ func (ctx *applicationContext) GroupResourceType() *spec.ResourceType {
 
 func (ctx *applicationContext) ensureSchemaRegistered() {
 	ctx.registerSchemaOnce.Do(func() {
+		spec.SetWarningHandler(func(warning error) {
+			ctx.Logger().Warn().Err(warning).Msg("schema warning")
+		})
 		if err := ctx.args.RegisterSchemas(); err != nil {
 			ctx.logInitFailure("schema", err)
 			panic(err)
@@ -19,6 +19,26 @@ type Scim struct {
 	GroupResourceTypePath string
 	// Path to the directory containing all schema JSON file
 	SchemasDirectory string
+	// Whether to accept the non-standard patch payloads sent by Azure Active Directory
+	AzurePatchCompat bool
+	// Whether to render meta.version as a strong ETag instead of a weak one
+	StrongETag bool
+	// Whether to respond to patches with 204 No Content, unless the client prefers the representation
+	MinimalPatchResponse bool
+	// Page size of queries without the count parameter, or 0 to use MaxPageSize
+	DefaultPageSize int
+	// Maximum page size of queries, advertised as the maxResults of the generated service provider config
+	MaxPageSize int
+	// Request header carrying the id of the authenticated user, set by an authenticating proxy, or empty to disable /Me
+	MeSubjectHeader string
+	// Whether to allow users to register themselves by POST /Me
+	SelfRegistration bool
+	// Whether to render meta.location and $ref as paths, without the scheme and host of the request
+	PathOnlyLocations bool
+	// Base URL of the service root, i.e. https://scim.example.com/v2, used for locations in place of the request host
+	BaseURL string
+	// Whether to derive locations from the X-Forwarded-* headers, which must then be set by a proxy in front of the server
+	TrustForwardedHeaders bool
 }
 
 // ParseServiceProviderConfig returns an instance of spec.ServiceProviderConfig from the JSON definition at
@@ -28,6 +48,7 @@ func (arg *Scim) ParseServiceProviderConfig() (*spec.ServiceProviderConfig, erro
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	config := new(spec.ServiceProviderConfig)
 	err = json.NewDecoder(f).Decode(config)
@@ -35,6 +56,10 @@ func (arg *Scim) ParseServiceProviderConfig() (*spec.ServiceProviderConfig, erro
 		return nil, err
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -54,15 +79,14 @@ func (arg *Scim) RegisterSchemas() error {
 		if err != nil {
 			return err
 		}
+		defer f.Close()
 
-		schema := new(spec.Schema)
-		err = json.NewDecoder(f).Decode(schema)
+		schema, err := spec.ParseSchema(f)
 		if err != nil {
 			return err
 		}
 
-		spec.Schemas().Register(schema)
-		return nil
+		return spec.Schemas().Register(schema)
 	})
 }
 
@@ -83,14 +107,9 @@ func (arg *Scim) parseResourceType(path string) (*spec.ResourceType, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	rt := new(spec.ResourceType)
-	err = json.NewDecoder(f).Decode(rt)
-	if err != nil {
-		return nil, err
-	}
-
-	return rt, nil
+	return spec.ParseResourceType(f)
 }
 
 func (arg *Scim) Flags() []cli.Flag {
@@ -118,10 +137,70 @@ func (arg *Scim) Flags() []cli.Flag {
 		},
 		&cli.StringFlag{
 			Name:        "service-provider-config",
-			Usage:       "Absolute path to service Provider Config JSON definition",
+			Usage:       "Absolute path to service Provider Config JSON definition, generated from the supported features if omitted",
 			EnvVars:     []string{"SERVICE_PROVIDER_CONFIG"},
-			Required:    true,
 			Destination: &arg.ServiceProviderConfigPath,
 		},
+		&cli.BoolFlag{
+			Name:        "azure-patch-compat",
+			Usage:       "Accept the non-standard patch payloads sent by Azure Active Directory",
+			EnvVars:     []string{"AZURE_PATCH_COMPAT"},
+			Destination: &arg.AzurePatchCompat,
+		},
+		&cli.BoolFlag{
+			Name:        "strong-etag",
+			Usage:       "Render meta.version as a strong ETag instead of a weak one",
+			EnvVars:     []string{"STRONG_ETAG"},
+			Destination: &arg.StrongETag,
+		},
+		&cli.BoolFlag{
+			Name:        "minimal-patch-response",
+			Usage:       "Respond to patches with 204 No Content, unless the client sends Prefer: return=representation",
+			EnvVars:     []string{"MINIMAL_PATCH_RESPONSE"},
+			Destination: &arg.MinimalPatchResponse,
+		},
+		&cli.IntFlag{
+			Name:        "default-page-size",
+			Usage:       "Page size of queries without the count parameter, the maximum page size if 0",
+			EnvVars:     []string{"DEFAULT_PAGE_SIZE"},
+			Destination: &arg.DefaultPageSize,
+		},
+		&cli.IntFlag{
+			Name:        "max-page-size",
+			Usage:       "Maximum page size of queries, larger counts are clamped to it",
+			EnvVars:     []string{"MAX_PAGE_SIZE"},
+			Value:       100,
+			Destination: &arg.MaxPageSize,
+		},
+		&cli.StringFlag{
+			Name:        "me-subject-header",
+			Usage:       "Request header, set by an authenticating proxy, carrying the id of the user served by /Me; /Me responds 501 if empty",
+			EnvVars:     []string{"ME_SUBJECT_HEADER"},
+			Destination: &arg.MeSubjectHeader,
+		},
+		&cli.BoolFlag{
+			Name:        "self-registration",
+			Usage:       "Allow users to register themselves by POST /Me; requires --me-subject-header",
+			EnvVars:     []string{"SELF_REGISTRATION"},
+			Destination: &arg.SelfRegistration,
+		},
+		&cli.BoolFlag{
+			Name:        "path-only-locations",
+			Usage:       "Render meta.location and $ref as paths, without the scheme and host derived from the request",
+			EnvVars:     []string{"PATH_ONLY_LOCATIONS"},
+			Destination: &arg.PathOnlyLocations,
+		},
+		&cli.StringFlag{
+			Name:        "base-url",
+			Usage:       "Base URL of the service root rendered in meta.location and $ref, i.e. https://scim.example.com/v2; derived from each request if empty",
+			EnvVars:     []string{"BASE_URL"},
+			Destination: &arg.BaseURL,
+		},
+		&cli.BoolFlag{
+			Name:        "trust-forwarded-headers",
+			Usage:       "Derive meta.location and $ref from the X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Prefix headers; only enable behind a proxy setting them",
+			EnvVars:     []string{"TRUST_FORWARDED_HEADERS"},
+			Destination: &arg.TrustForwardedHeaders,
+		},
 	}
 }
@@ -37,7 +37,8 @@ import (
 //
 // This implementation also has limited capability to correctly performing sorting according to the specification. The
 // control is not as fine grained as in crud.SeekSortTarget. It can sort on singular type, but may fail if asked to sort
-// on multiValued type, or a singular type within a multiValued type.
+// on multiValued type, or a singular type within a multiValued type. Hence, it implements db.SortCapable, so that the
+// query service sorts in memory those queries that it cannot sort.
 //
 // This implementation do not directly use the SCIM attribute path to persist into MongoDB. Instead, it uses a concept
 // of MongoDB persistence paths (or mongo paths). These mongo paths are introduced to provide an alternative name to
@@ -52,10 +53,10 @@ import (
 // The atomicity of MongoDB is utilized to avoid explicit locking when modifying the resource. When performing Replace
 // (which provides service to SCIM replace and SCIM patch) and Delete operations, the resources id and version is used
 // as the criteria to match a document in store before carrying out the operation. If the provided id and version failed
-// to match a document, a conflict error is returned instead of a notFound error. This is because caller already
+// to match a document, a version mismatch error is returned instead of a notFound error. This is because caller already
 // provided a resource as argument which was fetched from the database, hence, the resource by the id must have existed.
 // The only reason that id and version failed to match would then because another process modified the resource concurrently.
-// Therefore, conflict seems to be a reasonable error code.
+// Therefore, the error is the same failed pre condition as an outdated If-Match header, i.e. spec.ErrVersionMismatch.
 func DB(resourceType *spec.ResourceType, coll *mongo.Collection, opt *DBOptions) db.DB {
 	d := &mongoDB{
 		resourceType: resourceType,
@@ -252,6 +253,31 @@ func (d *mongoDB) mongoPathFor(path string) string {
 	return mp
 }
 
+// CanSort returns true if the sortBy refers to a singular attribute, which is not within a multiValued attribute, and
+// which is always assigned, i.e. required, or readOnly and hence assigned by the server, so that MongoDB, which places
+// missing fields first when sorting in ascending order, sorts as crud.Sort does. Sorting in descending order, missing
+// fields are placed last by MongoDB, hence any singular attribute can be sorted on.
+func (d *mongoDB) CanSort(sort *crud.Sort) bool {
+	cursor, err := expr.CompileSortPath(sort.By)
+	if err != nil {
+		return false
+	}
+	if cursor.Token() == d.resourceType.Schema().ID() {
+		cursor = cursor.Next()
+	}
+	if cursor == nil {
+		return false
+	}
+
+	curAttr := d.superAttr
+	for ; cursor != nil; cursor = cursor.Next() {
+		if curAttr = curAttr.SubAttributeForName(cursor.Token()); curAttr == nil || curAttr.MultiValued() {
+			return false
+		}
+	}
+	return sort.Order == crud.SortDesc || curAttr.Required() || curAttr.Mutability() == spec.MutabilityReadOnly
+}
+
 // Convert the crud.Sort structure to MongoDB driver compatible bson.D structure, so that it can be serialized by the
 // driver. The supplied sort parameter must not be nil. If the sort.By is empty, or sort.By cannot resolve its
 // corresponding MongoDB persistence path, sort is done on the internal "_id" field instead.
@@ -327,7 +353,7 @@ func (d *mongoDB) mongoFilter(filter string) (bson.D, error) {
 }
 
 func (d *mongoDB) errNotFoundOrModified(id string) error {
-	return fmt.Errorf("%w: resource by id '%s' was not found or was modified since by another request", spec.ErrConflict, id)
+	return fmt.Errorf("%w: resource by id '%s' was not found or was modified since by another request", spec.ErrVersionMismatch, id)
 }
 
 // DB options
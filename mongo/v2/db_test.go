@@ -2,7 +2,6 @@ package v2
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
@@ -16,7 +15,6 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
-	"io/ioutil"
 	"os"
 	"os/signal"
 	"strconv"
@@ -607,46 +605,9 @@ func (s *MongoDatabaseTestSuite) setupDockerMongoDB() {
 }
 
 func (s *MongoDatabaseTestSuite) parseResourceType() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
 }
 
 // Clean and disconnect from MongoDB docker container after the suite
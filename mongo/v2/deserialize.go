@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"math"
 	"time"
 )
 
@@ -32,13 +33,32 @@ func (d *deserializer) Resource() *prop.Resource {
 
 func (d *deserializer) UnmarshalBSON(raw []byte) error {
 	vr := bsonrw.NewBSONDocumentReader(raw)
-	return d.deserializeComplex(vr, true)
+	if err := d.deserializeComplex(vr, true); err != nil {
+		return err
+	}
+	return d.computeLocation()
+}
+
+// Computes meta.location, which is not persisted, from the id of the resource. The location stored by the documents
+// written before it stopped being persisted is kept.
+func (d *deserializer) computeLocation() error {
+	id := d.resource.IdOrEmpty()
+	if len(id) == 0 || len(d.resource.MetaLocationOrEmpty()) > 0 {
+		return nil
+	}
+
+	nav := d.resource.Navigator()
+	if nav.Dot("meta").Dot("location").HasError() {
+		// resource types without meta.location
+		return nil
+	}
+	return nav.Replace(d.resource.ResourceType().Location(id)).Error()
 }
 
 func (d *deserializer) deserializeComplex(vr bsonrw.ValueReader, isTopLevel bool) error {
 	// ensure property type
 	p := d.navigator.Current()
-	if p.Attribute().MultiValued() || p.Attribute().Type() != spec.TypeComplex {
+	if p.Attribute().IsMultiValued() || !p.Attribute().IsComplex() {
 		return d.errPropertyType(spec.TypeComplex.String(), d.describeType(p.Attribute()))
 	}
 
@@ -273,22 +293,39 @@ func (d *deserializer) deserializeInteger(vr bsonrw.ValueReader) error {
 		return d.errPropertyType(spec.TypeInteger.String(), d.describeType(p.Attribute()))
 	}
 
-	// ensure value type is int64
-	if vr.Type() != bsontype.Int64 {
-		if vr.Type() == bsontype.Null {
-			_ = vr.ReadNull()
-			_, _ = d.navigator.Current().Delete()
-			return nil
+	// Ensure value type is int64. Documents written by other drivers or tools may store integers as int32 or
+	// double, which are also accepted, as long as the double value is integral. The conversion is done here, since
+	// integer properties only accept integer values.
+	var (
+		v   interface{}
+		err error
+	)
+	switch vr.Type() {
+	case bsontype.Int64:
+		v, err = vr.ReadInt64()
+	case bsontype.Int32:
+		v, err = vr.ReadInt32()
+	case bsontype.Double:
+		var f64 float64
+		if f64, err = vr.ReadDouble(); err == nil {
+			if f64 != math.Trunc(f64) || f64 < math.MinInt64 || f64 >= math.MaxInt64 {
+				return fmt.Errorf("%w: non-integral value %v in document for integer attribute '%s'",
+					spec.ErrInternal, f64, p.Attribute().Path())
+			}
+			v = int64(f64)
 		}
+	case bsontype.Null:
+		_ = vr.ReadNull()
+		_, _ = d.navigator.Current().Delete()
+		return nil
+	default:
 		return d.errInvalidDocType(bsontype.Int64, vr.Type())
 	}
-
-	i64, err := vr.ReadInt64()
 	if err != nil {
 		return d.errReadDoc(err)
 	}
 
-	if _, err := d.navigator.Current().Replace(i64); err != nil {
+	if _, err := d.navigator.Current().Replace(v); err != nil {
 		return err
 	}
 
@@ -302,22 +339,30 @@ func (d *deserializer) deserializeDecimal(vr bsonrw.ValueReader) error {
 		return d.errPropertyType(spec.TypeDecimal.String(), d.describeType(p.Attribute()))
 	}
 
-	// ensure value type is double
-	if vr.Type() != bsontype.Double {
-		if vr.Type() == bsontype.Null {
-			_ = vr.ReadNull()
-			_, _ = d.navigator.Current().Delete()
-			return nil
-		}
+	// Ensure value type is double. Integral decimals stored as int32 or int64 are also accepted.
+	var (
+		v   interface{}
+		err error
+	)
+	switch vr.Type() {
+	case bsontype.Double:
+		v, err = vr.ReadDouble()
+	case bsontype.Int64:
+		v, err = vr.ReadInt64()
+	case bsontype.Int32:
+		v, err = vr.ReadInt32()
+	case bsontype.Null:
+		_ = vr.ReadNull()
+		_, _ = d.navigator.Current().Delete()
+		return nil
+	default:
 		return d.errInvalidDocType(bsontype.Double, vr.Type())
 	}
-
-	f64, err := vr.ReadDouble()
 	if err != nil {
 		return d.errReadDoc(err)
 	}
 
-	if _, err := d.navigator.Current().Replace(f64); err != nil {
+	if _, err := d.navigator.Current().Replace(v); err != nil {
 		return err
 	}
 
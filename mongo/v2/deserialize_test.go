@@ -2,13 +2,13 @@ package v2
 
 import (
 	"encoding/json"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
+	"go.mongodb.org/mongo-driver/bson"
 	"testing"
 )
 
@@ -127,45 +127,151 @@ func (s *MongoDeserializerTestSuite) TestDeserialize() {
 	}
 }
 
-func (s *MongoDeserializerTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
+func (s *MongoDeserializerTestSuite) TestComputedLocation() {
+	r := prop.NewResource(s.resourceType)
+	require.False(s.T(), r.Navigator().Replace(map[string]interface{}{
+		"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"id":       "3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+		"userName": "imulab",
+		"meta": map[string]interface{}{
+			"resourceType": "User",
+			"location":     "/Users/3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+		},
+	}).HasError())
+
+	raw, err := newBsonAdapter(r).MarshalBSON()
+	require.Nil(s.T(), err)
+
+	// not persisted
+	_, err = bson.Raw(raw).LookupErr("meta", "location")
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), "User", bson.Raw(raw).Lookup("meta", "resourceType").StringValue())
+
+	// computed again when read
+	um := newResourceUnmarshaler(s.resourceType)
+	require.Nil(s.T(), um.UnmarshalBSON(raw))
+	assert.Equal(s.T(), "/Users/3cc032f5-2361-417f-9e2f-bc80adddf4a3", um.Resource().MetaLocationOrEmpty())
+
+	// kept when persisted by earlier versions
+	legacy, err := bson.Marshal(bson.D{
+		{Key: "id", Value: "3cc032f5-2361-417f-9e2f-bc80adddf4a3"},
+		{Key: "meta", Value: bson.D{{Key: "location", Value: "https://identity.imulab.io/Users/3cc032f5-2361-417f-9e2f-bc80adddf4a3"}}},
+	})
+	require.Nil(s.T(), err)
+	um = newResourceUnmarshaler(s.resourceType)
+	require.Nil(s.T(), um.UnmarshalBSON(legacy))
+	assert.Equal(s.T(), "https://identity.imulab.io/Users/3cc032f5-2361-417f-9e2f-bc80adddf4a3", um.Resource().MetaLocationOrEmpty())
+}
+
+func (s *MongoDeserializerTestSuite) TestDeserializeNumbers() {
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberSchema), schema))
+	spec.Schemas().Register(schema)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberResourceType), resourceType))
+
+	tests := []struct {
+		name        string
+		doc         bson.D
+		expectCount interface{}
+		expectScore interface{}
+		expectJson  []string
+		expectErr   bool
 	}{
 		{
-			filepath:  "../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
+			name:        "canonical representation",
+			doc:         bson.D{{Key: "count", Value: int64(42)}, {Key: "score", Value: 4.2}},
+			expectCount: int64(42),
+			expectScore: 4.2,
+			expectJson:  []string{`"count":42`, `"score":4.2`},
 		},
 		{
-			filepath:  "../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
+			name:        "integer stored as double",
+			doc:         bson.D{{Key: "count", Value: float64(1234567)}, {Key: "score", Value: float64(1234567)}},
+			expectCount: int64(1234567),
+			expectScore: float64(1234567),
+			expectJson:  []string{`"count":1234567`, `"score":1234567`},
 		},
 		{
-			filepath:  "../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-			},
+			name:        "integer stored as int32 and decimal stored as int64",
+			doc:         bson.D{{Key: "count", Value: int32(7)}, {Key: "score", Value: int64(3)}},
+			expectCount: int64(7),
+			expectScore: float64(3),
+			expectJson:  []string{`"count":7`, `"score":3`},
 		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+		{
+			name:      "non-integral double for integer",
+			doc:       bson.D{{Key: "count", Value: 4.5}},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			raw, err := bson.Marshal(test.doc)
+			require.Nil(t, err)
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+			um := newResourceUnmarshaler(resourceType)
+			err = um.UnmarshalBSON(raw)
+			if test.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, test.expectCount, um.Resource().Navigator().Dot("count").Current().Raw())
+			assert.Equal(t, test.expectScore, um.Resource().Navigator().Dot("score").Current().Raw())
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+			// round trip through the mongo document mapping
+			raw, err = newBsonAdapter(um.Resource()).MarshalBSON()
+			require.Nil(t, err)
+			roundTrip := newResourceUnmarshaler(resourceType)
+			require.Nil(t, roundTrip.UnmarshalBSON(raw))
 
-		if each.post != nil {
-			each.post(each.structure)
-		}
+			j, err := scimjson.Serialize(roundTrip.Resource())
+			require.Nil(t, err)
+			for _, each := range test.expectJson {
+				assert.Contains(t, string(j), each)
+			}
+		})
 	}
 }
+
+func (s *MongoDeserializerTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+}
+
+const (
+	testNumberSchema = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Number",
+  "name": "Number",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Number:count",
+      "name": "count",
+      "type": "integer",
+      "_index": 100,
+      "_path": "count"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Number:score",
+      "name": "score",
+      "type": "decimal",
+      "_index": 101,
+      "_path": "score"
+    }
+  ]
+}
+`
+	testNumberResourceType = `
+{
+  "id": "Number",
+  "name": "Number",
+  "endpoint": "/Numbers",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Number"
+}
+`
+)
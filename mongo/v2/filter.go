@@ -2,13 +2,13 @@ package v2
 
 import (
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // Contrary to the main theme in this package, the methods in this file transforms SCIM filter to an
@@ -341,14 +341,14 @@ func (t transformer) errIncompatibleValue(attr *spec.Attribute) error {
 // Parse the given raw value to the appropriate data type according to the type information in attribute.
 // The attribute will be treated as singleValued even if it is multiValued.
 func (t transformer) parseValue(raw string, attr *spec.Attribute) (interface{}, error) {
-	if attr.Type() == spec.TypeComplex {
+	if attr.IsComplex() {
 		return nil, fmt.Errorf("%w: operations cannot be applied to complex attribute", spec.ErrInvalidFilter)
 	}
 	switch attr.Type() {
 	case spec.TypeString, spec.TypeReference, spec.TypeBinary:
 		return unquote(raw), nil
 	case spec.TypeDateTime:
-		parsed, err := time.Parse(spec.ISO8601, unquote(raw))
+		parsed, err := crud.ParseDateTimeLiteral(unquote(raw))
 		if err != nil {
 			return nil, t.errIncompatibleValue(attr)
 		}
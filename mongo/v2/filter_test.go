@@ -1,14 +1,11 @@
 package v2
 
 import (
-	"encoding/json"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -127,6 +124,15 @@ func (s *TransformFilterTestSuite) TestTransform() {
 				assert.JSONEq(t, expect, extJson)
 			},
 		},
+		{
+			name:   "dateTime ge date only",
+			filter: "meta.created ge \"2019-12-20\"",
+			expect: func(t *testing.T, extJson string, err error) {
+				assert.Nil(t, err)
+				expect := `{"meta.created":{"$gte":{"$date":{"$numberLong":"1576800000000"}}}}`
+				assert.JSONEq(t, expect, extJson)
+			},
+		},
 		{
 			name:   "logical operator",
 			filter: "(userName eq \"imulab\") and (meta.created gt \"2019-12-20T04:40:00\")",
@@ -150,44 +156,7 @@ func (s *TransformFilterTestSuite) TestTransform() {
 }
 
 func (s *TransformFilterTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
 }
@@ -3,6 +3,7 @@ package v2
 import (
 	"context"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,6 +17,10 @@ const (
 	AnnotationMongoIndex = "@MongoIndex"
 )
 
+func init() {
+	annotation.Register(AnnotationMongoIndex, nil)
+}
+
 func (d *mongoDB) ensureIndex() {
 	d.superAttr.DFS(func(a *spec.Attribute) {
 		if a.Uniqueness() == spec.UniquenessNone {
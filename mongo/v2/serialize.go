@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"go.mongodb.org/mongo-driver/bson"
@@ -43,6 +44,10 @@ type serializer struct {
 }
 
 func (s *serializer) ShouldVisit(property prop.Property) bool {
+	// Values computed by the server, i.e. meta.location, are not persisted, but computed again when read.
+	if _, ok := property.Attribute().Annotation(annotation.Computed); ok {
+		return false
+	}
 	// In case of a top level unassigned complex property, which pushes the state mObject onto the frame,
 	// we cannot prevent the complex property itself from calling Visit/BeginComplex/EndComplex,
 	// because we still want it to be serialized as NULL. However, we wouldn't want to visit its sub properties,
@@ -76,7 +81,7 @@ func (s *serializer) Visit(property prop.Property) error {
 		panic("invalid property type")
 	}
 
-	if property.Attribute().Type() != spec.TypeComplex {
+	if !property.Attribute().IsComplex() {
 		s.current().index++
 	}
 
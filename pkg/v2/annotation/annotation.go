@@ -46,6 +46,46 @@ const (
 	ReadOnly = "@ReadOnly"
 	// @Enum annotates attributes that has canonicalValues and enforces its values to the range of the defined
 	// canonicalValues. The defined values will be treated as strings and compared with respect to the caseExact
-	// setting.
+	// setting. The annotation takes a boolean parameter named "normalize": if true, values differing from a canonical
+	// value only by case are accepted regardless of the caseExact setting, and replaced by the canonical spelling.
 	Enum = "@Enum"
+	// @EmitEmpty annotates a multiValued attribute which wishes to be serialized as an empty JSON array, instead of
+	// being omitted, when it is unassigned. It only takes effect when the attribute would otherwise have been returned,
+	// hence it can still be excluded through the attributes and excludedAttributes projection.
+	EmitEmpty = "@EmitEmpty"
+	// @MaxSize annotates a binary attribute to limit the size of its value. The annotation takes an integer parameter
+	// named "bytes", which is the maximum number of bytes of the base64 decoded value. Values exceeding the limit are
+	// rejected upon assignment.
+	MaxSize = "@MaxSize"
+	// @Redact annotates a string attribute whose value is sensitive. The value is stored in full, but masked when
+	// serialized, unless the serializer is explicitly asked not to. The annotation takes an integer parameter named
+	// "keepLast", which is the number of trailing characters left unmasked. If omitted, the entire value is masked.
+	// Only letters and digits are masked, so that the format of values like "123-45-6789" is still recognizable.
+	Redact = "@Redact"
+	// @Internal annotates a schema which is not advertised by the /Schemas endpoint, i.e. a schema only used for
+	// bookkeeping by the server. The core schema of common attributes is always internal.
+	Internal = "@Internal"
+	// @StandardSubAttributes annotates a multiValued complex attribute, or a schema to cover all of its multiValued
+	// complex attributes, which wishes to receive the standard sub attributes defined in RFC7643 section 2.4 (type,
+	// primary, display, value and $ref) when the schema is loaded from JSON. Only the sub attributes not declared are
+	// added, so declared ones always take precedence.
+	StandardSubAttributes = "@StandardSubAttributes"
+	// @Computed annotates an attribute whose value is derived by the server, i.e. meta.location, which is composed from
+	// the endpoint and the id. Its value is still serialized, but it does not take part in the hash and equality of the
+	// properties containing it, so that two resources differing only in computed values are considered the same. It is
+	// not persisted by the mongo database either, which computes it again when reading.
+	Computed = "@Computed"
+	// @Constraints annotates a string, integer or decimal attribute to constrain its values beyond RFC 7643. For string
+	// attributes, the annotation takes the integer parameters "minLength" and "maxLength", which bound the number of
+	// characters, and the string parameter "pattern", which is an RE2 regular expression the value must match. The
+	// pattern is not anchored, hence it shall use ^ and $ to match the whole value. For integer and decimal attributes,
+	// the annotation takes the number parameters "minimum" and "maximum", which bound the value inclusively, unless the
+	// boolean parameters "exclusiveMinimum" or "exclusiveMaximum" are true. All parameters are optional. Values
+	// violating the constraints are rejected upon assignment.
+	Constraints = "@Constraints"
+	// @Deprecated annotates an attribute which clients shall stop using. Assigning it still succeeds, but is reported by
+	// the DeprecationFilter of the service filters, so that servers can warn clients during a transition period. The
+	// annotation takes the optional string parameters "message", i.e. the replacing attribute, and "sunset", which is a
+	// date or an RFC3339 timestamp after which assignments may be rejected. Reading the attribute is unaffected.
+	Deprecated = "@Deprecated"
 )
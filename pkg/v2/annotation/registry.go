@@ -0,0 +1,259 @@
+package annotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Params are the parameters of an annotation, as they appear in the "_annotations" of the JSON definition of a schema,
+// i.e. {"cost": 10} of "@BCrypt". Numbers decoded from JSON are float64, hence the typed getters convert where needed.
+type Params map[string]interface{}
+
+// Int returns the named parameter as an integer. Integral numbers of any type are accepted, as are strings of decimal
+// integers. The returned boolean is false if the parameter is absent or not an integer.
+func (p Params) Int(name string) (int, bool) {
+	switch v := p[name].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	case json.Number:
+		i, err := v.Int64()
+		return int(i), err == nil
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Float returns the named parameter as a float64. Numbers of any type are accepted, as are strings of decimal numbers.
+// The returned boolean is false if the parameter is absent or not a number.
+func (p Params) Float(name string) (float64, bool) {
+	switch v := p[name].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Bool returns the named parameter as a boolean. The returned boolean is false if the parameter is absent or not a
+// boolean.
+func (p Params) Bool(name string) (bool, bool) {
+	v, ok := p[name].(bool)
+	return v, ok
+}
+
+// String returns the named parameter as a string. The returned boolean is false if the parameter is absent or not a
+// string.
+func (p Params) String(name string) (string, bool) {
+	v, ok := p[name].(string)
+	return v, ok
+}
+
+// Time returns the named parameter as a time. Strings of RFC3339 timestamps, i.e. "2027-01-01T00:00:00Z", and of dates,
+// i.e. "2027-01-01", which stand for the start of the day in UTC, are accepted. The returned boolean is false if the
+// parameter is absent or not a time.
+func (p Params) Time(name string) (time.Time, bool) {
+	v, ok := p[name].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Validator checks the parameters of an annotation, and returns an error describing the offending parameter if they
+// are not acceptable. A nil Validator accepts any parameters.
+type Validator func(params Params) error
+
+// Register makes the annotation known, so that schema loading can tell it apart from a misspelled one, and validates its
+// parameters with validator, which may be nil. Registering a name again replaces its validator. Custom annotations,
+// i.e. those driving a custom prop.Subscriber, should be registered before any schema using them is loaded.
+func Register(name string, validator Validator) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.validators[name] = validator
+}
+
+// IsRegistered returns true if the annotation was registered, either as a built-in annotation or by Register.
+func IsRegistered(name string) bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	_, ok := registry.validators[name]
+	return ok
+}
+
+// Validate checks the parameters of the registered annotation with its validator. The error of an unregistered
+// annotation can be told apart by IsRegistered.
+func Validate(name string, params Params) error {
+	registry.RLock()
+	validator, ok := registry.validators[name]
+	registry.RUnlock()
+
+	switch {
+	case !ok:
+		return fmt.Errorf("annotation '%s' is not registered", name)
+	case validator == nil:
+		return nil
+	default:
+		return validator(params)
+	}
+}
+
+var registry = struct {
+	sync.RWMutex
+	validators map[string]Validator
+}{
+	validators: map[string]Validator{},
+}
+
+// Bounds of the bcrypt cost, as defined by golang.org/x/crypto/bcrypt.
+const (
+	minBCryptCost = 4
+	maxBCryptCost = 31
+)
+
+func init() {
+	for _, name := range []string{
+		Primary, ExclusivePrimary, Root, SyncSchema, StateSummary, SchemaExtensionRoot, AutoCompact, Identity, UUID,
+		EmitEmpty, Internal, StandardSubAttributes, Computed,
+	} {
+		Register(name, nil)
+	}
+
+	Register(ElementAnnotations, func(params Params) error {
+		for name, each := range params {
+			if _, ok := each.(map[string]interface{}); !ok {
+				return fmt.Errorf("parameter '%s' must be the parameters of an annotation", name)
+			}
+		}
+		return nil
+	})
+	Register(BCrypt, func(params Params) error {
+		return intRange(params, "cost", minBCryptCost, maxBCryptCost)
+	})
+	Register(ReadOnly, func(params Params) error {
+		return bools(params, "reset", "copy")
+	})
+	Register(Enum, func(params Params) error {
+		return bools(params, "normalize")
+	})
+	Register(MaxSize, func(params Params) error {
+		if _, ok := params["bytes"]; !ok {
+			return fmt.Errorf("parameter 'bytes' is required")
+		}
+		return intRange(params, "bytes", 0, -1)
+	})
+	Register(Redact, func(params Params) error {
+		return intRange(params, "keepLast", 0, -1)
+	})
+	Register(Constraints, func(params Params) error {
+		for _, name := range []string{"minLength", "maxLength"} {
+			if err := intRange(params, name, 0, -1); err != nil {
+				return err
+			}
+		}
+		if min, ok := params.Int("minLength"); ok {
+			if max, ok := params.Int("maxLength"); ok && min > max {
+				return fmt.Errorf("parameter 'minLength' must not be greater than 'maxLength'")
+			}
+		}
+		if _, ok := params["pattern"]; ok {
+			pattern, ok := params.String("pattern")
+			if !ok {
+				return fmt.Errorf("parameter 'pattern' must be a string")
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("parameter 'pattern' is not a valid regular expression: %s", err)
+			}
+		}
+		for _, name := range []string{"minimum", "maximum"} {
+			if _, ok := params[name]; ok {
+				if _, ok := params.Float(name); !ok {
+					return fmt.Errorf("parameter '%s' must be a number", name)
+				}
+			}
+		}
+		if err := bools(params, "exclusiveMinimum", "exclusiveMaximum"); err != nil {
+			return err
+		}
+		if min, ok := params.Float("minimum"); ok {
+			if max, ok := params.Float("maximum"); ok {
+				exclusiveMin, _ := params.Bool("exclusiveMinimum")
+				exclusiveMax, _ := params.Bool("exclusiveMaximum")
+				if min > max || (min == max && (exclusiveMin || exclusiveMax)) {
+					return fmt.Errorf("parameters 'minimum' and 'maximum' leave no value allowed")
+				}
+			}
+		}
+		return nil
+	})
+	Register(Deprecated, func(params Params) error {
+		if _, ok := params["message"]; ok {
+			if _, ok := params.String("message"); !ok {
+				return fmt.Errorf("parameter 'message' must be a string")
+			}
+		}
+		if _, ok := params["sunset"]; ok {
+			if _, ok := params.Time("sunset"); !ok {
+				return fmt.Errorf("parameter 'sunset' must be a date, i.e. 2027-01-01, or an RFC3339 timestamp")
+			}
+		}
+		return nil
+	})
+}
+
+// Checks that the optional parameter, if present, is an integer not less than min, and not greater than max unless max
+// is negative.
+func intRange(params Params, name string, min int, max int) error {
+	if _, ok := params[name]; !ok {
+		return nil
+	}
+	n, ok := params.Int(name)
+	switch {
+	case !ok:
+		return fmt.Errorf("parameter '%s' must be an integer", name)
+	case n < min:
+		return fmt.Errorf("parameter '%s' must not be less than %d", name, min)
+	case max >= 0 && n > max:
+		return fmt.Errorf("parameter '%s' must not be greater than %d", name, max)
+	default:
+		return nil
+	}
+}
+
+// Checks that the optional parameters, if present, are booleans.
+func bools(params Params, names ...string) error {
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			continue
+		}
+		if _, ok := params.Bool(name); !ok {
+			return fmt.Errorf("parameter '%s' must be a boolean", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// CoerceValues returns a PatchOptions that converts the string values of the operations to the integer, decimal or
+// boolean type of the attribute they are assigned to, before each operation is applied, as done by CoerceOperation.
+// Without this option, Patch stays strict and rejects such values with spec.ErrInvalidValue.
+func CoerceValues() PatchOptions {
+	return coerceValues{}
+}
+
+type coerceValues struct{}
+
+func (o coerceValues) apply(p *patcher) {
+	p.coerce = true
+}
+
+var (
+	// The JSON grammar of numbers, which is what an unambiguous number looks like in a string.
+	integerString = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	decimalString = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// Coerce returns the value with the strings assigned to integer, decimal and boolean attributes converted to the type
+// of the attribute, for clients which send "42" instead of 42. Values of complex and multiValued attributes are
+// coerced recursively, and the other values are returned as is, so that they are checked upon assignment as usual.
+//
+// The conversion only happens when it is unambiguous: a string for an integer or decimal attribute must be a number in
+// the JSON grammar, i.e. "42" or "-0.5", but not "042", "+42", " 42" or "0x2A", and an integer must not have a fraction
+// or an exponent. A string for a boolean attribute must be "true" or "false", case insensitive, but not "1" or "yes".
+// Any other string is rejected with spec.ErrInvalidValue naming the attribute.
+func Coerce(attr *spec.Attribute, value interface{}) (interface{}, error) {
+	if attr == nil || value == nil {
+		return value, nil
+	}
+
+	if attr.MultiValued() {
+		elemAttr := attr.DeriveElementAttribute()
+		array, ok := value.([]interface{})
+		if !ok {
+			// single element may be used in place of an array
+			return Coerce(elemAttr, value)
+		}
+		coerced := make([]interface{}, len(array))
+		for i, elem := range array {
+			var err error
+			if coerced[i], err = Coerce(elemAttr, elem); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	}
+
+	if attr.IsComplex() {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		coerced := make(map[string]interface{}, len(object))
+		for k, v := range object {
+			var err error
+			if coerced[k], err = Coerce(attr.SubAttributeForName(k), v); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	switch attr.Type() {
+	case spec.TypeInteger:
+		if integerString.MatchString(s) {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case spec.TypeDecimal:
+		if decimalString.MatchString(s) {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		}
+	case spec.TypeBoolean:
+		switch {
+		case strings.EqualFold(s, "true"):
+			return true, nil
+		case strings.EqualFold(s, "false"):
+			return false, nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("%w: value '%s' for '%s' cannot be coerced to %s", spec.ErrInvalidValue, s, attr.Path(),
+		attr.Type().String())
+}
+
+// CoerceOperation returns the value of the operation coerced with Coerce against the attribute targeted by its path,
+// as resolved by TargetAttribute. This is what Patch does to each operation with the CoerceValues option.
+func CoerceOperation(resource *prop.Resource, op PatchOperation) (interface{}, error) {
+	return coerceOperation(resource, op, compilePath)
+}
+
+func coerceOperation(resource *prop.Resource, op PatchOperation, compile pathCompiler) (interface{}, error) {
+	attr, err := targetAttribute(resource, op.Path, compile)
+	if err != nil {
+		return nil, err
+	}
+	return Coerce(attr, op.Value)
+}
+
+// TargetAttribute returns the attribute of the property targeted by the patch path: the root attribute when there is
+// no path, and the element attribute when the path ends with a filter selecting elements of a multiValued attribute,
+// i.e. emails[type eq "work"].
+func TargetAttribute(resource *prop.Resource, path string) (*spec.Attribute, error) {
+	return targetAttribute(resource, path, compilePath)
+}
+
+func targetAttribute(resource *prop.Resource, path string, compile pathCompiler) (*spec.Attribute, error) {
+	if len(path) == 0 {
+		return resource.RootAttribute(), nil
+	}
+
+	head, err := compile(resource, path)
+	if err != nil {
+		return nil, err
+	}
+	attr, err := resource.ResourceType().AttributeByPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for head != nil && head.Next() != nil {
+		head = head.Next()
+	}
+	if head != nil && head.IsRootOfFilter() && attr.MultiValued() {
+		return attr.DeriveElementAttribute(), nil
+	}
+	return attr, nil
+}
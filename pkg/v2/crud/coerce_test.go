@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerce(t *testing.T) {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Coerce", "Coerce").
+		Attribute(spec.IntegerAttr("seatCount")).
+		Attribute(spec.DecimalAttr("discount")).
+		Attribute(spec.BooleanAttr("active")).
+		Attribute(spec.StringAttr("code")).
+		Attribute(spec.ComplexAttr("licenses", spec.IntegerAttr("seats"), spec.BooleanAttr("primary")).MultiValued()).
+		Build()
+	require.Nil(t, err)
+
+	attrs := map[string]*spec.Attribute{}
+	_ = schema.ForEachAttribute(func(attr *spec.Attribute) error {
+		attrs[attr.Name()] = attr
+		return nil
+	})
+
+	tests := []struct {
+		name   string
+		attr   string
+		value  interface{}
+		expect interface{}
+		err    string
+	}{
+		{name: "integer string", attr: "seatCount", value: "42", expect: int64(42)},
+		{name: "negative integer string", attr: "seatCount", value: "-7", expect: int64(-7)},
+		{name: "decimal string", attr: "discount", value: "0.25", expect: 0.25},
+		{name: "decimal string with exponent", attr: "discount", value: "1e-2", expect: 0.01},
+		{name: "boolean string", attr: "active", value: "true", expect: true},
+		{name: "boolean string in other case", attr: "active", value: "False", expect: false},
+		{name: "string attribute untouched", attr: "code", value: "42", expect: "42"},
+		{name: "number untouched", attr: "seatCount", value: int64(42), expect: int64(42)},
+		{
+			name:   "multiValued complex",
+			attr:   "licenses",
+			value:  []interface{}{map[string]interface{}{"seats": "10", "primary": "true"}},
+			expect: []interface{}{map[string]interface{}{"seats": int64(10), "primary": true}},
+		},
+		{name: "integer with fraction", attr: "seatCount", value: "42.0", err: "value '42.0' for 'seatCount' cannot be coerced to integer"},
+		{name: "integer with leading zero", attr: "seatCount", value: "042", err: "cannot be coerced to integer"},
+		{name: "integer with plus sign", attr: "seatCount", value: "+42", err: "cannot be coerced to integer"},
+		{name: "integer with spaces", attr: "seatCount", value: " 42", err: "cannot be coerced to integer"},
+		{name: "hexadecimal integer", attr: "seatCount", value: "0x2A", err: "cannot be coerced to integer"},
+		{name: "integer overflow", attr: "seatCount", value: "9223372036854775808", err: "cannot be coerced to integer"},
+		{name: "decimal not a number", attr: "discount", value: "NaN", err: "cannot be coerced to decimal"},
+		{name: "boolean as number", attr: "active", value: "1", err: "value '1' for 'active' cannot be coerced to boolean"},
+		{name: "boolean as word", attr: "active", value: "yes", err: "cannot be coerced to boolean"},
+		{
+			name:  "sub attribute of element",
+			attr:  "licenses",
+			value: []interface{}{map[string]interface{}{"seats": "ten"}},
+			err:   "value 'ten' for 'licenses.seats' cannot be coerced to integer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			coerced, err := Coerce(attrs[test.attr], test.value)
+			if len(test.err) > 0 {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, coerced)
+		})
+	}
+}
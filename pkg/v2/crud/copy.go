@@ -0,0 +1,16 @@
+package crud
+
+import (
+	"github.com/imulab/go-scim/pkg/v2/prop"
+)
+
+// Copy returns a deep copy of the resource that is fully independent of the original. Unlike prop.Resource#Clone,
+// whose properties share the subscribers of the original, each property of the copy owns its subscribers, and values
+// are copied without aliasing any slice or sub property. Values are copied as they are: they are not validated or
+// normalized again, hence a resource predating a change of the schema is copied faithfully, and no event is emitted
+// during the process.
+//
+// Copy is suitable for keeping the original resource around for rollback or diffing while the copy is modified.
+func Copy(resource *prop.Resource) *prop.Resource {
+	return resource.Copy()
+}
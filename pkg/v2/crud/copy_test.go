@@ -0,0 +1,170 @@
+package crud
+
+import (
+	"encoding/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	s := new(CopyTestSuite)
+	suite.Run(t, s)
+}
+
+type CopyTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *CopyTestSuite) TestCopy() {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, c *prop.Resource)
+		expect func(t *testing.T, original *prop.Resource, c *prop.Resource)
+	}{
+		{
+			name:   "copy without modification",
+			modify: func(t *testing.T, c *prop.Resource) {},
+			expect: func(t *testing.T, original *prop.Resource, c *prop.Resource) {
+				assert.Equal(t, original.Hash(), c.Hash())
+				assert.Equal(t, original.Navigator().Current().Raw(), c.Navigator().Current().Raw())
+			},
+		},
+		{
+			name: "modify simple attributes of the copy",
+			modify: func(t *testing.T, c *prop.Resource) {
+				assert.Nil(t, c.Navigator().Dot("id").Replace("bar").Error())
+				assert.Nil(t, c.Navigator().Dot("meta").Dot("version").Delete().Error())
+				assert.Nil(t, c.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Replace("654321").Error())
+			},
+			expect: func(t *testing.T, original *prop.Resource, c *prop.Resource) {
+				assert.Equal(t, "foo", original.Navigator().Dot("id").Current().Raw())
+				assert.Equal(t, "v1", original.Navigator().Dot("meta").Dot("version").Current().Raw())
+				assert.Equal(t, "123456", original.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Current().Raw())
+				assert.Equal(t, "bar", c.Navigator().Dot("id").Current().Raw())
+				assert.True(t, c.Navigator().Dot("meta").Dot("version").Current().IsUnassigned())
+			},
+		},
+		{
+			name: "modify multiValued attributes of the copy",
+			modify: func(t *testing.T, c *prop.Resource) {
+				assert.Nil(t, c.Navigator().Dot("schemas").Add("C").Error())
+				assert.Nil(t, c.Navigator().Dot("emails").At(0).Dot("value").Replace("baz@foo.com").Error())
+				assert.Nil(t, c.Navigator().Dot("emails").Add(map[string]interface{}{
+					"value": "qux@foo.com",
+				}).Error())
+			},
+			expect: func(t *testing.T, original *prop.Resource, c *prop.Resource) {
+				assert.Equal(t, []interface{}{"A", "B"}, original.Navigator().Dot("schemas").Current().Raw())
+				assert.Equal(t, 2, original.Navigator().Dot("emails").Current().CountChildren())
+				assert.Equal(t, "foo@bar.com", original.Navigator().Dot("emails").At(0).Dot("value").Current().Raw())
+				assert.Equal(t, []interface{}{"A", "B", "C"}, c.Navigator().Dot("schemas").Current().Raw())
+				assert.Equal(t, 3, c.Navigator().Dot("emails").Current().CountChildren())
+				assert.Equal(t, "baz@foo.com", c.Navigator().Dot("emails").At(0).Dot("value").Current().Raw())
+			},
+		},
+		{
+			name: "subscribers on the copy act on the copy only",
+			modify: func(t *testing.T, c *prop.Resource) {
+				assert.Nil(t, c.Navigator().Dot("emails").At(1).Dot("primary").Replace(true).Error())
+			},
+			expect: func(t *testing.T, original *prop.Resource, c *prop.Resource) {
+				assert.Equal(t, true, original.Navigator().Dot("emails").At(0).Dot("primary").Current().Raw())
+				assert.True(t, original.Navigator().Dot("emails").At(1).Dot("primary").Current().IsUnassigned())
+				assert.NotEqual(t, true, c.Navigator().Dot("emails").At(0).Dot("primary").Current().Raw())
+				assert.Equal(t, true, c.Navigator().Dot("emails").At(1).Dot("primary").Current().Raw())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			original := prop.NewResource(s.resourceType)
+			require.Nil(t, original.Navigator().Replace(map[string]interface{}{
+				"schemas": []interface{}{"A", "B"},
+				"id":      "foo",
+				"meta": map[string]interface{}{
+					"version": "v1",
+				},
+				"emails": []interface{}{
+					map[string]interface{}{
+						"value":   "foo@bar.com",
+						"primary": true,
+					},
+					map[string]interface{}{
+						"value": "bar@foo.com",
+					},
+				},
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+					"employeeNumber": "123456",
+				},
+			}).Error())
+
+			c := Copy(original)
+			test.modify(t, c)
+			test.expect(t, original, c)
+		})
+	}
+}
+
+func (s *CopyTestSuite) TestCopyKeepsValues() {
+	schemaOf := func(annotations string) *spec.Schema {
+		schema := new(spec.Schema)
+		require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "copy",
+  "name": "copy",
+  "attributes": [
+    {
+      "id": "code",
+      "name": "code",
+      "type": "string",
+      "_index": 0,
+      "_path": "code",
+      "_annotations": `+annotations+`
+    }
+  ]
+}
+`), schema))
+		return schema
+	}
+
+	require.Nil(s.T(), spec.Schemas().Register(schemaOf(`{}`)))
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`{"id": "Copy", "name": "Copy", "schema": "copy"}`), resourceType))
+
+	original := prop.NewResource(resourceType)
+	require.Nil(s.T(), original.Navigator().Dot("code").Replace("abcdef").Error())
+
+	// The schema is registered again with a constraint the stored value violates.
+	require.Nil(s.T(), spec.Schemas().Register(schemaOf(`{"@Constraints": {"maxLength": 3}}`)))
+
+	c := Copy(original)
+	assert.Equal(s.T(), "abcdef", c.Navigator().Dot("code").Current().Raw())
+	assert.Nil(s.T(), c.Navigator().Dot("code").Replace("ghijkl").Error())
+	assert.Equal(s.T(), "abcdef", original.Navigator().Dot("code").Current().Raw())
+}
+
+func (s *CopyTestSuite) SetupSuite() {
+	core := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testMainSchema), schema))
+	spec.Schemas().Register(schema)
+
+	schemaExtension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testSchemaExtension), schemaExtension))
+	spec.Schemas().Register(schemaExtension)
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), s.resourceType))
+	Register(s.resourceType)
+}
@@ -1,29 +1,44 @@
 package crud
 
 import (
+	"encoding/binary"
 	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
+	"hash/fnv"
+	"strings"
 )
 
 // Add value to SCIM resource at the given SCIM path. If SCIM path is empty, value will be added
 // to the root of the resource. The supplied value must be compatible with the target property attribute,
 // otherwise error will be returned.
-func Add(resource *prop.Resource, path string, value interface{}) error {
+//
+// When the path targets a multiValued property, the value, which is either an array or a single element, is appended
+// to the existing elements. Elements matching an existing element are not added again. With the SkipIfExists option,
+// elements equal to an existing element, as in Equal, are not added either.
+func Add(resource *prop.Resource, path string, value interface{}, options ...AddOptions) error {
+	a := new(adder)
+	for _, opt := range options {
+		opt.apply(a)
+	}
+	return a.addAt(resource, path, value, compilePath)
+}
+
+func (a *adder) addAt(resource *prop.Resource, path string, value interface{}, compile pathCompiler) error {
 	if len(path) == 0 {
-		return resource.Navigator().Add(value).Error()
+		return a.add(resource.Navigator(), value)
 	}
 
-	head, err := expr.CompilePath(path)
+	head, err := compile(resource, path)
 	if err != nil {
 		return err
 	}
 
 	isFound := false
-	err = defaultTraverse(resource.RootProperty(), skipMainSchemaNamespace(resource, head), func(nav prop.Navigator) error {
+	err = defaultTraverse(resource.RootProperty(), head, func(nav prop.Navigator) error {
 		isFound = true
-		return nav.Add(value).Error()
+		return a.add(nav, value)
 	})
 	if err != nil || isFound {
 		return err
@@ -33,44 +48,252 @@ func Add(resource *prop.Resource, path string, value interface{}) error {
 		if err := nav.Error(); err != nil {
 			return err
 		}
-		nav.Add(value)
+		return a.add(nav, value)
+	}
+	return eqFilterTraverse(value, resource.RootProperty(), head, cb)
+}
+
+// AddOptions customizes the behaviour of Add.
+type AddOptions interface {
+	apply(a *adder)
+}
+
+// SkipIfExists returns an AddOptions that leaves out the elements of the value which are equal to an existing element
+// of the target multiValued property, comparing all their sub properties, so that adding the same element twice, i.e.
+// the same work email with emails[type eq "work"].value, is idempotent. When all elements are left out, Add does
+// nothing. Values of singular properties are added as usual.
+func SkipIfExists() AddOptions {
+	return skipIfExists{}
+}
+
+type skipIfExists struct{}
+
+func (o skipIfExists) apply(a *adder) {
+	a.skipIfExists = true
+}
+
+type adder struct {
+	skipIfExists bool
+}
+
+func (a *adder) add(nav prop.Navigator, value interface{}) error {
+	if a.skipIfExists {
+		if value = withoutExistingElements(nav.Current(), value); value == nil {
+			return nil
+		}
+	}
+	return nav.Add(value).Error()
+}
+
+// Returns the elements of the value not equal to any element of the multiValued property, or nil if there is none. The
+// value is returned as is if the property is not multiValued. Elements which are not compatible with the attribute are
+// kept, so that Add reports them.
+func withoutExistingElements(property prop.Property, value interface{}) interface{} {
+	if !property.Attribute().MultiValued() {
+		return value
+	}
+
+	candidates, ok := value.([]interface{})
+	if !ok {
+		candidates = []interface{}{value}
+	}
+
+	remaining := make([]interface{}, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		elem := prop.NewProperty(property.Attribute().DeriveElementAttribute())
+		if _, err := elem.Add(candidate); err != nil {
+			remaining = append(remaining, candidate)
+			continue
+		}
+		exists := false
+		_ = property.ForEachChild(func(_ int, child prop.Property) error {
+			if !exists && equalProperty(child, elem, false) {
+				exists = true
+			}
+			return nil
+		})
+		if !exists {
+			remaining = append(remaining, candidate)
+		}
+	}
+
+	if len(remaining) == 0 {
 		return nil
 	}
-	return eqFilterTraverse(value, resource.RootProperty(), skipMainSchemaNamespace(resource, head), cb)
+	return remaining
 }
 
 // Replace value in SCIM resource at the given SCIM path. If SCIM path is empty, the root of the resource
 // will be replaced. The supplied value must be compatible with the target property attribute, otherwise
 // error will be returned.
+//
+// When the path targets a multiValued property, the existing elements are all replaced by the value.
 func Replace(resource *prop.Resource, path string, value interface{}) error {
+	return replaceAt(resource, path, value, compilePath)
+}
+
+func replaceAt(resource *prop.Resource, path string, value interface{}, compile pathCompiler) error {
 	if len(path) == 0 {
 		return resource.Navigator().Replace(value).Error()
 	}
 
-	head, err := expr.CompilePath(path)
+	head, err := compile(resource, path)
 	if err != nil {
 		return err
 	}
 
-	return defaultTraverse(resource.RootProperty(), skipMainSchemaNamespace(resource, head), func(nav prop.Navigator) error {
+	return defaultTraverse(resource.RootProperty(), head, func(nav prop.Navigator) error {
 		return nav.Replace(value).Error()
 	})
 }
 
-// Delete value from the SCIM resource at the specified SCIM path. The path cannot be empty.
+// Delete value from the SCIM resource at the specified SCIM path. The path cannot be empty. A path to a sub attribute
+// of a multiValued attribute without filter, i.e. emails.display, removes the sub attribute from every element, leaving
+// the other sub attributes intact.
+//
+// As required by RFC7644 section 3.5.2.2, a multiValued attribute whose elements are all removed, whether by a filter
+// or by removing their only sub attributes, becomes unassigned instead of being left as an empty array, regardless of
+// the @AutoCompact annotation.
 func Delete(resource *prop.Resource, path string) error {
+	return deleteAt(resource, path, compilePath)
+}
+
+func deleteAt(resource *prop.Resource, path string, compile pathCompiler) error {
 	if len(path) == 0 {
 		return fmt.Errorf("%w: path must be specified for delete operation", spec.ErrInvalidPath)
 	}
 
-	head, err := expr.CompilePath(path)
+	head, err := compile(resource, path)
 	if err != nil {
 		return err
 	}
 
-	return defaultTraverse(resource.RootProperty(), skipMainSchemaNamespace(resource, head), func(nav prop.Navigator) error {
+	if err := defaultTraverse(resource.RootProperty(), head, func(nav prop.Navigator) error {
 		return nav.Delete().Error()
+	}); err != nil {
+		return err
+	}
+
+	compactAlong(resource.RootProperty(), head)
+	return nil
+}
+
+// Removes the unassigned elements of the multiValued property on the path, if any, so that it becomes unassigned when
+// all of its elements were deleted.
+func compactAlong(property prop.Property, query *expr.Expression) {
+	nav := prop.Navigate(property)
+	for cursor := query; cursor != nil && cursor.IsPath(); cursor = cursor.Next() {
+		if nav.Dot(cursor.Token()).HasError() {
+			return
+		}
+		if nav.Current().Attribute().MultiValued() {
+			if c, ok := nav.Current().(interface{ Compact() }); ok {
+				c.Compact()
+			}
+			return
+		}
+	}
+}
+
+// Get returns the raw value in the SCIM resource at the given SCIM path, i.e. "name.familyName", or nil if it is
+// unassigned. If SCIM path is empty, the raw value of the resource is returned. An invalid path returns an error.
+//
+// When the path visits more than one property, i.e. "emails[type eq \"work\"].value" matching more than one element,
+// the raw values of the assigned properties are returned as a slice in the order of traversal. A path visiting a single
+// assigned property returns its raw value as is.
+//
+// Get lives in this package, instead of being a method of prop.Resource, because it depends on the filter evaluation
+// of this package, which in turn depends on prop.
+func Get(resource *prop.Resource, path string) (interface{}, error) {
+	if len(path) == 0 {
+		return resource.RootProperty().Raw(), nil
+	}
+
+	head, err := compilePath(resource, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	if err := defaultTraverse(resource.RootProperty(), head, func(nav prop.Navigator) error {
+		if !nav.Current().IsUnassigned() {
+			values = append(values, nav.Current().Raw())
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// SubResourceHash returns the hash of the property at the given SCIM path, computed like Resource#Hash, so that the
+// version of a sub tree can be tracked independently of the rest of the resource, i.e. as the ETag of an endpoint
+// serving the "members" of a group. Changes outside of the path do not change the hash. If SCIM path is empty, the hash
+// of the resource is returned.
+//
+// When the path visits more than one property, i.e. "emails[type eq \"work\"]", their hashes are combined in the
+// order of traversal. An unassigned property hashes to 0.
+func SubResourceHash(resource *prop.Resource, path string) (uint64, error) {
+	if len(path) == 0 {
+		return resource.Hash(), nil
+	}
+
+	head, err := compilePath(resource, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var hashes []uint64
+	err = defaultTraverse(resource.RootProperty(), head, func(nav prop.Navigator) error {
+		hashes = append(hashes, nav.Current().Hash())
+		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(hashes) {
+	case 0:
+		return 0, nil
+	case 1:
+		return hashes[0], nil
+	default:
+		h := fnv.New64a()
+		b := make([]byte, 8)
+		for _, hash := range hashes {
+			binary.LittleEndian.PutUint64(b, hash)
+			_, _ = h.Write(b)
+		}
+		return h.Sum64(), nil
+	}
+}
+
+// pathCompiler compiles a SCIM path against the resource, as compilePath does.
+type pathCompiler func(resource *prop.Resource, path string) (*expr.Expression, error)
+
+// Compiles the SCIM path, and checks that it refers to an attribute of the resource type with
+// spec.ResourceType#AttributeByPath, so that a misspelled path is reported with the segment that does not resolve,
+// instead of silently matching nothing. The main schema URN prefix, if any, is skipped.
+func compilePath(resource *prop.Resource, path string) (*expr.Expression, error) {
+	head, err := expr.CompilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resource.ResourceType().AttributeByPath(path); err != nil {
+		return nil, err
+	}
+	return skipMainSchemaNamespace(resource, head), nil
 }
 
 func skipMainSchemaNamespace(resource *prop.Resource, query *expr.Expression) *expr.Expression {
@@ -78,7 +301,7 @@ func skipMainSchemaNamespace(resource *prop.Resource, query *expr.Expression) *e
 		return nil
 	}
 
-	if query.IsPath() && query.Token() == resource.ResourceType().Schema().ID() {
+	if query.IsPath() && strings.EqualFold(query.Token(), resource.ResourceType().Schema().ID()) {
 		return query.Next()
 	}
 
@@ -3,11 +3,14 @@ package crud
 import (
 	"encoding/json"
 	"errors"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"strconv"
 	"testing"
 )
 
@@ -97,6 +100,86 @@ func (s *CrudTestSuite) TestAdd() {
 				}, r.Navigator().Dot("emails").Current().Raw())
 			},
 		},
+		{
+			name: "add to simple multiValued property appends elements",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("schemas").Add([]interface{}{"A"}).HasError())
+				return r
+			},
+			path:  "schemas",
+			value: []interface{}{"B", "A", "C"},
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{"A", "B", "C"}, r.Navigator().Dot("schemas").Current().Raw())
+			},
+		},
+		{
+			name: "add to complex multiValued property appends elements",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value":   "foo",
+						"primary": true,
+					},
+				}).HasError())
+				return r
+			},
+			path: "emails",
+			value: []interface{}{
+				map[string]interface{}{
+					"value": "bar",
+				},
+				map[string]interface{}{
+					"value": "baz",
+				},
+			},
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value":   "foo",
+						"primary": true,
+					},
+					map[string]interface{}{
+						"value": "bar",
+					},
+					map[string]interface{}{
+						"value": "baz",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
+		{
+			name: "add single object to complex multiValued property appends one element",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+					},
+				}).HasError())
+				return r
+			},
+			path: "emails",
+			value: map[string]interface{}{
+				"value":   "bar",
+				"primary": true,
+			},
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "foo",
+					},
+					map[string]interface{}{
+						"value":   "bar",
+						"primary": true,
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
 		{
 			name: "add to every simple property inside a complex multiValued property",
 			getResource: func(t *testing.T) *prop.Resource {
@@ -188,6 +271,87 @@ func (s *CrudTestSuite) TestAdd() {
 				}, r.Navigator().Dot("emails").Current().Raw())
 			},
 		},
+		{
+			name: "add using eq filter path on a dateTime sub attribute",
+			getResource: func(t *testing.T) *prop.Resource {
+				return prop.NewResource(s.resourceType)
+			},
+			path:  `logins[at eq "2020-01-01T00:00:00"].value`,
+			value: "web",
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "web",
+						"at":    "2020-01-01T00:00:00",
+					},
+				}, r.Navigator().Dot("logins").Current().Raw())
+			},
+		},
+		{
+			name: "add using eq filter path on a dateTime sub attribute with an RFC3339 literal",
+			getResource: func(t *testing.T) *prop.Resource {
+				return prop.NewResource(s.resourceType)
+			},
+			path:  `logins[at eq "2020-01-01T02:00:00+02:00"].value`,
+			value: "web",
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "2020-01-01T00:00:00", r.Navigator().Dot("logins").At(0).Dot("at").Current().Raw())
+			},
+		},
+		{
+			name: "add using eq filter path creates an element with the canonical spelling",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "work",
+					},
+				}).HasError())
+				return r
+			},
+			path:  `emails[type eq "HOME"].value`,
+			value: "bar",
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "work",
+					},
+					map[string]interface{}{
+						"value": "bar",
+						"type":  "home",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
+		{
+			name: "add using eq filter path folds case to match an existing element",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "work",
+					},
+				}).HasError())
+				return r
+			},
+			path:  `emails[type eq "WORK"].value`,
+			value: "bar",
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "bar",
+						"type":  "work",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -199,6 +363,37 @@ func (s *CrudTestSuite) TestAdd() {
 	}
 }
 
+func (s *CrudTestSuite) TestAddSkipIfExists() {
+	workEmail := map[string]interface{}{
+		"value": "foo@bar.com",
+		"type":  "work",
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		value interface{}
+	}{
+		{name: "add through eq filter path", path: `emails[type eq "work"].value`, value: "foo@bar.com"},
+		{name: "add element", path: "emails", value: workEmail},
+		{name: "add array of elements", path: "emails", value: []interface{}{workEmail}},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			r := prop.NewResource(s.resourceType)
+			require.Nil(t, Add(r, test.path, test.value, SkipIfExists()))
+			require.Nil(t, Add(r, test.path, test.value, SkipIfExists()))
+			assert.Equal(t, []interface{}{workEmail}, r.Navigator().Dot("emails").Current().Raw())
+
+			// elements which are not present yet are still added
+			homeEmail := map[string]interface{}{"value": "foo@home.com", "type": "home"}
+			require.Nil(t, Add(r, "emails", []interface{}{workEmail, homeEmail}, SkipIfExists()))
+			assert.Equal(t, []interface{}{workEmail, homeEmail}, r.Navigator().Dot("emails").Current().Raw())
+		})
+	}
+}
+
 func (s *CrudTestSuite) TestReplace() {
 	tests := []struct {
 		name        string
@@ -243,6 +438,50 @@ func (s *CrudTestSuite) TestReplace() {
 				assert.Equal(t, []interface{}{"A"}, r.Navigator().Dot("schemas").Current().Raw())
 			},
 		},
+		{
+			name: "replace simple multiValued property overwrites elements",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("schemas").Add([]interface{}{"A", "B"}).HasError())
+				return r
+			},
+			path:  "schemas",
+			value: []interface{}{"C"},
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{"C"}, r.Navigator().Dot("schemas").Current().Raw())
+			},
+		},
+		{
+			name: "replace complex multiValued property overwrites elements",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value":   "foo",
+						"primary": true,
+					},
+					map[string]interface{}{
+						"value": "bar",
+					},
+				}).HasError())
+				return r
+			},
+			path: "emails",
+			value: []interface{}{
+				map[string]interface{}{
+					"value": "baz",
+				},
+			},
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "baz",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
 		{
 			name: "replace single multiValued property element",
 			getResource: func(t *testing.T) *prop.Resource {
@@ -306,6 +545,44 @@ func (s *CrudTestSuite) TestReplace() {
 				}, r.Navigator().Dot("emails").Current().Raw())
 			},
 		},
+		{
+			name: "replace multiValued property element field with ne filter",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "work",
+					},
+					map[string]interface{}{
+						"value": "bar",
+						"type":  "home",
+					},
+					map[string]interface{}{
+						"value": "baz",
+					},
+				}).HasError())
+				return r
+			},
+			path:  `emails[type ne "work"].value`,
+			value: "qux",
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "work",
+					},
+					map[string]interface{}{
+						"value": "qux",
+						"type":  "home",
+					},
+					map[string]interface{}{
+						"value": "qux",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -447,6 +724,164 @@ func (s *CrudTestSuite) TestDelete() {
 				}, r.Navigator().Dot("emails").Current().Raw())
 			},
 		},
+		{
+			name: "delete sub attribute of every multiValued property element",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value":   "foo",
+						"type":    "work",
+						"primary": true,
+						"display": "Foo",
+					},
+					map[string]interface{}{
+						"value":   "bar",
+						"display": "Bar",
+					},
+					map[string]interface{}{
+						"value": "baz",
+						"type":  "home",
+					},
+				}).HasError())
+				return r
+			},
+			path: `emails.display`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				nav := r.Navigator().Dot("emails")
+				assert.Equal(t, 3, nav.Current().CountChildren())
+				for i, value := range []string{"foo", "bar", "baz"} {
+					assert.True(t, nav.At(i).Dot("display").Current().IsUnassigned())
+					nav.Retract()
+					assert.Equal(t, value, nav.Dot("value").Current().Raw())
+					nav.Retract()
+					nav.Retract()
+				}
+				assert.Equal(t, "work", nav.At(0).Dot("type").Current().Raw())
+				nav.Retract()
+				assert.Equal(t, true, nav.Dot("primary").Current().Raw())
+				nav.Retract()
+				nav.Retract()
+				assert.Equal(t, "home", nav.At(2).Dot("type").Current().Raw())
+			},
+		},
+		{
+			name: "delete multiValued property elements with ne filter",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+						"type":  "home",
+					},
+					map[string]interface{}{
+						"value": "bar",
+						"type":  "work",
+					},
+					map[string]interface{}{
+						"value": "baz",
+					},
+					map[string]interface{}{
+						"value": "qux",
+						"type":  "work",
+					},
+				}).HasError())
+				return r
+			},
+			path: `emails[type ne "work"]`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value": "bar",
+						"type":  "work",
+					},
+					map[string]interface{}{
+						"value": "qux",
+						"type":  "work",
+					},
+				}, r.Navigator().Dot("emails").Current().Raw())
+			},
+		},
+		{
+			name: "delete the only multiValued property element",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+					},
+				}).HasError())
+				return r
+			},
+			path: `emails[value eq "foo"]`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.True(t, r.Navigator().Dot("emails").Current().IsUnassigned())
+
+				raw, err := scimjson.Serialize(r)
+				assert.Nil(t, err)
+				assert.NotContains(t, string(raw), "emails")
+			},
+		},
+		{
+			name: "delete the only field of the only multiValued property element",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add([]interface{}{
+					map[string]interface{}{
+						"value": "foo",
+					},
+				}).HasError())
+				return r
+			},
+			path: `emails.value`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.True(t, r.Navigator().Dot("emails").Current().IsUnassigned())
+			},
+		},
+		{
+			name: "delete all elements of multiValued property without auto compact",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("tags").Add([]interface{}{
+					map[string]interface{}{"value": "foo"},
+					map[string]interface{}{"value": "bar"},
+				}).HasError())
+				return r
+			},
+			path: `tags[value sw "ba"]`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "foo"},
+				}, r.Navigator().Dot("tags").Current().Raw())
+
+				assert.Nil(t, Delete(r, `tags[value eq "foo"]`))
+				assert.True(t, r.Navigator().Dot("tags").Current().IsUnassigned())
+
+				raw, err := scimjson.Serialize(r)
+				assert.Nil(t, err)
+				assert.NotContains(t, string(raw), "tags")
+			},
+		},
+		{
+			name: "delete whole multiValued property",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("tags").Add([]interface{}{
+					map[string]interface{}{"value": "foo"},
+				}).HasError())
+				return r
+			},
+			path: `tags`,
+			expect: func(t *testing.T, r *prop.Resource, err error) {
+				assert.Nil(t, err)
+				assert.True(t, r.Navigator().Dot("tags").Current().IsUnassigned())
+			},
+		},
 		{
 			name: "delete empty path yields error",
 			getResource: func(t *testing.T) *prop.Resource {
@@ -480,6 +915,183 @@ func (s *CrudTestSuite) TestDelete() {
 	}
 }
 
+func (s *CrudTestSuite) TestDeleteManyElements() {
+	r := prop.NewResource(s.resourceType)
+	var emails []interface{}
+	for i := 0; i < 1000; i++ {
+		email := map[string]interface{}{"value": "user" + strconv.Itoa(i) + "@example.com", "type": "work"}
+		if i%3 == 0 {
+			email["type"] = "home"
+		}
+		emails = append(emails, email)
+	}
+	require.False(s.T(), r.Navigator().Dot("emails").Add(emails).HasError())
+
+	require.Nil(s.T(), Delete(r, `emails[type eq "home"]`))
+
+	nav := r.Navigator().Dot("emails")
+	require.Equal(s.T(), 666, nav.Current().CountChildren())
+	n := 0
+	for i := 0; i < 1000; i++ {
+		if i%3 == 0 {
+			continue
+		}
+		assert.Equal(s.T(), "user"+strconv.Itoa(i)+"@example.com", nav.At(n).Dot("value").Current().Raw())
+		nav.Retract()
+		nav.Retract()
+		n++
+	}
+}
+
+func (s *CrudTestSuite) TestGet() {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(s.T(), Add(r, "emails", []interface{}{
+		map[string]interface{}{"value": "foo@work.com", "type": "work", "primary": true},
+		map[string]interface{}{"value": "bar@work.com", "type": "work"},
+		map[string]interface{}{"type": "home"},
+	}))
+	require.Nil(s.T(), Add(r, "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", "6546579"))
+
+	tests := []struct {
+		name   string
+		path   string
+		expect interface{}
+		err    bool
+	}{
+		{name: "single value", path: `emails[primary eq true].value`, expect: "foo@work.com"},
+		{name: "multiple values", path: `emails[type eq "work"].value`, expect: []interface{}{"foo@work.com", "bar@work.com"}},
+		{name: "unassigned value", path: `emails[type eq "home"].value`, expect: nil},
+		{name: "no match", path: `emails[type eq "other"].value`, expect: nil},
+		{name: "extension value", path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", expect: "6546579"},
+		{name: "unassigned property", path: "tags", expect: nil},
+		{name: "unknown attribute", path: "phoneNumbers", err: true},
+		{name: "unknown sub attribute after filter", path: `emails[type eq "work"].vaule`, err: true},
+		{name: "invalid path", path: "emails[type eq", err: true},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			v, err := Get(r, test.path)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, v)
+		})
+	}
+}
+
+func (s *CrudTestSuite) TestMembersByRef() {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Crew", "Crew").
+		Attribute(spec.ComplexAttr("members",
+			spec.StringAttr("value").Annotation(annotation.Identity, nil),
+			spec.ReferenceAttr("$ref", "User"),
+			spec.StringAttr("display"),
+		).MultiValued()).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Crew",
+  "name": "Crew",
+  "endpoint": "/Crews",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Crew"
+}
+`), resourceType))
+	Register(resourceType)
+
+	const (
+		alice = "https://example.com/v2/Users/2819c223"
+		bob   = "https://example.com/v2/Users/902c246b"
+	)
+	r := prop.NewResource(resourceType)
+	require.Nil(s.T(), Add(r, "members", []interface{}{
+		map[string]interface{}{"value": "2819c223", "$ref": alice, "display": "Alice"},
+		map[string]interface{}{"value": "902c246b", "$ref": bob, "display": "Bob"},
+	}))
+
+	ok, err := Evaluate(r, `members.$ref eq "`+bob+`"`)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+
+	require.Nil(s.T(), Replace(r, `members[$ref eq "`+alice+`"].display`, "Alice Smith"))
+	display, err := Get(r, `members[$ref eq "`+alice+`"].display`)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "Alice Smith", display)
+
+	require.Nil(s.T(), Delete(r, `members[$ref eq "`+bob+`"]`))
+	ok, err = Evaluate(r, `members.$ref eq "`+bob+`"`)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+}
+
+func (s *CrudTestSuite) TestSubResourceHash() {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Team", "Team").
+		Attribute(spec.StringAttr("displayName")).
+		Attribute(spec.ComplexAttr("members", spec.StringAttr("value").Annotation(annotation.Identity, nil)).MultiValued()).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Team",
+  "name": "Team",
+  "endpoint": "/Teams",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Team"
+}
+`), resourceType))
+	Register(resourceType)
+
+	r := prop.NewResource(resourceType)
+	require.Nil(s.T(), Replace(r, "displayName", "Engineering"))
+	require.Nil(s.T(), Add(r, "members", []interface{}{
+		map[string]interface{}{"value": "a1"},
+		map[string]interface{}{"value": "b2"},
+	}))
+
+	membersHash, err := SubResourceHash(r, "members")
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), uint64(0), membersHash)
+
+	qualified, err := SubResourceHash(r, "urn:ietf:params:scim:schemas:test:2.0:Team:members")
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), membersHash, qualified)
+
+	s.T().Run("changing displayName does not change members hash", func(t *testing.T) {
+		resourceHash, err := SubResourceHash(r, "")
+		require.Nil(t, err)
+
+		require.Nil(t, Replace(r, "displayName", "Research"))
+
+		h, err := SubResourceHash(r, "members")
+		require.Nil(t, err)
+		assert.Equal(t, membersHash, h)
+
+		h, err = SubResourceHash(r, "")
+		require.Nil(t, err)
+		assert.NotEqual(t, resourceHash, h)
+	})
+
+	s.T().Run("changing members changes members hash", func(t *testing.T) {
+		require.Nil(t, Add(r, "members", map[string]interface{}{"value": "c3"}))
+
+		h, err := SubResourceHash(r, "members")
+		require.Nil(t, err)
+		assert.NotEqual(t, membersHash, h)
+	})
+
+	s.T().Run("invalid path", func(t *testing.T) {
+		_, err := SubResourceHash(r, "owners")
+		assert.NotNil(t, err)
+	})
+}
+
 func (s *CrudTestSuite) SetupSuite() {
 	core := new(spec.Schema)
 	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
@@ -544,7 +1156,24 @@ const (
           "name": "location",
           "type": "reference",
           "_index": 1,
-          "_path": "meta.location"
+          "_path": "meta.location",
+          "_annotations": {
+            "@Computed": {}
+          }
+        },
+        {
+          "id": "meta.created",
+          "name": "created",
+          "type": "dateTime",
+          "_index": 2,
+          "_path": "meta.created"
+        },
+        {
+          "id": "meta.lastModified",
+          "name": "lastModified",
+          "type": "dateTime",
+          "_index": 3,
+          "_path": "meta.lastModified"
         }
       ]
     }
@@ -590,6 +1219,69 @@ const (
           "_annotations": {
             "@Primary": {}
           }
+        },
+        {
+          "id": "emails.type",
+          "name": "type",
+          "type": "string",
+          "canonicalValues": ["work", "home"],
+          "_index": 2,
+          "_path": "emails.type"
+        },
+        {
+          "id": "emails.display",
+          "name": "display",
+          "type": "string",
+          "_index": 3,
+          "_path": "emails.display"
+        }
+      ]
+    },
+    {
+      "id": "tags",
+      "name": "tags",
+      "type": "complex",
+      "multiValued": true,
+      "_index": 101,
+      "_path": "tags",
+      "subAttributes": [
+        {
+          "id": "tags.value",
+          "name": "value",
+          "type": "string",
+          "_index": 0,
+          "_path": "tags.value"
+        }
+      ]
+    },
+    {
+      "id": "loginCount",
+      "name": "loginCount",
+      "type": "integer",
+      "_index": 102,
+      "_path": "loginCount"
+    },
+    {
+      "id": "logins",
+      "name": "logins",
+      "type": "complex",
+      "multiValued": true,
+      "_index": 103,
+      "_path": "logins",
+      "subAttributes": [
+        {
+          "id": "logins.value",
+          "name": "value",
+          "type": "string",
+          "_index": 0,
+          "_path": "logins.value"
+        },
+        {
+          "id": "logins.at",
+          "name": "at",
+          "type": "dateTime",
+          "_index": 1,
+          "_path": "logins.at"
         }
       ]
     }
@@ -605,6 +1297,7 @@ const (
       "id": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
       "name": "employeeNumber",
       "type": "string",
+      "caseExact": true,
       "_index": 100,
       "_path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber"
     }
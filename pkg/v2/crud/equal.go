@@ -0,0 +1,115 @@
+package crud
+
+import (
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Equal returns true if the two resources of the same resource type carry the same data. Attributes annotated with
+// @Computed, i.e. meta.location, are disregarded, since their values are derived by the server rather than carried by
+// the resource.
+//
+// Like EqualIgnoringMeta, all sub properties are compared, elements of multiValued properties are compared regardless
+// of order, and string values are compared with respect to the caseExact setting of the attribute.
+func Equal(a, b *prop.Resource) bool {
+	if a.ResourceType().ID() != b.ResourceType().ID() {
+		return false
+	}
+	return equalChildren(a.RootProperty(), b.RootProperty(), nil, false)
+}
+
+// Identical returns true if the two resources of the same resource type carry the same data spelled the same way. It
+// is like Equal, except that string values are compared case sensitively regardless of the caseExact setting of the
+// attribute, so that a change of case, i.e. of the displayName, is told apart. It is useful to tell whether a patch
+// actually changes anything.
+func Identical(a, b *prop.Resource) bool {
+	if a.ResourceType().ID() != b.ResourceType().ID() {
+		return false
+	}
+	return equalChildren(a.RootProperty(), b.RootProperty(), nil, true)
+}
+
+// EqualIgnoringMeta returns true if the two resources of the same resource type carry the same data, disregarding the
+// server managed top level attributes "id" and "meta". It is useful to tell whether an update actually changes anything.
+//
+// Unlike prop.Property#Matches, which compares the identity of complex properties, all sub properties are compared.
+// Elements of multiValued properties are compared regardless of order, and string values are compared with respect to
+// the caseExact setting of the attribute.
+func EqualIgnoringMeta(a, b *prop.Resource) bool {
+	if a.ResourceType().ID() != b.ResourceType().ID() {
+		return false
+	}
+	return equalChildren(a.RootProperty(), b.RootProperty(), func(child prop.Property) bool {
+		switch child.Attribute().Path() {
+		case "id", "meta":
+			return true
+		}
+		return false
+	}, false)
+}
+
+// Returns true if the sub properties of the complex properties are equal, except for computed ones, and those for which
+// the optional skip returns true. When exact, string values must be spelled the same.
+func equalChildren(a, b prop.Property, skip func(child prop.Property) bool, exact bool) bool {
+	equal := true
+	_ = a.ForEachChild(func(_ int, child prop.Property) error {
+		if _, ok := child.Attribute().Annotation(annotation.Computed); ok {
+			return nil
+		}
+		if skip != nil && skip(child) {
+			return nil
+		}
+		other, err := b.ChildAtIndex(child.Attribute().Name())
+		if err != nil || other == nil || !equalProperty(child, other, exact) {
+			equal = false
+		}
+		return nil
+	})
+	return equal
+}
+
+func equalProperty(a, b prop.Property, exact bool) bool {
+	if a.IsUnassigned() || b.IsUnassigned() {
+		return a.IsUnassigned() && b.IsUnassigned()
+	}
+
+	switch {
+	case a.Attribute().MultiValued():
+		return equalElements(a, b, exact)
+	case a.Attribute().IsComplex():
+		return equalChildren(a, b, nil, exact)
+	case exact && a.Attribute().Type() == spec.TypeString:
+		return a.Raw() == b.Raw()
+	default:
+		if eq, ok := a.(prop.EqCapable); ok {
+			return eq.EqualsTo(b.Raw())
+		}
+		return a.Matches(b)
+	}
+}
+
+// Returns true if each element of a equals to a distinct element of b, regardless of order.
+func equalElements(a, b prop.Property, exact bool) bool {
+	if a.CountChildren() != b.CountChildren() {
+		return false
+	}
+
+	matched := make([]bool, b.CountChildren())
+	equal := true
+	_ = a.ForEachChild(func(_ int, elem prop.Property) error {
+		found := false
+		_ = b.ForEachChild(func(j int, other prop.Property) error {
+			if !found && !matched[j] && equalProperty(elem, other, exact) {
+				matched[j] = true
+				found = true
+			}
+			return nil
+		})
+		if !found {
+			equal = false
+		}
+		return nil
+	})
+	return equal
+}
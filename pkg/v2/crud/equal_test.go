@@ -0,0 +1,249 @@
+package crud
+
+import (
+	"encoding/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestEqualIgnoringMeta(t *testing.T) {
+	s := new(EqualTestSuite)
+	suite.Run(t, s)
+}
+
+type EqualTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *EqualTestSuite) TestEqualIgnoringMeta() {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, r *prop.Resource)
+		expect bool
+	}{
+		{
+			name:   "identical",
+			modify: func(t *testing.T, r *prop.Resource) {},
+			expect: true,
+		},
+		{
+			name: "only meta.lastModified differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("lastModified").Replace("2020-01-01T00:00:00").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "only meta differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("created").Replace("2020-01-01T00:00:00").Error())
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("version").Replace("v2").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "only id differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("id").Replace("bar").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "multiValued elements in different order",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("emails").Replace([]interface{}{
+					map[string]interface{}{
+						"value": "bar@foo.com",
+						"type":  "home",
+					},
+					map[string]interface{}{
+						"value":   "foo@bar.com",
+						"type":    "work",
+						"primary": true,
+					},
+				}).Error())
+				assert.Nil(t, r.Navigator().Dot("schemas").Replace([]interface{}{"B", "A"}).Error())
+			},
+			expect: true,
+		},
+		{
+			name: "string differs in case only when caseExact is false",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("emails").At(0).Dot("type").Replace("WORK").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "string differs in case when caseExact is true",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Replace("123456A").Error())
+			},
+			expect: false,
+		},
+		{
+			name: "sub attribute outside of identity differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("emails").At(1).Dot("type").Replace("other").Error())
+			},
+			expect: false,
+		},
+		{
+			name: "multiValued element removed",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("emails").At(1).Delete().Error())
+			},
+			expect: false,
+		},
+		{
+			name: "schema extension differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Delete().Error())
+			},
+			expect: false,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			a, b := s.newResource(t), s.newResource(t)
+			test.modify(t, b)
+			assert.Equal(t, test.expect, EqualIgnoringMeta(a, b))
+			assert.Equal(t, test.expect, EqualIgnoringMeta(b, a))
+		})
+	}
+}
+
+func (s *EqualTestSuite) TestEqual() {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, r *prop.Resource)
+		expect bool
+	}{
+		{
+			name:   "identical",
+			modify: func(t *testing.T, r *prop.Resource) {},
+			expect: true,
+		},
+		{
+			name: "only computed meta.location differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("location").Replace("https://example.com/Users/foo").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "meta.lastModified differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("lastModified").Replace("2020-01-01T00:00:00").Error())
+			},
+			expect: false,
+		},
+		{
+			name: "id differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("id").Replace("bar").Error())
+			},
+			expect: false,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			a, b := s.newResource(t), s.newResource(t)
+			test.modify(t, b)
+			assert.Equal(t, test.expect, Equal(a, b))
+			assert.Equal(t, test.expect, Equal(b, a))
+			assert.Equal(t, test.expect, a.Hash() == b.Hash())
+		})
+	}
+}
+
+func (s *EqualTestSuite) TestIdentical() {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, r *prop.Resource)
+		expect bool
+	}{
+		{
+			name:   "identical",
+			modify: func(t *testing.T, r *prop.Resource) {},
+			expect: true,
+		},
+		{
+			name: "only computed meta.location differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("meta").Dot("location").Replace("https://example.com/Users/foo").Error())
+			},
+			expect: true,
+		},
+		{
+			name: "case of a value differs",
+			modify: func(t *testing.T, r *prop.Resource) {
+				assert.Nil(t, r.Navigator().Dot("emails").At(0).Dot("type").Replace("WORK").Error())
+			},
+			expect: false,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			a, b := s.newResource(t), s.newResource(t)
+			test.modify(t, b)
+			assert.Equal(t, test.expect, Identical(a, b))
+			assert.Equal(t, test.expect, Identical(b, a))
+		})
+	}
+}
+
+func (s *EqualTestSuite) newResource(t *testing.T) *prop.Resource {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(t, r.Navigator().Replace(map[string]interface{}{
+		"schemas": []interface{}{"A", "B"},
+		"id":      "foo",
+		"meta": map[string]interface{}{
+			"version":      "v1",
+			"created":      "2019-11-20T13:09:00",
+			"lastModified": "2019-11-20T13:09:00",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{
+				"value":   "foo@bar.com",
+				"type":    "work",
+				"primary": true,
+			},
+			map[string]interface{}{
+				"value": "bar@foo.com",
+				"type":  "home",
+			},
+		},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "123456a",
+		},
+	}).Error())
+	return r
+}
+
+func (s *EqualTestSuite) SetupSuite() {
+	core := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testMainSchema), schema))
+	spec.Schemas().Register(schema)
+
+	schemaExtension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testSchemaExtension), schemaExtension))
+	spec.Schemas().Register(schemaExtension)
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), s.resourceType))
+	Register(s.resourceType)
+}
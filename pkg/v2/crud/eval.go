@@ -8,30 +8,94 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// EvaluateOptions customizes the behaviour of Evaluate.
+type EvaluateOptions interface {
+	apply(v *evaluator)
+}
+
+// WithResolver returns an EvaluateOptions that supplies the values of virtual attributes, which are computed rather
+// than stored on the resource. When the filter compares an unassigned property, the resolver is invoked with the path
+// of its attribute, as in spec.Attribute#Path, i.e. "fullName" or "name.formatted". If the resolver returns true, the
+// comparison is made against the returned value, which must be compatible with the attribute, as if it were assigned
+// to the property. Otherwise, the property remains unassigned. The resource is never modified.
+//
+// Virtual attributes must be defined by the schema, so that their values are compared according to the attribute
+// type and characteristics. Note that the resolver is not invoked for sub attributes of a multiValued attribute
+// without elements, because the filter path does not reach any property in that case.
+func WithResolver(resolver func(path string) (interface{}, bool)) EvaluateOptions {
+	return evalResolver{resolver: resolver}
+}
+
+type evalResolver struct {
+	resolver func(path string) (interface{}, bool)
+}
+
+func (o evalResolver) apply(v *evaluator) {
+	v.resolver = o.resolver
+}
+
+// AllowNullLiteral returns an EvaluateOptions that compiles the filter with expr.AllowNullLiteral, so that "title eq null"
+// is evaluated as "not (title pr)" and "title ne null" as "title pr". This is a compatibility extension of RFC7644.
+// Expressions compiled with expr.AllowNullLiteral are evaluated the same way by EvaluateExpressionOnProperty, with or
+// without this option.
+func AllowNullLiteral() EvaluateOptions {
+	return evalCompileOptions{options: []expr.CompileOptions{expr.AllowNullLiteral()}}
+}
+
+type evalCompileOptions struct {
+	options []expr.CompileOptions
+}
+
+func (o evalCompileOptions) apply(v *evaluator) {
+	v.compileOptions = append(v.compileOptions, o.options...)
+}
+
 // Evaluate the resource with the given SCIM filter and return the boolean result or an error.
-func Evaluate(resource *prop.Resource, filter string) (bool, error) {
-	cf, err := expr.CompileFilter(filter)
+func Evaluate(resource *prop.Resource, filter string, options ...EvaluateOptions) (bool, error) {
+	v := newEvaluator(resource.RootProperty(), nil, options)
+	cf, err := expr.CompileFilter(filter, v.compileOptions...)
 	if err != nil {
 		return false, err
 	}
-	return evaluator{
-		base:   resource.RootProperty(),
-		filter: cf,
-	}.evaluate()
+	v.filter = cf
+	return v.evaluate()
 }
 
-func EvaluateExpressionOnProperty(prop prop.Property, expr *expr.Expression) (bool, error) {
-	return evaluator{
-		base:   prop,
-		filter: expr,
-	}.evaluate()
+func EvaluateExpressionOnProperty(prop prop.Property, expr *expr.Expression, options ...EvaluateOptions) (bool, error) {
+	return newEvaluator(prop, expr, options).evaluate()
+}
+
+// EvaluateAt evaluates the filter against the property currently focused by the navigator, i.e. an element of a
+// multiValued complex attribute, so that custom traversal logic can decide whether to operate on it. Attribute paths
+// in the filter are relative to the focused property, i.e. `type eq "work"` against an element of emails. This is how
+// the elements qualified by a filter in a path, as in emails[type eq "work"], are determined. The error of the
+// navigator, if any, is returned.
+func EvaluateAt(nav prop.Navigator, filter *expr.Expression) (bool, error) {
+	if nav.HasError() {
+		return false, nav.Error()
+	}
+	return evaluator{base: nav.Current(), filter: filter}.evaluate()
+}
+
+func newEvaluator(base prop.Property, filter *expr.Expression, options []EvaluateOptions) evaluator {
+	v := evaluator{
+		base:   base,
+		filter: filter,
+	}
+	for _, opt := range options {
+		opt.apply(&v)
+	}
+	return v
 }
 
 type evaluator struct {
-	base   prop.Property
-	filter *expr.Expression
+	base           prop.Property
+	filter         *expr.Expression
+	resolver       func(path string) (interface{}, bool)
+	compileOptions []expr.CompileOptions
 }
 
 func (v evaluator) evaluate() (bool, error) {
@@ -52,6 +116,121 @@ func (v evaluator) evalAny(p prop.Property, op *expr.Expression) (bool, error) {
 		return false, fmt.Errorf("%w: nested filter detected", spec.ErrInvalidFilter)
 	}
 
+	if op.Right() != nil && op.Right().IsNull() {
+		return v.evalNull(p, op)
+	}
+
+	if r, ok := v.evalSimpleEq(p, op); ok {
+		return r, nil
+	}
+
+	if err := checkSubstringOperand(p, op); err != nil {
+		return false, err
+	}
+
+	return v.evalRelational(p, op)
+}
+
+// Rejects the substring operators 'sw', 'ew' and 'co' on attributes which are not strings or references, i.e.
+// meta.created co "2023", which would otherwise compare a stringified dateTime. The attribute is derived from the
+// path of the filter, so that the filter is rejected regardless of whether the resource has a value for it. Paths
+// that do not name an attribute are left to the traversal to report.
+func checkSubstringOperand(p prop.Property, op *expr.Expression) error {
+	switch op.Token() {
+	case expr.Sw, expr.Ew, expr.Co:
+	default:
+		return nil
+	}
+
+	attr := p.Attribute()
+	for step := op.Left(); step != nil; step = step.Next() {
+		if attr = attr.SubAttributeForName(step.Token()); attr == nil {
+			return nil
+		}
+	}
+
+	switch attr.Type() {
+	case spec.TypeString, spec.TypeReference:
+		return nil
+	default:
+		return fmt.Errorf("%w: operator '%s' cannot be applied to %s attribute '%s'",
+			spec.ErrInvalidFilter, op.Token(), attr.Type().String(), attr.Path())
+	}
+}
+
+// Evaluates a comparison against the null literal, which is a compatibility extension enabled by expr.AllowNullLiteral.
+// "eq null" is true when the path visits no present property, as "not (path pr)" is; "ne null" is its negation. Null
+// cannot be compared with other operators.
+func (v evaluator) evalNull(p prop.Property, op *expr.Expression) (bool, error) {
+	if op.Token() != expr.Eq && op.Token() != expr.Ne {
+		return false, fmt.Errorf("%w: null can only be compared with '%s' or '%s'", spec.ErrInvalidFilter, expr.Eq, expr.Ne)
+	}
+
+	present, err := v.collect(p, op.Left(), func(target prop.Property) (bool, error) {
+		return v.evalPr(target)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if op.Token() == expr.Eq {
+		return !present, nil
+	}
+	return present, nil
+}
+
+// Fast path for the most common form of filters: a single eq on a singular non-complex attribute directly
+// beneath the property, i.e. userName eq "imulab". Such filter leads to exactly one comparison, hence the traversal
+// machinery and the collection of intermediate results can be skipped. The second return value is false if the fast
+// path does not apply or the comparison produced an error, in which case the caller should fall back to evalRelational.
+func (v evaluator) evalSimpleEq(p prop.Property, op *expr.Expression) (bool, bool) {
+	if op.Token() != expr.Eq || op.Left() == nil || op.Left().Next() != nil {
+		return false, false
+	}
+
+	if !p.Attribute().IsComplex() || p.Attribute().IsMultiValued() {
+		return false, false
+	}
+
+	child, err := p.ChildAtIndex(op.Left().Token())
+	if err != nil || child.Attribute().IsComplex() || child.Attribute().IsMultiValued() {
+		return false, false
+	}
+
+	child, err = v.resolve(child)
+	if err != nil {
+		return false, false
+	}
+
+	r, err := v.evalEq(child, op)
+	if err != nil {
+		return false, false
+	}
+	return r, true
+}
+
+// Returns the property to be compared in place of target. Unless the target is unassigned and the resolver supplies
+// a value for its attribute, the target itself is returned. Otherwise, the value is assigned to a new property of the
+// same attribute, leaving the resource untouched.
+func (v evaluator) resolve(target prop.Property) (prop.Property, error) {
+	if v.resolver == nil || !target.IsUnassigned() {
+		return target, nil
+	}
+
+	value, ok := v.resolver(target.Attribute().Path())
+	if !ok {
+		return target, nil
+	}
+
+	resolved := prop.NewProperty(target.Attribute())
+	if _, err := resolved.Replace(value); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// General path for evaluating a relational operator, whose path may visit any number of properties.
+func (v evaluator) evalRelational(p prop.Property, op *expr.Expression) (bool, error) {
 	// Normally, we are expecting a single boolean result. For instance, conventional filters like
 	//
 	//		userName eq "imulab"
@@ -81,37 +260,51 @@ func (v evaluator) evalAny(p prop.Property, op *expr.Expression) (bool, error) {
 	//
 	// This filter leads to two comparisons of "user1@foo.com" sw "user1", and "user2@foo.com" sw "user1" respectively,
 	// which produces "true" and "false". As a result, this resource should pass the filter.
-	var results = make([]bool, 0)
-	if err := defaultTraverse(p, op.Left(), func(nav prop.Navigator) (fe error) {
-		var r bool
-
+	return v.collect(p, op.Left(), func(target prop.Property) (bool, error) {
 		switch op.Token() {
 		case expr.Eq:
-			r, fe = v.evalEq(nav.Current(), op)
+			return v.evalEq(target, op)
 		case expr.Ne:
-			r, fe = v.evalNe(nav.Current(), op)
+			return v.evalNe(target, op)
 		case expr.Sw:
-			r, fe = v.evalSw(nav.Current(), op)
+			return v.evalSw(target, op)
 		case expr.Ew:
-			r, fe = v.evalEw(nav.Current(), op)
+			return v.evalEw(target, op)
 		case expr.Co:
-			r, fe = v.evalCo(nav.Current(), op)
+			return v.evalCo(target, op)
 		case expr.Gt:
-			r, fe = v.evalGt(nav.Current(), op)
+			return v.evalGt(target, op)
 		case expr.Ge:
-			r, fe = v.evalGe(nav.Current(), op)
+			return v.evalGe(target, op)
 		case expr.Lt:
-			r, fe = v.evalLt(nav.Current(), op)
+			return v.evalLt(target, op)
 		case expr.Le:
-			r, fe = v.evalLe(nav.Current(), op)
+			return v.evalLe(target, op)
 		case expr.Pr:
-			r, fe = v.evalPr(nav.Current())
+			return v.evalPr(target)
 		default:
 			panic("unsupported operator")
 		}
+	})
+}
+
+// Traverses the path from the property and compares each visited property, after resolution, and returns true as long
+// as one of the comparisons is true.
+func (v evaluator) collect(p prop.Property, path *expr.Expression, compare func(target prop.Property) (bool, error)) (bool, error) {
+	var results = make([]bool, 0)
+	if err := defaultTraverse(p, path, func(nav prop.Navigator) error {
+		target, err := v.resolve(nav.Current())
+		if err != nil {
+			return err
+		}
+
+		r, err := compare(target)
+		if err != nil {
+			return err
+		}
 
 		results = append(results, r)
-		return
+		return nil
 	}); err != nil {
 		switch errors.Unwrap(err) {
 		case spec.ErrInvalidFilter:
@@ -305,7 +498,13 @@ func (v evaluator) evalNot(p prop.Property, not *expr.Expression) (bool, error)
 // Take the raw string presentation of a value and normalize it to corresponding types according to the attribute.
 func (v evaluator) normalize(attr *spec.Attribute, token string) (interface{}, error) {
 	switch attr.Type() {
-	case spec.TypeString, spec.TypeDateTime, spec.TypeBinary, spec.TypeReference:
+	case spec.TypeDateTime:
+		if strings.HasPrefix(token, "\"") && strings.HasSuffix(token, "\"") {
+			return ParseDateTimeLiteral(strings.TrimSuffix(strings.TrimPrefix(token, "\""), "\""))
+		} else {
+			return nil, spec.ErrInvalidValue
+		}
+	case spec.TypeString, spec.TypeBinary, spec.TypeReference:
 		if strings.HasPrefix(token, "\"") && strings.HasSuffix(token, "\"") {
 			token = strings.TrimPrefix(token, "\"")
 			token = strings.TrimSuffix(token, "\"")
@@ -335,3 +534,15 @@ func (v evaluator) normalize(attr *spec.Attribute, token string) (interface{}, e
 		return nil, spec.ErrInvalidValue
 	}
 }
+
+// ParseDateTimeLiteral parses the unquoted dateTime value in a filter. RFC3339 is attempted first, followed by the
+// spec.ISO8601 format that dateTime values are stored in, and finally the spec.DateOnly format, which is interpreted
+// as midnight UTC. spec.ErrInvalidValue is returned if none of the formats parses.
+func ParseDateTimeLiteral(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, spec.ISO8601, spec.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, spec.ErrInvalidValue
+}
@@ -2,7 +2,9 @@ package crud
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
@@ -214,6 +216,98 @@ func (s *EvaluateTestSuite) TestEvaluate() {
 				assert.False(t, result)
 			},
 		},
+		{
+			name: `[meta.created ge "2023-06-01"] evaluates to true against {"meta":{"created":"2023-06-01T00:00:00"}}`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("meta").Dot("created").Replace("2023-06-01T00:00:00").HasError())
+				return r
+			},
+			filter: fmt.Sprintf("meta.created ge %s", strconv.Quote("2023-06-01")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.Nil(t, err)
+				assert.True(t, result)
+			},
+		},
+		{
+			name: `[meta.created lt "2023-06-01"] evaluates to false against {"meta":{"created":"2023-06-01T08:30:00"}}`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("meta").Dot("created").Replace("2023-06-01T08:30:00").HasError())
+				return r
+			},
+			filter: fmt.Sprintf("meta.created lt %s", strconv.Quote("2023-06-01")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.Nil(t, err)
+				assert.False(t, result)
+			},
+		},
+		{
+			name: `[meta.created lt "2023-06-01T09:00:00+08:00"] evaluates to true against {"meta":{"created":"2023-05-31T23:59:59"}}`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("meta").Dot("created").Replace("2023-05-31T23:59:59").HasError())
+				return r
+			},
+			filter: fmt.Sprintf("meta.created lt %s", strconv.Quote("2023-06-01T09:00:00+08:00")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.Nil(t, err)
+				assert.True(t, result)
+			},
+		},
+		{
+			name: `[meta.created gt "June 1st"] evaluates to error`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("meta").Dot("created").Replace("2023-06-01T00:00:00").HasError())
+				return r
+			},
+			filter: fmt.Sprintf("meta.created gt %s", strconv.Quote("June 1st")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.NotNil(t, err)
+			},
+		},
+		{
+			name: `[meta.created co "2023"] is rejected`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("meta").Dot("created").Replace("2023-06-01T00:00:00").HasError())
+				return r
+			},
+			filter: fmt.Sprintf("meta.created co %s", strconv.Quote("2023")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+				assert.Contains(t, err.Error(), "'co'")
+				assert.Contains(t, err.Error(), "'meta.created'")
+			},
+		},
+		{
+			name: `[loginCount sw "1"] is rejected without loginCount`,
+			getResource: func(t *testing.T) *prop.Resource {
+				return prop.NewResource(s.resourceType)
+			},
+			filter: fmt.Sprintf("loginCount sw %s", strconv.Quote("1")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+				assert.Contains(t, err.Error(), "'sw'")
+				assert.Contains(t, err.Error(), "'loginCount'")
+			},
+		},
+		{
+			name: `[emails.primary ew "e"] is rejected`,
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("emails").Add(map[string]interface{}{
+					"value":   "foo@bar.com",
+					"primary": true,
+				}).HasError())
+				return r
+			},
+			filter: fmt.Sprintf("emails.primary ew %s", strconv.Quote("e")),
+			expect: func(t *testing.T, result bool, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -225,6 +319,362 @@ func (s *EvaluateTestSuite) TestEvaluate() {
 	}
 }
 
+func (s *EvaluateTestSuite) TestEvaluateExpressionOnElement() {
+	r := prop.NewResource(s.resourceType)
+	require.False(s.T(), r.Navigator().Dot("emails").Replace([]interface{}{
+		map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+		map[string]interface{}{"value": "bar@foo.com", "type": "home"},
+		map[string]interface{}{"value": "baz@foo.com"},
+	}).HasError())
+
+	tests := []struct {
+		filter string
+		expect []bool
+	}{
+		{
+			filter: `type ne "work"`,
+			expect: []bool{false, true, true},
+		},
+		{
+			filter: `type eq "work"`,
+			expect: []bool{true, false, false},
+		},
+		{
+			filter: `type ne "work" and value ew "foo.com"`,
+			expect: []bool{false, true, true},
+		},
+		{
+			filter: `not (type ne "home")`,
+			expect: []bool{false, true, false},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.filter, func(t *testing.T) {
+			filter, err := expr.CompileFilter(test.filter)
+			require.Nil(t, err)
+
+			results := make([]bool, 0)
+			_ = r.Navigator().Dot("emails").Current().ForEachChild(func(_ int, child prop.Property) error {
+				ok, err := EvaluateExpressionOnProperty(child, filter)
+				assert.Nil(t, err)
+				results = append(results, ok)
+				return nil
+			})
+			assert.Equal(t, test.expect, results)
+		})
+	}
+}
+
+func (s *EvaluateTestSuite) TestEvaluateAt() {
+	r := prop.NewResource(s.resourceType)
+	require.False(s.T(), r.Navigator().Dot("emails").Replace([]interface{}{
+		map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+		map[string]interface{}{"value": "bar@foo.com", "type": "home"},
+	}).HasError())
+
+	filter, err := expr.CompileFilter(`type eq "work"`)
+	require.Nil(s.T(), err)
+
+	nav := r.Navigator().Dot("emails").At(0)
+	ok, err := EvaluateAt(nav, filter)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+
+	nav.Retract()
+	ok, err = EvaluateAt(nav.At(1), filter)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+
+	nav.Retract()
+	_, err = EvaluateAt(nav.At(2), filter)
+	assert.True(s.T(), errors.Is(err, spec.ErrNoTarget))
+}
+
+func (s *EvaluateTestSuite) TestEvaluateWithResolver() {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Virtual", "Virtual").
+		Attribute(spec.ComplexAttr("name", spec.StringAttr("givenName"), spec.StringAttr("familyName"))).
+		Attribute(spec.StringAttr("fullName")).
+		Attribute(spec.StringAttr("nickName")).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Virtual",
+  "name": "Virtual",
+  "endpoint": "/Virtuals",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Virtual"
+}
+`), resourceType))
+
+	newResource := func(t *testing.T, fullName string) *prop.Resource {
+		r := prop.NewResource(resourceType)
+		require.False(t, r.Navigator().Dot("name").Replace(map[string]interface{}{
+			"givenName":  "Jane",
+			"familyName": "Doe",
+		}).HasError())
+		if len(fullName) > 0 {
+			require.False(t, r.Navigator().Dot("fullName").Replace(fullName).HasError())
+		}
+		return r
+	}
+
+	// computes fullName from the name of the resource
+	resolverOf := func(r *prop.Resource) func(path string) (interface{}, bool) {
+		return func(path string) (interface{}, bool) {
+			if path != "fullName" {
+				return nil, false
+			}
+			name, _ := r.RootProperty().ChildAtIndex("name")
+			givenName, _ := name.ChildAtIndex("givenName")
+			familyName, _ := name.ChildAtIndex("familyName")
+			return fmt.Sprintf("%s %s", givenName.Raw(), familyName.Raw()), true
+		}
+	}
+
+	tests := []struct {
+		name     string
+		fullName string
+		filter   string
+		expect   bool
+	}{
+		{name: "eq resolved value", filter: `fullName eq "Jane Doe"`, expect: true},
+		{name: "eq resolved value case insensitive", filter: `fullName eq "jane doe"`, expect: true},
+		{name: "sw resolved value", filter: `fullName sw "Jane" and name.familyName eq "Doe"`, expect: true},
+		{name: "pr resolved value", filter: `fullName pr`, expect: true},
+		{name: "ne resolved value", filter: `fullName ne "Jane Doe"`, expect: false},
+		{name: "assigned value takes precedence", fullName: "J. Doe", filter: `fullName eq "Jane Doe"`, expect: false},
+		{name: "unresolved attribute", filter: `nickName pr`, expect: false},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			r := newResource(t, test.fullName)
+			result, err := Evaluate(r, test.filter, WithResolver(resolverOf(r)))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, result)
+
+			fullName, _ := r.RootProperty().ChildAtIndex("fullName")
+			assert.Equal(t, len(test.fullName) == 0, fullName.IsUnassigned(), "resource must not be modified")
+		})
+	}
+
+	s.T().Run("without resolver", func(t *testing.T) {
+		result, err := Evaluate(newResource(t, ""), `fullName eq "Jane Doe"`)
+		assert.Nil(t, err)
+		assert.False(t, result)
+	})
+}
+
+func (s *EvaluateTestSuite) TestEvaluateNullLiteral() {
+	newResource := func(t *testing.T, id string, emails ...string) *prop.Resource {
+		r := prop.NewResource(s.resourceType)
+		if len(id) > 0 {
+			require.False(t, r.Navigator().Dot("id").Replace(id).HasError())
+		}
+		for _, email := range emails {
+			require.False(t, r.Navigator().Dot("emails").Add(map[string]interface{}{
+				"value": email,
+			}).HasError())
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		resource func(t *testing.T) *prop.Resource
+		filter   string
+		expect   bool
+	}{
+		{
+			name:     "eq null on assigned attribute",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "foobar") },
+			filter:   "id eq null",
+			expect:   false,
+		},
+		{
+			name:     "eq null on unassigned attribute",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "") },
+			filter:   "id eq null",
+			expect:   true,
+		},
+		{
+			name:     "ne null on assigned attribute",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "foobar") },
+			filter:   "id ne NULL",
+			expect:   true,
+		},
+		{
+			name:     "ne null on unassigned attribute",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "") },
+			filter:   "id ne null",
+			expect:   false,
+		},
+		{
+			name:     "eq null on multiValued attribute with elements",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "", "foo@bar.com") },
+			filter:   "emails eq null",
+			expect:   false,
+		},
+		{
+			name:     "eq null on sub attribute assigned in one of the elements",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "", "", "foo@bar.com") },
+			filter:   "emails.value eq null",
+			expect:   false,
+		},
+		{
+			name:     "eq null within logical expression",
+			resource: func(t *testing.T) *prop.Resource { return newResource(t, "foobar") },
+			filter:   "id pr and emails.value eq null",
+			expect:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			result, err := Evaluate(test.resource(t), test.filter, AllowNullLiteral())
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, result)
+		})
+	}
+
+	s.T().Run("null with other operator", func(t *testing.T) {
+		_, err := Evaluate(newResource(t, "foobar"), "id sw null", AllowNullLiteral())
+		assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+	})
+
+	s.T().Run("without option", func(t *testing.T) {
+		_, err := Evaluate(newResource(t, ""), "id eq null")
+		assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+	})
+}
+
+func (s *EvaluateTestSuite) TestEvaluateSimpleEq() {
+	tests := []struct {
+		name     string
+		filter   string
+		fastPath bool
+	}{
+		{
+			name:     "single eq on top level string",
+			filter:   `id eq "foobar"`,
+			fastPath: true,
+		},
+		{
+			name:     "single eq on top level string not matching",
+			filter:   `id eq "barfoo"`,
+			fastPath: true,
+		},
+		{
+			name:     "single eq on top level string with different case",
+			filter:   `ID eq "foobar"`,
+			fastPath: true,
+		},
+		{
+			name:     "single eq on unassigned top level string",
+			filter:   `meta.location eq "foobar"`,
+			fastPath: false,
+		},
+		{
+			name:     "single eq on sub attribute",
+			filter:   `meta.version eq "v1"`,
+			fastPath: false,
+		},
+		{
+			name:     "single eq on multiValued attribute",
+			filter:   `schemas eq "B"`,
+			fastPath: false,
+		},
+		{
+			name:     "single eq on sub attribute of multiValued attribute",
+			filter:   `emails.value eq "foo"`,
+			fastPath: false,
+		},
+		{
+			name:     "single ne on top level string",
+			filter:   `id ne "foobar"`,
+			fastPath: false,
+		},
+	}
+
+	resource := prop.NewResource(s.resourceType)
+	assert.False(s.T(), resource.Navigator().Dot("id").Replace("foobar").HasError())
+	assert.False(s.T(), resource.Navigator().Dot("schemas").Replace([]interface{}{"A", "B"}).HasError())
+	assert.False(s.T(), resource.Navigator().Dot("meta").Dot("version").Replace("v1").HasError())
+	assert.False(s.T(), resource.Navigator().Dot("emails").Replace([]interface{}{
+		map[string]interface{}{"value": "foo"},
+	}).HasError())
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			cf, err := expr.CompileFilter(test.filter)
+			require.Nil(t, err)
+
+			v := evaluator{base: resource.RootProperty(), filter: cf}
+
+			fast, ok := v.evalSimpleEq(resource.RootProperty(), cf)
+			assert.Equal(t, test.fastPath, ok)
+
+			general, err := v.evalRelational(resource.RootProperty(), cf)
+			assert.Nil(t, err)
+			if ok {
+				assert.Equal(t, general, fast)
+			}
+
+			result, err := v.evaluate()
+			assert.Nil(t, err)
+			assert.Equal(t, general, result)
+		})
+	}
+}
+
+func BenchmarkEvaluateSimpleEq(b *testing.B) {
+	core := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testMainSchema), schema))
+	spec.Schemas().Register(schema)
+
+	extension := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testSchemaExtension), extension))
+	spec.Schemas().Register(extension)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(b, json.Unmarshal([]byte(testResourceType), resourceType))
+	Register(resourceType)
+
+	resource := prop.NewResource(resourceType)
+	require.False(b, resource.Navigator().Dot("id").Replace("foobar").HasError())
+
+	cf, err := expr.CompileFilter(`id eq "foobar"`)
+	require.Nil(b, err)
+
+	v := evaluator{base: resource.RootProperty(), filter: cf}
+
+	b.Run("fast path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if r, ok := v.evalSimpleEq(resource.RootProperty(), cf); !ok || !r {
+				b.Fatal("expects fast path to evaluate to true")
+			}
+		}
+	})
+
+	b.Run("general path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if r, err := v.evalRelational(resource.RootProperty(), cf); err != nil || !r {
+				b.Fatal("expects general path to evaluate to true")
+			}
+		}
+	})
+}
+
 // Prepares a core schema with 'schemas', 'id', 'meta'('version', 'location') attributes, and a main schema
 // with 'emails'('value', 'primary') attributes. Aggregate the two schemas in the test resource type.
 func (s *EvaluateTestSuite) SetupSuite() {
@@ -236,6 +686,10 @@ func (s *EvaluateTestSuite) SetupSuite() {
 	require.Nil(s.T(), json.Unmarshal([]byte(testMainSchema), schema))
 	spec.Schemas().Register(schema)
 
+	schemaExtension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testSchemaExtension), schemaExtension))
+	spec.Schemas().Register(schemaExtension)
+
 	s.resourceType = new(spec.ResourceType)
 	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), s.resourceType))
 	Register(s.resourceType)
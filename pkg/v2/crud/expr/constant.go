@@ -17,4 +17,5 @@ const (
 	Ge         = "ge"
 	Lt         = "lt"
 	Le         = "le"
+	Null       = "null"
 )
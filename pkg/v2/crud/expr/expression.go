@@ -77,6 +77,11 @@ func (e *Expression) IsLiteral() bool {
 	return e.typ == literal
 }
 
+// IsNull returns true if this Expression is the null literal, which is only compiled with AllowNullLiteral.
+func (e *Expression) IsNull() bool {
+	return e.typ == literal && strings.EqualFold(e.token, Null)
+}
+
 // IsParenthesis returns true if this Expression is a parenthesis
 func (e *Expression) IsParenthesis() bool {
 	return e.typ == parenthesis
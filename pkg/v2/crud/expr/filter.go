@@ -19,7 +19,12 @@ import (
 //	                     /  \
 //	                primary true
 //
-func CompileFilter(filter string) (*Expression, error) {
+// Tokens may be separated by any number of spaces, tabs and line breaks. A comparison operator may be directly followed
+// by a string literal, and a string literal by a logical operator, i.e. userName eq"foo"and title pr, since the double
+// quote separates them unambiguously. Everywhere else, the separation is required.
+//
+// The behaviour of the compiler can be customized with CompileOptions.
+func CompileFilter(filter string, options ...CompileOptions) (*Expression, error) {
 	compiler := &filterCompiler{
 		scan:    &filterScanner{},
 		data:    append(copyOf(filter), 0, 0),
@@ -29,6 +34,9 @@ func CompileFilter(filter string) (*Expression, error) {
 		rsStack: make([]*Expression, 0),
 	}
 	compiler.scan.init()
+	for _, opt := range options {
+		opt.apply(compiler)
+	}
 
 	for compiler.hasMore() {
 		step, err := compiler.next()
@@ -75,7 +83,8 @@ func CompileFilter(filter string) (*Expression, error) {
 			minPriority := opPriority(step.token)
 			for {
 				popped := compiler.popOperatorIf(func(top *Expression) bool {
-					return opPriority(top.token) >= minPriority
+					// stop at left parenthesis so grouping is respected
+					return top.IsOperator() && opPriority(top.token) >= minPriority
 				})
 				if popped != nil {
 					// ignore error. we are sure it won't err
@@ -93,9 +102,13 @@ func CompileFilter(filter string) (*Expression, error) {
 
 	// pop all remaining operators
 	for len(compiler.opStack) > 0 {
-		_ = compiler.pushBuildResult(compiler.popOperatorIf(func(top *Expression) bool {
+		popped := compiler.popOperatorIf(func(top *Expression) bool {
 			return true
-		}))
+		})
+		if popped.IsLeftParenthesis() {
+			return nil, fmt.Errorf("%w: mismatched parenthesis", spec.ErrInvalidFilter)
+		}
+		_ = compiler.pushBuildResult(popped)
 	}
 
 	// assertion check
@@ -110,12 +123,147 @@ func CompileFilter(filter string) (*Expression, error) {
 	return root, nil
 }
 
+// CompileFilterAll is like CompileFilter, but reports as many distinct syntax errors as it can in one pass, so that
+// clients may fix several mistakes at once. The errors are nil if the filter compiles.
+//
+// The compiler stops at the first error, hence recovery is done by dividing the filter into the operands of its top
+// level logical operators, i.e. 'userName eq' and 'title xx "boss"' of:
+//	userName eq and title xx "boss"
+// Each operand that does not compile is divided further, after unwrapping its enclosing parenthesis and 'not' operator,
+// until it cannot be divided, in which case its error is reported, suffixed by the operand. If all operands compile,
+// the error lies in how they are combined, and the error of the filter as a whole is reported instead.
+func CompileFilterAll(filter string, options ...CompileOptions) (*Expression, []error) {
+	root, err := CompileFilter(filter, options...)
+	if err == nil {
+		return root, nil
+	}
+
+	var (
+		errs []error
+		seen = map[string]struct{}{}
+	)
+	for _, each := range collectFilterErrors(filter, err, options) {
+		if _, ok := seen[each.Error()]; !ok {
+			seen[each.Error()] = struct{}{}
+			errs = append(errs, each)
+		}
+	}
+	return nil, errs
+}
+
+// Returns the errors of the operands of the filter, which failed to compile with err.
+func collectFilterErrors(filter string, err error, options []CompileOptions) []error {
+	operands := splitFilter(filter)
+	if len(operands) == 1 {
+		if inner, ok := unwrapFilter(operands[0]); ok {
+			operands = []string{inner}
+		}
+	}
+	if len(operands) == 1 && operands[0] == strings.TrimSpace(filter) {
+		return []error{fmt.Errorf("%w (filter:'%s')", err, strings.TrimSpace(filter))}
+	}
+
+	var errs []error
+	for _, operand := range operands {
+		if len(operand) == 0 {
+			errs = append(errs, fmt.Errorf("%w: missing expression (filter:'%s')", spec.ErrInvalidFilter, strings.TrimSpace(filter)))
+			continue
+		}
+		if _, operandErr := CompileFilter(operand, options...); operandErr != nil {
+			errs = append(errs, collectFilterErrors(operand, operandErr, options)...)
+		}
+	}
+	if len(errs) == 0 {
+		return []error{fmt.Errorf("%w (filter:'%s')", err, strings.TrimSpace(filter))}
+	}
+	return errs
+}
+
+// Splits the filter into the operands of its top level logical operators, ignoring the operators within string literals,
+// parenthesis and brackets. Operands are trimmed, and empty ones, i.e. that preceding a leading operator, are kept so
+// that they are reported.
+func splitFilter(filter string) []string {
+	var (
+		operands []string
+		depth    int
+		quoted   bool
+		escaped  bool
+		start    int
+	)
+	for i := 0; i < len(filter); i++ {
+		c := filter[i]
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && (i == 0 || normalizeSpace(filter[i-1]) == ' '):
+			for _, op := range []string{And, Or} {
+				end := i + len(op)
+				if end < len(filter) && normalizeSpace(filter[end]) == ' ' && strings.EqualFold(filter[i:end], op) {
+					operands = append(operands, strings.TrimSpace(filter[start:i]))
+					start = end
+					i = end - 1
+					break
+				}
+			}
+		}
+	}
+	return append(operands, strings.TrimSpace(filter[start:]))
+}
+
+// Returns the operand of the 'not' operator, or the content of the parenthesis, that encloses the whole filter.
+func unwrapFilter(filter string) (string, bool) {
+	if len(filter) > len(Not) && strings.EqualFold(filter[:len(Not)], Not) &&
+		(normalizeSpace(filter[len(Not)]) == ' ' || filter[len(Not)] == '(') {
+		return strings.TrimSpace(filter[len(Not):]), true
+	}
+
+	if len(filter) < 2 || filter[0] != '(' || filter[len(filter)-1] != ')' {
+		return "", false
+	}
+	depth, quoted, escaped := 0, false, false
+	for i := 0; i < len(filter)-1; i++ {
+		switch c := filter[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				// the first parenthesis closes before the end
+				return "", false
+			}
+		}
+	}
+	return strings.TrimSpace(filter[1 : len(filter)-1]), true
+}
+
 // priority and precedence definitions
 var (
-	// function to return the relative priority
+	// function to return the relative priority. As defined in RFC7644 section 3.4.2.2, the logical operators, in
+	// the order of descending precedence, are "not", "and" and "or"; all of which binds looser than the attribute
+	// operators.
 	opPriority = func(op string) int {
 		switch strings.ToLower(op) {
-		case And, Or, Not:
+		case Or:
+			return 30
+		case And:
+			return 40
+		case Not:
 			return 50
 		case Eq, Ne, Sw, Ew, Co, Pr, Gt, Ge, Lt, Le:
 			return 100
@@ -147,6 +295,26 @@ var (
 	}
 )
 
+// CompileOptions customizes the behaviour of CompileFilter.
+type CompileOptions interface {
+	apply(c *filterCompiler)
+}
+
+// AllowNullLiteral returns a CompileOptions that accepts the bare literal null, as in "title eq null". This is a
+// compatibility extension: RFC7644 does not define a null literal and expresses absence as "not (title pr)", but some
+// clients send "eq null" and "ne null" nonetheless. The evaluator treats them as absence and presence tests of the
+// attribute respectively, and rejects null compared with any other operator. Without this option, null is an invalid
+// literal.
+func AllowNullLiteral() CompileOptions {
+	return allowNullLiteral{}
+}
+
+type allowNullLiteral struct{}
+
+func (o allowNullLiteral) apply(c *filterCompiler) {
+	c.scan.allowNull = true
+}
+
 // Compiler that utilizes filterScanner to convert a string based filter query to tree.
 type filterCompiler struct {
 	scan *filterScanner
@@ -175,7 +343,7 @@ func (c *filterCompiler) pushOperator(step *Expression) int {
 		if p.IsOperator() {
 			if opLeftAssociative(step.token) && opPriority(step.token) <= opPriority(p.token) {
 				return pushOpInsufficientPriority
-			} else if opLeftAssociative(step.token) && opPriority(step.token) < opPriority(p.token) {
+			} else if !opLeftAssociative(step.token) && opPriority(step.token) < opPriority(p.token) {
 				return pushOpInsufficientPriority
 			}
 		}
@@ -290,7 +458,11 @@ func (c *filterCompiler) scanLiteral() (*Expression, error) {
 	end := c.scanWhile(scanFilterContinue)
 	switch c.op {
 	case scanFilterEndLiteral, scanFilterEnd:
-		return newLiteral(string(c.data[start:end])), nil
+		token := string(c.data[start:end])
+		if (token[0] == 'n' || token[0] == 'N') && !strings.EqualFold(token, Null) {
+			return nil, fmt.Errorf("%w: invalid literal '%s'", spec.ErrInvalidFilter, token)
+		}
+		return newLiteral(token), nil
 	default:
 		return nil, c.errCompile()
 	}
@@ -349,7 +521,8 @@ func (c *filterCompiler) scanOperatorOrPath() (*Expression, error) {
 // send a space byte (i.e. ' ') to the scanner, in order to receive that explicit ending op code instruction.
 func (c *filterCompiler) scanWhile(op int) int {
 	for c.off < len(c.data) {
-		c.op = c.scan.step(c.scan, c.data[c.off])
+		c.scan.bytes = int64(c.off)
+		c.op = c.scan.step(c.scan, normalizeSpace(c.data[c.off]))
 
 		// scanner instructs us to insert space before rescanning the last bit.
 		// hence we will not need to increment the offset here as it shall be
@@ -369,7 +542,22 @@ func (c *filterCompiler) scanWhile(op int) int {
 	return len(c.data) + 1
 }
 
+// Returns a space for tabs and line breaks, so that the scanner treats any whitespace between tokens as a space. The
+// tokens are sliced from the original filter, hence the content of string literals is preserved.
+func normalizeSpace(c byte) byte {
+	switch c {
+	case '\t', '\n', '\r':
+		return ' '
+	default:
+		return c
+	}
+}
+
+// Returns the error of the scanner, which locates the offending character, if any, or a generic compile error.
 func (c *filterCompiler) errCompile() error {
+	if c.scan.err != nil {
+		return c.scan.err
+	}
 	return fmt.Errorf("%w: error compiling filter", spec.ErrInvalidFilter)
 }
 
@@ -446,6 +634,8 @@ type filterScanner struct {
 	// number of bytes that has been scanned. This is assisting data that helps formulating
 	// error information.
 	bytes int64
+	// true if the null literal is accepted. See AllowNullLiteral.
+	allowNull bool
 }
 
 // Initialize the scanner for use
@@ -521,7 +711,11 @@ func (fs *filterScanner) stateN(scan *filterScanner, c byte) int {
 	}
 
 	// just a path
-	if c == '.' || c == ':' || isNonFirstAlphabet(c) {
+	if c == '.' {
+		scan.step = fs.stateBeginSubPath
+		return scanFilterContinue
+	}
+	if c == ':' || isNonFirstAlphabet(c) {
 		scan.step = fs.stateInPath
 		return scanFilterContinue
 	}
@@ -540,7 +734,11 @@ func (fs *filterScanner) stateNo(scan *filterScanner, c byte) int {
 	}
 
 	// just a path
-	if c == '.' || c == ':' || isNonFirstAlphabet(c) {
+	if c == '.' {
+		scan.step = fs.stateBeginSubPath
+		return scanFilterContinue
+	}
+	if c == ':' || isNonFirstAlphabet(c) {
 		scan.step = fs.stateInPath
 		return scanFilterContinue
 	}
@@ -564,7 +762,11 @@ func (fs *filterScanner) stateNot(scan *filterScanner, c byte) int {
 	}
 
 	// seem like just a path that starts with 'not' (i.e. notes.title)
-	if c == '.' || c == ':' || isNonFirstAlphabet(c) {
+	if c == '.' {
+		scan.step = fs.stateBeginSubPath
+		return scanFilterContinue
+	}
+	if c == ':' || isNonFirstAlphabet(c) {
 		scan.step = fs.stateInPath
 		return scanFilterContinue
 	}
@@ -580,7 +782,23 @@ func (fs *filterScanner) stateInPath(scan *filterScanner, c byte) int {
 		return scanFilterEndPath
 	}
 
-	if c == '.' || c == ':' || isNonFirstAlphabet(c) {
+	if c == '.' {
+		scan.step = fs.stateBeginSubPath
+		return scanFilterContinue
+	}
+	if c == ':' || isNonFirstAlphabet(c) {
+		return scanFilterContinue
+	}
+
+	return fs.error(c, "invalid character in path")
+}
+
+// Intermediate state right after a dot inside an attribute path name. Besides the characters that may follow a dot
+// anywhere in the path, i.e. the digits of the version in a schema URN, the sub attribute name may start with '$', as
+// standard sub attributes like "$ref" do.
+func (fs *filterScanner) stateBeginSubPath(scan *filterScanner, c byte) int {
+	if isFirstAlphabet(c) || isNonFirstAlphabet(c) {
+		scan.step = fs.stateInPath
 		return scanFilterContinue
 	}
 
@@ -682,13 +900,17 @@ func (fs *filterScanner) stateOpC(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where the last two characters were 'c' and 'o' (case insensitive). The current
-// character must be space to end the operator.
+// character must be space to end the operator, or begin a string literal.
 func (fs *filterScanner) stateOpCo(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -708,24 +930,32 @@ func (fs *filterScanner) stateOpE(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where last two characters were 'e' and 'q' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpEq(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
 // Intermediate state in operator where last two characters were 'e' and 'w' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpEw(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -745,24 +975,32 @@ func (fs *filterScanner) stateOpG(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where last two characters were 'g' and 't' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpGt(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
 // Intermediate state in operator where last two characters were 'g' and 'e' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpGe(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -782,24 +1020,32 @@ func (fs *filterScanner) stateOpL(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where last two characters were 'l' and 't' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpLt(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
 // Intermediate state in operator where last two characters were 'l' and 'e' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpLe(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -819,13 +1065,17 @@ func (fs *filterScanner) stateOpN(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where last two characters were 'n' and 'e' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpNe(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -919,13 +1169,17 @@ func (fs *filterScanner) stateOpS(scan *filterScanner, c byte) int {
 }
 
 // Intermediate state in operator where last two characters were 's' and 'w' (case insensitive). The current character
-// must end the operator with space.
+// must end the operator with space, or begin a string literal.
 func (fs *filterScanner) stateOpSw(scan *filterScanner, c byte) int {
 	if c == ' ' {
 		scan.step = fs.stateBeginLiteral
 		return scanFilterEndOp
 	}
 
+	if c == '"' {
+		return scanFilterInsertSpace
+	}
+
 	return fs.errInvalidOperator(c)
 }
 
@@ -938,6 +1192,11 @@ func (fs *filterScanner) stateBeginLiteral(scan *filterScanner, c byte) int {
 	case 't', 'T', 'f', 'F', '-', '+', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		scan.step = fs.stateInNonStringLiteral
 		return scanFilterBeginLiteral
+	case 'n', 'N':
+		if scan.allowNull {
+			scan.step = fs.stateInNonStringLiteral
+			return scanFilterBeginLiteral
+		}
 	}
 
 	return fs.error(c, "invalid literal")
@@ -984,7 +1243,8 @@ func (fs *filterScanner) stateEndStringLiteral(scan *filterScanner, c byte) int
 	case ' ':
 		scan.step = fs.stateEndLiteral
 		return scanFilterEndLiteral
-	case ')':
+	case ')', 'a', 'A', 'o', 'O':
+		// the closing double quote unambiguously ends the literal, hence the logical operator may follow it directly
 		return scanFilterInsertSpace
 	case 0:
 		scan.step = fs.stateEof
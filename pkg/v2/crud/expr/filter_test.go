@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"errors"
+	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"testing"
@@ -77,6 +79,24 @@ func (s *FilterTestSuite) TestFilterCompiler() {
 				assert.Equal(t, literal, trail[2].typ)
 			},
 		},
+		{
+			name:   "simple filter on $ref",
+			filter: `members.$ref eq "https://example.com/v2/Users/2819c223"`,
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, trail, 4)
+
+				assert.Equal(t, Eq, trail[0].value)
+				assert.Equal(t, "members", trail[1].value)
+				assert.Equal(t, "$ref", trail[2].value)
+				assert.Equal(t, `"https://example.com/v2/Users/2819c223"`, trail[3].value)
+
+				assert.Equal(t, operator, trail[0].typ)
+				assert.Equal(t, step, trail[1].typ)
+				assert.Equal(t, step, trail[2].typ)
+				assert.Equal(t, literal, trail[3].typ)
+			},
+		},
 		{
 			name:   "simple filter with urn prefix",
 			filter: "urn:ietf:params:scim:schemas:core:2.0:User:meta.created gt \"2019-10-10T10:10:10\"",
@@ -166,6 +186,249 @@ func (s *FilterTestSuite) TestFilterCompiler() {
 	}
 }
 
+func (s *FilterTestSuite) TestFilterPrecedence() {
+	// evaluate the compiled filter against an assignment of truth values, where "x pr" is true if x is assigned
+	// true; and compare the result against the same expression written in Go.
+	var evaluate func(root *Expression, vars map[string]bool) bool
+	evaluate = func(root *Expression, vars map[string]bool) bool {
+		switch root.Token() {
+		case And:
+			return evaluate(root.Left(), vars) && evaluate(root.Right(), vars)
+		case Or:
+			return evaluate(root.Left(), vars) || evaluate(root.Right(), vars)
+		case Not:
+			return !evaluate(root.Left(), vars)
+		case Pr:
+			return vars[root.Left().Token()]
+		default:
+			panic("unexpected token")
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		expect func(a, b, c, d bool) bool
+	}{
+		{
+			name:   "and binds tighter than or",
+			filter: "a pr or b pr and c pr",
+			expect: func(a, b, c, d bool) bool { return a || (b && c) },
+		},
+		{
+			name:   "and binds tighter than or on the left",
+			filter: "a pr and b pr or c pr",
+			expect: func(a, b, c, d bool) bool { return (a && b) || c },
+		},
+		{
+			name:   "top level group followed by and",
+			filter: "(a pr or b pr) and c pr",
+			expect: func(a, b, c, d bool) bool { return (a || b) && c },
+		},
+		{
+			name:   "top level group preceded by and",
+			filter: "a pr and (b pr or c pr)",
+			expect: func(a, b, c, d bool) bool { return a && (b || c) },
+		},
+		{
+			name:   "chained and within a group",
+			filter: "(a pr and b pr and c pr) or d pr",
+			expect: func(a, b, c, d bool) bool { return (a && b && c) || d },
+		},
+		{
+			name:   "nested groups",
+			filter: "((a pr or b pr) and (c pr or d pr))",
+			expect: func(a, b, c, d bool) bool { return (a || b) && (c || d) },
+		},
+		{
+			name:   "not binds tighter than and",
+			filter: "not (a pr) and b pr or c pr",
+			expect: func(a, b, c, d bool) bool { return (!a && b) || c },
+		},
+		{
+			name:   "not on a group",
+			filter: "not (a pr or b pr) and (c pr or d pr)",
+			expect: func(a, b, c, d bool) bool { return !(a || b) && (c || d) },
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			root, err := CompileFilter(test.filter)
+			assert.Nil(t, err)
+			for i := 0; i < 16; i++ {
+				a, b, c, d := i&1 != 0, i&2 != 0, i&4 != 0, i&8 != 0
+				vars := map[string]bool{"a": a, "b": b, "c": c, "d": d}
+				assert.Equal(t, test.expect(a, b, c, d), evaluate(root, vars), "a=%t b=%t c=%t d=%t", a, b, c, d)
+			}
+		})
+	}
+}
+
+func (s *FilterTestSuite) TestFilterMismatchedParenthesis() {
+	for _, filter := range []string{
+		"(a pr",
+		"a pr)",
+		"((a pr or b pr) and c pr",
+	} {
+		s.T().Run(filter, func(t *testing.T) {
+			_, err := CompileFilter(filter)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func (s *FilterTestSuite) TestFilterNullLiteral() {
+	s.T().Run("allowed", func(t *testing.T) {
+		for _, filter := range []string{
+			"title eq null",
+			"title ne NULL",
+			"(title eq null) and userName pr",
+		} {
+			_, err := CompileFilter(filter, AllowNullLiteral())
+			assert.Nil(t, err, filter)
+		}
+
+		root, err := CompileFilter("title eq null", AllowNullLiteral())
+		assert.Nil(t, err)
+		assert.True(t, root.Right().IsNull())
+	})
+
+	s.T().Run("not allowed", func(t *testing.T) {
+		_, err := CompileFilter("title eq null")
+		assert.NotNil(t, err)
+	})
+
+	s.T().Run("not null", func(t *testing.T) {
+		_, err := CompileFilter("title eq nullable", AllowNullLiteral())
+		assert.NotNil(t, err)
+	})
+}
+
+func (s *FilterTestSuite) TestFilterWhitespace() {
+	// renders the compiled filter with single spaces, so that it can be compared regardless of the original whitespace
+	var render func(root *Expression) string
+	render = func(root *Expression) string {
+		switch {
+		case root == nil:
+			return ""
+		case root.IsLogicalOperator() || root.IsRelationalOperator():
+			s := "(" + render(root.Left()) + " " + root.Token()
+			if root.Right() != nil {
+				s += " " + render(root.Right())
+			}
+			return s + ")"
+		case root.IsPath() && root.Next() != nil:
+			return root.Token() + "." + render(root.Next())
+		default:
+			return root.Token()
+		}
+	}
+
+	s.T().Run("valid", func(t *testing.T) {
+		for _, test := range []struct {
+			filter string
+			expect string
+		}{
+			{filter: "userName   eq\"x\"", expect: `(userName eq "x")`},
+			{filter: "userName\teq\t\"x\"", expect: `(userName eq "x")`},
+			{filter: "\r\n userName\npr \r\n", expect: `(userName pr)`},
+			{filter: "userName eq \"x\"\nand\ttitle pr", expect: `((userName eq "x") and (title pr))`},
+			{filter: "userName eq\"x\"and title pr", expect: `((userName eq "x") and (title pr))`},
+			{filter: "(userName sw\"x\")or(title\tpr)", expect: `((userName sw "x") or (title pr))`},
+			{filter: "not\t(name.familyName co\"x\")", expect: `((name.familyName co "x") not)`},
+			{filter: "userName eq \"a\tb\"", expect: "(userName eq \"a\tb\")"},
+		} {
+			root, err := CompileFilter(test.filter)
+			if assert.Nil(t, err, test.filter) {
+				assert.Equal(t, test.expect, render(root), test.filter)
+			}
+		}
+	})
+
+	s.T().Run("invalid", func(t *testing.T) {
+		for _, filter := range []string{
+			"userNameeq \"x\"",
+			"userName\teq\"x\"\"y\"",
+			"age gt10",
+			"userName pr\tandtitle pr",
+		} {
+			_, err := CompileFilter(filter)
+			assert.True(t, errors.Is(err, spec.ErrInvalidFilter), filter)
+		}
+	})
+}
+
+func (s *FilterTestSuite) TestCompileFilterAll() {
+	tests := []struct {
+		name   string
+		filter string
+		expect []string
+	}{
+		{
+			name:   "valid filter",
+			filter: `userName eq "foo" and title pr`,
+		},
+		{
+			name:   "two independent errors",
+			filter: `userName eq and title xx "boss"`,
+			expect: []string{
+				"(hint:invalid operator) (filter:'userName eq')",
+				"(hint:invalid character in operator) (filter:'title xx \"boss\"')",
+			},
+		},
+		{
+			name:   "errors in nested groups",
+			filter: `(userName sw "a" or nickName eq) and not (title xx "x" or emails.value pr)`,
+			expect: []string{
+				"(filter:'nickName eq')",
+				"(filter:'title xx \"x\"')",
+			},
+		},
+		{
+			name:   "logical operator in string literal",
+			filter: `title eq "boss and owner" or userName eq`,
+			expect: []string{
+				"(filter:'userName eq')",
+			},
+		},
+		{
+			name:   "missing operand",
+			filter: `and userName pr or title xx "x"`,
+			expect: []string{
+				"missing expression",
+				"(filter:'title xx \"x\"')",
+			},
+		},
+		{
+			name:   "error in combination of valid operands",
+			filter: `(userName pr or title pr`,
+			expect: []string{
+				"mismatched parenthesis (filter:'(userName pr or title pr')",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			root, errs := CompileFilterAll(test.filter)
+			if len(test.expect) == 0 {
+				assert.Empty(t, errs)
+				assert.NotNil(t, root)
+				return
+			}
+
+			assert.Nil(t, root)
+			if assert.Len(t, errs, len(test.expect)) {
+				for i, err := range errs {
+					assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+					assert.Contains(t, err.Error(), test.expect[i])
+				}
+			}
+		})
+	}
+}
+
 func (s *FilterTestSuite) TestFilterScanner() {
 	type signals struct {
 		event   int
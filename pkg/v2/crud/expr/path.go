@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"strconv"
+	"strings"
 )
 
 // CompilePath compiles the given SCIM path expression and returns the head of the path expression linked list, or any error.
@@ -47,6 +48,31 @@ func CompilePath(path string) (*Expression, error) {
 	return head, nil
 }
 
+// CompileSortPath compiles the value of the sortBy parameter, which is an attribute path, optionally prefixed with a
+// schema URN, i.e. "name.familyName" or "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber".
+// Unlike CompilePath, it rejects filters, so that a value like
+//	emails[type eq "work"].value
+// fails with spec.ErrInvalidPath instead of reaching the sort layer, and the returned path never contains a filter
+// root node.
+func CompileSortPath(path string) (*Expression, error) {
+	if len(strings.TrimSpace(path)) == 0 {
+		return nil, fmt.Errorf("%w: sortBy must not be empty", spec.ErrInvalidPath)
+	}
+	if i := strings.IndexAny(path, "[]()\" \t\r\n"); i >= 0 {
+		return nil, fmt.Errorf("%w: sortBy '%s' must be an attribute path, but contains '%c', as in a filter",
+			spec.ErrInvalidPath, path, path[i])
+	}
+
+	head, err := CompilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil || head.ContainsFilter() {
+		return nil, fmt.Errorf("%w: sortBy '%s' must be an attribute path", spec.ErrInvalidPath, path)
+	}
+	return head, nil
+}
+
 // Compiler that utilizes pathScanner to convert a string based path query to a linked list of steps, each representing
 // a unit in the path.
 type pathCompiler struct {
@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"errors"
+	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"testing"
@@ -115,6 +117,77 @@ func (s *PathTestSuite) TestPathCompiler() {
 				assert.Equal(t, step, trail[4].typ)
 			},
 		},
+		{
+			name: "path with $ref",
+			path: "manager.$ref",
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, trail, 2)
+				assert.Equal(t, "manager", trail[0].value)
+				assert.Equal(t, "$ref", trail[1].value)
+				assert.Equal(t, step, trail[0].typ)
+				assert.Equal(t, step, trail[1].typ)
+			},
+		},
+		{
+			name: "path with filter on $ref",
+			path: `members[$ref eq "https://example.com/v2/Users/2819c223"].display`,
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, trail, 5)
+				assert.Equal(t, "members", trail[0].value)
+				assert.Equal(t, Eq, trail[1].value)
+				assert.Equal(t, "$ref", trail[2].value)
+				assert.Equal(t, `"https://example.com/v2/Users/2819c223"`, trail[3].value)
+				assert.Equal(t, "display", trail[4].value)
+				assert.Equal(t, step, trail[0].typ)
+				assert.Equal(t, operator, trail[1].typ)
+				assert.Equal(t, step, trail[2].typ)
+				assert.Equal(t, literal, trail[3].typ)
+				assert.Equal(t, step, trail[4].typ)
+			},
+		},
+		{
+			name: "triplex path",
+			path: "org.unit.code",
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []expect{
+					{value: "org", typ: step},
+					{value: "unit", typ: step},
+					{value: "code", typ: step},
+				}, trail)
+			},
+		},
+		{
+			name: "triplex path with urn namespace",
+			path: "urn:ietf:params:scim:schemas:core:2.0:User:org.unit.code",
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []expect{
+					{value: "urn:ietf:params:scim:schemas:core:2.0:User", typ: step},
+					{value: "org", typ: step},
+					{value: "unit", typ: step},
+					{value: "code", typ: step},
+				}, trail)
+			},
+		},
+		{
+			name: "triplex path with filter",
+			path: `positions[location.city eq "Paris"].location.city`,
+			assert: func(t *testing.T, trail []expect, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []expect{
+					{value: "positions", typ: step},
+					{value: Eq, typ: operator},
+					{value: "location", typ: step},
+					{value: "city", typ: step},
+					{value: `"Paris"`, typ: literal},
+					{value: "location", typ: step},
+					{value: "city", typ: step},
+				}, trail)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -137,6 +210,49 @@ func (s *PathTestSuite) TestPathCompiler() {
 	}
 }
 
+func (s *PathTestSuite) TestCompileSortPath() {
+	RegisterURN("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User")
+
+	tests := []struct {
+		name  string
+		path  string
+		steps []string
+		err   string
+	}{
+		{name: "simple path", path: "userName", steps: []string{"userName"}},
+		{name: "dotted path", path: "name.familyName", steps: []string{"name", "familyName"}},
+		{
+			name:  "schema URN prefixed path",
+			path:  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+			steps: []string{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User", "employeeNumber"},
+		},
+		{name: "empty", path: " ", err: "sortBy must not be empty"},
+		{
+			name: "filtered path",
+			path: `emails[type eq "work"].value`,
+			err:  `sortBy 'emails[type eq "work"].value' must be an attribute path, but contains '['`,
+		},
+		{name: "filter", path: `userName eq "foo"`, err: "must be an attribute path, but contains ' '"},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			head, err := CompileSortPath(test.path)
+			if len(test.err) > 0 {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			assert.Nil(t, err)
+			var steps []string
+			for cursor := head; cursor != nil; cursor = cursor.Next() {
+				steps = append(steps, cursor.Token())
+			}
+			assert.Equal(t, test.steps, steps)
+		})
+	}
+}
+
 func (s *PathTestSuite) TestPathScanner() {
 	type signals struct {
 		event   int
@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// CompilePathForType compiles the given SCIM path expression like CompilePath, and additionally checks that every
+// attribute referenced by the path, including those referenced by its filter, is defined by the resource type, either
+// in the core schema, the main schema or one of the schema extensions. An optional main schema URN prefix is allowed.
+// Otherwise, spec.ErrInvalidPath is returned naming the attribute and the resource type.
+func CompilePathForType(path string, resourceType *spec.ResourceType) (*Expression, error) {
+	head, err := CompilePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPath(head, resourceType.SuperAttribute(true), resourceType); err != nil {
+		return nil, fmt.Errorf("%w: %s", spec.ErrInvalidPath, err.Error())
+	}
+
+	return head, nil
+}
+
+// CompileFilterForType compiles the given SCIM filter like CompileFilter, and additionally checks that every attribute
+// referenced by the filter is defined by the resource type. This allows to reject a filter that could never match,
+// i.e. filtering Users by the Group attribute "members", before it reaches the database. Otherwise, the error is
+// spec.ErrInvalidFilter naming the attribute and the resource type.
+func CompileFilterForType(filter string, resourceType *spec.ResourceType) (*Expression, error) {
+	root, err := CompileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkFilter(root, resourceType.SuperAttribute(true), resourceType); err != nil {
+		return nil, fmt.Errorf("%w: %s", spec.ErrInvalidFilter, err.Error())
+	}
+
+	return root, nil
+}
+
+// Checks the path linked list against the attribute it is relative to. Filter root nodes on the list are checked
+// against the attribute of the preceding step.
+func checkPath(head *Expression, attr *spec.Attribute, resourceType *spec.ResourceType) error {
+	cursor := head
+	if cursor != nil && cursor.IsPath() && strings.EqualFold(cursor.token, resourceType.Schema().ID()) {
+		cursor = cursor.next
+	}
+
+	for ; cursor != nil; cursor = cursor.next {
+		if cursor.IsRootOfFilter() {
+			if err := checkFilter(cursor, attr, resourceType); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sub := attr.SubAttributeForName(cursor.token)
+		if sub == nil {
+			if _, root := attr.Annotation(annotation.Root); root {
+				return unqualified(cursor.token, resourceType)
+			}
+			return fmt.Errorf("no attribute '%s' in resource type '%s'", cursor.token, resourceType.Name())
+		}
+		attr = sub
+	}
+
+	return nil
+}
+
+// Returns the error for a top level attribute name which is not defined by the core schema or the main schema. If schema
+// extensions define it, the error asks for the schema URN prefix, and reports the name as ambiguous when more than one
+// schema extension defines it.
+func unqualified(name string, resourceType *spec.ResourceType) error {
+	var definedBy []string
+	_ = resourceType.ForEachExtension(func(extension *spec.Schema, _ bool) error {
+		return extension.ForEachAttribute(func(attr *spec.Attribute) error {
+			if strings.EqualFold(attr.Name(), name) {
+				definedBy = append(definedBy, extension.ID())
+			}
+			return nil
+		})
+	})
+
+	switch len(definedBy) {
+	case 0:
+		return fmt.Errorf("no attribute '%s' in resource type '%s'", name, resourceType.Name())
+	case 1:
+		return fmt.Errorf("attribute '%s' of schema extension '%s' must be prefixed with the schema URN in resource type '%s'",
+			name, definedBy[0], resourceType.Name())
+	default:
+		return fmt.Errorf("attribute '%s' is ambiguous in resource type '%s', as it is defined by schema extensions '%s'; "+
+			"prefix it with the schema URN", name, resourceType.Name(), strings.Join(definedBy, "', '"))
+	}
+}
+
+// Checks the paths in the filter tree against the attribute they are relative to.
+func checkFilter(root *Expression, attr *spec.Attribute, resourceType *spec.ResourceType) error {
+	switch {
+	case root == nil:
+		return nil
+	case root.IsLogicalOperator():
+		if err := checkFilter(root.left, attr, resourceType); err != nil {
+			return err
+		}
+		return checkFilter(root.right, attr, resourceType)
+	case root.IsRelationalOperator():
+		return checkPath(root.left, attr, resourceType)
+	default:
+		return nil
+	}
+}
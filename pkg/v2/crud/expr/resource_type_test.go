@@ -0,0 +1,189 @@
+package expr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCompileForType(t *testing.T) {
+	s := new(CompileForTypeTestSuite)
+	suite.Run(t, s)
+}
+
+type CompileForTypeTestSuite struct {
+	suite.Suite
+	userResourceType  *spec.ResourceType
+	groupResourceType *spec.ResourceType
+}
+
+func (s *CompileForTypeTestSuite) TestCompileFilterForType() {
+	tests := []struct {
+		name   string
+		filter string
+		rt     func() *spec.ResourceType
+		expect func(t *testing.T, root *Expression, err error)
+	}{
+		{
+			name:   "group attribute on users",
+			filter: `members.value eq "6d2e0d5a"`,
+			rt:     func() *spec.ResourceType { return s.userResourceType },
+			expect: func(t *testing.T, root *Expression, err error) {
+				assert.Nil(t, root)
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+				assert.Contains(t, err.Error(), "no attribute 'members' in resource type 'User'")
+			},
+		},
+		{
+			name:   "group attribute on groups",
+			filter: `members.value eq "6d2e0d5a"`,
+			rt:     func() *spec.ResourceType { return s.groupResourceType },
+			expect: func(t *testing.T, root *Expression, err error) {
+				assert.Nil(t, err)
+				assert.NotNil(t, root)
+			},
+		},
+		{
+			name:   "unknown sub attribute within composite filter",
+			filter: `(userName eq "foo") and not (name.nickName pr)`,
+			rt:     func() *spec.ResourceType { return s.userResourceType },
+			expect: func(t *testing.T, root *Expression, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+				assert.Contains(t, err.Error(), "no attribute 'nickName' in resource type 'User'")
+			},
+		},
+		{
+			name:   "core, main schema and extension attributes",
+			filter: `meta.resourceType eq "User" and urn:ietf:params:scim:schemas:core:2.0:User:emails.type eq "work" and urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value pr`,
+			rt:     func() *spec.ResourceType { return s.userResourceType },
+			expect: func(t *testing.T, root *Expression, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:   "syntax errors are reported as is",
+			filter: `userName eq`,
+			rt:     func() *spec.ResourceType { return s.userResourceType },
+			expect: func(t *testing.T, root *Expression, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidFilter))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			root, err := CompileFilterForType(test.filter, test.rt())
+			test.expect(t, root, err)
+		})
+	}
+}
+
+func (s *CompileForTypeTestSuite) TestCompilePathForType() {
+	tests := []struct {
+		name   string
+		path   string
+		expect func(t *testing.T, head *Expression, err error)
+	}{
+		{
+			name: "group attribute",
+			path: `members`,
+			expect: func(t *testing.T, head *Expression, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+				assert.Contains(t, err.Error(), "no attribute 'members' in resource type 'User'")
+			},
+		},
+		{
+			name: "group attribute in filter",
+			path: `emails[members eq "6d2e0d5a"].value`,
+			expect: func(t *testing.T, head *Expression, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+				assert.Contains(t, err.Error(), "no attribute 'members' in resource type 'User'")
+			},
+		},
+		{
+			name: "filtered path",
+			path: `emails[type eq "work"].value`,
+			expect: func(t *testing.T, head *Expression, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "emails", head.Token())
+			},
+		},
+		{
+			name: "extension path",
+			path: `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber`,
+			expect: func(t *testing.T, head *Expression, err error) {
+				assert.Nil(t, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			head, err := CompilePathForType(test.path, s.userResourceType)
+			test.expect(t, head, err)
+		})
+	}
+}
+
+func (s *CompileForTypeTestSuite) TestExtensionAttributeOfSameName() {
+	for _, id := range []string{
+		"urn:ietf:params:scim:schemas:test:2.0:Finance",
+		"urn:ietf:params:scim:schemas:test:2.0:Payroll",
+	} {
+		schema, err := spec.NewSchemaBuilder(id, "Department").Attribute(spec.StringAttr("department")).Build()
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), spec.Schemas().Register(schema))
+		RegisterURN(id)
+	}
+
+	rt := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Staff",
+  "name": "Staff",
+  "endpoint": "/Staff",
+  "schema": "urn:ietf:params:scim:schemas:core:2.0:User",
+  "schemaExtensions": [
+    {"schema": "urn:ietf:params:scim:schemas:test:2.0:Finance"},
+    {"schema": "urn:ietf:params:scim:schemas:test:2.0:Payroll"}
+  ]
+}
+`), rt))
+
+	_, err := CompilePathForType("department", rt)
+	assert.True(s.T(), errors.Is(err, spec.ErrInvalidPath))
+	assert.Contains(s.T(), err.Error(), "attribute 'department' is ambiguous in resource type 'Staff'")
+
+	_, err = CompileFilterForType(`department eq "R&D"`, rt)
+	assert.True(s.T(), errors.Is(err, spec.ErrInvalidFilter))
+	assert.Contains(s.T(), err.Error(), "attribute 'department' is ambiguous in resource type 'Staff'")
+
+	_, err = CompilePathForType("urn:ietf:params:scim:schemas:test:2.0:Payroll:department", rt)
+	assert.Nil(s.T(), err)
+
+	_, err = CompilePathForType("employeeNumber", s.userResourceType)
+	assert.True(s.T(), errors.Is(err, spec.ErrInvalidPath))
+	assert.Contains(s.T(), err.Error(), "must be prefixed with the schema URN")
+}
+
+func (s *CompileForTypeTestSuite) SetupSuite() {
+	var err error
+	s.userResourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	s.groupResourceType, err = spec.RegisterStandardGroupResourceType()
+	require.Nil(s.T(), err)
+
+	RegisterURN(spec.CoreSchemaId)
+	for _, rt := range []*spec.ResourceType{s.userResourceType, s.groupResourceType} {
+		RegisterURN(rt.Schema().ID())
+		_ = rt.ForEachExtension(func(extension *spec.Schema, _ bool) error {
+			RegisterURN(extension.ID())
+			return nil
+		})
+	}
+}
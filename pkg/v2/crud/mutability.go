@@ -0,0 +1,167 @@
+package crud
+
+import (
+	"fmt"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// MutabilityPolicy decides how EnforceMutability treats an incoming value that differs from the reference value.
+type MutabilityPolicy int
+
+const (
+	// KeepIncoming leaves the incoming value as it is.
+	KeepIncoming MutabilityPolicy = iota
+	// CopyForward replaces the incoming value with the reference value.
+	CopyForward
+	// RejectChange fails with spec.ErrMutability.
+	RejectChange
+)
+
+// MutabilityOptions customizes the behaviour of EnforceMutability.
+type MutabilityOptions interface {
+	apply(e *mutabilityEnforcer)
+}
+
+// ReadOnlyPolicy returns a MutabilityOptions that sets the policy for readOnly attributes. The default is CopyForward,
+// since clients usually send back the readOnly values they received, and the server has the final say on them.
+func ReadOnlyPolicy(policy MutabilityPolicy) MutabilityOptions {
+	return mutabilityPolicy{mutability: spec.MutabilityReadOnly, policy: policy}
+}
+
+// ImmutablePolicy returns a MutabilityOptions that sets the policy for immutable attributes. The default is
+// RejectChange, as required by the specification.
+func ImmutablePolicy(policy MutabilityPolicy) MutabilityOptions {
+	return mutabilityPolicy{mutability: spec.MutabilityImmutable, policy: policy}
+}
+
+type mutabilityPolicy struct {
+	mutability spec.Mutability
+	policy     MutabilityPolicy
+}
+
+func (o mutabilityPolicy) apply(e *mutabilityEnforcer) {
+	e.policies[o.mutability] = o.policy
+}
+
+type mutabilityEnforcer struct {
+	policies map[spec.Mutability]MutabilityPolicy
+}
+
+// EnforceMutability compares the incoming resource, which is about to replace the reference resource, against the
+// reference resource and enforces the mutability of readOnly and immutable attributes according to their policies.
+// All attributes are walked, including those of schema extensions and sub attributes of complex and multiValued
+// attributes. Elements of multiValued attributes are paired with reference elements by their identity, as in
+// prop.Property#Matches, and elements without a counterpart are compared against an unassigned reference.
+//
+// An immutable attribute is only considered changed when the reference value is assigned, because the specification
+// allows to set it once, and the incoming value is assigned, because omitting it in a replacement carries no intent
+// to change it. An omitted immutable value is copied forward unless the policy is KeepIncoming. A readOnly attribute
+// is considered changed whenever the two values differ.
+//
+// Copied values are assigned through a navigator from the root of the incoming resource, hence the modification events
+// are propagated. Errors are spec.ErrMutability naming the path of the offending attribute.
+func EnforceMutability(ref *prop.Resource, incoming *prop.Resource, options ...MutabilityOptions) error {
+	e := &mutabilityEnforcer{policies: map[spec.Mutability]MutabilityPolicy{
+		spec.MutabilityReadOnly:  CopyForward,
+		spec.MutabilityImmutable: RejectChange,
+	}}
+	for _, opt := range options {
+		opt.apply(e)
+	}
+
+	return e.enforceChildren(prop.Navigate(incoming.RootProperty()), ref.RootProperty())
+}
+
+// Enforces mutability on each child of the current property of nav, with ref being the corresponding reference
+// property, which may be nil when there is no counterpart.
+func (e *mutabilityEnforcer) enforceChildren(nav prop.Navigator, ref prop.Property) error {
+	current := nav.Current()
+
+	if current.Attribute().MultiValued() {
+		// Reversed, so that an element compacted upon modification does not shift the elements yet to visit.
+		for i := current.CountChildren() - 1; i >= 0; i-- {
+			elem := nav.At(i).Current()
+			if err := nav.Error(); err != nil {
+				return err
+			}
+
+			var refElem prop.Property
+			if ref != nil {
+				refElem = ref.FindChild(func(child prop.Property) bool {
+					return child.Matches(elem)
+				})
+			}
+
+			err := e.enforceChildren(nav, refElem)
+			nav.Retract()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return current.ForEachChild(func(_ int, child prop.Property) error {
+		var refChild prop.Property
+		if ref != nil {
+			refChild, _ = ref.ChildAtIndex(child.Attribute().Name())
+		}
+
+		nav.Dot(child.Attribute().Name())
+		defer nav.Retract()
+		if err := nav.Error(); err != nil {
+			return err
+		}
+
+		return e.enforce(nav, refChild)
+	})
+}
+
+func (e *mutabilityEnforcer) enforce(nav prop.Navigator, ref prop.Property) error {
+	property := nav.Current()
+	attr := property.Attribute()
+
+	policy, ok := e.policies[attr.Mutability()]
+	if !ok {
+		if !attr.IsScalar() {
+			return e.enforceChildren(nav, ref)
+		}
+		return nil
+	}
+
+	refUnassigned := ref == nil || ref.IsUnassigned()
+	switch attr.Mutability() {
+	case spec.MutabilityImmutable:
+		if refUnassigned {
+			return nil
+		}
+		if property.IsUnassigned() {
+			if policy == KeepIncoming {
+				return nil
+			}
+			return nav.Replace(ref.Raw()).Error()
+		}
+	case spec.MutabilityReadOnly:
+		if refUnassigned && property.IsUnassigned() {
+			return nil
+		}
+	}
+
+	if !refUnassigned && !property.IsUnassigned() && equalProperty(property, ref, false) {
+		return nil
+	}
+
+	switch policy {
+	case CopyForward:
+		if refUnassigned {
+			return nav.Unassign().Error()
+		}
+		return nav.Replace(ref.Raw()).Error()
+	case RejectChange:
+		return fmt.Errorf("%w: '%s' is %s", spec.ErrMutability, attr.Path(), attr.Mutability().String())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,321 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEnforceMutability(t *testing.T) {
+	s := new(EnforceMutabilityTestSuite)
+	suite.Run(t, s)
+}
+
+type EnforceMutabilityTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *EnforceMutabilityTestSuite) TestEnforceMutability() {
+	reference := map[string]interface{}{
+		"id":       "a1",
+		"badge":    "B-1",
+		"serial":   "S-1",
+		"nickName": "foo",
+		"devices": []interface{}{
+			map[string]interface{}{"value": "d1", "serial": "DS-1", "enrolled": "2020-01-01"},
+			map[string]interface{}{"value": "d2", "enrolled": "2020-02-01"},
+		},
+		"urn:ietf:params:scim:schemas:test:2.0:Mutability": map[string]interface{}{
+			"costCenter": "C-1",
+			"grade":      "G-1",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		incoming map[string]interface{}
+		options  []MutabilityOptions
+		expect   func(t *testing.T, resource *prop.Resource, err error)
+	}{
+		{
+			name: "readOnly values are copied forward",
+			incoming: map[string]interface{}{
+				"id":       "a1",
+				"badge":    "B-2",
+				"serial":   "S-1",
+				"nickName": "bar",
+				"devices": []interface{}{
+					map[string]interface{}{"value": "d2", "enrolled": "2021-01-01"},
+					map[string]interface{}{"value": "d1", "serial": "DS-1"},
+					map[string]interface{}{"value": "d3", "serial": "DS-3", "enrolled": "2021-01-01"},
+				},
+				"urn:ietf:params:scim:schemas:test:2.0:Mutability": map[string]interface{}{
+					"costCenter": "C-1",
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "B-1", s.valueAt(t, resource, "badge"))
+				assert.Equal(t, "bar", s.valueAt(t, resource, "nickName"))
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "d2", "enrolled": "2020-02-01"},
+					map[string]interface{}{"value": "d1", "serial": "DS-1", "enrolled": "2020-01-01"},
+					map[string]interface{}{"value": "d3", "serial": "DS-3", "enrolled": nil},
+				}, s.valueAt(t, resource, "devices"))
+				assert.Equal(t, "G-1", s.valueAt(t, resource, "urn:ietf:params:scim:schemas:test:2.0:Mutability", "grade"))
+			},
+		},
+		{
+			name: "omitted immutable values are copied forward",
+			incoming: map[string]interface{}{
+				"id": "a1",
+				"devices": []interface{}{
+					map[string]interface{}{"value": "d1"},
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "S-1", s.valueAt(t, resource, "serial"))
+				assert.Equal(t, "C-1", s.valueAt(t, resource, "urn:ietf:params:scim:schemas:test:2.0:Mutability", "costCenter"))
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "d1", "serial": "DS-1", "enrolled": "2020-01-01"},
+				}, s.valueAt(t, resource, "devices"))
+			},
+		},
+		{
+			name: "immutable values can be set once",
+			incoming: map[string]interface{}{
+				"id":     "a1",
+				"serial": "S-1",
+				"devices": []interface{}{
+					map[string]interface{}{"value": "d2", "serial": "DS-2"},
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "d2", "serial": "DS-2", "enrolled": "2020-02-01"},
+				}, s.valueAt(t, resource, "devices"))
+			},
+		},
+		{
+			name: "changed immutable value is rejected",
+			incoming: map[string]interface{}{
+				"id":     "a1",
+				"serial": "S-2",
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrMutability))
+				assert.Contains(t, err.Error(), "'serial' is immutable")
+			},
+		},
+		{
+			name: "changed immutable sub attribute of element is rejected",
+			incoming: map[string]interface{}{
+				"id": "a1",
+				"devices": []interface{}{
+					map[string]interface{}{"value": "d1", "serial": "DS-2"},
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrMutability))
+				assert.Contains(t, err.Error(), "'devices.serial' is immutable")
+			},
+		},
+		{
+			name: "changed immutable extension attribute is rejected",
+			incoming: map[string]interface{}{
+				"id": "a1",
+				"urn:ietf:params:scim:schemas:test:2.0:Mutability": map[string]interface{}{
+					"costCenter": "C-2",
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrMutability))
+				assert.Contains(t, err.Error(), "'urn:ietf:params:scim:schemas:test:2.0:Mutability:costCenter' is immutable")
+			},
+		},
+		{
+			name: "changed readOnly value is rejected by policy",
+			incoming: map[string]interface{}{
+				"id":     "a1",
+				"serial": "S-1",
+				"badge":  "B-2",
+			},
+			options: []MutabilityOptions{ReadOnlyPolicy(RejectChange)},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrMutability))
+				assert.Contains(t, err.Error(), "'badge' is readOnly")
+			},
+		},
+		{
+			name: "changes are kept by policy",
+			incoming: map[string]interface{}{
+				"id":     "a1",
+				"serial": "S-2",
+				"badge":  "B-2",
+			},
+			options: []MutabilityOptions{ReadOnlyPolicy(KeepIncoming), ImmutablePolicy(KeepIncoming)},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "S-2", s.valueAt(t, resource, "serial"))
+				assert.Equal(t, "B-2", s.valueAt(t, resource, "badge"))
+				assert.Nil(t, s.valueAt(t, resource, "urn:ietf:params:scim:schemas:test:2.0:Mutability", "costCenter"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			ref := prop.NewResource(s.resourceType)
+			_, err := ref.RootProperty().Replace(reference)
+			require.Nil(t, err)
+
+			resource := prop.NewResource(s.resourceType)
+			_, err = resource.RootProperty().Replace(test.incoming)
+			require.Nil(t, err)
+
+			err = EnforceMutability(ref, resource, test.options...)
+			test.expect(t, resource, err)
+		})
+	}
+}
+
+func (s *EnforceMutabilityTestSuite) valueAt(t *testing.T, resource *prop.Resource, names ...string) interface{} {
+	nav := resource.Navigator()
+	for _, name := range names {
+		nav.Dot(name)
+	}
+	require.Nil(t, nav.Error())
+	return nav.Current().Raw()
+}
+
+func (s *EnforceMutabilityTestSuite) SetupSuite() {
+	for _, each := range []string{testCoreSchema, testMutabilitySchema, testMutabilitySchemaExtension} {
+		schema := new(spec.Schema)
+		require.Nil(s.T(), json.Unmarshal([]byte(each), schema))
+		require.Nil(s.T(), spec.Schemas().Register(schema))
+	}
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testMutabilityResourceType), s.resourceType))
+}
+
+const (
+	testMutabilitySchema = `
+{
+  "id": "mutability",
+  "name": "mutability",
+  "attributes": [
+    {
+      "id": "badge",
+      "name": "badge",
+      "type": "string",
+      "mutability": "readOnly",
+      "_index": 100,
+      "_path": "badge"
+    },
+    {
+      "id": "serial",
+      "name": "serial",
+      "type": "string",
+      "mutability": "immutable",
+      "_index": 101,
+      "_path": "serial"
+    },
+    {
+      "id": "nickName",
+      "name": "nickName",
+      "type": "string",
+      "_index": 102,
+      "_path": "nickName"
+    },
+    {
+      "id": "devices",
+      "name": "devices",
+      "type": "complex",
+      "multiValued": true,
+      "_index": 103,
+      "_path": "devices",
+      "_annotations": {
+        "@AutoCompact": {},
+        "@ElementAnnotations": {
+          "@StateSummary": {}
+        }
+      },
+      "subAttributes": [
+        {
+          "id": "devices.value",
+          "name": "value",
+          "type": "string",
+          "_index": 0,
+          "_path": "devices.value",
+          "_annotations": {
+            "@Identity": {}
+          }
+        },
+        {
+          "id": "devices.serial",
+          "name": "serial",
+          "type": "string",
+          "mutability": "immutable",
+          "_index": 1,
+          "_path": "devices.serial"
+        },
+        {
+          "id": "devices.enrolled",
+          "name": "enrolled",
+          "type": "string",
+          "mutability": "readOnly",
+          "_index": 2,
+          "_path": "devices.enrolled"
+        }
+      ]
+    }
+  ]
+}
+`
+	testMutabilitySchemaExtension = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Mutability",
+  "name": "Mutability Extension",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Mutability:costCenter",
+      "name": "costCenter",
+      "type": "string",
+      "mutability": "immutable",
+      "_index": 100,
+      "_path": "urn:ietf:params:scim:schemas:test:2.0:Mutability:costCenter"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Mutability:grade",
+      "name": "grade",
+      "type": "string",
+      "mutability": "readOnly",
+      "_index": 101,
+      "_path": "urn:ietf:params:scim:schemas:test:2.0:Mutability:grade"
+    }
+  ]
+}
+`
+	testMutabilityResourceType = `
+{
+  "id": "Mutability",
+  "name": "Mutability",
+  "schema": "mutability",
+  "schemaExtensions": [
+    {
+      "schema": "urn:ietf:params:scim:schemas:test:2.0:Mutability"
+    }
+  ]
+}
+`
+)
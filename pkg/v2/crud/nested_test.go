@@ -0,0 +1,365 @@
+package crud
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud/expr"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNestedComplex(t *testing.T) {
+	s := new(NestedComplexTestSuite)
+	suite.Run(t, s)
+}
+
+type NestedComplexTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *NestedComplexTestSuite) TestNestedComplex() {
+	const data = `
+{
+  "org": {
+    "unit": {
+      "code": "A1",
+      "name": "Research"
+    }
+  },
+  "positions": [
+    {
+      "title": "engineer",
+      "location": {
+        "city": "Paris"
+      }
+    },
+    {
+      "title": "manager",
+      "location": {
+        "city": "Lyon"
+      }
+    }
+  ],
+  "urn:example:params:scim:schemas:extension:2.0:Training": {
+    "certifications": [
+      {
+        "name": "CKA",
+        "issuer": "CNCF",
+        "year": 2019
+      },
+      {
+        "name": "CISSP",
+        "issuer": "ISC2",
+        "year": 2020
+      }
+    ]
+  }
+}
+`
+	const certifications = "urn:example:params:scim:schemas:extension:2.0:Training:certifications"
+	certificationsOf := func(resource *prop.Resource) interface{} {
+		return resource.Navigator().
+			Dot("urn:example:params:scim:schemas:extension:2.0:Training").
+			Dot("certifications").
+			Current().Raw()
+	}
+
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, resource *prop.Resource)
+		expect func(t *testing.T, resource *prop.Resource)
+	}{
+		{
+			name: "evaluate filter on sub attribute of sub attribute",
+			expect: func(t *testing.T, resource *prop.Resource) {
+				for filter, match := range map[string]bool{
+					`org.unit.code eq "A1"`:                                  true,
+					`org.unit.code eq "B2"`:                                  false,
+					`org.unit pr and org.unit.name sw "Re"`:                  true,
+					`positions.location.city eq "Lyon"`:                      true,
+					`positions.location.city eq "Nice"`:                      false,
+					`positions.location.city eq "Lyon" and org.unit.code pr`: true,
+				} {
+					r, err := Evaluate(resource, filter)
+					assert.Nil(t, err, filter)
+					assert.Equal(t, match, r, filter)
+				}
+			},
+		},
+		{
+			name: "replace sub attribute of sub attribute",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Replace(resource, "urn:example:params:scim:schemas:2.0:Nested:org.unit.code", "B2"))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				r, err := Evaluate(resource, `org.unit.code eq "B2" and org.unit.name eq "Research"`)
+				assert.Nil(t, err)
+				assert.True(t, r)
+			},
+		},
+		{
+			name: "replace sub attribute of sub attribute of selected element",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Replace(resource, `positions[location.city eq "Paris"].location.city`, "Nice"))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"title": "engineer", "location": map[string]interface{}{"city": "Nice"}},
+					map[string]interface{}{"title": "manager", "location": map[string]interface{}{"city": "Lyon"}},
+				}, resource.Navigator().Dot("positions").Current().Raw())
+			},
+		},
+		{
+			name: "add complex sub attribute",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Delete(resource, "org.unit"))
+				require.Nil(t, Add(resource, "org.unit", map[string]interface{}{"code": "C3"}))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, map[string]interface{}{
+					"unit": map[string]interface{}{"code": "C3", "name": nil},
+				}, resource.Navigator().Dot("org").Current().Raw())
+			},
+		},
+		{
+			name: "delete sub attribute of sub attribute",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Delete(resource, "org.unit.name"))
+				require.Nil(t, Delete(resource, `positions[title eq "manager"].location.city`))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				r, err := Evaluate(resource, `org.unit.name pr`)
+				assert.Nil(t, err)
+				assert.False(t, r)
+				r, err = Evaluate(resource, `positions.location.city eq "Lyon"`)
+				assert.Nil(t, err)
+				assert.False(t, r)
+			},
+		},
+		{
+			name: "evaluate filter on multiValued complex extension attribute",
+			expect: func(t *testing.T, resource *prop.Resource) {
+				for filter, match := range map[string]bool{
+					certifications + `.name eq "CISSP"`:                                          true,
+					certifications + `.issuer eq "CNCF" and ` + certifications + `.year gt 2019`: true,
+					certifications + `.year gt 2020`:                                             false,
+				} {
+					r, err := Evaluate(resource, filter)
+					assert.Nil(t, err, filter)
+					assert.Equal(t, match, r, filter)
+				}
+			},
+		},
+		{
+			name: "replace sub attribute of selected element of extension attribute",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Replace(resource, certifications+`[issuer eq "ISC2"].year`, int64(2023)))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"name": "CKA", "issuer": "CNCF", "year": int64(2019)},
+					map[string]interface{}{"name": "CISSP", "issuer": "ISC2", "year": int64(2023)},
+				}, certificationsOf(resource))
+			},
+		},
+		{
+			name: "add sub attribute of element of extension attribute selected by eq filter",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Add(resource, certifications+`[name eq "CKAD"].issuer`, "CNCF"))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"name": "CKA", "issuer": "CNCF", "year": int64(2019)},
+					map[string]interface{}{"name": "CISSP", "issuer": "ISC2", "year": int64(2020)},
+					map[string]interface{}{"name": "CKAD", "issuer": "CNCF"},
+				}, certificationsOf(resource))
+			},
+		},
+		{
+			name: "delete selected element of extension attribute",
+			modify: func(t *testing.T, resource *prop.Resource) {
+				require.Nil(t, Delete(resource, certifications+`[name eq "CKA"]`))
+				require.Nil(t, Delete(resource, certifications+`[name eq "CISSP"].year`))
+			},
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"name": "CISSP", "issuer": "ISC2", "year": nil},
+				}, certificationsOf(resource))
+			},
+		},
+		{
+			name: "seek sort target",
+			expect: func(t *testing.T, resource *prop.Resource) {
+				by, err := expr.CompilePath("org.unit.code")
+				require.Nil(t, err)
+				target, err := SeekSortTarget(resource, by)
+				assert.Nil(t, err)
+				require.NotNil(t, target)
+				assert.Equal(t, "A1", target.Raw())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(s.resourceType)
+			require.Nil(t, scimjson.Deserialize([]byte(data), resource))
+			if test.modify != nil {
+				test.modify(t, resource)
+			}
+			test.expect(t, resource)
+		})
+	}
+}
+
+func (s *NestedComplexTestSuite) SetupSuite() {
+	core := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNestedSchema), schema))
+	spec.Schemas().Register(schema)
+
+	extension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNestedExtensionSchema), extension))
+	spec.Schemas().Register(extension)
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNestedResourceType), s.resourceType))
+	Register(s.resourceType)
+}
+
+const (
+	testNestedSchema = `
+{
+  "id": "urn:example:params:scim:schemas:2.0:Nested",
+  "name": "nested",
+  "attributes": [
+    {
+      "id": "org",
+      "name": "org",
+      "type": "complex",
+      "_index": 100,
+      "_path": "org",
+      "subAttributes": [
+        {
+          "id": "org.unit",
+          "name": "unit",
+          "type": "complex",
+          "_index": 0,
+          "_path": "org.unit",
+          "subAttributes": [
+            {
+              "id": "org.unit.code",
+              "name": "code",
+              "type": "string",
+              "_index": 0,
+              "_path": "org.unit.code"
+            },
+            {
+              "id": "org.unit.name",
+              "name": "name",
+              "type": "string",
+              "_index": 1,
+              "_path": "org.unit.name"
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "id": "positions",
+      "name": "positions",
+      "type": "complex",
+      "multiValued": true,
+      "_index": 101,
+      "_path": "positions",
+      "subAttributes": [
+        {
+          "id": "positions.title",
+          "name": "title",
+          "type": "string",
+          "_index": 0,
+          "_path": "positions.title"
+        },
+        {
+          "id": "positions.location",
+          "name": "location",
+          "type": "complex",
+          "_index": 1,
+          "_path": "positions.location",
+          "subAttributes": [
+            {
+              "id": "positions.location.city",
+              "name": "city",
+              "type": "string",
+              "_index": 0,
+              "_path": "positions.location.city"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	testNestedExtensionSchema = `
+{
+  "id": "urn:example:params:scim:schemas:extension:2.0:Training",
+  "name": "training",
+  "attributes": [
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Training:certifications",
+      "name": "certifications",
+      "type": "complex",
+      "multiValued": true,
+      "_index": 100,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Training:certifications",
+      "subAttributes": [
+        {
+          "id": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.name",
+          "name": "name",
+          "type": "string",
+          "_index": 0,
+          "_path": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.name"
+        },
+        {
+          "id": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.issuer",
+          "name": "issuer",
+          "type": "string",
+          "_index": 1,
+          "_path": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.issuer"
+        },
+        {
+          "id": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.year",
+          "name": "year",
+          "type": "integer",
+          "_index": 2,
+          "_path": "urn:example:params:scim:schemas:extension:2.0:Training:certifications.year"
+        }
+      ]
+    }
+  ]
+}
+`
+	testNestedResourceType = `
+{
+  "id": "Nested",
+  "name": "Nested",
+  "schema": "urn:example:params:scim:schemas:2.0:Nested",
+  "schemaExtensions": [
+    {
+      "schema": "urn:example:params:scim:schemas:extension:2.0:Training",
+      "required": false
+    }
+  ]
+}
+`
+)
@@ -0,0 +1,100 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/crud/expr"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// PatchOperation is a single modification applied by Patch. Op is one of "add", "replace" and "remove", case
+// insensitive. Path and Value carry the same meaning as in Add, Replace and Delete; Value is ignored by "remove".
+type PatchOperation struct {
+	Op    string
+	Path  string
+	Value interface{}
+}
+
+// PatchOptions customizes the behaviour of Patch.
+type PatchOptions interface {
+	apply(p *patcher)
+}
+
+// DryRun returns a PatchOptions that applies the operations to a copy of the resource, as returned by Copy, instead
+// of the resource itself. The copy is returned as the projected result while the original resource stays untouched,
+// which is useful to preview or validate changes before persisting them. Because the operations run through the
+// same code path, errors are identical to those of a real apply.
+func DryRun() PatchOptions {
+	return dryRun{}
+}
+
+type dryRun struct{}
+
+func (o dryRun) apply(p *patcher) {
+	p.dryRun = true
+}
+
+type patcher struct {
+	dryRun bool
+	coerce bool
+}
+
+// Patch applies the operations to the resource in order and returns the patched resource. By default, the resource
+// is modified in place and returned; with DryRun, the returned resource is a modified copy. Patch stops at the first
+// failing operation and returns its error, in which case operations prior to it may have been applied. Each distinct
+// path is compiled once per call, and reused by the operations sharing it.
+func Patch(resource *prop.Resource, operations []PatchOperation, options ...PatchOptions) (*prop.Resource, error) {
+	p := new(patcher)
+	for _, opt := range options {
+		opt.apply(p)
+	}
+
+	target := resource
+	if p.dryRun {
+		target = Copy(resource)
+	}
+
+	paths := pathCache{}
+	for _, op := range operations {
+		var err error
+		if p.coerce && !strings.EqualFold(op.Op, "remove") {
+			if op.Value, err = coerceOperation(target, op, paths.compile); err != nil {
+				return nil, err
+			}
+		}
+		switch strings.ToLower(op.Op) {
+		case "add":
+			err = new(adder).addAt(target, op.Path, op.Value, paths.compile)
+		case "replace":
+			err = replaceAt(target, op.Path, op.Value, paths.compile)
+		case "remove":
+			err = deleteAt(target, op.Path, paths.compile)
+		default:
+			err = fmt.Errorf("%w: invalid patch operation '%s'", spec.ErrInvalidSyntax, op.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+// pathCache holds the paths compiled during a single Patch call, keyed by the path as it appears in the operations.
+// Compiled paths are never modified by the traversal, hence they can be shared by operations. A cache must not outlive
+// the call, since the compiled path depends on the resource type of the patched resource.
+type pathCache map[string]*expr.Expression
+
+func (c pathCache) compile(resource *prop.Resource, path string) (*expr.Expression, error) {
+	if head, ok := c[path]; ok {
+		return head, nil
+	}
+	head, err := compilePath(resource, path)
+	if err != nil {
+		return nil, err
+	}
+	c[path] = head
+	return head, nil
+}
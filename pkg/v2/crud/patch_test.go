@@ -0,0 +1,317 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+)
+
+func TestPatch(t *testing.T) {
+	s := new(PatchTestSuite)
+	suite.Run(t, s)
+}
+
+type PatchTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *PatchTestSuite) TestPatch() {
+	tests := []struct {
+		name       string
+		operations []PatchOperation
+		expect     func(t *testing.T, patched *prop.Resource, err error)
+	}{
+		{
+			name: "add, replace and remove",
+			operations: []PatchOperation{
+				{Op: "add", Path: "emails", Value: []interface{}{
+					map[string]interface{}{"value": "baz@foo.com"},
+				}},
+				{Op: "Replace", Path: `emails[value eq "foo@bar.com"].type`, Value: "home"},
+				{Op: "remove", Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, 3, patched.Navigator().Dot("emails").Current().CountChildren())
+				assert.Equal(t, "home", patched.Navigator().Dot("emails").At(0).Dot("type").Current().Raw())
+				assert.True(t, patched.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Current().IsUnassigned())
+			},
+		},
+		{
+			name: "remove sub attribute of every element",
+			operations: []PatchOperation{
+				{Op: "add", Path: "emails.display", Value: "Foo"},
+				{Op: "remove", Path: "emails.display"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				require.Nil(t, err)
+				display, err := Get(patched, "emails.display")
+				require.Nil(t, err)
+				assert.Nil(t, display)
+				value, err := Get(patched, "emails.value")
+				require.Nil(t, err)
+				assert.Equal(t, []interface{}{"foo@bar.com", "bar@foo.com"}, value)
+			},
+		},
+		{
+			name: "invalid operation",
+			operations: []PatchOperation{
+				{Op: "move", Path: "emails"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				assert.Nil(t, patched)
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+		{
+			name: "incompatible value",
+			operations: []PatchOperation{
+				{Op: "replace", Path: "emails.primary", Value: "true"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				assert.Nil(t, patched)
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := s.newResource(t)
+			patched, err := Patch(resource, test.operations)
+			test.expect(t, patched, err)
+			if err == nil {
+				assert.Same(t, resource, patched)
+			}
+		})
+	}
+}
+
+func (s *PatchTestSuite) TestPatchCoerceValues() {
+	tests := []struct {
+		name       string
+		operations []PatchOperation
+		expect     func(t *testing.T, patched *prop.Resource, err error)
+	}{
+		{
+			name: "boolean string",
+			operations: []PatchOperation{
+				{Op: "replace", Path: `emails[type eq "home"].primary`, Value: "true"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, true, patched.Navigator().Dot("emails").At(1).Dot("primary").Current().Raw())
+			},
+		},
+		{
+			name: "boolean string in added element",
+			operations: []PatchOperation{
+				{Op: "add", Path: "emails", Value: []interface{}{
+					map[string]interface{}{"value": "baz@foo.com", "primary": "false"},
+				}},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, false, patched.Navigator().Dot("emails").At(2).Dot("primary").Current().Raw())
+			},
+		},
+		{
+			name: "ambiguous boolean string",
+			operations: []PatchOperation{
+				{Op: "replace", Path: "emails.primary", Value: "1"},
+			},
+			expect: func(t *testing.T, patched *prop.Resource, err error) {
+				assert.Nil(t, patched)
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "cannot be coerced to boolean")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			patched, err := Patch(s.newResource(t), test.operations, CoerceValues())
+			test.expect(t, patched, err)
+		})
+	}
+}
+
+func (s *PatchTestSuite) TestPatchDryRun() {
+	tests := []struct {
+		name       string
+		operations []PatchOperation
+	}{
+		{
+			name: "successful operations",
+			operations: []PatchOperation{
+				{Op: "add", Path: "emails", Value: []interface{}{
+					map[string]interface{}{"value": "baz@foo.com"},
+				}},
+				{Op: "replace", Path: "meta.version", Value: "v2"},
+				{Op: "remove", Path: `emails[type eq "home"]`},
+			},
+		},
+		{
+			name: "failed operation after successful ones",
+			operations: []PatchOperation{
+				{Op: "replace", Path: "meta.version", Value: "v2"},
+				{Op: "replace", Path: "emails.primary", Value: "true"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := s.newResource(t)
+			before, err := scimjson.Serialize(resource)
+			require.Nil(t, err)
+
+			projected, dryRunErr := Patch(resource, test.operations, DryRun())
+
+			after, err := scimjson.Serialize(resource)
+			require.Nil(t, err)
+			assert.Equal(t, before, after)
+
+			// a real apply to another instance must produce the same outcome
+			applied, applyErr := Patch(s.newResource(t), test.operations)
+			assert.Equal(t, applyErr, dryRunErr)
+			if applyErr == nil {
+				assert.True(t, resource != projected)
+
+				expect, err := scimjson.Serialize(applied)
+				require.Nil(t, err)
+				actual, err := scimjson.Serialize(projected)
+				require.Nil(t, err)
+				assert.Equal(t, expect, actual)
+			}
+		})
+	}
+}
+
+func (s *PatchTestSuite) newResource(t *testing.T) *prop.Resource {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(t, r.Navigator().Replace(map[string]interface{}{
+		"schemas": []interface{}{"A"},
+		"id":      "foo",
+		"meta": map[string]interface{}{
+			"version": "v1",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{
+				"value":   "foo@bar.com",
+				"type":    "work",
+				"primary": true,
+			},
+			map[string]interface{}{
+				"value": "bar@foo.com",
+				"type":  "home",
+			},
+		},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "123456",
+		},
+	}).Error())
+	return r
+}
+
+func (s *PatchTestSuite) SetupSuite() {
+	core := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testMainSchema), schema))
+	spec.Schemas().Register(schema)
+
+	schemaExtension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testSchemaExtension), schemaExtension))
+	spec.Schemas().Register(schemaExtension)
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), s.resourceType))
+	Register(s.resourceType)
+}
+
+func BenchmarkPatch(b *testing.B) {
+	core := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testCoreSchema), core))
+	spec.Schemas().Register(core)
+
+	schema := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testMainSchema), schema))
+	spec.Schemas().Register(schema)
+
+	extension := new(spec.Schema)
+	require.Nil(b, json.Unmarshal([]byte(testSchemaExtension), extension))
+	spec.Schemas().Register(extension)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(b, json.Unmarshal([]byte(testResourceType), resourceType))
+	Register(resourceType)
+
+	newResource := func() *prop.Resource {
+		r := prop.NewResource(resourceType)
+		require.Nil(b, r.Navigator().Replace(map[string]interface{}{
+			"id": "foo",
+			"emails": []interface{}{
+				map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+				map[string]interface{}{"value": "bar@foo.com", "type": "home"},
+			},
+		}).Error())
+		return r
+	}
+
+	paths := []string{
+		`emails[type eq "work"].value`,
+		`emails[type eq "work"].primary`,
+		`emails[type eq "home"].value`,
+		`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber`,
+		`loginCount`,
+	}
+	values := []interface{}{"foo@work.com", true, "foo@home.com", "654321", int64(42)}
+
+	operations := make([]PatchOperation, 0, 100)
+	for i := 0; i < 100; i++ {
+		operations = append(operations, PatchOperation{
+			Op:    "replace",
+			Path:  paths[i%len(paths)],
+			Value: values[i%len(values)],
+		})
+	}
+
+	b.Run("patch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			resource := newResource()
+			b.StartTimer()
+			if _, err := Patch(resource, operations); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("operation by operation", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			resource := newResource()
+			b.StartTimer()
+			for _, op := range operations {
+				if err := Replace(resource, op.Path, op.Value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
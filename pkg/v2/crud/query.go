@@ -1,9 +1,13 @@
 package crud
 
 import (
+	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
 	"sort"
+	"strings"
+	"time"
 )
 
 // Order for sorting
@@ -34,7 +38,32 @@ type (
 	}
 )
 
-// Sort the given list of resources according to the sort options.
+// Validate checks that at most one of Attributes and ExcludedAttributes is specified, as required by RFC7644 section
+// 3.9, which is reported as spec.ErrInvalidValue, and that each of them is an attribute path, optionally prefixed by
+// the schema URN, i.e. "name.givenName" or "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName". A nil
+// projection is valid.
+func (p *Projection) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if len(p.Attributes) > 0 && len(p.ExcludedAttributes) > 0 {
+		return fmt.Errorf("%w: only one of attributes and excludedAttributes may be specified", spec.ErrInvalidValue)
+	}
+	for _, paths := range [][]string{p.Attributes, p.ExcludedAttributes} {
+		for _, path := range paths {
+			if _, err := expr.CompilePath(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sort the given list of resources according to the sort options. Resources are compared by their sort target, as
+// located by SeekSortTarget, with a comparator chosen by the type of the sortBy attribute: integer and decimal values
+// are compared numerically, dateTime values chronologically, boolean values with false before true, and other values
+// lexically, regardless of case unless the attribute is caseExact. Resources without a sort target are placed last,
+// regardless of order. The sort is stable.
 func (s Sort) Sort(resources []*prop.Resource) error {
 	if len(resources) <= 1 {
 		return nil
@@ -42,23 +71,38 @@ func (s Sort) Sort(resources []*prop.Resource) error {
 		return nil
 	}
 
-	head, err := expr.CompilePath(s.By)
+	switch s.Order {
+	case SortDefault, SortAsc, SortDesc:
+	default:
+		panic("invalid sortOrder")
+	}
+
+	head, err := expr.CompileSortPath(s.By)
 	if err != nil {
 		return err
 	}
 
-	sort.Sort(&sortWrapper{
+	w := &sortWrapper{
 		resources: resources,
-		by:        head,
+		targets:   make([]prop.Property, len(resources)),
 		dir:       s.Order,
-	})
+	}
+	for i, resource := range resources {
+		// Unassigned sort targets, i.e. attributes of a schema extension absent from the resource, are considered as
+		// not available.
+		if p, err := SeekSortTarget(resource, head); err == nil && !p.IsUnassigned() {
+			w.targets[i] = p
+		}
+	}
+
+	sort.Stable(w)
 	return nil
 }
 
 type sortWrapper struct {
-	by        *expr.Expression
 	dir       SortOrder
 	resources []*prop.Resource
+	targets   []prop.Property // sort target of the resource at the same index, or nil
 }
 
 func (s *sortWrapper) Len() int {
@@ -66,50 +110,94 @@ func (s *sortWrapper) Len() int {
 }
 
 func (s *sortWrapper) Less(i, j int) bool {
-	var (
-		a   prop.Property
-		b   prop.Property
-		err error
-	)
+	a, b := s.targets[i], s.targets[j]
+	switch {
+	case a == nil:
+		return false
+	case b == nil:
+		return true
+	}
 
-	a, err = SeekSortTarget(s.resources[i], s.by)
-	if err != nil {
-		switch s.dir {
-		case SortDefault, SortAsc:
-			return false
-		case SortDesc:
-			return true
-		default:
-			panic("invalid sortOrder")
-		}
+	if s.dir == SortDesc {
+		return compareSortTargets(b, a) < 0
 	}
+	return compareSortTargets(a, b) < 0
+}
 
-	b, err = SeekSortTarget(s.resources[j], s.by)
-	if err != nil {
-		switch s.dir {
-		case SortDefault, SortAsc:
-			return true
-		case SortDesc:
-			return false
+func (s *sortWrapper) Swap(i, j int) {
+	s.resources[i], s.resources[j] = s.resources[j], s.resources[i]
+	s.targets[i], s.targets[j] = s.targets[j], s.targets[i]
+}
+
+// Compares the values of two assigned sort targets according to the type of the attribute of a, and returns a negative
+// number, zero or a positive number when a sorts before, the same as, or after b. Values that do not conform to the
+// type, which may happen when the targets are of different attributes, are considered equal.
+func compareSortTargets(a, b prop.Property) int {
+	attr := a.Attribute()
+	switch attr.Type() {
+	case spec.TypeInteger:
+		x, xOk := a.Raw().(int64)
+		y, yOk := b.Raw().(int64)
+		switch {
+		case !xOk || !yOk || x == y:
+			return 0
+		case x < y:
+			return -1
 		default:
-			panic("invalid sortOrder")
+			return 1
 		}
-	}
-
-	if ltCapable, ok := a.(prop.LtCapable); !ok {
-		return false
-	} else {
-		switch s.dir {
-		case SortDefault, SortAsc:
-			return ltCapable.LessThan(b.Raw())
-		case SortDesc:
-			return !ltCapable.LessThan(b.Raw())
+	case spec.TypeDecimal:
+		x, xOk := a.Raw().(float64)
+		y, yOk := b.Raw().(float64)
+		switch {
+		case !xOk || !yOk || x == y:
+			return 0
+		case x < y:
+			return -1
 		default:
-			panic("invalid sortOrder")
+			return 1
 		}
+	case spec.TypeDateTime:
+		x, xErr := parseSortDateTime(a.Raw())
+		y, yErr := parseSortDateTime(b.Raw())
+		switch {
+		case xErr != nil || yErr != nil || x.Equal(y):
+			return 0
+		case x.Before(y):
+			return -1
+		default:
+			return 1
+		}
+	case spec.TypeBoolean:
+		x, xOk := a.Raw().(bool)
+		y, yOk := b.Raw().(bool)
+		switch {
+		case !xOk || !yOk || x == y:
+			return 0
+		case !x:
+			return -1
+		default:
+			return 1
+		}
+	case spec.TypeString, spec.TypeReference, spec.TypeBinary:
+		x, xOk := a.Raw().(string)
+		y, yOk := b.Raw().(string)
+		if !xOk || !yOk {
+			return 0
+		}
+		if !attr.CaseExact() {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+		return strings.Compare(x, y)
+	default:
+		return 0
 	}
 }
 
-func (s *sortWrapper) Swap(i, j int) {
-	s.resources[i], s.resources[j] = s.resources[j], s.resources[i]
+func parseSortDateTime(raw interface{}) (time.Time, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return time.Time{}, spec.ErrInvalidValue
+	}
+	return time.Parse(spec.ISO8601, s)
 }
@@ -1,15 +1,70 @@
 package crud
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
+	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
-// Register calls expr.RegisterURN for the main schema ids and all schema extension ids in the resource type.
+// Register calls expr.RegisterURN for the main schema ids and all schema extension ids in the resource type, and
+// compiles the discriminating filter of the resource type, if any. Because the filter is part of the configuration,
+// Register panics if it does not compile.
 func Register(resourceType *spec.ResourceType) {
 	expr.RegisterURN(resourceType.Schema().ID())
 	_ = resourceType.ForEachExtension(func(extension *spec.Schema, required bool) error {
 		expr.RegisterURN(extension.ID())
 		return nil
 	})
+
+	if _, err := resourceTypeFilter(resourceType); err != nil {
+		panic(fmt.Sprintf("resource type %s has invalid filter: %s", resourceType.ID(), err))
+	}
+}
+
+// Compiled discriminating filters by resource type.
+var resourceTypeFilters sync.Map
+
+// Returns the compiled discriminating filter of the resource type, or nil if it has none.
+func resourceTypeFilter(resourceType *spec.ResourceType) (*expr.Expression, error) {
+	if len(resourceType.Filter()) == 0 {
+		return nil, nil
+	}
+	if cf, ok := resourceTypeFilters.Load(resourceType); ok {
+		return cf.(*expr.Expression), nil
+	}
+
+	cf, err := expr.CompileFilter(resourceType.Filter())
+	if err != nil {
+		return nil, err
+	}
+	resourceTypeFilters.Store(resourceType, cf)
+	return cf, nil
+}
+
+// DiscriminateFilter returns the filter narrowed down to the resources of the resource type, by AND-ing the
+// discriminating filter of the resource type onto it, so that a query against a collection shared by several resource
+// types only matches the resources of this one. The filter is returned as is if the resource type has no
+// discriminating filter, and the discriminating filter is returned if the filter is empty.
+func DiscriminateFilter(resourceType *spec.ResourceType, filter string) string {
+	switch {
+	case len(resourceType.Filter()) == 0:
+		return filter
+	case len(filter) == 0:
+		return resourceType.Filter()
+	default:
+		return fmt.Sprintf("(%s) and (%s)", filter, resourceType.Filter())
+	}
+}
+
+// BelongsToResourceType returns true if the resource satisfies the discriminating filter of its resource type, or if
+// the resource type has none.
+func BelongsToResourceType(resource *prop.Resource) (bool, error) {
+	cf, err := resourceTypeFilter(resource.ResourceType())
+	if err != nil || cf == nil {
+		return err == nil, err
+	}
+	return EvaluateExpressionOnProperty(resource.RootProperty(), cf)
 }
@@ -76,13 +76,19 @@ import (
 //
 // Other sortBy parameters that does not fall into the above three categories are considered to be invalid.
 //
+// In all cases, the sortBy parameter may be prefixed with a schema URN. The main schema URN is optional, while the
+// schema extension URN is necessary to refer to attributes in the schema extension. For instance,
+//	sortBy=urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber
+// Given the above resource snippet, the value "11250" will be used to sort the resource. If the resource does not carry
+// the schema extension, the returned sort target is unassigned.
+//
 func SeekSortTarget(resource *prop.Resource, by *expr.Expression) (prop.Property, error) {
 	if by.ContainsFilter() {
 		return nil, fmt.Errorf("%w: sortBy attribute cannot contain filter", spec.ErrInvalidPath)
 	}
 
 	var candidates []prop.Property
-	if err := primaryOrFirstTraverse(resource.RootProperty(), by, func(nav prop.Navigator) error {
+	if err := primaryOrFirstTraverse(resource.RootProperty(), skipMainSchemaNamespace(resource, by), func(nav prop.Navigator) error {
 		candidates = append(candidates, nav.Current())
 		return nil
 	}); err != nil {
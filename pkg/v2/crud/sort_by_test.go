@@ -114,6 +114,31 @@ func (s *SeekSortByTargetTestSuite) TestSeekSortTarget() {
 				assert.Equal(t, "bar", target.Raw())
 			},
 		},
+		{
+			name: "schema extension target",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				assert.False(t, r.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+					Dot("employeeNumber").Replace("11250").HasError())
+				return r
+			},
+			sortBy: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+			expect: func(t *testing.T, target prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "11250", target.Raw())
+			},
+		},
+		{
+			name: "absent schema extension target",
+			getResource: func(t *testing.T) *prop.Resource {
+				return prop.NewResource(s.resourceType)
+			},
+			sortBy: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+			expect: func(t *testing.T, target prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.True(t, target.IsUnassigned())
+			},
+		},
 		{
 			name: "invalid target",
 			getResource: func(t *testing.T) *prop.Resource {
@@ -150,6 +175,244 @@ func (s *SeekSortByTargetTestSuite) TestSeekSortTarget() {
 	}
 }
 
+func (s *SeekSortByTargetTestSuite) TestSortBySchemaExtension() {
+	employee := func(employeeNumber string) *prop.Resource {
+		r := prop.NewResource(s.resourceType)
+		if len(employeeNumber) > 0 {
+			require.False(s.T(), r.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+				Dot("employeeNumber").Replace(employeeNumber).HasError())
+		}
+		return r
+	}
+	employeeNumbers := func(resources []*prop.Resource) []interface{} {
+		var numbers []interface{}
+		for _, r := range resources {
+			numbers = append(numbers, r.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").
+				Dot("employeeNumber").Current().Raw())
+		}
+		return numbers
+	}
+
+	tests := []struct {
+		name   string
+		order  SortOrder
+		expect []interface{}
+	}{
+		{
+			name:   "ascending",
+			order:  SortAsc,
+			expect: []interface{}{"1", "2", "3", nil},
+		},
+		{
+			name:   "descending",
+			order:  SortDesc,
+			expect: []interface{}{"3", "2", "1", nil},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resources := []*prop.Resource{employee("2"), employee(""), employee("3"), employee("1")}
+			err := Sort{
+				By:    "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+				Order: test.order,
+			}.Sort(resources)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, employeeNumbers(resources))
+		})
+	}
+}
+
+func (s *SeekSortByTargetTestSuite) TestSortByType() {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Sortable", "Sortable").
+		Attribute(spec.IntegerAttr("rank")).
+		Attribute(spec.DecimalAttr("score")).
+		Attribute(spec.DateTimeAttr("since")).
+		Attribute(spec.StringAttr("code").CaseExact()).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Sortable",
+  "name": "Sortable",
+  "endpoint": "/Sortables",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Sortable"
+}
+`), resourceType))
+
+	values := func(by string, resources []*prop.Resource) []interface{} {
+		var result []interface{}
+		for _, r := range resources {
+			result = append(result, r.Navigator().Dot(by).Current().Raw())
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		by     string
+		order  SortOrder
+		values []interface{}
+		expect []interface{}
+	}{
+		{
+			name:   "integer ascending",
+			by:     "rank",
+			order:  SortAsc,
+			values: []interface{}{int64(10), int64(2), nil, int64(-1), int64(100)},
+			expect: []interface{}{int64(-1), int64(2), int64(10), int64(100), nil},
+		},
+		{
+			name:   "integer descending",
+			by:     "rank",
+			order:  SortDesc,
+			values: []interface{}{int64(10), int64(2), nil, int64(-1), int64(100)},
+			expect: []interface{}{int64(100), int64(10), int64(2), int64(-1), nil},
+		},
+		{
+			name:   "decimal",
+			by:     "score",
+			order:  SortDefault,
+			values: []interface{}{9.5, 10.25, 1e3, 0.5},
+			expect: []interface{}{0.5, 9.5, 10.25, 1e3},
+		},
+		{
+			name:   "dateTime",
+			by:     "since",
+			order:  SortAsc,
+			values: []interface{}{"2020-01-02T00:00:00", "2019-12-31T23:59:59", "2020-01-01T12:00:00"},
+			expect: []interface{}{"2019-12-31T23:59:59", "2020-01-01T12:00:00", "2020-01-02T00:00:00"},
+		},
+		{
+			name:   "caseExact string",
+			by:     "code",
+			order:  SortAsc,
+			values: []interface{}{"b", "B", "a", "A"},
+			expect: []interface{}{"A", "B", "a", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			var resources []*prop.Resource
+			for _, value := range test.values {
+				r := prop.NewResource(resourceType)
+				if value != nil {
+					require.False(t, r.Navigator().Dot(test.by).Replace(value).HasError())
+				}
+				resources = append(resources, r)
+			}
+
+			err := Sort{By: test.by, Order: test.order}.Sort(resources)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, values(test.by, resources))
+		})
+	}
+}
+
+func (s *SeekSortByTargetTestSuite) TestSortByPrimary() {
+	// the primary sub attribute of contacts is not annotated with @Primary
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Contactable", "Contactable").
+		Attribute(spec.ComplexAttr("contacts",
+			spec.StringAttr("value"),
+			spec.BooleanAttr("primary"),
+		).MultiValued().WithoutDefaultSubAttributes()).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	contactable := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "Contactable",
+  "name": "Contactable",
+  "endpoint": "/Contactables",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Contactable"
+}
+`), contactable))
+
+	newResource := func(t *testing.T, resourceType *spec.ResourceType, id string, path string, elements ...interface{}) *prop.Resource {
+		r := prop.NewResource(resourceType)
+		require.False(t, r.Navigator().Dot("id").Replace(id).HasError())
+		if len(elements) > 0 {
+			require.False(t, r.Navigator().Dot(path).Add(elements).HasError())
+		}
+		return r
+	}
+
+	tests := []struct {
+		name      string
+		by        string
+		order     SortOrder
+		resources func(t *testing.T) []*prop.Resource
+		expect    []interface{}
+	}{
+		{
+			name:  "primary annotated",
+			by:    "emails.value",
+			order: SortAsc,
+			resources: func(t *testing.T) []*prop.Resource {
+				return []*prop.Resource{
+					newResource(t, s.resourceType, "z", "emails",
+						map[string]interface{}{"value": "a@example.com"},
+						map[string]interface{}{"value": "z@example.com", "primary": true},
+					),
+					newResource(t, s.resourceType, "m", "emails",
+						map[string]interface{}{"value": "m@example.com"},
+						map[string]interface{}{"value": "b@example.com"},
+					),
+					newResource(t, s.resourceType, "none", "emails"),
+					newResource(t, s.resourceType, "c", "emails",
+						map[string]interface{}{"value": "y@example.com"},
+						map[string]interface{}{"value": "c@example.com", "primary": true},
+					),
+				}
+			},
+			// sorted by the primary email, or the first one, and the resource without emails last
+			expect: []interface{}{"c", "m", "z", "none"},
+		},
+		{
+			name:  "primary not annotated",
+			by:    "contacts.value",
+			order: SortDesc,
+			resources: func(t *testing.T) []*prop.Resource {
+				return []*prop.Resource{
+					newResource(t, contactable, "b", "contacts",
+						map[string]interface{}{"value": "a"},
+						map[string]interface{}{"value": "b", "primary": true},
+					),
+					newResource(t, contactable, "c", "contacts",
+						map[string]interface{}{"value": "c"},
+						map[string]interface{}{"value": "d", "primary": false},
+					),
+					newResource(t, contactable, "a", "contacts",
+						map[string]interface{}{"value": "z"},
+						map[string]interface{}{"value": "a", "primary": true},
+					),
+				}
+			},
+			expect: []interface{}{"c", "b", "a"},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resources := test.resources(t)
+			err := Sort{By: test.by, Order: test.order}.Sort(resources)
+			assert.Nil(t, err)
+
+			var ids []interface{}
+			for _, r := range resources {
+				ids = append(ids, r.Navigator().Dot("id").Current().Raw())
+			}
+			assert.Equal(t, test.expect, ids)
+		})
+	}
+}
+
 func (s *SeekSortByTargetTestSuite) SetupSuite() {
 	core := new(spec.Schema)
 	require.Nil(s.T(), json.Unmarshal([]byte(testCoreSchema), core))
@@ -159,6 +422,10 @@ func (s *SeekSortByTargetTestSuite) SetupSuite() {
 	require.Nil(s.T(), json.Unmarshal([]byte(testMainSchema), schema))
 	spec.Schemas().Register(schema)
 
+	schemaExtension := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testSchemaExtension), schemaExtension))
+	spec.Schemas().Register(schemaExtension)
+
 	s.resourceType = new(spec.ResourceType)
 	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), s.resourceType))
 	Register(s.resourceType)
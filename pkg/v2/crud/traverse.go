@@ -6,6 +6,8 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
+	"strings"
+	"time"
 )
 
 type traverseCb func(nav prop.Navigator) error
@@ -42,6 +44,10 @@ func defaultTraverse(property prop.Property, query *expr.Expression, callback tr
 //			"value": "foo@bar.com"
 //		}
 //	]
+//
+// This traverse is only reached when no existing element matches the filter. As filters compare case insensitive
+// attributes regardless of case, an existing element of type "work" is matched by emails[type eq "WORK"] and is not
+// duplicated.
 func eqFilterTraverse(value interface{}, property prop.Property, query *expr.Expression, callback traverseValueModifiedCb) error {
 	cb := func(nav prop.Navigator, query *expr.Expression) error {
 		v, err := composeValueByEqFilter(value, query, nav)
@@ -136,6 +142,11 @@ func composeValueByEqFilter(value interface{}, query *expr.Expression, nav prop.
 		if err != nil {
 			return nil, fmt.Errorf("%w: invalid filter value: %w", spec.ErrInvalidFilter, err)
 		}
+		filterValue = conformFilterValue(navCopy.Current().Attribute(), filterValue)
+	}
+	// dateTime literals are parsed to time.Time for comparison, but dateTime properties hold the stored format.
+	if t, ok := filterValue.(time.Time); ok {
+		filterValue = t.Format(spec.ISO8601)
 	}
 	return []interface{}{
 		map[string]interface{}{
@@ -144,6 +155,20 @@ func composeValueByEqFilter(value interface{}, query *expr.Expression, nav prop.
 		}}, nil
 }
 
+// Returns the canonical spelling of the filter value if the attribute is case insensitive and the value is one of its
+// canonical values regardless of case, so that adding to emails[type eq "WORK"] creates an element of type "work", like
+// the existing elements it would have been matched against. Otherwise, the value is returned as is.
+func conformFilterValue(attr *spec.Attribute, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || attr.CaseExact() || attr.CountCanonicalValues() == 0 {
+		return value
+	}
+	if canonical, err := attr.ConformCanonicalValue(s, true); err == nil {
+		return canonical
+	}
+	return value
+}
+
 func (t traverser) traverseNext(query *expr.Expression) error {
 	t.nav.Dot(query.Token())
 	if err := t.nav.Error(); err != nil {
@@ -157,7 +182,7 @@ func (t traverser) traverseNext(query *expr.Expression) error {
 func (t traverser) traverseSelectedElements(query *expr.Expression) error {
 	selector := t.elementStrategy(t.nav.Current())
 
-	return t.nav.Current().ForEachChild(func(index int, child prop.Property) error {
+	return t.forEachElement(func(index int, child prop.Property) error {
 		if !selector(index, child) { // skip elements not satisfied by strategy
 			return nil
 		}
@@ -173,14 +198,14 @@ func (t traverser) traverseSelectedElements(query *expr.Expression) error {
 }
 
 func (t traverser) traverseQualifiedElements(filter *expr.Expression) error {
-	return t.nav.ForEachChild(func(index int, child prop.Property) error {
+	return t.forEachElement(func(index int, child prop.Property) error {
 		t.nav.At(index)
 		if err := t.nav.Error(); err != nil {
 			return err
 		}
 		defer t.nav.Retract()
 
-		r, err := evaluator{base: t.nav.Current(), filter: filter}.evaluate()
+		r, err := EvaluateAt(t.nav, filter)
 		if err != nil {
 			return err
 		} else if !r {
@@ -191,6 +216,50 @@ func (t traverser) traverseQualifiedElements(filter *expr.Expression) error {
 	})
 }
 
+// Invokes callback on each element of the currently focused multiValued property, with the element's index at the
+// time of invocation. Elements are captured before the iteration, because the callback may remove elements (i.e.
+// deleting an element of a property annotated with @AutoCompact), shifting the index of the remaining elements. The
+// shift is tracked by comparing the number of elements before and after each invocation; the elements are only
+// searched for when the callback removed elements other than the invoked one. Elements removed by earlier invocations
+// are skipped.
+func (t traverser) forEachElement(callback func(index int, child prop.Property) error) error {
+	elements := make([]prop.Property, 0, t.nav.Current().CountChildren())
+	_ = t.nav.Current().ForEachChild(func(_ int, child prop.Property) error {
+		elements = append(elements, child)
+		return nil
+	})
+
+	removed := 0
+	for i, elem := range elements {
+		index := i - removed
+		if child, err := t.nav.Current().ChildAtIndex(index); err != nil || child != elem {
+			if index = t.indexOf(elem); index < 0 {
+				continue
+			}
+			removed = i - index
+		}
+
+		count := t.nav.Current().CountChildren()
+		if err := callback(index, elem); err != nil {
+			return err
+		}
+		removed += count - t.nav.Current().CountChildren()
+	}
+	return nil
+}
+
+// Returns the index of the element in the currently focused multiValued property, or -1 if it is no longer present.
+func (t traverser) indexOf(elem prop.Property) int {
+	index := -1
+	_ = t.nav.Current().ForEachChild(func(i int, child prop.Property) error {
+		if child == elem {
+			index = i
+		}
+		return nil
+	})
+	return index
+}
+
 type traverseStrategy func() func(nav prop.Navigator, query *expr.Expression) bool
 
 var (
@@ -246,12 +315,19 @@ var (
 			return true
 		}
 	}
-	// strategy to traverse the element whose primary attribute is true, or the first element when no primary attribute is true
+	// strategy to traverse the element whose primary attribute is true, or the first element when no primary attribute is true.
+	// The primary attribute is the boolean sub attribute annotated with @Primary, or else the standard "primary" sub
+	// attribute, so that schemas not annotating it still sort by the primary element.
 	primaryOrFirstStrategy elementStrategy = func(multiValuedComplex prop.Property) func(index int, child prop.Property) bool {
 		primaryAttr := multiValuedComplex.Attribute().FindSubAttribute(func(subAttr *spec.Attribute) bool {
 			_, ok := subAttr.Annotation(annotation.Primary)
 			return ok && subAttr.Type() == spec.TypeBoolean
 		})
+		if primaryAttr == nil {
+			primaryAttr = multiValuedComplex.Attribute().FindSubAttribute(func(subAttr *spec.Attribute) bool {
+				return strings.EqualFold(subAttr.Name(), "primary") && subAttr.Type() == spec.TypeBoolean
+			})
+		}
 
 		if primaryAttr != nil {
 			truePrimary := multiValuedComplex.FindChild(func(child prop.Property) bool {
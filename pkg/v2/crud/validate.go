@@ -0,0 +1,327 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// ValidationErrors is the combined error returned by Validate. It contains every violation found, in the order of
+// the validations and then of the properties. errors.Is and errors.As test each of the contained errors, hence
+// errors.Is(err, spec.ErrInvalidValue) is true when any violation is caused by spec.ErrInvalidValue.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOptions customizes the behaviour of Validate.
+type ValidateOptions interface {
+	apply(v *validator)
+}
+
+// SkipSchema returns a ValidateOptions that disables the schema validation.
+func SkipSchema() ValidateOptions {
+	return skip{validation: validateSchema}
+}
+
+// SkipRequired returns a ValidateOptions that disables the required validation.
+func SkipRequired() ValidateOptions {
+	return skip{validation: validateRequired}
+}
+
+// SkipCanonical returns a ValidateOptions that disables the canonical values validation.
+func SkipCanonical() ValidateOptions {
+	return skip{validation: validateCanonical}
+}
+
+// SkipReference returns a ValidateOptions that disables the reference validation.
+func SkipReference() ValidateOptions {
+	return skip{validation: validateReference}
+}
+
+// Uniqueness returns a ValidateOptions that enables the uniqueness validation, using count to find out the number of
+// other resources matching a filter, which is usually db.DB.Count bound to the request context. The validation is
+// disabled by default, because it requires access to the persistence store.
+func Uniqueness(count func(filter string) (int, error)) ValidateOptions {
+	return uniqueness{count: count}
+}
+
+const (
+	validateSchema = iota
+	validateRequired
+	validateCanonical
+	validateReference
+)
+
+type skip struct {
+	validation int
+}
+
+func (o skip) apply(v *validator) {
+	v.skip[o.validation] = true
+}
+
+type uniqueness struct {
+	count func(filter string) (int, error)
+}
+
+func (o uniqueness) apply(v *validator) {
+	v.count = o.count
+}
+
+type validator struct {
+	skip  map[int]bool
+	count func(filter string) (int, error)
+	errs  ValidationErrors
+}
+
+// Validate runs all validations on the resource, as a single gate before it is persisted, and returns the violations
+// combined in ValidationErrors, or nil if there is none. The validations run in order:
+//
+// The schema validation checks that the resource is of the resource type, that "schemas" contains the main schema
+// and every schema extension present in the resource, and that required schema extensions are present.
+//
+// The required validation checks that required attributes are assigned. Sub attributes of an unassigned complex
+// property are not checked, as the requirement only applies when the complex property is present.
+//
+// The canonical values validation checks that values of attributes annotated with @Enum are among the canonicalValues.
+// Values that the "normalize" parameter of @Enum would replace by the canonical spelling are accepted as they are.
+//
+// The reference validation checks that reference values are valid URIs, and are absolute when "external" is the only
+// referenceTypes of the attribute.
+//
+// The uniqueness validation, which is only enabled with the Uniqueness option, checks that values of attributes with
+// uniqueness=server or uniqueness=global are not used by any other resource.
+//
+// Other validations can be disabled individually with the Skip options. The checks on individual properties are
+// exported as CheckRequired, CheckCanonical, CheckReference and CheckUniqueness, and shared with the validation filter
+// of the service package. Unlike the filters, Validate does not stop at the first violation, so that all of them can be
+// reported at once.
+func Validate(resource *prop.Resource, rt *spec.ResourceType, options ...ValidateOptions) error {
+	v := &validator{skip: map[int]bool{}}
+	for _, opt := range options {
+		opt.apply(v)
+	}
+
+	if !v.skip[validateSchema] {
+		v.validateSchema(resource, rt)
+	}
+	if !v.skip[validateRequired] {
+		v.forEachProperty(resource.RootProperty(), v.validateRequired)
+	}
+	if !v.skip[validateCanonical] {
+		v.forEachProperty(resource.RootProperty(), v.validateCanonical)
+	}
+	if !v.skip[validateReference] {
+		v.forEachProperty(resource.RootProperty(), v.validateReference)
+	}
+	if v.count != nil {
+		v.forEachProperty(resource.RootProperty(), func(property prop.Property) {
+			v.validateUniqueness(resource, property)
+		})
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *validator) report(err error) {
+	v.errs = append(v.errs, err)
+}
+
+// Invokes the callback on each property beneath the root in a depth-first fashion. Children of unassigned properties
+// are not visited.
+func (v *validator) forEachProperty(root prop.Property, callback func(property prop.Property)) {
+	_ = root.ForEachChild(func(_ int, child prop.Property) error {
+		callback(child)
+		if !child.IsUnassigned() {
+			v.forEachProperty(child, callback)
+		}
+		return nil
+	})
+}
+
+func (v *validator) validateSchema(resource *prop.Resource, rt *spec.ResourceType) {
+	if resource.ResourceType().ID() != rt.ID() {
+		v.report(fmt.Errorf("%w: resource is of resource type '%s' instead of '%s'",
+			spec.ErrInvalidValue, resource.ResourceType().ID(), rt.ID()))
+		return
+	}
+
+	schemas := map[string]bool{}
+	if p, err := resource.RootProperty().ChildAtIndex("schemas"); err == nil && p != nil {
+		_ = p.ForEachChild(func(_ int, child prop.Property) error {
+			if s, ok := child.Raw().(string); ok {
+				schemas[s] = true
+			}
+			return nil
+		})
+	}
+
+	if !schemas[rt.Schema().ID()] {
+		v.report(fmt.Errorf("%w: 'schemas' does not contain the main schema '%s'", spec.ErrInvalidValue, rt.Schema().ID()))
+	}
+
+	_ = rt.ForEachExtension(func(extension *spec.Schema, required bool) error {
+		p, err := resource.RootProperty().ChildAtIndex(extension.ID())
+		present := err == nil && p != nil && !p.IsUnassigned()
+		switch {
+		case required && !present:
+			v.report(fmt.Errorf("%w: required schema extension '%s' is missing", spec.ErrInvalidValue, extension.ID()))
+		case present && !schemas[extension.ID()]:
+			v.report(fmt.Errorf("%w: 'schemas' does not contain the schema extension '%s'", spec.ErrInvalidValue, extension.ID()))
+		}
+		return nil
+	})
+}
+
+func (v *validator) validateRequired(property prop.Property) {
+	if err := CheckRequired(property); err != nil {
+		v.report(err)
+	}
+}
+
+func (v *validator) validateCanonical(property prop.Property) {
+	if _, err := CheckCanonical(property, false); err != nil {
+		v.report(err)
+	}
+}
+
+func (v *validator) validateReference(property prop.Property) {
+	if err := CheckReference(property); err != nil {
+		v.report(err)
+	}
+}
+
+func (v *validator) validateUniqueness(resource *prop.Resource, property prop.Property) {
+	if err := CheckUniqueness(property, resource.IdOrEmpty(), v.count); err != nil {
+		v.report(err)
+	}
+}
+
+// CheckRequired returns spec.ErrInvalidValue if the property is required but unassigned. Schema extension roots are
+// not checked, as their requirement is declared by the resource type, and checked along with "schemas".
+func CheckRequired(property prop.Property) error {
+	if _, ok := property.Attribute().Annotation(annotation.SchemaExtensionRoot); ok {
+		return nil
+	}
+	if property.Attribute().Required() && property.IsUnassigned() {
+		return fmt.Errorf("%w: '%s' is required", spec.ErrInvalidValue, property.Attribute().Path())
+	}
+	return nil
+}
+
+// CheckCanonical checks that the value of a property whose attribute is annotated with @Enum is among the
+// canonicalValues. Values differing from a canonical value only by case are accepted if normalize is true, or if the
+// "normalize" parameter of @Enum is set, in which case the canonical spelling is returned, so that the caller may
+// replace the value with it. Otherwise, the returned string is empty.
+func CheckCanonical(property prop.Property, normalize bool) (string, error) {
+	attr := property.Attribute()
+	if attr.CountCanonicalValues() == 0 || property.IsUnassigned() {
+		return "", nil
+	}
+	if _, ok := attr.Annotation(annotation.Enum); !ok {
+		return "", nil
+	}
+
+	value, ok := property.Raw().(string)
+	if !ok {
+		return "", nil
+	}
+
+	canonical, err := attr.ConformCanonicalValue(value, normalize)
+	if err != nil {
+		return "", err
+	}
+	if canonical == value {
+		return "", nil
+	}
+	return canonical, nil
+}
+
+// CheckReference checks that the value of a reference property is a valid URI, and is absolute when "external" is the
+// only referenceTypes of the attribute. Errors are spec.ErrInvalidValue.
+func CheckReference(property prop.Property) error {
+	attr := property.Attribute()
+	if attr.Type() != spec.TypeReference || property.IsUnassigned() {
+		return nil
+	}
+
+	value, ok := property.Raw().(string)
+	if !ok {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%w: value of '%s' is not a valid reference", spec.ErrInvalidValue, attr.Path())
+	}
+
+	externalOnly := attr.CountReferenceTypes() > 0 && !attr.ExistsReferenceType(func(referenceType string) bool {
+		return referenceType != "external"
+	})
+	if externalOnly && !u.IsAbs() {
+		return fmt.Errorf("%w: value of '%s' is not an absolute URI to an external resource", spec.ErrInvalidValue, attr.Path())
+	}
+	return nil
+}
+
+// CheckUniqueness checks that the value of a property whose attribute has uniqueness=server or uniqueness=global is not
+// used by any resource other than the one identified by id, which may be empty for a resource yet to be created. A
+// globally unique value is unique within the server as well, while checking it beyond the server is up to the caller.
+// It invokes count with the filter (id ne <id>) and (<path> eq <value>), which is usually db.DB.Count bound to the
+// request context, and returns spec.ErrUniqueness if any resource matches. The "id" attribute itself, which is assigned
+// by the server, is not checked.
+func CheckUniqueness(property prop.Property, id string, count func(filter string) (int, error)) error {
+	attr := property.Attribute()
+	switch {
+	case attr.Uniqueness() != spec.UniquenessServer && attr.Uniqueness() != spec.UniquenessGlobal:
+		return nil
+	case property.IsUnassigned() || attr.MultiValued() || attr.ID() == "id":
+		return nil
+	}
+
+	filter := fmt.Sprintf("%s eq %s", attr.Path(), strconv.Quote(fmt.Sprintf("%v", property.Raw())))
+	if len(id) > 0 {
+		filter = fmt.Sprintf("(id ne %s) and (%s)", strconv.Quote(id), filter)
+	}
+
+	n, err := count(filter)
+	if err != nil {
+		return err
+	} else if n > 0 {
+		return fmt.Errorf("%w: value of '%s' is not unique", spec.ErrUniqueness, attr.Path())
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestValidate(t *testing.T) {
+	s := new(ValidateTestSuite)
+	suite.Run(t, s)
+}
+
+type ValidateTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *ValidateTestSuite) TestValidate() {
+	valid := map[string]interface{}{
+		"schemas":  []interface{}{"validate", "urn:ietf:params:scim:schemas:test:2.0:Validate"},
+		"id":       "a1",
+		"userName": "foo",
+		"status":   "Active",
+		"homepage": "https://example.com/foo",
+		"manager": map[string]interface{}{
+			"value": "b1",
+			"$ref":  "/Users/b1",
+		},
+		"urn:ietf:params:scim:schemas:test:2.0:Validate": map[string]interface{}{
+			"code": "c1",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    func() map[string]interface{}
+		options []ValidateOptions
+		expect  func(t *testing.T, err error)
+	}{
+		{
+			name: "valid resource",
+			data: func() map[string]interface{} {
+				return valid
+			},
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "all violations are reported",
+			data: func() map[string]interface{} {
+				return map[string]interface{}{
+					"schemas":  []interface{}{"validate"},
+					"id":       "a1",
+					"status":   "retired",
+					"homepage": "/foo",
+					"manager": map[string]interface{}{
+						"$ref": "/Users/b1",
+					},
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				var errs ValidationErrors
+				require.True(t, errors.As(err, &errs))
+				assert.Len(t, errs, 5)
+				assert.Contains(t, errs[0].Error(), "required schema extension 'urn:ietf:params:scim:schemas:test:2.0:Validate' is missing")
+				assert.Contains(t, errs[1].Error(), "'userName' is required")
+				assert.Contains(t, errs[2].Error(), "'manager.value' is required")
+				assert.Contains(t, errs[3].Error(), "value of 'status' does not conform to canonicalValues")
+				assert.Contains(t, errs[4].Error(), "value of 'homepage' is not an absolute URI")
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.False(t, errors.Is(err, spec.ErrUniqueness))
+			},
+		},
+		{
+			name: "skipped validations",
+			data: func() map[string]interface{} {
+				return map[string]interface{}{
+					"id":       "a1",
+					"status":   "retired",
+					"homepage": "/foo",
+				}
+			},
+			options: []ValidateOptions{SkipSchema(), SkipRequired(), SkipCanonical()},
+			expect: func(t *testing.T, err error) {
+				var errs ValidationErrors
+				require.True(t, errors.As(err, &errs))
+				assert.Len(t, errs, 1)
+				assert.Contains(t, errs[0].Error(), "'homepage'")
+			},
+		},
+		{
+			name: "uniqueness",
+			data: func() map[string]interface{} {
+				return valid
+			},
+			options: []ValidateOptions{Uniqueness(func(filter string) (int, error) {
+				if filter == `(id ne "a1") and (userName eq "foo")` {
+					return 1, nil
+				}
+				return 0, nil
+			})},
+			expect: func(t *testing.T, err error) {
+				var errs ValidationErrors
+				require.True(t, errors.As(err, &errs))
+				assert.Len(t, errs, 1)
+				assert.True(t, errors.Is(err, spec.ErrUniqueness))
+				assert.Contains(t, err.Error(), "value of 'userName' is not unique")
+			},
+		},
+		{
+			name: "uniqueness and other violations",
+			data: func() map[string]interface{} {
+				return map[string]interface{}{
+					"schemas":  []interface{}{"validate", "urn:ietf:params:scim:schemas:test:2.0:Validate"},
+					"id":       "a1",
+					"userName": "foo",
+					"status":   "retired",
+					"urn:ietf:params:scim:schemas:test:2.0:Validate": map[string]interface{}{
+						"code": "c1",
+					},
+				}
+			},
+			options: []ValidateOptions{Uniqueness(func(filter string) (int, error) {
+				return 1, nil
+			})},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.True(t, errors.Is(err, spec.ErrUniqueness))
+				assert.Equal(t, "invalidValue: value of 'status' does not conform to canonicalValues, allowed values are \"active\", \"inactive\"; "+
+					"uniqueness: value of 'userName' is not unique", err.Error())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(s.resourceType)
+			_, err := resource.RootProperty().Replace(test.data())
+			require.Nil(t, err)
+
+			test.expect(t, Validate(resource, s.resourceType, test.options...))
+		})
+	}
+}
+
+func (s *ValidateTestSuite) TestValidateResourceType() {
+	other := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testResourceType), other))
+
+	resource := prop.NewResource(s.resourceType)
+	err := Validate(resource, other, SkipRequired())
+	assert.True(s.T(), errors.Is(err, spec.ErrInvalidValue))
+	assert.Contains(s.T(), err.Error(), "resource is of resource type 'Validate' instead of 'Test'")
+}
+
+func (s *ValidateTestSuite) SetupSuite() {
+	for _, each := range []string{testCoreSchema, testMainSchema, testSchemaExtension, testValidateSchema, testValidateSchemaExtension} {
+		schema := new(spec.Schema)
+		require.Nil(s.T(), json.Unmarshal([]byte(each), schema))
+		require.Nil(s.T(), spec.Schemas().Register(schema))
+	}
+
+	s.resourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testValidateResourceType), s.resourceType))
+	Register(s.resourceType)
+}
+
+const (
+	testValidateSchema = `
+{
+  "id": "validate",
+  "name": "validate",
+  "attributes": [
+    {
+      "id": "userName",
+      "name": "userName",
+      "type": "string",
+      "required": true,
+      "uniqueness": "server",
+      "_index": 100,
+      "_path": "userName"
+    },
+    {
+      "id": "status",
+      "name": "status",
+      "type": "string",
+      "canonicalValues": ["active", "inactive"],
+      "_index": 101,
+      "_path": "status",
+      "_annotations": {
+        "@Enum": {}
+      }
+    },
+    {
+      "id": "homepage",
+      "name": "homepage",
+      "type": "reference",
+      "referenceTypes": ["external"],
+      "_index": 102,
+      "_path": "homepage"
+    },
+    {
+      "id": "manager",
+      "name": "manager",
+      "type": "complex",
+      "_index": 103,
+      "_path": "manager",
+      "subAttributes": [
+        {
+          "id": "manager.value",
+          "name": "value",
+          "type": "string",
+          "required": true,
+          "_index": 0,
+          "_path": "manager.value"
+        },
+        {
+          "id": "manager.$ref",
+          "name": "$ref",
+          "type": "reference",
+          "referenceTypes": ["User"],
+          "_index": 1,
+          "_path": "manager.$ref"
+        }
+      ]
+    }
+  ]
+}
+`
+	testValidateSchemaExtension = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Validate",
+  "name": "Validate Extension",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Validate:code",
+      "name": "code",
+      "type": "string",
+      "_index": 100,
+      "_path": "urn:ietf:params:scim:schemas:test:2.0:Validate:code"
+    }
+  ]
+}
+`
+	testValidateResourceType = `
+{
+  "id": "Validate",
+  "name": "Validate",
+  "schema": "validate",
+  "schemaExtensions": [
+    {
+      "schema": "urn:ietf:params:scim:schemas:test:2.0:Validate",
+      "required": true
+    }
+  ]
+}
+`
+)
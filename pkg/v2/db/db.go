@@ -26,3 +26,12 @@ type DB interface {
 	// additional processing.
 	Query(ctx context.Context, filter string, sort *crud.Sort, pagination *crud.Pagination, projection *crud.Projection) ([]*prop.Resource, error)
 }
+
+// SortCapable is an optional capability of DB. A database implementing it is asked, before each query, whether it
+// sorts the results of Query in the same way as crud.Sort: resources compared by their sort target located by
+// crud.SeekSortTarget, with those without a sort target placed last. When it does not, or when the database does not
+// implement SortCapable, the query service fetches the unsorted results and sorts them in memory before pagination.
+type SortCapable interface {
+	// CanSort returns true if the database sorts the results of Query according to the given sort.
+	CanSort(sort *crud.Sort) bool
+}
@@ -68,14 +68,15 @@ func (m *memoryDB) Count(_ context.Context, filter string) (int, error) {
 
 func (m *memoryDB) Replace(_ context.Context, ref *prop.Resource, replacement *prop.Resource) error {
 	id := ref.IdOrEmpty()
-	_, ok := m.db[id]
-	if !ok {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.db[id]; !ok {
 		return fmt.Errorf("%w: resource not found by id", spec.ErrNotFound)
 	}
-
-	version := ref.MetaVersionOrEmpty()
-	if len(version) > 0 && m.db[id].MetaVersionOrEmpty() != version {
-		return spec.ErrConflict
+	if err := m.checkVersion(id, ref.MetaVersionOrEmpty()); err != nil {
+		return err
 	}
 
 	m.db[id] = replacement
@@ -83,10 +84,36 @@ func (m *memoryDB) Replace(_ context.Context, ref *prop.Resource, replacement *p
 }
 
 func (m *memoryDB) Delete(_ context.Context, resource *prop.Resource) error {
-	delete(m.db, resource.IdOrEmpty())
+	id := resource.IdOrEmpty()
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.db[id]; !ok {
+		return fmt.Errorf("%w: resource not found by id", spec.ErrNotFound)
+	}
+	if err := m.checkVersion(id, resource.MetaVersionOrEmpty()); err != nil {
+		return err
+	}
+
+	delete(m.db, id)
+	return nil
+}
+
+// Returns spec.ErrVersionMismatch if the stored resource by the id no longer has the version, i.e. because it was
+// replaced since it was fetched. An empty version is not checked. Must be called under the lock.
+func (m *memoryDB) checkVersion(id string, version string) error {
+	if stored := m.db[id].MetaVersionOrEmpty(); len(version) > 0 && stored != version {
+		return fmt.Errorf("%w: resource '%s' was modified since by another request", spec.ErrVersionMismatch, id)
+	}
 	return nil
 }
 
+// The memory database sorts with crud.Sort, hence it can sort on any valid sortBy.
+func (m *memoryDB) CanSort(_ *crud.Sort) bool {
+	return true
+}
+
 func (m *memoryDB) Query(_ context.Context, filter string, sort *crud.Sort, pagination *crud.Pagination, _ *crud.Projection) ([]*prop.Resource, error) {
 	var candidates = make([]*prop.Resource, 0)
 	for _, r := range m.db {
@@ -1,7 +1,6 @@
 package facade_test
 
 import (
-	"encoding/json"
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/facade"
 	scimjson "github.com/imulab/go-scim/pkg/v2/json"
@@ -10,8 +9,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -55,53 +52,9 @@ func (s *facadeTestSuite) TestImport() {
 }
 
 func (s *facadeTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.rt = parsed.(*spec.ResourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.NoError(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.NoError(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.NoError(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.rt, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
 
 	expr.RegisterURN("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User")
 }
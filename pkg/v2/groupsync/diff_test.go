@@ -1,14 +1,11 @@
 package groupsync
 
 import (
-	"encoding/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -177,44 +174,7 @@ func (s *CompareTestSuite) TestCompare() {
 }
 
 func (s *CompareTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/group_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/group_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardGroupResourceType()
+	require.Nil(s.T(), err)
 }
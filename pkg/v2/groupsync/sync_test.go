@@ -2,7 +2,6 @@ package groupsync
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/prop"
@@ -10,8 +9,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -118,67 +115,11 @@ func (s *SyncServiceTestSuite) TestSyncGroupPropertyForUser() {
 }
 
 func (s *SyncServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/group_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.userResourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.userResourceType)
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/group_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.groupResourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.groupResourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.userResourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.userResourceType)
+	s.groupResourceType, err = spec.RegisterStandardGroupResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.groupResourceType)
 }
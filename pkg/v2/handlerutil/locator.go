@@ -0,0 +1,115 @@
+package handlerutil
+
+import (
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"net/http"
+	"strings"
+)
+
+// LocateOptions customizes the locators derived by Locate and RequestLocator.
+type LocateOptions interface {
+	apply(p *locatePolicy)
+}
+
+// PathOnly returns a LocateOptions that renders the locations without scheme and host.
+func PathOnly() LocateOptions {
+	return pathOnly{}
+}
+
+type pathOnly struct{}
+
+func (o pathOnly) apply(p *locatePolicy) {
+	p.pathOnly = true
+}
+
+// BaseURL returns a LocateOptions that uses the given base URL, i.e. https://scim.example.com/v2, for every request,
+// regardless of its host and headers. It takes precedence over TrustForwardedHeaders.
+func BaseURL(url string) LocateOptions {
+	return baseURL(strings.TrimSuffix(url, "/"))
+}
+
+type baseURL string
+
+func (o baseURL) apply(p *locatePolicy) {
+	p.baseURL = string(o)
+}
+
+// TrustForwardedHeaders returns a LocateOptions that takes the scheme, host and path prefix from the X-Forwarded-Proto,
+// X-Forwarded-Host and X-Forwarded-Prefix headers. It must only be used when the server is exclusively reachable
+// through a proxy which sets these headers, as they are otherwise chosen by the client.
+func TrustForwardedHeaders() LocateOptions {
+	return trustForwardedHeaders{}
+}
+
+type trustForwardedHeaders struct{}
+
+func (o trustForwardedHeaders) apply(p *locatePolicy) {
+	p.trustForwarded = true
+}
+
+type locatePolicy struct {
+	pathOnly       bool
+	baseURL        string
+	trustForwarded bool
+}
+
+func newLocatePolicy(options []LocateOptions) locatePolicy {
+	var p locatePolicy
+	for _, opt := range options {
+		opt.apply(&p)
+	}
+	return p
+}
+
+// Locate returns a http.Handler which attaches the locator of each request, as returned by RequestLocator, to the
+// request context before passing it to next. Handlers retrieve it with spec.LocatorFromContext, and render the locations
+// through the json.Locate option.
+func Locate(next http.Handler, options ...LocateOptions) http.Handler {
+	policy := newLocatePolicy(options)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(spec.ContextWithLocator(r.Context(), policy.locator(r))))
+	})
+}
+
+// RequestLocator returns the locator whose base URL is the service root as seen by the client of the request. By
+// default, it is made of the scheme and host of the request itself, without prefix. The options may set a fixed base
+// URL, or trust the forwarded headers of a proxy in front of the server.
+func RequestLocator(r *http.Request, options ...LocateOptions) *spec.Locator {
+	return newLocatePolicy(options).locator(r)
+}
+
+func (p locatePolicy) locator(r *http.Request) *spec.Locator {
+	if len(p.baseURL) > 0 {
+		return &spec.Locator{BaseURL: p.baseURL, PathOnly: p.pathOnly}
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	prefix := ""
+
+	if p.trustForwarded {
+		if proto := forwardedHeader(r, "X-Forwarded-Proto"); len(proto) > 0 {
+			scheme = proto
+		}
+		if forwardedHost := forwardedHeader(r, "X-Forwarded-Host"); len(forwardedHost) > 0 {
+			host = forwardedHost
+		}
+		prefix = strings.TrimSuffix(forwardedHeader(r, "X-Forwarded-Prefix"), "/")
+		if len(prefix) > 0 && !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+	}
+
+	return &spec.Locator{BaseURL: scheme + "://" + host + prefix, PathOnly: p.pathOnly}
+}
+
+// Returns the last value of the forwarded header, or empty string if it is absent. When the header carries several
+// comma delimited values, the last one was set by the proxy directly in front of the server, while the preceding ones
+// may have been supplied by the client.
+func forwardedHeader(r *http.Request, name string) string {
+	values := strings.Split(r.Header.Get(name), ",")
+	return strings.TrimSpace(values[len(values)-1])
+}
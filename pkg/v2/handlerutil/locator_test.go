@@ -0,0 +1,129 @@
+package handlerutil
+
+import (
+	"encoding/json"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+
+	resource := prop.NewResource(resourceType)
+	require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+		"id": "123",
+		"meta": map[string]interface{}{
+			"location": "/Users/123",
+		},
+	}).Error())
+
+	tests := []struct {
+		name    string
+		options []LocateOptions
+		headers map[string]string
+		expect  func(t *testing.T, server *httptest.Server, location string)
+	}{
+		{
+			name: "host of the request",
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, server.URL+"/Users/123", location)
+			},
+		},
+		{
+			name: "forwarded headers are ignored by default",
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "evil.example.com",
+				"X-Forwarded-Prefix": "/v2/",
+			},
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, server.URL+"/Users/123", location)
+			},
+		},
+		{
+			name:    "trusted forwarded headers",
+			options: []LocateOptions{TrustForwardedHeaders()},
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "scim.example.com",
+				"X-Forwarded-Prefix": "/v2/",
+			},
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, "https://scim.example.com/v2/Users/123", location)
+			},
+		},
+		{
+			name:    "last of several forwarded values",
+			options: []LocateOptions{TrustForwardedHeaders()},
+			headers: map[string]string{
+				"X-Forwarded-Proto": "http, https",
+				"X-Forwarded-Host":  "evil.example.com, scim.example.com",
+			},
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, "https://scim.example.com/Users/123", location)
+			},
+		},
+		{
+			name:    "base url",
+			options: []LocateOptions{BaseURL("https://scim.example.com/v2/"), TrustForwardedHeaders()},
+			headers: map[string]string{
+				"X-Forwarded-Host": "evil.example.com",
+			},
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, "https://scim.example.com/v2/Users/123", location)
+			},
+		},
+		{
+			name:    "path only",
+			options: []LocateOptions{PathOnly(), TrustForwardedHeaders()},
+			headers: map[string]string{
+				"X-Forwarded-Host":   "scim.example.com",
+				"X-Forwarded-Prefix": "v2",
+			},
+			expect: func(t *testing.T, server *httptest.Server, location string) {
+				assert.Equal(t, "/v2/Users/123", location)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(Locate(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				_ = WriteResourceToResponse(rw, resource, scimjson.Locate(spec.LocatorFromContext(r.Context())))
+			}), test.options...))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/Users/123", nil)
+			require.Nil(t, err)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err)
+			defer resp.Body.Close()
+
+			raw, err := ioutil.ReadAll(resp.Body)
+			require.Nil(t, err)
+
+			var body struct {
+				Meta struct {
+					Location string `json:"location"`
+				} `json:"meta"`
+			}
+			require.Nil(t, json.NewDecoder(strings.NewReader(string(raw))).Decode(&body))
+
+			test.expect(t, server, resp.Header.Get("Location"))
+			test.expect(t, server, body.Meta.Location)
+		})
+	}
+}
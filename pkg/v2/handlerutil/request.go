@@ -23,27 +23,40 @@ const (
 )
 
 // GetRequestProjection returns a nullable *crud.Projection structure that may encapsulate the attributes or excludedAttributes
-// parameters present in the HTTP GET request.
+// parameters present in the HTTP GET request. The parameters are comma delimited attribute paths, which may be prefixed
+// by the schema URN and may refer to sub attributes. Specifying both parameters is rejected with spec.ErrInvalidValue,
+// as required by RFC7644 section 3.9.
 func GetRequestProjection(request *http.Request) (projection *crud.Projection, err error) {
-	if attrValue := request.URL.Query().Get(paramAttributes); len(attrValue) > 0 {
+	if attributes := splitAttributePaths(request.URL.Query().Get(paramAttributes)); len(attributes) > 0 {
 		projection = &crud.Projection{
-			Attributes: strings.Split(strings.TrimSpace(attrValue), ","),
+			Attributes: attributes,
 		}
 	}
 
-	if exclAttrValue := request.URL.Query().Get(paramExcludedAttributes); len(exclAttrValue) > 0 {
+	if excludedAttributes := splitAttributePaths(request.URL.Query().Get(paramExcludedAttributes)); len(excludedAttributes) > 0 {
 		if projection != nil && len(projection.Attributes) > 0 {
-			err = fmt.Errorf("%w: only one of attributes and excludedAttributes may be specified", spec.ErrInvalidSyntax)
+			err = fmt.Errorf("%w: only one of attributes and excludedAttributes may be specified", spec.ErrInvalidValue)
 			return
 		}
 		projection = &crud.Projection{
-			ExcludedAttributes: strings.Split(strings.TrimSpace(exclAttrValue), ","),
+			ExcludedAttributes: excludedAttributes,
 		}
 	}
 
 	return
 }
 
+// Splits the comma delimited attribute paths, trimming the spaces around each of them and dropping empty ones.
+func splitAttributePaths(value string) []string {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // CreateRequest returns a parsed *service.CreateRequest directly from *http.Request, and a closer function which should
 // be called after resource processing is done (preferably using defer).
 func CreateRequest(request *http.Request) (cr *service.CreateRequest, closer func()) {
@@ -54,9 +67,92 @@ func CreateRequest(request *http.Request) (cr *service.CreateRequest, closer fun
 	return
 }
 
+// BulkRequest returns a parsed *service.BulkRequest directly from *http.Request, and a closer function which should
+// be called after the bulk operations are done (preferably using defer).
+func BulkRequest(request *http.Request) (br *service.BulkRequest, closer func()) {
+	br = &service.BulkRequest{PayloadSource: request.Body}
+	closer = func() {
+		_ = request.Body.Close()
+	}
+	return
+}
+
+// QueryOptions customizes the pagination of the query requests parsed by QueryRequestFromGet and QueryRequestFromPost.
+type QueryOptions interface {
+	apply(p *paginationPolicy)
+}
+
+// DefaultCount returns a QueryOptions that sets the page size of query requests without the count parameter.
+func DefaultCount(count int) QueryOptions {
+	return defaultCount(count)
+}
+
+type defaultCount int
+
+func (o defaultCount) apply(p *paginationPolicy) {
+	p.defaultCount = int(o)
+}
+
+// MaxCount returns a QueryOptions that caps the count parameter of query requests. It also serves as the page size of
+// query requests without the count parameter, unless DefaultCount is set.
+func MaxCount(count int) QueryOptions {
+	return maxCount(count)
+}
+
+type maxCount int
+
+func (o maxCount) apply(p *paginationPolicy) {
+	p.maxCount = int(o)
+}
+
+type paginationPolicy struct {
+	defaultCount int
+	maxCount     int
+}
+
+// Returns the effective pagination of the startIndex and count parameters, which are nil when absent. As defined in
+// RFC7644 section 3.4.2.4, a startIndex less than 1 is interpreted as 1, and a negative count as 0. A count of 0 is
+// kept, so that only the total number of results is returned. The result is nil when neither parameter is present and
+// the page size is unlimited.
+func (p paginationPolicy) resolve(startIndex *int, count *int) *crud.Pagination {
+	if startIndex == nil && count == nil && p.defaultCount <= 0 && p.maxCount <= 0 {
+		return nil
+	}
+
+	pagination := &crud.Pagination{StartIndex: 1}
+	if startIndex != nil && *startIndex > 1 {
+		pagination.StartIndex = *startIndex
+	}
+
+	switch {
+	case count != nil:
+		if *count > 0 {
+			pagination.Count = *count
+		}
+	case p.defaultCount > 0:
+		pagination.Count = p.defaultCount
+	default:
+		pagination.Count = p.maxCount
+	}
+	if p.maxCount > 0 && pagination.Count > p.maxCount {
+		pagination.Count = p.maxCount
+	}
+
+	return pagination
+}
+
+func newPaginationPolicy(options []QueryOptions) paginationPolicy {
+	var p paginationPolicy
+	for _, opt := range options {
+		opt.apply(&p)
+	}
+	return p
+}
+
 // QueryRequestFromGet returns a parsed *service.QueryRequest from *http.Request using HTTP GET method, and any error
-// during parsing.
-func QueryRequestFromGet(request *http.Request) (qr *service.QueryRequest, err error) {
+// during parsing. The pagination of the returned request holds the effective startIndex and count, after applying the
+// options.
+func QueryRequestFromGet(request *http.Request, options ...QueryOptions) (qr *service.QueryRequest, err error) {
 	qr = &service.QueryRequest{}
 
 	if filter := request.URL.Query().Get(paramFilter); len(filter) > 0 {
@@ -70,30 +166,22 @@ func QueryRequestFromGet(request *http.Request) (qr *service.QueryRequest, err e
 		}
 	}
 
-	if startIndexValue, countValue := request.URL.Query().Get(paramStartIndex), request.URL.Query().Get(paramCount); len(startIndexValue) > 0 || len(countValue) > 0 {
-
-		qr.Pagination = &crud.Pagination{}
-
-		if len(startIndexValue) > 0 {
-			qr.Pagination.StartIndex, err = strconv.Atoi(startIndexValue)
-			if err != nil || qr.Pagination.StartIndex < 1 {
-				err = fmt.Errorf("%w: parameter startIndex must be a 1-based integer", spec.ErrInvalidSyntax)
-				return
-			}
-		} else {
-			qr.Pagination.StartIndex = 1
+	var startIndex, count *int
+	if startIndexValue := request.URL.Query().Get(paramStartIndex); len(startIndexValue) > 0 {
+		startIndex = new(int)
+		if *startIndex, err = strconv.Atoi(startIndexValue); err != nil {
+			err = fmt.Errorf("%w: parameter startIndex must be a 1-based integer", spec.ErrInvalidSyntax)
+			return
 		}
-
-		if len(countValue) > 0 {
-			qr.Pagination.Count, err = strconv.Atoi(countValue)
-			if err != nil || qr.Pagination.Count < 0 {
-				err = fmt.Errorf("%w: parameter count must be a non-negative integer", spec.ErrInvalidSyntax)
-				return
-			}
-		} else {
-			qr.Pagination.Count = 0
+	}
+	if countValue := request.URL.Query().Get(paramCount); len(countValue) > 0 {
+		count = new(int)
+		if *count, err = strconv.Atoi(countValue); err != nil {
+			err = fmt.Errorf("%w: parameter count must be a non-negative integer", spec.ErrInvalidSyntax)
+			return
 		}
 	}
+	qr.Pagination = newPaginationPolicy(options).resolve(startIndex, count)
 
 	qr.Projection, err = GetRequestProjection(request)
 	if err != nil {
@@ -104,56 +192,47 @@ func QueryRequestFromGet(request *http.Request) (qr *service.QueryRequest, err e
 }
 
 // QueryRequestFromPost returns a parsed *service.QueryRequest from *http.Request using HTTP POST method, a closer function
-// to be invoked when the search is finished, and any error during the parsing.
-func QueryRequestFromPost(request *http.Request) (qr *service.QueryRequest, closer func(), err error) {
-	wip := new(struct {
-		Schemas            []string `json:"schemas"`
-		Attributes         []string `json:"attributes"`
-		ExcludedAttributes []string `json:"excludedAttributes"`
-		Filter             string   `json:"filter"`
-		SortBy             string   `json:"sortBy"`
-		SortOrder          string   `json:"sortOrder"`
-		StartIndex         int      `json:"startIndex"`
-		Count              int      `json:"count"`
-	})
-	if err = json.NewDecoder(request.Body).Decode(wip); err != nil {
-		return
-	}
+// to be invoked when the search is finished, and any error during the parsing. The body is the search request message
+// of RFC7644 section 3.4.3, whose parameters are interpreted identically to those of QueryRequestFromGet. The pagination
+// of the returned request holds the effective startIndex and count, after applying the options.
+func QueryRequestFromPost(request *http.Request, options ...QueryOptions) (qr *service.QueryRequest, closer func(), err error) {
 	closer = func() {
 		_ = request.Body.Close()
 	}
 
-	if len(wip.Schemas) != 1 || wip.Schemas[0] != "urn:ietf:params:scim:api:messages:2.0:SearchRequest" {
+	payload := new(service.SearchPayload)
+	if err = json.NewDecoder(request.Body).Decode(payload); err != nil {
+		err = fmt.Errorf("%w: malformed search request: %s", spec.ErrInvalidSyntax, err.Error())
+		return
+	}
+
+	if len(payload.Schemas) != 1 || payload.Schemas[0] != service.SearchRequestSchema {
 		err = fmt.Errorf("%w: invalid schema for search request", spec.ErrInvalidSyntax)
 		return
 	}
 	qr = &service.QueryRequest{
-		Filter: wip.Filter,
+		Filter: payload.Filter,
 	}
 
-	if len(wip.SortBy) > 0 {
+	if len(payload.SortBy) > 0 {
 		qr.Sort = &crud.Sort{
-			By:    wip.SortBy,
-			Order: crud.SortOrder(wip.SortOrder), // validate it later
+			By:    payload.SortBy,
+			Order: crud.SortOrder(payload.SortOrder), // validate it later
 		}
 	}
 
-	if len(wip.Attributes) > 0 || len(wip.ExcludedAttributes) > 0 {
+	if len(payload.Attributes) > 0 && len(payload.ExcludedAttributes) > 0 {
+		err = fmt.Errorf("%w: only one of attributes and excludedAttributes may be specified", spec.ErrInvalidValue)
+		return
+	}
+	if len(payload.Attributes) > 0 || len(payload.ExcludedAttributes) > 0 {
 		qr.Projection = &crud.Projection{
-			Attributes:         wip.Attributes,
-			ExcludedAttributes: wip.ExcludedAttributes,
+			Attributes:         payload.Attributes,
+			ExcludedAttributes: payload.ExcludedAttributes,
 		}
 	}
 
-	if wip.StartIndex > 0 || wip.Count > 0 {
-		if wip.StartIndex == 0 {
-			wip.StartIndex = 1
-		}
-		qr.Pagination = &crud.Pagination{
-			StartIndex: wip.StartIndex,
-			Count:      wip.Count,
-		}
-	}
+	qr.Pagination = newPaginationPolicy(options).resolve(payload.StartIndex, payload.Count)
 
 	return
 }
@@ -181,6 +260,7 @@ func PatchRequest(request *http.Request) (pr func(resourceId string) *service.Pa
 		return &service.PatchRequest{
 			ResourceID:    resourceId,
 			MatchCriteria: MatchCriteria(request),
+			Return:        ReturnPreference(request),
 			PayloadSource: request.Body,
 		}
 	}
@@ -190,6 +270,28 @@ func PatchRequest(request *http.Request) (pr func(resourceId string) *service.Pa
 	return
 }
 
+// ReturnPreference returns the return preference of the Prefer headers, as defined in RFC7240 section 4.2, which is
+// service.ReturnMinimal for "Prefer: return=minimal", service.ReturnRepresentation for "Prefer: return=representation",
+// or empty when absent or of any other value. Other preferences, and the parameters of the preference, are ignored.
+func ReturnPreference(request *http.Request) string {
+	for _, header := range request.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			preference = strings.SplitN(preference, ";", 2)[0]
+			parts := strings.SplitN(preference, "=", 2)
+			if len(parts) != 2 || !strings.EqualFold(strings.TrimSpace(parts[0]), "return") {
+				continue
+			}
+			switch value := strings.Trim(strings.TrimSpace(parts[1]), `"`); strings.ToLower(value) {
+			case service.ReturnMinimal:
+				return service.ReturnMinimal
+			case service.ReturnRepresentation:
+				return service.ReturnRepresentation
+			}
+		}
+	}
+	return ""
+}
+
 // DeleteRequest returns a function that will supply a complete built *service.DeleteRequest when given resourceId.
 func DeleteRequest(request *http.Request) func(resourceId string) *service.DeleteRequest {
 	return func(resourceId string) *service.DeleteRequest {
@@ -241,3 +343,24 @@ func MatchCriteria(request *http.Request) func(resource *prop.Resource) bool {
 		return true
 	}
 }
+
+// NotModified returns true if the If-None-Match header of the GET request matches the version of the resource, in
+// which case the handler should respond with 304 Not Modified and the ETag header instead of the resource. The header
+// may be an asterisk (*) or comma delimited resource versions, which are compared ignoring the weak indicator (W/), as
+// required by RFC7232 section 3.2.
+func NotModified(request *http.Request, resource *prop.Resource) bool {
+	ifNoneMatch := strings.TrimSpace(request.Header.Get("If-None-Match"))
+	version := resource.MetaVersionOrEmpty()
+	if len(ifNoneMatch) == 0 || len(version) == 0 {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+	for _, eachVersion := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(eachVersion), "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -3,12 +3,15 @@ package handlerutil
 import (
 	"errors"
 	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/service"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -34,13 +37,13 @@ func TestGetRequestProjection(t *testing.T) {
 			requestFunc: func() *http.Request {
 				r := httptest.NewRequest(http.MethodGet, "/", nil)
 				r.URL.RawQuery = url.Values{
-					paramAttributes: []string{"foo,bar,baz"},
+					paramAttributes: []string{"foo, bar.baz,,urn:ietf:params:scim:schemas:core:2.0:User:baz"},
 				}.Encode()
 				return r
 			},
 			expect: func(t *testing.T, projection *crud.Projection, err error) {
 				assert.Nil(t, err)
-				assert.Equal(t, []string{"foo", "bar", "baz"}, projection.Attributes)
+				assert.Equal(t, []string{"foo", "bar.baz", "urn:ietf:params:scim:schemas:core:2.0:User:baz"}, projection.Attributes)
 				assert.Nil(t, projection.ExcludedAttributes)
 			},
 		},
@@ -71,7 +74,7 @@ func TestGetRequestProjection(t *testing.T) {
 			},
 			expect: func(t *testing.T, projection *crud.Projection, err error) {
 				assert.NotNil(t, err)
-				assert.Equal(t, spec.ErrInvalidSyntax, errors.Unwrap(err))
+				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
 			},
 		},
 	}
@@ -148,6 +151,96 @@ func TestQueryRequestFromGet(t *testing.T) {
 	}
 }
 
+func TestQueryRequestPagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  url.Values
+		body    string
+		options []QueryOptions
+		expect  *crud.Pagination
+	}{
+		{
+			name:   "no pagination",
+			params: url.Values{},
+			body:   ``,
+			expect: nil,
+		},
+		{
+			name:    "default count",
+			params:  url.Values{},
+			body:    ``,
+			options: []QueryOptions{DefaultCount(50), MaxCount(100)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 50},
+		},
+		{
+			name:    "max count as default",
+			params:  url.Values{paramStartIndex: []string{"3"}},
+			body:    `, "startIndex": 3`,
+			options: []QueryOptions{MaxCount(100)},
+			expect:  &crud.Pagination{StartIndex: 3, Count: 100},
+		},
+		{
+			name:    "count within max",
+			params:  url.Values{paramCount: []string{"100"}},
+			body:    `, "count": 100`,
+			options: []QueryOptions{DefaultCount(50), MaxCount(100)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 100},
+		},
+		{
+			name:    "count capped",
+			params:  url.Values{paramCount: []string{"101"}},
+			body:    `, "count": 101`,
+			options: []QueryOptions{DefaultCount(50), MaxCount(100)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 100},
+		},
+		{
+			name:    "count of zero is kept",
+			params:  url.Values{paramCount: []string{"0"}},
+			body:    `, "count": 0`,
+			options: []QueryOptions{DefaultCount(50), MaxCount(100)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 0},
+		},
+		{
+			name:    "negative count as zero",
+			params:  url.Values{paramCount: []string{"-1"}},
+			body:    `, "count": -1`,
+			options: []QueryOptions{DefaultCount(50)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 0},
+		},
+		{
+			name:    "startIndex less than one as one",
+			params:  url.Values{paramStartIndex: []string{"0"}, paramCount: []string{"10"}},
+			body:    `, "startIndex": -5, "count": 10`,
+			options: []QueryOptions{DefaultCount(50)},
+			expect:  &crud.Pagination{StartIndex: 1, Count: 10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+" (GET)", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.URL.RawQuery = test.params.Encode()
+			qr, err := QueryRequestFromGet(r, test.options...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, qr.Pagination)
+		})
+		t.Run(test.name+" (POST)", func(t *testing.T) {
+			body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"]` + test.body + `}`
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			qr, _, err := QueryRequestFromPost(r, test.options...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, qr.Pagination)
+		})
+	}
+
+	t.Run("invalid count", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.RawQuery = url.Values{paramCount: []string{"ten"}}.Encode()
+		_, err := QueryRequestFromGet(r)
+		assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+	})
+}
+
 func TestQueryRequestFromPost(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -181,6 +274,67 @@ func TestQueryRequestFromPost(t *testing.T) {
 				assert.Equal(t, []string{"id", "meta", "userName"}, qr.Projection.Attributes)
 			},
 		},
+		{
+			name: "invalid schema",
+			requestFunc: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+  "filter": "id pr"
+}
+`))
+			},
+			expect: func(t *testing.T, qr *service.QueryRequest, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+		{
+			name: "malformed JSON",
+			requestFunc: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"schemas": [`))
+			},
+			expect: func(t *testing.T, qr *service.QueryRequest, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+		{
+			name: "both attributes and excludedAttributes",
+			requestFunc: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+  "attributes": ["userName"],
+  "excludedAttributes": ["emails"]
+}
+`))
+			},
+			expect: func(t *testing.T, qr *service.QueryRequest, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+		{
+			name: "filter too long for a query string",
+			requestFunc: func() *http.Request {
+				var terms []string
+				for i := 0; i < 500; i++ {
+					terms = append(terms, `userName eq \"user`+strconv.Itoa(i)+`@example.com\"`)
+				}
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+  "filter": "`+strings.Join(terms, " or ")+`",
+  "excludedAttributes": ["emails"]
+}
+`))
+			},
+			expect: func(t *testing.T, qr *service.QueryRequest, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, 499, strings.Count(qr.Filter, " or "))
+				assert.Nil(t, qr.Sort)
+				assert.Nil(t, qr.Pagination)
+				assert.Equal(t, []string{"emails"}, qr.Projection.ExcludedAttributes)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -191,3 +345,63 @@ func TestQueryRequestFromPost(t *testing.T) {
 		})
 	}
 }
+
+func TestNotModified(t *testing.T) {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+	resource := prop.NewResource(resourceType)
+	require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+		"id": "foobar",
+		"meta": map[string]interface{}{
+			"version": `W/"1"`,
+		},
+	}).Error())
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expect      bool
+	}{
+		{name: "no header", ifNoneMatch: "", expect: false},
+		{name: "same version", ifNoneMatch: `W/"1"`, expect: true},
+		{name: "same version without weak indicator", ifNoneMatch: `"1"`, expect: true},
+		{name: "one of the versions", ifNoneMatch: `W/"0", W/"1"`, expect: true},
+		{name: "other version", ifNoneMatch: `W/"2"`, expect: false},
+		{name: "asterisk", ifNoneMatch: "*", expect: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/Users/foobar", nil)
+			if len(test.ifNoneMatch) > 0 {
+				request.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			assert.Equal(t, test.expect, NotModified(request, resource))
+		})
+	}
+}
+
+func TestReturnPreference(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefer []string
+		expect string
+	}{
+		{name: "no header", expect: ""},
+		{name: "minimal", prefer: []string{"return=minimal"}, expect: service.ReturnMinimal},
+		{name: "representation", prefer: []string{"return=representation"}, expect: service.ReturnRepresentation},
+		{name: "case insensitive and quoted", prefer: []string{`Return = "Minimal"`}, expect: service.ReturnMinimal},
+		{name: "among other preferences", prefer: []string{"respond-async, wait=10", "return=minimal; foo=bar"}, expect: service.ReturnMinimal},
+		{name: "unknown value", prefer: []string{"return=everything"}, expect: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPatch, "/Users/foobar", nil)
+			for _, prefer := range test.prefer {
+				request.Header.Add("Prefer", prefer)
+			}
+			assert.Equal(t, test.expect, ReturnPreference(request))
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package handlerutil
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"github.com/imulab/go-scim/pkg/v2/crud"
 	scimjson "github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/service"
+	"github.com/imulab/go-scim/pkg/v2/service/filter"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"net/http"
+	"strconv"
 )
 
 // WriteResourceToResponse writes the given resource to http.ResponseWriter, respecting the attributes or excludedAttributes
@@ -15,7 +18,8 @@ import (
 // Apart from writing the JSON representation of the resource to body, this method also sets Content-Type header to
 // application/scim+json; sets Location header to resource's meta.location field, if any; and sets ETag header to
 // resource's meta.version field, if any. This method does not set response status, which should be set before calling
-// this method.
+// this method. If a locator is supplied through the json.Locate option, the Location header is rendered by it, just
+// like meta.location in the body.
 func WriteResourceToResponse(rw http.ResponseWriter, resource *prop.Resource, options ...scimjson.Options) error {
 	raw, jsonErr := scimjson.Serialize(resource, options...)
 	if jsonErr != nil {
@@ -23,15 +27,49 @@ func WriteResourceToResponse(rw http.ResponseWriter, resource *prop.Resource, op
 	}
 
 	rw.Header().Set("Content-Type", spec.ApplicationScimJson)
+	WriteResourceHeaders(rw, resource, options...)
+
+	_, writeErr := rw.Write(raw)
+	return writeErr
+}
+
+// WriteResourceHeaders sets the Location header to the resource's meta.location field, if any, rendered by the locator
+// supplied through the json.Locate option, and the ETag header to the resource's meta.version field, if any. It is used
+// by WriteResourceToResponse, and on its own for responses without body, i.e. 204 No Content to a patch, so that the
+// client still learns the version to be sent in If-Match. Like other headers, they must be set before the status.
+func WriteResourceHeaders(rw http.ResponseWriter, resource *prop.Resource, options ...scimjson.Options) {
 	if location := resource.MetaLocationOrEmpty(); len(location) > 0 {
-		rw.Header().Set("Location", location)
+		rw.Header().Set("Location", scimjson.LocatorOf(options...).Render(location))
 	}
 	if version := resource.MetaVersionOrEmpty(); len(version) > 0 {
 		rw.Header().Set("ETag", version)
 	}
+}
 
-	_, writeErr := rw.Write(raw)
-	return writeErr
+// ProjectionOptions returns the json.Include or json.Exclude options that render the resources according to the
+// projection of a get or query request, or no options if the projection is nil. Attributes whose returned
+// characteristic is "always" are serialized regardless of the projection, those whose returned characteristic is
+// "never" are never serialized, and those whose returned characteristic is "request" only when included explicitly.
+func ProjectionOptions(projection *crud.Projection) []scimjson.Options {
+	var options []scimjson.Options
+	if projection != nil {
+		if len(projection.Attributes) > 0 {
+			options = append(options, scimjson.Include(projection.Attributes...))
+		}
+		if len(projection.ExcludedAttributes) > 0 {
+			options = append(options, scimjson.Exclude(projection.ExcludedAttributes...))
+		}
+	}
+	return options
+}
+
+// WriteWarnings adds a Warning header for each warning collected in the context by the filters, i.e. for the assignment
+// of deprecated attributes, if the context was prepared with filter.WithWarnings. Like other headers, the warnings
+// must be written before the response status.
+func WriteWarnings(rw http.ResponseWriter, ctx context.Context) {
+	for _, warning := range filter.Warnings(ctx) {
+		rw.Header().Add("Warning", warning)
+	}
 }
 
 // WriteSearchResultToResponse writes the search result to http.ResponseWrite, respecting the attribute or excludedAttributes
@@ -59,40 +97,70 @@ func WriteSearchResultToResponse(rw http.ResponseWriter, searchResult *service.Q
 	return json.NewEncoder(rw).Encode(render)
 }
 
-// WriteError writes the error to the http.ResponseWriter. Any error during the process will be returned.
-// If the cause of the error (determined using errors.Unwrap) is a *spec.Error, the cause status and scimType will be
-// used together with the error's message as detail. If the cause is not a *spec.Error, spec.ErrInternal is used instead.
+// WriteError writes the error to the http.ResponseWriter as the SCIM error response. Any error during the process will
+// be returned. The status, scimType and detail of the response are determined by json.SerializeError according to
+// spec.DetailsOf: errors caused by a *spec.Error (determined using errors.As) carry its status and message, while other
+// errors are reported as internal errors without disclosing their message. Errors from JSON deserialization carry the JSON pointer and the attribute
+// path of the offending value in their message, hence they are also included in the detail.
 // This method also writes the http status with the error's defined status, and set Content-Type header to application/scim+json.
 func WriteError(rw http.ResponseWriter, err error) error {
-	var errMsg = struct {
-		Schemas  []string `json:"schemas"`
-		Status   int      `json:"status"`
-		ScimType string   `json:"scimType"`
-		Detail   string   `json:"detail"`
-	}{
-		Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
-		Detail:  err.Error(),
+	raw, status := scimjson.SerializeError(err)
+
+	rw.Header().Set("Content-Type", spec.ApplicationScimJson)
+	rw.WriteHeader(status)
+
+	_, writeErr := rw.Write(raw)
+	return writeErr
+}
+
+// WriteBulkResponseToResponse writes the bulk response message (urn:ietf:params:scim:api:messages:2.0:BulkResponse) to
+// http.ResponseWriter, as defined in RFC7644 section 3.7.3. Any error during the process will be returned. Each
+// operation carries its method, bulkId, version, location and status, and the SCIM error response, as rendered by
+// WriteError, if it failed. The resources are not included, as the client can get them from their location. This
+// method also writes the 200 status, and sets Content-Type header to application/scim+json.
+func WriteBulkResponseToResponse(rw http.ResponseWriter, bulkResponse *service.BulkResponse, options ...scimjson.Options) error {
+	render := BulkResponseRendering{
+		Schemas:    []string{service.BulkResponseSchema},
+		Operations: []BulkOperationRendering{},
 	}
 
-	cause := errors.Unwrap(err)
-	if scimError, ok := cause.(*spec.Error); ok {
-		errMsg.Status = scimError.Status
-		errMsg.ScimType = scimError.Type
-	} else {
-		errMsg.Status = spec.ErrInternal.Status
-		errMsg.ScimType = spec.ErrInternal.Type
+	locator := scimjson.LocatorOf(options...)
+	for _, op := range bulkResponse.Operations {
+		opRender := BulkOperationRendering{
+			Method:  op.Method,
+			BulkID:  op.BulkID,
+			Version: op.Version,
+			Status:  strconv.Itoa(op.Status),
+		}
+		if len(op.Location) > 0 {
+			opRender.Location = locator.Render(op.Location)
+		}
+		if op.Err != nil {
+			opRender.Response, _ = scimjson.SerializeError(op.Err)
+		}
+		render.Operations = append(render.Operations, opRender)
 	}
 
 	rw.Header().Set("Content-Type", spec.ApplicationScimJson)
-	rw.WriteHeader(errMsg.Status)
+	rw.WriteHeader(http.StatusOK)
+	return json.NewEncoder(rw).Encode(render)
+}
 
-	raw, jsonErr := json.Marshal(errMsg)
-	if jsonErr != nil {
-		return jsonErr
-	}
+// BulkResponseRendering is the JSON rendering structure for bulk responses.
+type BulkResponseRendering struct {
+	Schemas    []string                 `json:"schemas"`
+	Operations []BulkOperationRendering `json:"Operations"`
+}
 
-	_, writeErr := rw.Write(raw)
-	return writeErr
+// BulkOperationRendering is the JSON rendering structure for a single operation of the bulk response. As required by
+// RFC7644, the status is rendered as a string.
+type BulkOperationRendering struct {
+	Method   string          `json:"method"`
+	BulkID   string          `json:"bulkId,omitempty"`
+	Version  string          `json:"version,omitempty"`
+	Location string          `json:"location,omitempty"`
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
 }
 
 // SearchResultRendering is the JSON rendering structure for search results. This is very similar to
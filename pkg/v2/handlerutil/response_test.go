@@ -3,12 +3,66 @@ package handlerutil
 import (
 	"errors"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/service"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http/httptest"
 	"testing"
 )
 
+func TestWriteResourceToResponse(t *testing.T) {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+
+	resource := prop.NewResource(resourceType)
+	require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+		"id": "123",
+		"meta": map[string]interface{}{
+			"location": "/Users/123",
+			"version":  "W/\"1\"",
+		},
+	}).Error())
+
+	tests := []struct {
+		name    string
+		options []scimjson.Options
+		expect  string
+	}{
+		{
+			name:   "default",
+			expect: "/Users/123",
+		},
+		{
+			name:    "with locator",
+			options: []scimjson.Options{scimjson.Locate(&spec.Locator{BaseURL: "https://example.com/v2"})},
+			expect:  "https://example.com/v2/Users/123",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			assert.Nil(t, WriteResourceToResponse(rw, resource, test.options...))
+			assert.Equal(t, test.expect, rw.Header().Get("Location"))
+			assert.Contains(t, rw.Body.String(), `"location":"`+test.expect+`"`)
+			assert.Equal(t, "W/\"1\"", rw.Header().Get("ETag"))
+		})
+		t.Run(test.name+" without body", func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			WriteResourceHeaders(rw, resource, test.options...)
+			rw.WriteHeader(204)
+			assert.Equal(t, 204, rw.Code)
+			assert.Equal(t, test.expect, rw.Header().Get("Location"))
+			assert.Equal(t, "W/\"1\"", rw.Header().Get("ETag"))
+			assert.Empty(t, rw.Body.String())
+		})
+	}
+}
+
 func TestWriteError(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -24,10 +78,30 @@ func TestWriteError(t *testing.T) {
   "schemas": [
     "urn:ietf:params:scim:api:messages:2.0:Error"
   ],
-  "status": 400,
+  "status": "400",
   "scimType": "invalidValue",
   "detail": "invalidValue: valid is invalid"
 }
+`, string(raw))
+			},
+		},
+		{
+			name: "deserialization error",
+			err: &scimjson.DeserializeError{
+				Pointer: "/emails/1/value",
+				Path:    "urn:ietf:params:scim:schemas:core:2.0:User:emails.value",
+				Err:     fmt.Errorf("%w: expects string literal value", spec.ErrInvalidSyntax),
+			},
+			expect: func(t *testing.T, raw []byte) {
+				assert.JSONEq(t, `
+{
+  "schemas": [
+    "urn:ietf:params:scim:api:messages:2.0:Error"
+  ],
+  "status": "400",
+  "scimType": "invalidSyntax",
+  "detail": "invalidSyntax: expects string literal value (pointer:'/emails/1/value', path:'urn:ietf:params:scim:schemas:core:2.0:User:emails.value')"
+}
 `, string(raw))
 			},
 		},
@@ -40,9 +114,8 @@ func TestWriteError(t *testing.T) {
   "schemas":[
     "urn:ietf:params:scim:api:messages:2.0:Error"
   ],
-  "status":500,
-  "scimType":"internal",
-  "detail":"something was wrong"
+  "status":"500",
+  "detail":"internal server error"
 }
 `, string(raw))
 			},
@@ -57,3 +130,104 @@ func TestWriteError(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteBulkResponseToResponse(t *testing.T) {
+	rw := httptest.NewRecorder()
+	err := WriteBulkResponseToResponse(rw, &service.BulkResponse{
+		Operations: []*service.BulkOperationResponse{
+			{
+				Method:   "POST",
+				BulkID:   "qwerty",
+				Version:  "W/\"1\"",
+				Location: "/Users/92b725cd",
+				Status:   201,
+			},
+			{
+				Method: "DELETE",
+				Status: 404,
+				Err:    fmt.Errorf("%w: resource not found", spec.ErrNotFound),
+			},
+		},
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, spec.ApplicationScimJson, rw.Header().Get("Content-Type"))
+	assert.JSONEq(t, `
+{
+  "schemas": [
+    "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+  ],
+  "Operations": [
+    {
+      "method": "POST",
+      "bulkId": "qwerty",
+      "version": "W/\"1\"",
+      "location": "/Users/92b725cd",
+      "status": "201"
+    },
+    {
+      "method": "DELETE",
+      "status": "404",
+      "response": {
+        "schemas": [
+          "urn:ietf:params:scim:api:messages:2.0:Error"
+        ],
+        "status": "404",
+        "detail": "notFound: resource not found"
+      }
+    }
+  ]
+}
+`, rw.Body.String())
+}
+
+func TestProjectionOptions(t *testing.T) {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+	resource := prop.NewResource(resourceType)
+	require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+		"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"id":       "foobar",
+		"userName": "foo",
+		"password": "s3cret",
+		"name": map[string]interface{}{
+			"givenName":  "Foo",
+			"familyName": "Bar",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+		},
+	}).Error())
+
+	tests := []struct {
+		name       string
+		projection *crud.Projection
+		expect     string
+	}{
+		{
+			name:       "no projection",
+			projection: nil,
+			expect: `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"id":"foobar","userName":"foo",
+				"name":{"givenName":"Foo","familyName":"Bar"},"emails":[{"value":"foo@bar.com","type":"work"}]}`,
+		},
+		{
+			name:       "attributes with schema URN and sub attribute",
+			projection: &crud.Projection{Attributes: []string{"urn:ietf:params:scim:schemas:core:2.0:User:name.givenName", "password"}},
+			expect:     `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"id":"foobar","name":{"givenName":"Foo"}}`,
+		},
+		{
+			name:       "excludedAttributes do not exclude attributes returned always",
+			projection: &crud.Projection{ExcludedAttributes: []string{"id", "emails", "name.familyName"}},
+			expect:     `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"id":"foobar","userName":"foo","name":{"givenName":"Foo"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := scimjson.Serialize(resource, ProjectionOptions(test.projection)...)
+			require.Nil(t, err)
+			assert.JSONEq(t, test.expect, string(raw))
+		})
+	}
+}
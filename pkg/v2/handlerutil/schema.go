@@ -0,0 +1,50 @@
+package handlerutil
+
+import (
+	"fmt"
+	"sort"
+
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/service"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// ListSchemas returns the response of GET /Schemas, which lists the registered schemas, sorted by id, in the
+// representation defined by RFC7643 section 7. Internal schemas, as reported by spec.Schema#IsInternal, are not listed.
+// The response can be written with WriteSearchResultToResponse. Because schemas may be registered at runtime, callers
+// caching the response should render it again when notified by spec.Schemas().Subscribe. The options are passed on to
+// json.SchemaToSerializable, i.e. to render the @Constraints of attributes.
+func ListSchemas(options ...scimjson.SchemaOptions) *service.QueryResponse {
+	var schemas []*spec.Schema
+	_ = spec.Schemas().ForEachSchema(func(schema *spec.Schema) error {
+		if !schema.IsInternal() {
+			schemas = append(schemas, schema)
+		}
+		return nil
+	})
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].ID() < schemas[j].ID()
+	})
+
+	result := &service.QueryResponse{
+		TotalResults: len(schemas),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    []scimjson.Serializable{},
+	}
+	for _, schema := range schemas {
+		result.Resources = append(result.Resources, scimjson.SchemaToSerializable(schema, options...))
+	}
+	return result
+}
+
+// GetSchema returns the response of GET /Schemas/{id}, which is the registered schema of the id in the representation
+// defined by RFC7643 section 7. It can be serialized with json.Serialize. If the schema is not registered, or is an
+// internal schema, the error is spec.ErrNotFound. The options are passed on to json.SchemaToSerializable.
+func GetSchema(id string, options ...scimjson.SchemaOptions) (scimjson.Serializable, error) {
+	schema, ok := spec.Schemas().Get(id)
+	if !ok || schema.IsInternal() {
+		return nil, fmt.Errorf("%w: schema '%s' is not found", spec.ErrNotFound, id)
+	}
+	return scimjson.SchemaToSerializable(schema, options...), nil
+}
@@ -0,0 +1,124 @@
+package handlerutil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemas(t *testing.T) {
+	visible, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Visible", "Visible").
+		Description("Visible schema").
+		Attribute(spec.ComplexAttr("manager",
+			spec.StringAttr("value").Annotation(annotation.Identity, nil),
+			spec.ReferenceAttr("$ref", "User"),
+		).Annotation(annotation.StateSummary, nil)).
+		Build()
+	require.Nil(t, err)
+	require.Nil(t, spec.Schemas().Register(visible))
+
+	internal, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Internal", "Internal").
+		Annotation(annotation.Internal, nil).
+		Attribute(spec.StringAttr("secret")).
+		Build()
+	require.Nil(t, err)
+	require.Nil(t, spec.Schemas().Register(internal))
+
+	t.Run("list", func(t *testing.T) {
+		result := ListSchemas()
+		assert.Equal(t, len(result.Resources), result.TotalResults)
+
+		rw := httptest.NewRecorder()
+		require.Nil(t, WriteSearchResultToResponse(rw, result))
+
+		var body struct {
+			Resources []struct {
+				ID string `json:"id"`
+			} `json:"Resources"`
+		}
+		require.Nil(t, json.Unmarshal(rw.Body.Bytes(), &body))
+
+		var ids []string
+		for _, each := range body.Resources {
+			ids = append(ids, each.ID)
+		}
+		assert.True(t, sort.StringsAreSorted(ids))
+		assert.Contains(t, ids, "urn:ietf:params:scim:schemas:test:2.0:Visible")
+		assert.NotContains(t, ids, "urn:ietf:params:scim:schemas:test:2.0:Internal")
+		assert.NotContains(t, ids, spec.CoreSchemaId)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		serializable, err := GetSchema("urn:ietf:params:scim:schemas:test:2.0:Visible")
+		require.Nil(t, err)
+
+		raw, err := scimjson.Serialize(serializable)
+		require.Nil(t, err)
+		assert.JSONEq(t, `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:Schema"],
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Visible",
+  "meta": {
+    "resourceType": "Schema",
+    "location": "/Schemas/urn:ietf:params:scim:schemas:test:2.0:Visible"
+  },
+  "name": "Visible",
+  "description": "Visible schema",
+  "attributes": [
+    {
+      "name": "manager",
+      "type": "complex",
+      "multiValued": false,
+      "required": false,
+      "caseExact": false,
+      "mutability": "readWrite",
+      "returned": "default",
+      "uniqueness": "none",
+      "subAttributes": [
+        {
+          "name": "value",
+          "type": "string",
+          "multiValued": false,
+          "required": false,
+          "caseExact": false,
+          "mutability": "readWrite",
+          "returned": "default",
+          "uniqueness": "none"
+        },
+        {
+          "name": "$ref",
+          "type": "reference",
+          "multiValued": false,
+          "required": false,
+          "caseExact": false,
+          "mutability": "readWrite",
+          "returned": "default",
+          "uniqueness": "none",
+          "referenceTypes": ["User"]
+        }
+      ]
+    }
+  ]
+}
+`, string(raw))
+	})
+
+	t.Run("get internal or unknown", func(t *testing.T) {
+		for _, id := range []string{
+			"urn:ietf:params:scim:schemas:test:2.0:Internal",
+			spec.CoreSchemaId,
+			"urn:ietf:params:scim:schemas:test:2.0:Unknown",
+		} {
+			_, err := GetSchema(id)
+			assert.True(t, errors.Is(err, spec.ErrNotFound), id)
+		}
+	})
+}
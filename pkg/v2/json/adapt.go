@@ -5,9 +5,34 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
-// SchemaToSerializable returns a Serializable wrapper for a schema so it can be used to call json.Serialize
-func SchemaToSerializable(sch *spec.Schema) Serializable {
-	return &internal.SerializableSchema{Sch: sch}
+// SchemaToSerializable returns a Serializable wrapper for a schema so it can be used to call json.Serialize. The
+// constraints set by the @Constraints annotation are not part of RFC7643, hence they are omitted, unless
+// EmitConstraints is among the options.
+func SchemaToSerializable(sch *spec.Schema, options ...SchemaOptions) Serializable {
+	s := &internal.SerializableSchema{Sch: sch}
+	for _, opt := range options {
+		opt.apply(s)
+	}
+	return s
+}
+
+// SchemaOptions customizes the representation of schemas by SchemaToSerializable.
+type SchemaOptions interface {
+	apply(s *internal.SerializableSchema)
+}
+
+// EmitConstraints returns SchemaOptions to render the constraints set by the @Constraints annotation as an object
+// under the given vendor namespace key of each constrained attribute, i.e. "urn:acme:constraints": {"maxLength": 256}.
+func EmitConstraints(namespace string) SchemaOptions {
+	return emitConstraints{namespace: namespace}
+}
+
+type emitConstraints struct {
+	namespace string
+}
+
+func (e emitConstraints) apply(s *internal.SerializableSchema) {
+	s.ConstraintsNamespace = e.namespace
 }
 
 // ResourceTypeToSerializable returns a Serializable wrapper for a resource type so it can be used to call json.Serialize
@@ -1,36 +1,16 @@
 package json
 
 import (
-	"encoding/json"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
-	"os"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
 func TestResourceTypeToSerializable(t *testing.T) {
-	f, err := os.Open("../../../public/schemas/user_schema.json")
-	assert.Nil(t, err)
-
-	sch := new(spec.Schema)
-	err = json.NewDecoder(f).Decode(sch)
-	assert.Nil(t, err)
-	spec.Schemas().Register(sch)
-
-	f, err = os.Open("../../../public/schemas/user_enterprise_extension_schema.json")
-	assert.Nil(t, err)
-
-	schExt := new(spec.Schema)
-	err = json.NewDecoder(f).Decode(schExt)
-	assert.Nil(t, err)
-	spec.Schemas().Register(schExt)
-
-	f, err = os.Open("../../../public/resource_types/user_resource_type.json")
-	assert.Nil(t, err)
-
-	rt := new(spec.ResourceType)
-	err = json.NewDecoder(f).Decode(rt)
+	rt, err := spec.RegisterStandardUserResourceType()
 	assert.Nil(t, err)
 
 	crud.Register(rt)
@@ -65,12 +45,9 @@ func TestResourceTypeToSerializable(t *testing.T) {
 }
 
 func TestSchemaToSerializable(t *testing.T) {
-	f, err := os.Open("../../../public/schemas/user_schema.json")
-	assert.Nil(t, err)
-
-	sch := new(spec.Schema)
-	err = json.NewDecoder(f).Decode(sch)
+	rt, err := spec.RegisterStandardUserResourceType()
 	assert.Nil(t, err)
+	sch := rt.Schema()
 
 	raw, err := Serialize(SchemaToSerializable(sch))
 	assert.Nil(t, err)
@@ -832,3 +809,23 @@ func TestSchemaToSerializable(t *testing.T) {
 
 	assert.JSONEq(t, expect, string(raw))
 }
+
+func TestSchemaToSerializableConstraints(t *testing.T) {
+	sch, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Constraints", "Constraints").
+		Attribute(spec.StringAttr("displayName").Annotation(annotation.Constraints, map[string]interface{}{"maxLength": 256})).
+		Attribute(spec.StringAttr("employeeId").Annotation(annotation.Constraints, map[string]interface{}{"pattern": "^E[0-9]+$"})).
+		Attribute(spec.IntegerAttr("seatCount").Annotation(annotation.Constraints, map[string]interface{}{"minimum": 1, "maximum": 10000})).
+		Build()
+	require.Nil(t, err)
+
+	raw, err := Serialize(SchemaToSerializable(sch))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(raw), "maxLength")
+	assert.NotContains(t, string(raw), "pattern")
+
+	raw, err = Serialize(SchemaToSerializable(sch, EmitConstraints("urn:example:constraints")))
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), `"urn:example:constraints":{"maxLength":256}`)
+	assert.Contains(t, string(raw), `"urn:example:constraints":{"pattern":"^E[0-9]+$"}`)
+	assert.Contains(t, string(raw), `"urn:example:constraints":{"minimum":1,"maximum":10000}`)
+}
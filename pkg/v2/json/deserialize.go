@@ -1,6 +1,9 @@
 package json
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -13,24 +16,31 @@ import (
 )
 
 // Deserialize is the entry point of JSON deserialization. Unmarshal the JSON input bytes into a pre-prepared unassigned
-// structure of Resource.
-func Deserialize(json []byte, resource *prop.Resource) error {
-	if err := checkValid(json, &scanner{}); err != nil {
-		return err
-	}
-
+// structure of Resource. Objects, at any level, containing duplicate member names (compared case insensitively, as
+// are attribute names) are rejected with spec.ErrInvalidSyntax, instead of silently taking the last occurrence. Input
+// exceeding DefaultLimits, or the limits of the WithLimits option, is rejected with spec.ErrInvalidSyntax as well.
+// Options may also relax the strictness of the deserialization, i.e. WrapSingleValues.
+func Deserialize(json []byte, resource *prop.Resource, options ...DeserializeOptions) error {
 	state := &deserializeState{
 		data:      json,
 		off:       0,
 		opCode:    scanContinue,
 		scan:      scanner{},
 		navigator: resource.Navigator(),
+		limits:    DefaultLimits,
 	}
 	state.scan.reset()
+	for _, opt := range options {
+		opt.apply(state)
+	}
+
+	if err := checkValid(json, &scanner{}, state.limits); err != nil {
+		return err
+	}
 
 	// skip the first few spaces
 	state.scanWhile(scanSkipSpace)
-	return state.parseComplexProperty(false)
+	return state.wrapError(state.parseComplexProperty(false))
 }
 
 // Entry point to deserialize a piece of JSON data into the given property. The JSON data is expected to be the content
@@ -39,15 +49,26 @@ func Deserialize(json []byte, resource *prop.Resource) error {
 //
 // The allowElementForArray option is provided to allow JSON array element values be provided for a multiValued property
 // so that it will be de-serialized as its element. The result will be a multiValued property containing a single element.
-func DeserializeProperty(json []byte, property prop.Property, allowElementForArray bool) error {
+//
+// Errors returned from this function, as well as from Deserialize, are *DeserializeError which details the location
+// of the error. Like Deserialize, input exceeding DefaultLimits, or the limits of the WithLimits option, is rejected.
+func DeserializeProperty(json []byte, property prop.Property, allowElementForArray bool, options ...DeserializeOptions) error {
 	state := &deserializeState{
 		data:      json,
 		off:       0,
 		opCode:    scanContinue,
 		scan:      scanner{},
 		navigator: prop.Navigate(property),
+		limits:    DefaultLimits,
 	}
 	state.scan.reset()
+	for _, opt := range options {
+		opt.apply(state)
+	}
+
+	if err := checkValid(json, &scanner{}, state.limits); err != nil {
+		return &DeserializeError{Path: property.Attribute().ID(), Err: err}
+	}
 
 	// Since this function is intended for bytes from json.RawMessage, it is not possible for it to precede with
 	// spaces. Hence, simply use scanNext to read in the first byte, then use stateBeginValue to forcibly set the
@@ -55,36 +76,66 @@ func DeserializeProperty(json []byte, property prop.Property, allowElementForArr
 	state.scanNext()
 	state.opCode = stateBeginValue(&state.scan, state.data[0])
 
-	if !property.Attribute().MultiValued() {
-		return state.parseSingleValuedProperty()
+	return state.wrapError(state.parseFragment(allowElementForArray))
+}
+
+// DeserializeValue parses a piece of JSON value with full knowledge of the attribute it targets, and returns the value
+// in the same form as prop.Property#Raw, so it can be assigned onto a property of the attribute without further type
+// errors. It is intended for values that are not part of a resource, such as the value of a patch operation.
+//
+// In addition to JSON arrays, a single element value is accepted for multiValued attributes and parsed as an array of
+// that element. Complex values are parsed recursively, and the same lenient coercions of Deserialize apply. Errors
+// returned from this function are *DeserializeError which details the location of the error within the value. Options
+// are passed on to DeserializeProperty.
+func DeserializeValue(raw json.RawMessage, attr *spec.Attribute, options ...DeserializeOptions) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, &DeserializeError{
+			Path: attr.ID(),
+			Err:  fmt.Errorf("%w: no value for '%s'", spec.ErrInvalidSyntax, attr.Path()),
+		}
+	}
+
+	p := prop.NewProperty(attr)
+	if err := DeserializeProperty(raw, p, attr.MultiValued(), options...); err != nil {
+		return nil, err
+	}
+
+	return p.Raw(), nil
+}
+
+// Parses the JSON fragment provided to DeserializeProperty into the property that the navigator was created with.
+func (d *deserializeState) parseFragment(allowElementForArray bool) error {
+	if !d.navigator.Source().Attribute().MultiValued() {
+		return d.parseSingleValuedProperty()
 	} else {
 		// Check the value is indeed a JSON array
-		if state.data[0] == '[' {
-			return state.parseMultiValuedProperty()
+		if d.data[0] == '[' {
+			return d.parseMultiValuedProperty()
 		}
 
 		// We may choose to allow callers to provide value that corresponds to multiValue element
 		// to be provided as a value for the multiValue property itself. If this feature is enabled,
 		// we will parse the value as the multiValued element and add it to the multiValued container.
 		if !allowElementForArray {
-			return state.errInvalidSyntax("expects JSON array")
+			return d.errInvalidSyntax("expects JSON array")
 		}
 
-		if mv, ok := state.navigator.Current().(interface {
+		if mv, ok := d.navigator.Current().(interface {
 			AppendElement() int
 		}); !ok {
-			return state.errInvalidSyntax("non-multiValued property at json array")
+			return d.errInvalidSyntax("non-multiValued property at json array")
 		} else {
 			i := mv.AppendElement()
 			if i < 0 {
 				return fmt.Errorf("%w: failed to create property to host json array element", spec.ErrInternal)
 			}
-			state.navigator.At(i)
-			defer state.navigator.Retract()
-			if state.navigator.Error() != nil {
-				return state.navigator.Error()
+			d.navigator.At(i)
+			defer d.navigator.Retract()
+			if d.navigator.Error() != nil {
+				return d.navigator.Error()
 			}
-			return state.parseSingleValuedProperty()
+			return d.parseSingleValuedProperty()
 		}
 	}
 }
@@ -97,18 +148,70 @@ func DeserializeProperty(json []byte, property prop.Property, allowElementForArr
 // As a side note, all parseXXX methods of this object shall maintain one courtesy: after done parsing the part of the
 // data of interest to the method, consume as much empty spaces or separators (i.e. scanObjectValue, scanArrayValue) as
 // possible so that the next parseXXX method invoked will not have to skip spaces as its first task.
+//
+// To report the location of errors, the reference tokens of the JSON pointer and the paths of the attributes being
+// parsed are tracked alongside the navigator. Since parseXXX methods return immediately upon error, the tracked
+// location is where the error occurred when the error reaches the entry point.
 type deserializeState struct {
 	data      []byte
 	off       int // next read offset in data
 	opCode    int // last read result
 	scan      scanner
 	navigator prop.Navigator
+	pointer   []string // escaped reference tokens of the JSON pointer to the value being parsed
+	paths     []string // fully qualified paths of the attributes being parsed
+	// if not nil, single values are accepted in place of JSON arrays, and the callback is invoked for each of them
+	wrapSingleValues func(pointer string, path string)
+	// limits on the complexity of the input, checked before parsing
+	limits Limits
 }
 
 func (d *deserializeState) errInvalidSyntax(msg string, args ...interface{}) error {
 	return fmt.Errorf("%w: %s (pos:%d)", spec.ErrInvalidSyntax, fmt.Sprintf(msg, args...), d.off)
 }
 
+// Record the reference token of the JSON pointer for the value about to be parsed.
+func (d *deserializeState) pushPointer(token string) {
+	d.pointer = append(d.pointer, pointerEscaper.Replace(token))
+}
+
+// Record the fully qualified path of the attribute about to be parsed.
+func (d *deserializeState) pushPath(path string) {
+	d.paths = append(d.paths, path)
+}
+
+// Discard the location recorded by the last pushPointer and pushPath.
+func (d *deserializeState) popLocation() {
+	d.pointer = d.pointer[:len(d.pointer)-1]
+	d.paths = d.paths[:len(d.paths)-1]
+}
+
+// Returns the fully qualified path of the attribute currently being parsed.
+func (d *deserializeState) currentPath() string {
+	if len(d.paths) == 0 {
+		return d.navigator.Source().Attribute().ID()
+	}
+	return d.paths[len(d.paths)-1]
+}
+
+// Wraps the error as a *DeserializeError with the currently tracked location, unless it already is one.
+func (d *deserializeState) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var de *DeserializeError
+	if errors.As(err, &de) {
+		return err
+	}
+
+	de = &DeserializeError{Path: d.currentPath(), Err: err}
+	if len(d.pointer) > 0 {
+		de.Pointer = "/" + strings.Join(d.pointer, "/")
+	}
+	return de
+}
+
 // Parses the attribute/field name in a JSON object. This method expects a quoted string and skips through
 // as much empty spaces and colon (appears as scanObjectKey) after it as possible.
 func (d *deserializeState) parseFieldName() (string, error) {
@@ -173,47 +276,28 @@ func (d *deserializeState) parseComplexProperty(allowNull bool) error {
 	// skip any potential spaces between '{' and '"'
 	d.scanWhile(scanSkipSpace)
 
+	// names of the members seen so far in this object, attribute names are case insensitive
+	seen := map[string]struct{}{}
+
 kvs:
 	for d.opCode != scanEndObject {
 		// Focus on the property that corresponds to the field name
-		propertyDepth := 0
-		var (
-			p   prop.Property
-			err error
-		)
-		{
-			attrName, err := d.parseFieldName()
-			if err != nil {
-				return err
-			}
-			p = d.navigator.Dot(attrName).Current()
-			if err := d.navigator.Error(); err == nil {
-				// Navigator changes focus only if there was no error
-				propertyDepth++
-			} else {
-				// parseFieldName() may return a complex dot-separated path that navigator.Dot() fails to accept
-				// parse such path and save the simple attribute names in stack order
-				d.navigator.ClearError()
-				names := d.getPropertyDotNamesByPath(attrName)
-				if len(names) == 0 {
-					return err
-				}
-				for i := range names {
-					p = d.navigator.Dot(names[len(names)-i-1]).Current()
-					if d.navigator.Error() == nil {
-						propertyDepth++
-						continue
-					}
-					// in case of Navigator error - restore the focus and return the initial error
-					for i := 0; i < propertyDepth; i++ {
-						d.navigator.Retract()
-					}
-					return err
-				}
-			}
+		attrName, err := d.parseFieldName()
+		if err != nil {
+			return err
+		}
+		d.pushPointer(attrName)
+		if _, ok := seen[strings.ToLower(attrName)]; ok {
+			return d.errInvalidSyntax("duplicate attribute '%s'", attrName)
+		}
+		seen[strings.ToLower(attrName)] = struct{}{}
+		p, propertyDepth, err := d.focusField(attrName)
+		if err != nil {
+			return err
 		}
 
 		// Parse field value
+		d.pushPath(p.Attribute().ID())
 		if p.Attribute().MultiValued() {
 			err = d.parseMultiValuedProperty()
 		} else {
@@ -227,6 +311,7 @@ kvs:
 		for i := 0; i < propertyDepth; i++ {
 			d.navigator.Retract()
 		}
+		d.popLocation()
 
 		// Fast forward to the next field name/value pair, or exit the loop.
 	fastForward:
@@ -253,6 +338,41 @@ kvs:
 	return nil
 }
 
+// Focus the navigator on the property that corresponds to the field name of a JSON object, and returns the property
+// along with the number of times the navigator must retract to exit the focus. The field name is usually the name
+// of a sub attribute, but may also be a dot-separated path to a nested attribute.
+func (d *deserializeState) focusField(name string) (prop.Property, int, error) {
+	p := d.navigator.Dot(name).Current()
+	err := d.navigator.Error()
+	if err == nil {
+		// Navigator changes focus only if there was no error
+		return p, 1, nil
+	}
+
+	// name may be a complex dot-separated path that navigator.Dot() fails to accept,
+	// parse such path and save the simple attribute names in stack order
+	d.navigator.ClearError()
+	names := d.getPropertyDotNamesByPath(name)
+	if len(names) == 0 {
+		return nil, 0, err
+	}
+	propertyDepth := 0
+	for i := range names {
+		p = d.navigator.Dot(names[len(names)-i-1]).Current()
+		if d.navigator.Error() == nil {
+			propertyDepth++
+			continue
+		}
+		// in case of Navigator error - restore the focus and return the initial error
+		d.navigator.ClearError()
+		for i := 0; i < propertyDepth; i++ {
+			d.navigator.Retract()
+		}
+		return nil, 0, err
+	}
+	return p, propertyDepth, nil
+}
+
 // Delegate method to parse single valued field values. The caller must ensure that the currently focused property
 // is indeed single valued.
 func (d *deserializeState) parseSingleValuedProperty() error {
@@ -277,6 +397,12 @@ func (d *deserializeState) parseSingleValuedProperty() error {
 func (d *deserializeState) parseMultiValuedProperty() error {
 	// Expect '[' or null.
 	if d.opCode != scanBeginArray {
+		if d.opCode == scanBeginLiteral && d.data[d.off-1] == 'n' {
+			return d.parseNull()
+		}
+		if d.wrapSingleValues != nil {
+			return d.parseSingleValueAsArray()
+		}
 		if d.opCode == scanBeginLiteral {
 			return d.parseNull()
 		}
@@ -305,6 +431,8 @@ elements:
 			if d.navigator.Error() != nil {
 				return d.navigator.Error()
 			}
+			d.pushPointer(strconv.Itoa(i))
+			d.pushPath(d.currentPath())
 		}
 
 		// Parse the focused element property
@@ -315,6 +443,7 @@ elements:
 
 		// Exit the focus
 		d.navigator.Retract()
+		d.popLocation()
 
 		// Fast forward to the next element, or exit the loop.
 	fastForward:
@@ -339,6 +468,35 @@ elements:
 	return nil
 }
 
+// Parses a single JSON value, which is not an array, as the only element of the currently focused multiValued property,
+// and reports the coercion. The value is a JSON object for multiValued complex properties, or a JSON literal for other
+// multiValued properties.
+func (d *deserializeState) parseSingleValueAsArray() error {
+	mv, ok := d.navigator.Current().(interface {
+		AppendElement() int
+	})
+	if !ok {
+		return d.errInvalidSyntax("non-multiValued property at json array")
+	}
+
+	i := mv.AppendElement()
+	if i < 0 {
+		return fmt.Errorf("%w: failed to create property to host json array element", spec.ErrInternal)
+	}
+	d.navigator.At(i)
+	if err := d.navigator.Error(); err != nil {
+		return err
+	}
+
+	if err := d.parseSingleValuedProperty(); err != nil {
+		return err
+	}
+	d.navigator.Retract()
+
+	d.wrapSingleValues("/"+strings.Join(d.pointer, "/"), d.currentPath())
+	return nil
+}
+
 // Parses a JSON string. This method expects a double quoted literal and the null literal.
 func (d *deserializeState) parseStringProperty() error {
 	p := d.navigator.Current()
@@ -2,13 +2,12 @@ package json
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -290,6 +289,32 @@ func (s *JsonDeserializeTestSuite) TestDeserializeProperty() {
 				assert.Equal(t, int64(18), property.Raw())
 			},
 		},
+		{
+			name: "deserialize integer property rejects float64 representation",
+			attr: `
+{
+	"name": "age",
+	"type": "integer"
+}
+`,
+			json: `1.234567e+06`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.NotNil(t, err)
+			},
+		},
+		{
+			name: "deserialize integer property from non-integral number",
+			attr: `
+{
+	"name": "age",
+	"type": "integer"
+}
+`,
+			json: `42.5`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.NotNil(t, err)
+			},
+		},
 		{
 			name: "deserialize decimal property",
 			attr: `
@@ -360,6 +385,41 @@ func (s *JsonDeserializeTestSuite) TestDeserializeProperty() {
 				assert.Equal(t, "aGVsbG8K", property.Raw())
 			},
 		},
+		{
+			name: "deserialize binary property without padding",
+			attr: `
+{
+	"name": "certificate",
+	"type": "binary"
+}
+`,
+			json: `"aGVsbG8"`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "aGVsbG8=", property.Raw())
+			},
+		},
+		{
+			name: "deserialize binary property exceeding maximum size",
+			attr: `
+{
+	"id": "urn:ietf:params:scim:schemas:core:2.0:User:x509Certificates.value",
+	"name": "value",
+	"type": "binary",
+	"_path": "x509Certificates.value",
+	"_annotations": {
+		"@MaxSize": {
+			"bytes": 4
+		}
+	}
+}
+`,
+			json: `"aGVsbG8K"`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "x509Certificates.value")
+			},
+		},
 		{
 			name: "deserialize complex property",
 			attr: `
@@ -527,49 +587,410 @@ func (s *JsonDeserializeTestSuite) TestDeserializeProperty() {
 	}
 }
 
-func (s *JsonDeserializeTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
+func (s *JsonDeserializeTestSuite) TestDeserializeValue() {
+	const (
+		emails = `
+{
+  "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails",
+  "name": "emails",
+  "type": "complex",
+  "multiValued": true,
+  "_path": "emails",
+  "subAttributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails.value",
+      "name": "value",
+      "type": "string",
+      "_path": "emails.value",
+      "_index": 0
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails.primary",
+      "name": "primary",
+      "type": "boolean",
+      "_path": "emails.primary",
+      "_index": 1
+    }
+  ]
+}
+`
+		count = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Number:count",
+  "name": "count",
+  "type": "integer",
+  "_path": "count"
+}
+`
+	)
+
+	tests := []struct {
+		name   string
+		attr   string
+		json   string
+		expect func(t *testing.T, value interface{}, err error)
 	}{
 		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name: "array for multiValued attribute",
+			attr: emails,
+			json: `[{"value": "foo@bar.com", "primary": true}, {"value": "bar@foo.com"}]`,
+			expect: func(t *testing.T, value interface{}, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "foo@bar.com", "primary": true},
+					map[string]interface{}{"value": "bar@foo.com"},
+				}, value)
+			},
+		},
+		{
+			name: "single element for multiValued attribute",
+			attr: emails,
+			json: ` {"value": "foo@bar.com"}`,
+			expect: func(t *testing.T, value interface{}, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "foo@bar.com"},
+				}, value)
+			},
+		},
+		{
+			name: "integral float for integer attribute",
+			attr: count,
+			json: `42.0`,
+			expect: func(t *testing.T, value interface{}, err error) {
+				assert.Nil(t, value)
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name: "incompatible sub attribute value",
+			attr: emails,
+			json: `[{"value": "foo@bar.com"}, {"value": "bar@foo.com", "primary": "true"}]`,
+			expect: func(t *testing.T, value interface{}, err error) {
+				assert.Nil(t, value)
+				var de *DeserializeError
+				require.True(t, errors.As(err, &de))
+				assert.Equal(t, "/1/primary", de.Pointer)
+				assert.Equal(t, "urn:ietf:params:scim:schemas:core:2.0:User:emails.primary", de.Path)
 			},
 		},
 		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
+			name: "no value",
+			attr: count,
+			json: ``,
+			expect: func(t *testing.T, value interface{}, err error) {
+				assert.Nil(t, value)
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
 			},
 		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+	}
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			attr := new(spec.Attribute)
+			require.Nil(t, json.Unmarshal([]byte(test.attr), attr))
+			value, err := DeserializeValue(json.RawMessage(test.json), attr)
+			test.expect(t, value, err)
+		})
+	}
+}
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+func (s *JsonDeserializeTestSuite) TestDeserializeErrorLocation() {
+	tests := []struct {
+		name    string
+		json    string
+		cause   error
+		pointer string
+		path    string
+	}{
+		{
+			name: "type mismatch in multiValued complex element",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "emails": [
+    {"value": "foo@bar.com"},
+    {"value": 123}
+  ]
+}
+`,
+			cause:   spec.ErrInvalidSyntax,
+			pointer: "/emails/1/value",
+			path:    "urn:ietf:params:scim:schemas:core:2.0:User:emails.value",
+		},
+		{
+			name: "unknown key",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "name": {
+    "nickName": "foo"
+  }
+}
+`,
+			pointer: "/name/nickName",
+			path:    "urn:ietf:params:scim:schemas:core:2.0:User:name",
+		},
+		{
+			name: "invalid dateTime",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "meta": {
+    "created": "yesterday"
+  }
+}
+`,
+			cause:   spec.ErrInvalidValue,
+			pointer: "/meta/created",
+			path:    "meta.created",
+		},
+		{
+			name: "duplicate key",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "a",
+  "userName": "b"
+}
+`,
+			cause:   spec.ErrInvalidSyntax,
+			pointer: "/userName",
+			path:    "urn:ietf:params:scim:schemas:core:2.0:User",
+		},
+		{
+			name: "duplicate key of different case in complex",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "name": {
+    "givenName": "a",
+    "GIVENNAME": "b"
+  }
+}
+`,
+			cause:   spec.ErrInvalidSyntax,
+			pointer: "/name/GIVENNAME",
+			path:    "urn:ietf:params:scim:schemas:core:2.0:User:name",
+		},
+		{
+			name: "invalid base64",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "x509Certificates": [
+    {"value": "not base64!"}
+  ]
+}
+`,
+			cause:   spec.ErrInvalidValue,
+			pointer: "/x509Certificates/0/value",
+			path:    "urn:ietf:params:scim:schemas:core:2.0:User:x509Certificates.value",
+		},
+	}
 
-		if each.post != nil {
-			each.post(each.structure)
-		}
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			err := Deserialize([]byte(test.json), prop.NewResource(s.resourceType))
+			require.NotNil(t, err)
+
+			var de *DeserializeError
+			require.True(t, errors.As(err, &de))
+			assert.Equal(t, test.pointer, de.Pointer)
+			assert.Equal(t, test.path, de.Path)
+			if test.cause != nil {
+				assert.True(t, errors.Is(err, test.cause))
+			}
+		})
 	}
 }
 
+func (s *JsonDeserializeTestSuite) TestDeserializeAttributeNameCasing() {
+	raw := []byte(`
+{
+  "SCHEMAS": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "ID": "123",
+  "username": "imulab",
+  "NAME": {
+    "givenname": "Weinan",
+    "FamilyName": "Qiu"
+  },
+  "eMails": [
+    {
+      "VALUE": "imulab@foo.com",
+      "Primary": true
+    }
+  ],
+  "URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER": {
+    "EMPLOYEENUMBER": "6546579",
+    "manager": {
+      "VALUE": "1",
+      "$REF": "/Users/1"
+    }
+  }
+}
+`)
+	resource := prop.NewResource(s.resourceType)
+	require.Nil(s.T(), Deserialize(raw, resource))
+
+	serialized, err := Serialize(resource)
+	require.Nil(s.T(), err)
+
+	assert.JSONEq(s.T(), `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "123",
+  "userName": "imulab",
+  "name": {
+    "givenName": "Weinan",
+    "familyName": "Qiu"
+  },
+  "emails": [
+    {
+      "value": "imulab@foo.com",
+      "primary": true
+    }
+  ],
+  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+    "employeeNumber": "6546579",
+    "manager": {
+      "value": "1",
+      "$ref": "/Users/1"
+    }
+  }
+}
+`, string(serialized))
+
+	// a second round trip must be stable
+	roundTrip := prop.NewResource(s.resourceType)
+	require.Nil(s.T(), Deserialize(serialized, roundTrip))
+	again, err := Serialize(roundTrip)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), string(serialized), string(again))
+}
+
+func (s *JsonDeserializeTestSuite) TestDeserializeWrapSingleValues() {
+	type coercion struct {
+		pointer string
+		path    string
+	}
+
+	tests := []struct {
+		name   string
+		json   string
+		strict bool
+		expect func(t *testing.T, resource *prop.Resource, coerced []coercion, err error)
+	}{
+		{
+			name: "single object for multiValued complex attribute",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "emails": {"value": "foo@bar.com", "primary": true}
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				require.Nil(t, err)
+				emails, _ := resource.RootProperty().ChildAtIndex("emails")
+				assert.Equal(t, 1, emails.CountChildren())
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "foo@bar.com", "primary": true},
+				}, emails.Raw())
+				assert.Equal(t, []coercion{{pointer: "/emails", path: "urn:ietf:params:scim:schemas:core:2.0:User:emails"}}, coerced)
+			},
+		},
+		{
+			name: "single literal for simple multiValued attribute",
+			json: `
+{
+  "schemas": "urn:ietf:params:scim:schemas:core:2.0:User",
+  "userName": "imulab"
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				require.Nil(t, err)
+				schemas, _ := resource.RootProperty().ChildAtIndex("schemas")
+				assert.Equal(t, []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"}, schemas.Raw())
+				assert.Equal(t, []coercion{{pointer: "/schemas", path: "schemas"}}, coerced)
+			},
+		},
+		{
+			name: "arrays are not coerced",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "emails": [{"value": "foo@bar.com"}],
+  "groups": null
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				require.Nil(t, err)
+				assert.Empty(t, coerced)
+			},
+		},
+		{
+			name: "invalid single value is rejected",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "emails": {"value": 123}
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+		{
+			name: "array for singular attribute is rejected",
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": ["imulab"]
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				assert.Empty(t, coerced)
+			},
+		},
+		{
+			name:   "single object is rejected in strict mode",
+			strict: true,
+			json: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "emails": {"value": "foo@bar.com"}
+}
+`,
+			expect: func(t *testing.T, resource *prop.Resource, coerced []coercion, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			coerced := make([]coercion, 0)
+			options := []DeserializeOptions{WrapSingleValues(func(pointer string, path string) {
+				coerced = append(coerced, coercion{pointer: pointer, path: path})
+			})}
+			if test.strict {
+				options = nil
+			}
+
+			resource := prop.NewResource(s.resourceType)
+			err := Deserialize([]byte(test.json), resource, options...)
+			test.expect(t, resource, coerced, err)
+		})
+	}
+}
+
+func (s *JsonDeserializeTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+}
+
 func (s *JsonDeserializeTestSuite) propForAttr(t *testing.T, attrJson string) prop.Property {
 	attr := new(spec.Attribute)
 	assert.Nil(t, json.Unmarshal([]byte(attrJson), attr))
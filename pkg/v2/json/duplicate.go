@@ -0,0 +1,86 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// CheckDuplicateKeys scans the JSON input token by token and returns a spec.ErrInvalidSyntax error, naming the key and
+// its JSON pointer, if any object, at any level, contains duplicate member names. Member names are compared case
+// insensitively, since encoding/json, as well as SCIM attribute names, match them case insensitively.
+//
+// This function is intended for payloads that are decoded by encoding/json, which silently takes the last occurrence
+// of duplicate keys, such as the patch request. Deserialize and DeserializeProperty perform the check on their own.
+// Malformed JSON is not reported by this function, and is left to the decoder.
+func CheckDuplicateKeys(data []byte) error {
+	type frame struct {
+		object    bool
+		keys      map[string]struct{}
+		expectKey bool
+		token     string // current member name or array index, as a reference token of JSON pointer
+		index     int
+	}
+
+	var (
+		decoder = json.NewDecoder(bytes.NewReader(data))
+		stack   = make([]*frame, 0)
+	)
+	decoder.UseNumber()
+
+	// Mark the value of the current member or element as consumed.
+	next := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+			top.token = strconv.Itoa(top.index)
+		}
+	}
+
+	for {
+		t, err := decoder.Token()
+		if err != nil {
+			// io.EOF, or malformed JSON which is left to the decoder
+			return nil
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+			top := stack[len(stack)-1]
+			if key, ok := t.(string); ok {
+				top.token = pointerEscaper.Replace(key)
+				if _, ok := top.keys[strings.ToLower(key)]; ok {
+					tokens := make([]string, 0, len(stack))
+					for _, f := range stack {
+						tokens = append(tokens, f.token)
+					}
+					return fmt.Errorf("%w: duplicate key '%s' (pointer:'/%s')", spec.ErrInvalidSyntax, key,
+						strings.Join(tokens, "/"))
+				}
+				top.keys[strings.ToLower(key)] = struct{}{}
+				top.expectKey = false
+				continue
+			}
+		}
+
+		switch t {
+		case json.Delim('{'):
+			stack = append(stack, &frame{object: true, keys: map[string]struct{}{}, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &frame{token: "0"})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			next()
+		default:
+			next()
+		}
+	}
+}
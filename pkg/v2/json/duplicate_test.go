@@ -0,0 +1,63 @@
+package json
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		expect string
+	}{
+		{
+			name: "no duplicates",
+			json: `{"a": 1, "b": {"a": 2}, "c": [{"a": 3}, {"a": 4}]}`,
+		},
+		{
+			name:   "duplicate at top level",
+			json:   `{"a": 1, "b": 2, "a": 3}`,
+			expect: "invalidSyntax: duplicate key 'a' (pointer:'/a')",
+		},
+		{
+			name:   "duplicate of different case",
+			json:   `{"op": "add", "OP": "remove"}`,
+			expect: "invalidSyntax: duplicate key 'OP' (pointer:'/OP')",
+		},
+		{
+			name:   "duplicate in nested object",
+			json:   `{"a": {"b": {"c": 1, "c": 2}}}`,
+			expect: "invalidSyntax: duplicate key 'c' (pointer:'/a/b/c')",
+		},
+		{
+			name:   "duplicate in array element",
+			json:   `{"Operations": [{"op": "add"}, {"op": "add", "value": [1, 2], "value": 3}]}`,
+			expect: "invalidSyntax: duplicate key 'value' (pointer:'/Operations/1/value')",
+		},
+		{
+			name:   "duplicate key requiring escape",
+			json:   `{"a/b": 1, "a/b": 2}`,
+			expect: "invalidSyntax: duplicate key 'a/b' (pointer:'/a~1b')",
+		},
+		{
+			name: "malformed json is left to the decoder",
+			json: `{"a": 1, "b"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckDuplicateKeys([]byte(test.json))
+			if len(test.expect) == 0 {
+				assert.Nil(t, err)
+			} else {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				assert.Equal(t, test.expect, err.Error())
+			}
+		})
+	}
+}
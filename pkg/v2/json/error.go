@@ -0,0 +1,34 @@
+package json
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeserializeError is the error returned by Deserialize and DeserializeProperty. In addition to the underlying cause,
+// it reports where in the JSON payload the error occurred, using a JSON pointer (RFC6901) relative to the input, and
+// the fully qualified path of the SCIM attribute in focus at that location. The underlying cause is available through
+// errors.Unwrap, hence errors.Is and errors.As continue to work against the spec error prototypes.
+type DeserializeError struct {
+	// JSON pointer to the offending value, i.e. /emails/1/value
+	Pointer string
+	// Fully qualified path of the attribute, i.e. urn:ietf:params:scim:schemas:core:2.0:User:emails.value
+	Path string
+	// The underlying cause
+	Err error
+}
+
+func (e *DeserializeError) Error() string {
+	return fmt.Sprintf("%s (pointer:'%s', path:'%s')", e.Err.Error(), e.Pointer, e.Path)
+}
+
+func (e *DeserializeError) Unwrap() error {
+	return e.Err
+}
+
+// pointerEscaper escapes the reference tokens in a JSON pointer, as defined in RFC6901.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+var (
+	_ error = (*DeserializeError)(nil)
+)
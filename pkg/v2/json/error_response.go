@@ -0,0 +1,49 @@
+package json
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Detail of the error response for errors that are not caused by the client. The actual error is not disclosed, as
+// it may contain internal details such as the database error or the stack of causes.
+const internalErrorDetail = "internal server error"
+
+// SerializeError converts the error to the SCIM error response (urn:ietf:params:scim:api:messages:2.0:Error) as
+// defined in RFC7644 section 3.12, and returns the JSON bytes together with the HTTP status of the response.
+//
+// The status and scimType are determined by spec.DetailsOf: if the cause of the error (determined using errors.As) is
+// a *spec.Error, its status is used and the error message is included as detail. The scimType is only included for
+// the types defined in RFC7644, hence it is omitted for, i.e., spec.ErrNotFound and spec.ErrConflict. As required by
+// RFC7644, the status is serialized as a string.
+//
+// Errors caused by spec.ErrInternal, and errors that are not caused by a *spec.Error, result in a 500 response with
+// a generic detail, so that internal details are not leaked to the client.
+func SerializeError(err error) ([]byte, int) {
+	var errMsg = struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail"`
+	}{
+		Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+	}
+
+	details := spec.DetailsOf(err)
+	errMsg.Status = strconv.Itoa(details.Status)
+	errMsg.ScimType = details.ScimType
+	if details.Internal {
+		errMsg.Detail = internalErrorDetail
+	} else {
+		errMsg.Detail = err.Error()
+	}
+
+	raw, jsonErr := json.Marshal(errMsg)
+	if jsonErr != nil {
+		// impossible: the error message only consists of strings.
+		panic(jsonErr)
+	}
+	return raw, details.Status
+}
@@ -0,0 +1,148 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		expect string
+	}{
+		{
+			name:   "invalid filter",
+			err:    fmt.Errorf("%w: unknown operator 'xx'", spec.ErrInvalidFilter),
+			status: 400,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "400",
+  "scimType": "invalidFilter",
+  "detail": "invalidFilter: unknown operator 'xx'"
+}`,
+		},
+		{
+			name:   "invalid path",
+			err:    fmt.Errorf("%w: no attribute named 'foo'", spec.ErrInvalidPath),
+			status: 400,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "400",
+  "scimType": "invalidPath",
+  "detail": "invalidPath: no attribute named 'foo'"
+}`,
+		},
+		{
+			name:   "mutability",
+			err:    fmt.Errorf("%w: 'id' is readOnly", spec.ErrMutability),
+			status: 400,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "400",
+  "scimType": "mutability",
+  "detail": "mutability: 'id' is readOnly"
+}`,
+		},
+		{
+			name:   "uniqueness",
+			err:    fmt.Errorf("%w: 'userName' is taken", spec.ErrUniqueness),
+			status: 409,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "409",
+  "scimType": "uniqueness",
+  "detail": "uniqueness: 'userName' is taken"
+}`,
+		},
+		{
+			name:   "no target",
+			err:    fmt.Errorf("%w: filter yields no match", spec.ErrNoTarget),
+			status: 400,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "400",
+  "scimType": "noTarget",
+  "detail": "noTarget: filter yields no match"
+}`,
+		},
+		{
+			name: "wrapped deserialize error",
+			err: &DeserializeError{
+				Pointer: "/userName",
+				Path:    "userName",
+				Err:     fmt.Errorf("%w: expects string literal value", spec.ErrInvalidValue),
+			},
+			status: 400,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "400",
+  "scimType": "invalidValue",
+  "detail": "invalidValue: expects string literal value (pointer:'/userName', path:'userName')"
+}`,
+		},
+		{
+			name:   "not found has no scimType",
+			err:    fmt.Errorf("%w: resource 'foo' is not found", spec.ErrNotFound),
+			status: 404,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "404",
+  "detail": "notFound: resource 'foo' is not found"
+}`,
+		},
+		{
+			name:   "version mismatch has no scimType",
+			err:    fmt.Errorf("%w: version mismatch", spec.ErrConflict),
+			status: 412,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "412",
+  "detail": "conflict: version mismatch"
+}`,
+		},
+		{
+			name:   "not implemented",
+			err:    fmt.Errorf("%w: GET /Me is not supported", spec.ErrNotImplemented),
+			status: 501,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "501",
+  "detail": "notImplemented: GET /Me is not supported"
+}`,
+		},
+		{
+			name:   "internal error is not disclosed",
+			err:    fmt.Errorf("%w: connection to 10.0.0.1:27017 refused", spec.ErrInternal),
+			status: 500,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "500",
+  "detail": "internal server error"
+}`,
+		},
+		{
+			name:   "unknown error is not disclosed",
+			err:    errors.New("runtime error: index out of range"),
+			status: 500,
+			expect: `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+  "status": "500",
+  "detail": "internal server error"
+}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, status := SerializeError(test.err)
+			assert.Equal(t, test.status, status)
+			assert.JSONEq(t, test.expect, string(raw))
+		})
+	}
+}
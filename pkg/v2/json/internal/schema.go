@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
@@ -8,6 +9,8 @@ import (
 // SerializableSchema is the json.Serializable wrapper for spec.Schema.
 type SerializableSchema struct {
 	Sch *spec.Schema
+	// ConstraintsNamespace is the key under which the @Constraints of attributes are rendered, or empty to omit them.
+	ConstraintsNamespace string
 }
 
 // MainSchemaId returns the main schema id for Schema as a resource.
@@ -180,6 +183,10 @@ func (s *SerializableSchema) visitAttribute(attr *spec.Attribute, visitor prop.V
 		visitor.EndChildren(rtp)
 	}
 
+	if err := s.visitConstraints(attr, visitor); err != nil {
+		return err
+	}
+
 	if attr.CountSubAttributes() > 0 {
 		dummyMulti := prop.NewMulti(spec.MetaAttributes().AttributeSubAttributesAttributeNoSub())
 		if err := visitor.Visit(dummyMulti); err != nil {
@@ -205,3 +212,49 @@ func (s *SerializableSchema) visitAttribute(attr *spec.Attribute, visitor prop.V
 
 	return nil
 }
+
+func (s *SerializableSchema) visitConstraints(attr *spec.Attribute, visitor prop.Visitor) error {
+	if len(s.ConstraintsNamespace) == 0 {
+		return nil
+	}
+	params, ok := attr.AnnotationParams(annotation.Constraints)
+	if !ok {
+		return nil
+	}
+
+	constraints := map[string]interface{}{}
+	for _, name := range []string{"minLength", "maxLength"} {
+		if n, ok := params.Int(name); ok {
+			constraints[name] = int64(n)
+		}
+	}
+	if pattern, ok := params.String("pattern"); ok {
+		constraints["pattern"] = pattern
+	}
+	for _, name := range []string{"minimum", "maximum"} {
+		if f, ok := params.Float(name); ok {
+			constraints[name] = f
+		}
+	}
+	for _, name := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		if b, ok := params.Bool(name); ok {
+			constraints[name] = b
+		}
+	}
+
+	cp := prop.NewComplexOf(spec.MetaAttributes().AttributeConstraintsAttribute(s.ConstraintsNamespace), constraints)
+	if err := visitor.Visit(cp); err != nil {
+		return err
+	}
+	visitor.BeginChildren(cp)
+	if err := cp.ForEachChild(func(_ int, child prop.Property) error {
+		if child.IsUnassigned() {
+			return nil
+		}
+		return visitor.Visit(child)
+	}); err != nil {
+		return err
+	}
+	visitor.EndChildren(cp)
+	return nil
+}
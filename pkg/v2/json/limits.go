@@ -0,0 +1,82 @@
+package json
+
+import (
+	"fmt"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Limits bounds the complexity of the JSON input accepted by the deserializer. The limits are enforced while the input
+// is being scanned, before any property is created, so that a crafted input is rejected with spec.ErrInvalidSyntax
+// before it could exhaust the memory. A zero or negative value disables the corresponding limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of JSON objects and arrays. The top level object is at depth 1.
+	MaxDepth int
+	// MaxElements is the maximum number of elements in a single JSON array.
+	MaxElements int
+	// MaxValues is the maximum number of JSON values in total, counting every object member value and array element.
+	MaxValues int
+}
+
+// DefaultLimits are the Limits enforced by Deserialize, DeserializeProperty and DeserializeValue, unless other limits
+// are given by the WithLimits option. The defaults are generous enough for legitimate resources, such as a group with
+// tens of thousands of members. As they are shared by every caller, they should not be changed: use WithLimits instead.
+var DefaultLimits = Limits{
+	MaxDepth:    32,
+	MaxElements: 50000,
+	MaxValues:   500000,
+}
+
+// Tracks the complexity of the JSON input following the op codes emitted by the scanner, and reports error as soon
+// as a limit is exceeded.
+type complexity struct {
+	limits   Limits
+	elements []int // number of elements of each array being scanned, innermost last
+	values   int
+}
+
+func (c *complexity) track(scan *scanner, opCode int) error {
+	switch opCode {
+	case scanBeginObject, scanBeginArray:
+		// the new composite value has already been pushed onto the parse state stack
+		depth := len(scan.parseState)
+		if c.limits.MaxDepth > 0 && depth > c.limits.MaxDepth {
+			return c.errExceeds("nesting depth exceeds %d", c.limits.MaxDepth)
+		}
+		if err := c.countValue(scan.parseState[:depth-1]); err != nil {
+			return err
+		}
+		if opCode == scanBeginArray {
+			c.elements = append(c.elements, 0)
+		}
+	case scanBeginLiteral:
+		n := len(scan.parseState)
+		if n > 0 && scan.parseState[n-1] == parseObjectKey {
+			return nil // object member name is not a value
+		}
+		return c.countValue(scan.parseState)
+	case scanEndArray:
+		c.elements = c.elements[:len(c.elements)-1]
+	}
+	return nil
+}
+
+// Count a new value whose containers are described by the given parse states.
+func (c *complexity) countValue(parents []int) error {
+	c.values++
+	if c.limits.MaxValues > 0 && c.values > c.limits.MaxValues {
+		return c.errExceeds("number of values exceeds %d", c.limits.MaxValues)
+	}
+
+	if n := len(parents); n > 0 && parents[n-1] == parseArrayValue {
+		c.elements[len(c.elements)-1]++
+		if c.limits.MaxElements > 0 && c.elements[len(c.elements)-1] > c.limits.MaxElements {
+			return c.errExceeds("number of array elements exceeds %d", c.limits.MaxElements)
+		}
+	}
+	return nil
+}
+
+func (c *complexity) errExceeds(msg string, args ...interface{}) error {
+	return fmt.Errorf("%w: request exceeds complexity limits (%s)", spec.ErrInvalidSyntax, fmt.Sprintf(msg, args...))
+}
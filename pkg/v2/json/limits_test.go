@@ -0,0 +1,122 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxDepth: 3, MaxElements: 3, MaxValues: 10}
+
+	tests := []struct {
+		name    string
+		json    string
+		limits  Limits
+		exceeds bool
+	}{
+		{
+			name:   "within limits",
+			json:   `{"a": [{"b": 1}, {"b": 2}, [3]], "c": {"d": "e"}}`,
+			limits: limits,
+		},
+		{
+			name:    "nesting depth exceeded",
+			json:    `{"a": [{"b": [1]}]}`,
+			limits:  limits,
+			exceeds: true,
+		},
+		{
+			name:    "array elements exceeded",
+			json:    `{"a": [1, 2, 3, 4]}`,
+			limits:  limits,
+			exceeds: true,
+		},
+		{
+			name:    "array elements exceeded by nested arrays",
+			json:    `{"a": [[], [], [], []]}`,
+			limits:  limits,
+			exceeds: true,
+		},
+		{
+			name:   "array elements are counted per array",
+			json:   `{"a": [1, 2, 3], "b": [1, 2, 3]}`,
+			limits: limits,
+		},
+		{
+			name:    "number of values exceeded",
+			json:    `{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8, "i": 9, "j": 10}`,
+			limits:  limits,
+			exceeds: true,
+		},
+		{
+			name: "limits disabled",
+			json: `{"a": [{"b": [[1, 2, 3, 4]]}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkValid([]byte(test.json), &scanner{}, test.limits)
+			if test.exceeds {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				assert.Contains(t, err.Error(), "request exceeds complexity limits")
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	t.Run("deeply nested objects", func(t *testing.T) {
+		raw := strings.Repeat(`{"a":`, 10000) + "1" + strings.Repeat("}", 10000)
+		err := checkValid([]byte(raw), &scanner{}, DefaultLimits)
+		assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+	})
+
+	t.Run("one million elements", func(t *testing.T) {
+		raw := `{"members": [` + strings.Repeat(`1,`, 1000000) + `1]}`
+		err := checkValid([]byte(raw), &scanner{}, DefaultLimits)
+		assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+	})
+
+	t.Run("group with 5000 members", func(t *testing.T) {
+		member := `{"value": "2819c223-7f76-453a-919d-413861904646", "$ref": "/Users/2819c223-7f76-453a-919d-413861904646", "display": "Babs Jensen", "type": "direct"}`
+		raw := `{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"], "displayName": "Tour Guides", "members": [` +
+			strings.TrimSuffix(strings.Repeat(member+",", 5000), ",") + `]}`
+		assert.Nil(t, checkValid([]byte(raw), &scanner{}, DefaultLimits))
+	})
+}
+
+func TestWithLimits(t *testing.T) {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+
+	raw := `{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "emails": [{"value": "a@example.com"}, {"value": "b@example.com"}, {"value": "c@example.com"}]}`
+	limits := WithLimits(Limits{MaxElements: 2})
+
+	t.Run("deserialize", func(t *testing.T) {
+		err := Deserialize([]byte(raw), prop.NewResource(resourceType), limits)
+		assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+		assert.Contains(t, err.Error(), "number of array elements exceeds 2")
+
+		assert.Nil(t, Deserialize([]byte(raw), prop.NewResource(resourceType)))
+	})
+
+	t.Run("deserialize value", func(t *testing.T) {
+		emails := prop.NewResource(resourceType).Navigator().Dot("emails").Current().Attribute()
+		value := `[{"value": "a@example.com"}, {"value": "b@example.com"}, {"value": "c@example.com"}]`
+
+		_, err := DeserializeValue([]byte(value), emails, limits)
+		assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+
+		_, err = DeserializeValue([]byte(value), emails)
+		assert.Nil(t, err)
+	})
+}
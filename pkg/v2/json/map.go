@@ -0,0 +1,240 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// ToMap converts the resource to a generic map, as if the resource was serialized by Serialize and then parsed back
+// by encoding/json, without going through the JSON bytes. The conversion subjects to the same options and SCIM
+// return-ability rules as Serialize.
+//
+// Unlike encoding/json, values retain their internal representation: integers are int64, decimals are float64, and
+// strings, references, binaries and dateTimes are string. JSON objects and arrays are map[string]interface{} and
+// []interface{} respectively, and unassigned values that would have been serialized as null are nil.
+func ToMap(resource *prop.Resource, options ...Options) (map[string]interface{}, error) {
+	m := mapper{
+		serializer: serializer{
+			includes: []string{},
+			excludes: []string{},
+		},
+		stack: []*mapFrame{},
+	}
+	for _, opt := range options {
+		opt.apply(&m.serializer, resource)
+	}
+
+	if len(m.includes) > 0 && len(m.excludes) > 0 {
+		return nil, fmt.Errorf("%w: attributes and excludedAttributes are mutually exclusive", spec.ErrInvalidValue)
+	}
+
+	if err := resource.Visit(&m); err != nil {
+		return nil, err
+	}
+
+	return m.result, nil
+}
+
+// FromMap creates a new resource of the given resource type from the generic map, as if the map was serialized by
+// encoding/json and then parsed by Deserialize, without going through the JSON bytes. The map is expected to be in the
+// same shape as the one returned by ToMap or parsed by encoding/json, hence integers may be any Go integer type,
+// json.Number or integral float64.
+//
+// Errors returned from this function are *DeserializeError which details the location of the error in the map.
+func FromMap(data map[string]interface{}, resourceType *spec.ResourceType) (*prop.Resource, error) {
+	resource := prop.NewResource(resourceType)
+	state := &deserializeState{
+		navigator: resource.Navigator(),
+	}
+	if err := state.wrapError(state.mapComplexProperty(data)); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+type (
+	// container being assembled during the traversal
+	mapFrame struct {
+		name   string
+		object map[string]interface{}
+		array  []interface{}
+	}
+	// map converter state. The embedded serializer is used only for the projection rules.
+	mapper struct {
+		serializer
+		stack  []*mapFrame
+		result map[string]interface{}
+	}
+)
+
+func (m *mapper) Visit(property prop.Property) error {
+	if !property.Attribute().IsScalar() {
+		return nil
+	}
+
+	switch {
+	case property.IsUnassigned():
+		m.set(property.Attribute().Name(), nil)
+	case property.Attribute().Type() == spec.TypeString:
+		m.set(property.Attribute().Name(), m.redact(property))
+	case property.Attribute().IsReference():
+		m.set(property.Attribute().Name(), m.render(property))
+	default:
+		m.set(property.Attribute().Name(), property.Raw())
+	}
+	return nil
+}
+
+func (m *mapper) BeginChildren(container prop.Property) {
+	f := &mapFrame{name: container.Attribute().Name()}
+	switch {
+	case container.Attribute().MultiValued():
+		f.array = []interface{}{}
+	case container.Attribute().IsComplex():
+		f.object = map[string]interface{}{}
+	default:
+		panic("unknown container")
+	}
+	m.stack = append(m.stack, f)
+}
+
+func (m *mapper) EndChildren(_ prop.Property) {
+	if len(m.stack) == 0 {
+		panic("cannot pop on empty stack")
+	}
+	f := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+
+	if len(m.stack) == 0 {
+		m.result = f.object
+	} else if f.object != nil {
+		m.set(f.name, f.object)
+	} else {
+		m.set(f.name, f.array)
+	}
+}
+
+// Set the value to the containing object under name, or append it to the containing array.
+func (m *mapper) set(name string, value interface{}) {
+	if len(m.stack) == 0 {
+		panic("stack is empty")
+	}
+	f := m.stack[len(m.stack)-1]
+	if f.object != nil {
+		f.object[name] = value
+	} else {
+		f.array = append(f.array, value)
+	}
+}
+
+// Assigns the fields of the map onto the currently focused complex property.
+func (d *deserializeState) mapComplexProperty(data map[string]interface{}) error {
+	for name, value := range data {
+		d.pushPointer(name)
+		p, propertyDepth, err := d.focusField(name)
+		if err != nil {
+			return err
+		}
+
+		d.pushPath(p.Attribute().ID())
+		if p.Attribute().MultiValued() {
+			err = d.mapMultiValuedProperty(value)
+		} else {
+			err = d.mapSingleValuedProperty(value)
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < propertyDepth; i++ {
+			d.navigator.Retract()
+		}
+		d.popLocation()
+	}
+	return nil
+}
+
+// Assigns the elements of the array onto the currently focused multiValued property.
+func (d *deserializeState) mapMultiValuedProperty(value interface{}) error {
+	if value == nil {
+		_, err := d.navigator.Current().Delete()
+		return err
+	}
+
+	elements, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("%w: expects array for '%s'", spec.ErrInvalidValue, d.navigator.Current().Attribute().Path())
+	}
+
+	mv, ok := d.navigator.Current().(interface {
+		AppendElement() int
+	})
+	if !ok {
+		return fmt.Errorf("%w: non-multiValued property at array", spec.ErrInvalidValue)
+	}
+
+	for i, element := range elements {
+		d.pushPointer(strconv.Itoa(i))
+		d.pushPath(d.currentPath())
+
+		at := mv.AppendElement()
+		if at < 0 {
+			return fmt.Errorf("%w: failed to create property to host array element", spec.ErrInternal)
+		}
+		d.navigator.At(at)
+		if err := d.navigator.Error(); err != nil {
+			return err
+		}
+
+		if err := d.mapSingleValuedProperty(element); err != nil {
+			return err
+		}
+
+		d.navigator.Retract()
+		d.popLocation()
+	}
+	return nil
+}
+
+// Assigns the value onto the currently focused single valued property, or element of a multiValued property.
+func (d *deserializeState) mapSingleValuedProperty(value interface{}) error {
+	p := d.navigator.Current()
+
+	if p.Attribute().IsComplex() {
+		if value == nil {
+			_, err := p.Delete()
+			return err
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: expects object for '%s'", spec.ErrInvalidValue, p.Attribute().Path())
+		}
+		return d.mapComplexProperty(m)
+	}
+
+	if n, ok := value.(json.Number); ok {
+		if i64, err := n.Int64(); err == nil && p.Attribute().Type() == spec.TypeInteger {
+			value = i64
+		} else if f64, err := n.Float64(); err == nil {
+			value = f64
+		} else {
+			return fmt.Errorf("%w: invalid number for '%s'", spec.ErrInvalidValue, p.Attribute().Path())
+		}
+	}
+
+	// Integers decoded by encoding/json into interface{} are float64, which integer properties do not accept.
+	if f64, ok := value.(float64); ok && p.Attribute().Type() == spec.TypeInteger {
+		if f64 != math.Trunc(f64) || f64 < math.MinInt64 || f64 >= math.MaxInt64 {
+			return fmt.Errorf("%w: value is not an integer for '%s'", spec.ErrInvalidValue, p.Attribute().Path())
+		}
+		value = int64(f64)
+	}
+
+	_, err := p.Replace(value)
+	return err
+}
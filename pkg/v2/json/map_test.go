@@ -0,0 +1,283 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestJsonMap(t *testing.T) {
+	s := new(JsonMapTestSuite)
+	suite.Run(t, s)
+}
+
+type JsonMapTestSuite struct {
+	suite.Suite
+	resourceType       *spec.ResourceType
+	numberResourceType *spec.ResourceType
+	resourceData       map[string]interface{}
+}
+
+func (s *JsonMapTestSuite) TestToMap() {
+	tests := []struct {
+		name    string
+		options []Options
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:    "include attributes",
+			options: []Options{Include("userName", "emails.value")},
+		},
+		{
+			name:    "exclude attributes",
+			options: []Options{Exclude("name", "emails")},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(s.resourceType)
+			require.Nil(t, resource.Navigator().Replace(s.resourceData).Error())
+
+			m, err := ToMap(resource, test.options...)
+			require.Nil(t, err)
+
+			expect, err := Serialize(resource, test.options...)
+			require.Nil(t, err)
+
+			actual, err := json.Marshal(m)
+			require.Nil(t, err)
+
+			assert.JSONEq(t, string(expect), string(actual))
+		})
+	}
+}
+
+func (s *JsonMapTestSuite) TestToMapMutuallyExclusiveOptions() {
+	resource := prop.NewResource(s.resourceType)
+	_, err := ToMap(resource, Include("userName"), Exclude("emails"))
+	assert.True(s.T(), errors.Is(err, spec.ErrInvalidValue))
+}
+
+func (s *JsonMapTestSuite) TestNumberFidelity() {
+	// 2^53+1 cannot be represented by float64
+	const large = int64(9007199254740993)
+
+	tests := []struct {
+		name  string
+		count interface{}
+	}{
+		{
+			name:  "int64",
+			count: large,
+		},
+		{
+			name:  "json.Number",
+			count: json.Number("9007199254740993"),
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource, err := FromMap(map[string]interface{}{
+				"count": test.count,
+				"score": json.Number("0.5"),
+			}, s.numberResourceType)
+			require.Nil(t, err)
+			assert.Equal(t, large, resource.Navigator().Dot("count").Current().Raw())
+			assert.Equal(t, 0.5, resource.Navigator().Dot("score").Current().Raw())
+
+			m, err := ToMap(resource)
+			require.Nil(t, err)
+			assert.Equal(t, large, m["count"])
+			assert.Equal(t, 0.5, m["score"])
+		})
+	}
+}
+
+func (s *JsonMapTestSuite) TestFromMapDecodedByEncodingJson() {
+	tests := []struct {
+		name   string
+		raw    string
+		expect func(t *testing.T, resource *prop.Resource, err error)
+	}{
+		{
+			name: "integral number",
+			raw:  `{"count": 42, "score": 0.5}`,
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, int64(42), resource.Navigator().Dot("count").Current().Raw())
+				assert.Equal(t, 0.5, resource.Navigator().Dot("score").Current().Raw())
+			},
+		},
+		{
+			name: "fractional number",
+			raw:  `{"count": 42.5}`,
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+		{
+			name: "number out of range",
+			raw:  `{"count": 1e19}`,
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			var data map[string]interface{}
+			require.Nil(t, json.Unmarshal([]byte(test.raw), &data))
+
+			resource, err := FromMap(data, s.numberResourceType)
+			test.expect(t, resource, err)
+		})
+	}
+}
+
+func (s *JsonMapTestSuite) TestFromMap() {
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		expect func(t *testing.T, resource *prop.Resource, err error)
+	}{
+		{
+			name: "round trip",
+			data: s.resourceData,
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+
+				m, err := ToMap(resource)
+				require.Nil(t, err)
+				assert.Equal(t, s.resourceData, m)
+			},
+		},
+		{
+			name: "case insensitive and dot separated names",
+			data: map[string]interface{}{
+				"USERNAME":       "imulab",
+				"name.givenName": "Weinan",
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "imulab", resource.Navigator().Dot("userName").Current().Raw())
+				assert.Equal(t, "Weinan", resource.Navigator().Dot("name").Dot("givenName").Current().Raw())
+			},
+		},
+		{
+			name: "null values",
+			data: map[string]interface{}{
+				"userName": "imulab",
+				"name":     nil,
+				"emails":   nil,
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				require.Nil(t, err)
+				assert.True(t, resource.Navigator().Dot("name").Current().IsUnassigned())
+				assert.True(t, resource.Navigator().Dot("emails").Current().IsUnassigned())
+			},
+		},
+		{
+			name: "unknown attribute",
+			data: map[string]interface{}{
+				"foo": "bar",
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+			},
+		},
+		{
+			name: "incompatible value",
+			data: map[string]interface{}{
+				"emails": []interface{}{
+					map[string]interface{}{
+						"value": "imulab@foo.com",
+					},
+					map[string]interface{}{
+						"value": 1,
+					},
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.Nil(t, resource)
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+
+				var de *DeserializeError
+				require.True(t, errors.As(err, &de))
+				assert.Equal(t, "/emails/1/value", de.Pointer)
+				assert.Equal(t, "urn:ietf:params:scim:schemas:core:2.0:User:emails.value", de.Path)
+			},
+		},
+		{
+			name: "object for multiValued attribute",
+			data: map[string]interface{}{
+				"emails": map[string]interface{}{
+					"value": "imulab@foo.com",
+				},
+			},
+			expect: func(t *testing.T, resource *prop.Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource, err := FromMap(test.data, s.resourceType)
+			test.expect(t, resource, err)
+		})
+	}
+}
+
+func (s *JsonMapTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberSchema), schema))
+	spec.Schemas().Register(schema)
+
+	s.numberResourceType = new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberResourceType), s.numberResourceType))
+
+	s.resourceData = map[string]interface{}{
+		"schemas": []interface{}{
+			"urn:ietf:params:scim:schemas:core:2.0:User",
+		},
+		"id": "3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+		"meta": map[string]interface{}{
+			"resourceType": "User",
+			"created":      "2019-11-20T13:09:00",
+			"lastModified": "2019-11-20T13:09:00",
+			"version":      "W/\"1\"",
+		},
+		"userName": "imulab",
+		"name": map[string]interface{}{
+			"familyName": "Qiu",
+			"givenName":  "Weinan",
+		},
+		"active": true,
+		"emails": []interface{}{
+			map[string]interface{}{
+				"value":   "imulab@foo.com",
+				"type":    "work",
+				"primary": true,
+			},
+			map[string]interface{}{
+				"value": "imulab@bar.com",
+				"type":  "home",
+			},
+		},
+	}
+}
@@ -0,0 +1,12 @@
+package json
+
+import "github.com/imulab/go-scim/pkg/v2/prop"
+
+// Resources are rendered by MarshalJSON as they are in responses, so that a resource embedded in a structure encoded
+// by encoding/json, i.e. an event, looks the same as when it is served. Values are not redacted, as MarshalJSON is not
+// meant to render responses.
+func init() {
+	prop.RegisterMarshaler(func(resource *prop.Resource) ([]byte, error) {
+		return Serialize(resource, Unredacted())
+	})
+}
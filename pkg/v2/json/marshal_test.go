@@ -0,0 +1,219 @@
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResource_MarshalJSON(t *testing.T) {
+	userResourceType := badgeUserResourceType(t)
+
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name: "enterprise user of RFC7643 section 8.3",
+			input: `
+{
+  "schemas": [
+    "urn:ietf:params:scim:schemas:core:2.0:User",
+    "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+  ],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "externalId": "701984",
+  "userName": "bjensen@example.com",
+  "name": {
+    "formatted": "Ms. Barbara J Jensen, III",
+    "familyName": "Jensen",
+    "givenName": "Barbara",
+    "middleName": "Jane",
+    "honorificPrefix": "Ms.",
+    "honorificSuffix": "III"
+  },
+  "displayName": "Babs Jensen",
+  "nickName": "Babs",
+  "profileUrl": "https://login.example.com/bjensen",
+  "emails": [
+    {
+      "value": "bjensen@example.com",
+      "type": "work",
+      "primary": true
+    },
+    {
+      "value": "babs@jensen.org",
+      "type": "home"
+    }
+  ],
+  "userType": "Employee",
+  "title": "Tour Guide",
+  "preferredLanguage": "en-US",
+  "locale": "en-US",
+  "timezone": "America/Los_Angeles",
+  "active": true,
+  "x509Certificates": [
+    {
+      "value": "MIIDQzCCAqygAwIBAgICEAAwDQYJKoZIhvcNAQEFBQAwTjELMAkGA1UEBhMCVVMx"
+    }
+  ],
+  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+    "employeeNumber": "701984",
+    "costCenter": "4130",
+    "organization": "Universal Studios",
+    "division": "Theme Park",
+    "department": "Tour Operations",
+    "manager": {
+      "value": "26118915-6090-4610-87e4-49d8ca9f808d",
+      "$ref": "../Users/26118915-6090-4610-87e4-49d8ca9f808d",
+      "displayName": "John Smith"
+    }
+  },
+  "meta": {
+    "resourceType": "User",
+    "created": "2010-01-23T04:56:22",
+    "lastModified": "2011-05-13T04:42:34",
+    "version": "W/\"3694e05e9dff591\"",
+    "location": "https://example.com/v2/Users/2819c223-7f76-453a-919d-413861904646"
+  }
+}
+`,
+		},
+		{
+			name: "integers, decimals and booleans are not quoted",
+			input: `
+{
+  "schemas": [
+    "urn:ietf:params:scim:schemas:core:2.0:User",
+    "urn:example:params:scim:schemas:extension:2.0:Badge"
+  ],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com",
+  "urn:example:params:scim:schemas:extension:2.0:Badge": {
+    "number": 42,
+    "score": 4.5,
+    "expired": false
+  }
+}
+`,
+		},
+		{
+			name: "writeOnly, unassigned and empty properties are omitted",
+			input: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com",
+  "password": "t1meMa$heen",
+  "name": {},
+  "emails": [],
+  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+    "employeeNumber": null
+  }
+}
+`,
+			expect: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com"
+}
+`,
+		},
+		{
+			name: "dateTimes are rendered in UTC as in responses",
+			input: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com",
+  "meta": {
+    "created": "2010-01-23T04:56:22",
+    "lastModified": "2011-05-13T06:42:34+02:00"
+  }
+}
+`,
+			expect: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "id": "2819c223-7f76-453a-919d-413861904646",
+  "userName": "bjensen@example.com",
+  "meta": {
+    "created": "2010-01-23T04:56:22",
+    "lastModified": "2011-05-13T04:42:34"
+  }
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expect := test.expect
+			if len(expect) == 0 {
+				expect = test.input
+			}
+
+			resource := prop.NewResource(userResourceType)
+			require.Nil(t, resource.UnmarshalJSON([]byte(test.input)))
+
+			raw, err := json.Marshal(resource)
+			require.Nil(t, err)
+			assert.True(t, strings.HasPrefix(string(raw), `{"schemas":`))
+			assert.JSONEq(t, expect, string(raw))
+
+			// the output unmarshals to the same resource
+			clone := prop.NewResource(userResourceType)
+			require.Nil(t, json.Unmarshal(raw, clone))
+			roundTrip, err := json.Marshal(clone)
+			require.Nil(t, err)
+			assert.JSONEq(t, string(raw), string(roundTrip))
+		})
+	}
+}
+
+// Returns the standard User resource type, with a schema extension of integer, decimal and boolean attributes.
+func badgeUserResourceType(t *testing.T) *spec.ResourceType {
+	userResourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+
+	extension, err := spec.ParseSchema(strings.NewReader(`
+{
+  "id": "urn:example:params:scim:schemas:extension:2.0:Badge",
+  "name": "Badge",
+  "attributes": [
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:number",
+      "name": "number",
+      "type": "integer",
+      "_index": 0,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:number"
+    },
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:score",
+      "name": "score",
+      "type": "decimal",
+      "_index": 1,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:score"
+    },
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:expired",
+      "name": "expired",
+      "type": "boolean",
+      "_index": 2,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:expired"
+    }
+  ]
+}
+`))
+	require.Nil(t, err)
+	require.Nil(t, spec.Schemas().Register(extension))
+	require.Nil(t, userResourceType.AddExtension(extension, false))
+	return userResourceType
+}
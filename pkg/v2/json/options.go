@@ -2,6 +2,8 @@ package json
 
 import (
 	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
 // Include returns Options to include given attributes in JSON serialization. Supplied attributes are still
@@ -16,6 +18,30 @@ func Exclude(attributes ...string) Options {
 	return exclude{attributes: attributes}
 }
 
+// Locate returns Options to render the locations composed by the server, namely meta.location and the $ref
+// sub attributes, using the given locator. A nil locator leaves the locations as they are stored.
+func Locate(locator *spec.Locator) Options {
+	return locate{locator: locator}
+}
+
+// LocatorOf returns the locator supplied to Locate among the options, or nil if Locate was not among them. This is
+// useful to render the locations outside the JSON body, i.e. the Location response header, in the same way.
+func LocatorOf(options ...Options) *spec.Locator {
+	var locator *spec.Locator
+	for _, opt := range options {
+		if l, ok := opt.(locate); ok {
+			locator = l.locator
+		}
+	}
+	return locator
+}
+
+// Unredacted returns Options to serialize the values of attributes annotated with @Redact in full. It is intended for
+// trusted internal callers only, i.e. when exporting resources to another system of record.
+func Unredacted() Options {
+	return unredacted{}
+}
+
 // JSON serialization options.
 type Options interface {
 	apply(s *serializer, serializable Serializable)
@@ -31,10 +57,7 @@ func (i include) apply(s *serializer, serializable Serializable) {
 	}
 	for _, path := range i.attributes {
 		if len(path) > 0 {
-			s.includes = append(s.includes, strings.TrimPrefix(
-				strings.ToLower(path),
-				strings.ToLower(serializable.MainSchemaId()+":")),
-			)
+			s.includes = append(s.includes, projectedPath(serializable, path))
 		}
 	}
 }
@@ -49,10 +72,73 @@ func (e exclude) apply(s *serializer, serializable Serializable) {
 	}
 	for _, path := range e.attributes {
 		if len(path) > 0 {
-			s.excludes = append(s.excludes, strings.TrimPrefix(
-				strings.ToLower(path),
-				strings.ToLower(serializable.MainSchemaId()+":")),
-			)
+			s.excludes = append(s.excludes, projectedPath(serializable, path))
 		}
 	}
 }
+
+// Returns the path of the attribute that the requested path refers to, so it can be compared to the paths of the
+// properties. When the serializable knows its resource type, the path is resolved by spec.ResourceType.AttributeByPath,
+// which accepts schema URN prefixes, value filters and any casing. Otherwise, or if the path does not resolve, the main
+// schema URN prefix is trimmed from the lower cased path.
+func projectedPath(serializable Serializable, path string) string {
+	if typed, ok := serializable.(interface{ ResourceType() *spec.ResourceType }); ok {
+		if attr, err := typed.ResourceType().AttributeByPath(path); err == nil {
+			return attr.Path()
+		}
+	}
+	return strings.TrimPrefix(strings.ToLower(path), strings.ToLower(serializable.MainSchemaId()+":"))
+}
+
+type locate struct {
+	locator *spec.Locator
+}
+
+func (l locate) apply(s *serializer, _ Serializable) {
+	s.locator = l.locator
+}
+
+type unredacted struct{}
+
+func (u unredacted) apply(s *serializer, _ Serializable) {
+	s.unredacted = true
+}
+
+// DeserializeOptions customizes the behaviour of Deserialize, DeserializeProperty and DeserializeValue.
+type DeserializeOptions interface {
+	apply(d *deserializeState)
+}
+
+// WrapSingleValues returns DeserializeOptions to accept a single value in place of a JSON array for multiValued
+// attributes, i.e. {"emails": {"value": "foo@bar.com"}}, which is a common mistake among clients. The value, a JSON
+// object for multiValued complex attributes or a JSON literal for other multiValued attributes, is deserialized as the
+// only element of the array. Each coercion is reported to the optional callback with the JSON pointer of the value and
+// the path of the attribute. Without this option, such values are rejected. A JSON array in place of a singular value
+// is always rejected.
+func WrapSingleValues(coerced func(pointer string, path string)) DeserializeOptions {
+	return wrapSingleValues{coerced: coerced}
+}
+
+type wrapSingleValues struct {
+	coerced func(pointer string, path string)
+}
+
+func (w wrapSingleValues) apply(d *deserializeState) {
+	d.wrapSingleValues = func(pointer string, path string) {
+		if w.coerced != nil {
+			w.coerced(pointer, path)
+		}
+	}
+}
+
+// WithLimits returns DeserializeOptions to enforce the given limits on the complexity of the input, in place of
+// DefaultLimits.
+func WithLimits(limits Limits) DeserializeOptions {
+	return withLimits(limits)
+}
+
+type withLimits Limits
+
+func (w withLimits) apply(d *deserializeState) {
+	d.limits = Limits(w)
+}
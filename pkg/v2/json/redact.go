@@ -0,0 +1,32 @@
+package json
+
+import (
+	"unicode"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+const redactMask = '*'
+
+// Returns the value masked according to the @Redact annotation of the attribute, or the value itself if the attribute
+// is not annotated. Letters and digits are replaced by the mask, except the trailing ones within "keepLast" characters.
+func redact(attr *spec.Attribute, value string) string {
+	params, ok := attr.AnnotationParams(annotation.Redact)
+	if !ok {
+		return value
+	}
+
+	keepLast, _ := params.Int("keepLast")
+	if keepLast < 0 {
+		keepLast = 0
+	}
+
+	runes := []rune(value)
+	for i := 0; i < len(runes)-keepLast; i++ {
+		if unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) {
+			runes[i] = redactMask
+		}
+	}
+	return string(runes)
+}
@@ -9,15 +9,20 @@ import (
 	"strconv"
 )
 
-// checkValid verifies that data is valid JSON-encoded data.
+// checkValid verifies that data is valid JSON-encoded data, whose complexity is within the limits.
 // scan is passed in for use by checkValid to avoid an allocation.
-func checkValid(data []byte, scan *scanner) error {
+func checkValid(data []byte, scan *scanner, limits Limits) error {
 	scan.reset()
-	for _, c := range data {
+	c := complexity{limits: limits}
+	for _, b := range data {
 		scan.bytes++
-		if scan.step(scan, c) == scanError {
+		op := scan.step(scan, b)
+		if op == scanError {
 			return scan.err
 		}
+		if err := c.track(scan, op); err != nil {
+			return err
+		}
 	}
 	if scan.eof() == scanError {
 		return scan.err
@@ -115,7 +120,7 @@ func (s *scanner) eof() int {
 		return scanEnd
 	}
 	if s.err == nil {
-		s.err = fmt.Errorf("%s: unexpected end of json at position %d", spec.ErrInvalidSyntax, s.bytes)
+		s.err = fmt.Errorf("%w: unexpected end of json at position %d", spec.ErrInvalidSyntax, s.bytes)
 	}
 	return scanError
 }
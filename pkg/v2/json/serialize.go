@@ -3,11 +3,13 @@ package json
 import (
 	"bytes"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -20,28 +22,55 @@ type Serializable interface {
 }
 
 // Serialize the given resource to JSON bytes. The serialization process subjects to the request attributes and
-// excludedAttributes from options, and the SCIM return-ability rules.
+// excludedAttributes from options, and the SCIM return-ability rules. Keys are always the attribute names as declared
+// in the registered schemas, regardless of the casing used when the values were assigned or deserialized. Values of
+// attributes annotated with @Redact are masked, unless the Unredacted option is supplied.
 func Serialize(serializable Serializable, options ...Options) ([]byte, error) {
-	s := serializer{
-		Buffer:   bytes.Buffer{},
-		includes: []string{},
-		excludes: []string{},
-		stack:    []*frame{},
-		scratch:  [64]byte{},
-	}
+	s := serializerPool.Get().(*serializer)
+	defer s.release()
+
 	for _, opt := range options {
-		opt.apply(&s, serializable)
+		opt.apply(s, serializable)
 	}
 
 	if len(s.includes) > 0 && len(s.excludes) > 0 {
 		return nil, fmt.Errorf("%w: attributes and excludedAttributes are mutually exclusive", spec.ErrInvalidValue)
 	}
 
-	if err := serializable.Visit(&s); err != nil {
+	if err := serializable.Visit(s); err != nil {
 		return nil, err
 	}
 
-	return s.Bytes(), nil
+	// The buffer is returned to the pool, hence a copy must be made.
+	raw := make([]byte, s.Len())
+	copy(raw, s.Bytes())
+	return raw, nil
+}
+
+// Serializers whose buffer grows beyond this capacity are not returned to the pool, so that an occasional large
+// resource does not pin the memory.
+const maxPooledBufferSize = 64 << 10
+
+// Pool of serializer states, so that the buffer, the frame stack and the scratch space are reused across invocations
+// of Serialize instead of being allocated and grown again for every resource.
+var serializerPool = sync.Pool{
+	New: func() interface{} {
+		return new(serializer)
+	},
+}
+
+// Resets the serializer and puts it back to the pool.
+func (s *serializer) release() {
+	if s.Cap() > maxPooledBufferSize {
+		return
+	}
+	s.Reset()
+	s.includes = s.includes[:0]
+	s.excludes = s.excludes[:0]
+	s.stack = s.stack[:0]
+	s.locator = nil
+	s.unredacted = false
+	serializerPool.Put(s)
 }
 
 const (
@@ -62,10 +91,12 @@ type (
 	// json serializer state
 	serializer struct {
 		bytes.Buffer
-		includes []string
-		excludes []string
-		stack    []*frame
-		scratch  [64]byte
+		includes   []string
+		excludes   []string
+		stack      []frame
+		scratch    [64]byte
+		locator    *spec.Locator
+		unredacted bool
 	}
 )
 
@@ -85,36 +116,47 @@ func (s *serializer) ShouldVisit(property prop.Property) bool {
 		return false
 	case spec.ReturnedDefault:
 		if len(s.includes) == 0 && len(s.excludes) == 0 {
-			return !property.IsUnassigned()
+			return s.isPresent(property)
 		} else {
-			test := strings.ToLower(property.Attribute().Path())
+			test := property.Attribute().Path()
 			if len(s.includes) > 0 {
-				for _, include := range s.includes {
-					if include == test || strings.HasPrefix(include, test+".") || strings.HasPrefix(test, include+".") {
-						return !property.IsUnassigned()
-					}
+				switch s.projection(test) {
+				case projectedFully:
+					return s.isPresent(property)
+				case projectedPartially:
+					return s.hasVisibleChild(property)
+				default:
+					return false
 				}
-				return false
 			} else if len(s.excludes) > 0 {
+				partial := false
 				for _, exclude := range s.excludes {
-					if exclude == test || strings.HasPrefix(test, exclude+".") {
+					if strings.EqualFold(exclude, test) || isSubPath(test, exclude) {
 						return false
 					}
+					if isSubPath(exclude, test) {
+						partial = true
+					}
+				}
+				// Containers left with nothing but the excluded sub attributes are omitted as a whole.
+				if partial && !property.IsUnassigned() {
+					return s.hasVisibleChild(property)
 				}
-				return !property.IsUnassigned()
+				return s.isPresent(property)
 			} else {
 				panic("impossible: either includeFamily or excludeFamily")
 			}
 		}
 	case spec.ReturnedRequest:
 		if len(s.includes) > 0 {
-			test := strings.ToLower(property.Attribute().Path())
-			for _, include := range s.includes {
-				if include == test || strings.HasPrefix(include, test+".") || strings.HasPrefix(test, include+".") {
-					return true
-				}
+			switch s.requested(property.Attribute().Path()) {
+			case projectedFully:
+				return true
+			case projectedPartially:
+				return s.hasVisibleChild(property)
+			default:
+				return false
 			}
-			return false
 		}
 		return false
 	default:
@@ -122,6 +164,80 @@ func (s *serializer) ShouldVisit(property prop.Property) bool {
 	}
 }
 
+const (
+	notProjected = iota
+	projectedFully
+	projectedPartially
+)
+
+// Returns how the attribute at the path is projected by the included attributes. An attribute that is included, or
+// is a sub attribute of an included attribute, is projected fully. An attribute whose sub attributes are included is
+// projected partially, hence only the included sub attributes are to be serialized.
+func (s *serializer) projection(path string) int {
+	partial := false
+	for _, include := range s.includes {
+		if strings.EqualFold(include, path) || isSubPath(path, include) {
+			return projectedFully
+		}
+		if isSubPath(include, path) {
+			partial = true
+		}
+	}
+	if partial {
+		return projectedPartially
+	}
+	return notProjected
+}
+
+// Returns how the attribute at the path, whose returned characteristic is "request", is projected by the included
+// attributes. Unlike projection, such an attribute is only projected when it is named explicitly, or partially when
+// its sub attributes are named, but not by naming its parent, because these attributes are typically large, and hence
+// only served on demand.
+func (s *serializer) requested(path string) int {
+	partial := false
+	for _, include := range s.includes {
+		if strings.EqualFold(include, path) {
+			return projectedFully
+		}
+		if isSubPath(include, path) {
+			partial = true
+		}
+	}
+	if partial {
+		return projectedPartially
+	}
+	return notProjected
+}
+
+// Returns true if any of the children of the partially projected property will be visited. Containers are kept in
+// the structure only if they have something to show, so that, for instance, "attributes=name.familyName" yields
+// "name" with only "familyName", and no "name" at all if "familyName" is absent. Likewise, elements of a multiValued
+// property without any of the included sub attributes are omitted.
+func (s *serializer) hasVisibleChild(property prop.Property) bool {
+	return property.FindChild(func(child prop.Property) bool {
+		return s.ShouldVisit(child)
+	}) != nil
+}
+
+// Returns true if path is a sub path of the parent path, case insensitively, i.e. "name.givenName" is a sub path of
+// "name". Paths are compared without lowering or concatenating them, which would allocate for every visited property.
+func isSubPath(path string, parent string) bool {
+	return len(path) > len(parent) && path[len(parent)] == '.' && strings.EqualFold(path[:len(parent)], parent)
+}
+
+// Returns true if the property has value to be serialized. Unassigned multiValued properties annotated with
+// @EmitEmpty are considered present, so they are serialized as empty arrays.
+func (s *serializer) isPresent(property prop.Property) bool {
+	if !property.IsUnassigned() {
+		return true
+	}
+	if property.Attribute().MultiValued() {
+		_, ok := property.Attribute().Annotation(annotation.EmitEmpty)
+		return ok
+	}
+	return false
+}
+
 func (s *serializer) Visit(property prop.Property) error {
 	if s.current().index > 0 {
 		_ = s.WriteByte(',')
@@ -131,7 +247,7 @@ func (s *serializer) Visit(property prop.Property) error {
 		s.appendPropertyName(property.Attribute())
 	}
 
-	if property.Attribute().MultiValued() || property.Attribute().Type() == spec.TypeComplex {
+	if !property.Attribute().IsScalar() {
 		return nil
 	}
 
@@ -141,8 +257,12 @@ func (s *serializer) Visit(property prop.Property) error {
 	}
 
 	switch property.Attribute().Type() {
-	case spec.TypeString, spec.TypeReference, spec.TypeDateTime, spec.TypeBinary:
+	case spec.TypeString:
+		s.appendString(s.redact(property))
+	case spec.TypeDateTime, spec.TypeBinary:
 		s.appendString(property.Raw().(string))
+	case spec.TypeReference:
+		s.appendString(s.render(property))
 	case spec.TypeInteger:
 		s.appendInteger(property.Raw().(int64))
 	case spec.TypeDecimal:
@@ -157,12 +277,32 @@ func (s *serializer) Visit(property prop.Property) error {
 	return nil
 }
 
+// Returns the value of the string property, masked if the attribute is annotated with @Redact.
+func (s *serializer) redact(property prop.Property) string {
+	if s.unredacted {
+		return property.Raw().(string)
+	}
+	return redact(property.Attribute(), property.Raw().(string))
+}
+
+// Returns the value of the reference property, rendered by the locator if the property is composed by the server.
+func (s *serializer) render(property prop.Property) string {
+	value := property.Raw().(string)
+	if s.locator == nil {
+		return value
+	}
+	if property.Attribute().Path() == "meta.location" || property.Attribute().Name() == "$ref" {
+		return s.locator.Render(value)
+	}
+	return value
+}
+
 func (s *serializer) BeginChildren(container prop.Property) {
 	switch {
 	case container.Attribute().MultiValued():
 		_ = s.WriteByte('[')
 		s.push(containerArray)
-	case container.Attribute().Type() == spec.TypeComplex:
+	case container.Attribute().IsComplex():
 		_ = s.WriteByte('{')
 		s.push(containerObject)
 	default:
@@ -174,7 +314,7 @@ func (s *serializer) EndChildren(container prop.Property) {
 	switch {
 	case container.Attribute().MultiValued():
 		_ = s.WriteByte(']')
-	case container.Attribute().Type() == spec.TypeComplex:
+	case container.Attribute().IsComplex():
 		_ = s.WriteByte('}')
 	default:
 		panic("unknown container")
@@ -267,11 +407,15 @@ func (s *serializer) appendString(value string) {
 	_ = s.WriteByte('"')
 }
 
+// Integers are always written as plain base-10 integers, without decimal point or exponent. Integer properties only
+// accept integer values, and hold them as int64; the entry points receiving integers as float64, i.e. FromMap, convert
+// integral values before assignment.
 func (s *serializer) appendInteger(value int64) {
 	b := strconv.AppendInt(s.scratch[:0], value, 10)
 	_, _ = s.Write(b)
 }
 
+// Decimals are written without exponent, unless the magnitude is less than 1e-6 or no less than 1e21.
 func (s *serializer) appendFloat(value float64) {
 	if math.IsInf(value, 0) || math.IsNaN(value) {
 		panic(fmt.Errorf("%w: invalid decimal in json serialization", spec.ErrInvalidValue))
@@ -311,7 +455,7 @@ func (s *serializer) appendBoolean(value bool) {
 }
 
 func (s *serializer) push(c container) {
-	s.stack = append(s.stack, &frame{
+	s.stack = append(s.stack, frame{
 		container: c,
 		index:     0,
 	})
@@ -328,5 +472,5 @@ func (s *serializer) current() *frame {
 	if len(s.stack) == 0 {
 		panic("stack is empty")
 	}
-	return s.stack[len(s.stack)-1]
+	return &s.stack[len(s.stack)-1]
 }
@@ -7,8 +7,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -129,6 +127,128 @@ func (s *JsonSerializeTestSuite) TestSerialize() {
       }
    ]
 }
+`
+				assert.JSONEq(t, expect, string(raw))
+			},
+		},
+		{
+			name: "include sub attribute of complex attribute",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				_, err := r.RootProperty().Replace(s.resourceData)
+				assert.Nil(t, err)
+				return r
+			},
+			options: []Options{
+				Include("name.familyName"),
+			},
+			expect: func(t *testing.T, raw []byte, err error) {
+				assert.Nil(t, err)
+				expect := `
+{
+   "schemas":[
+      "urn:ietf:params:scim:schemas:core:2.0:User"
+   ],
+   "id":"3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+   "name":{
+      "familyName":"Qiu"
+   }
+}
+`
+				assert.JSONEq(t, expect, string(raw))
+			},
+		},
+		{
+			name: "include sub attribute of multiValued attribute",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				_, err := r.RootProperty().Replace(s.resourceData)
+				assert.Nil(t, err)
+				return r
+			},
+			options: []Options{
+				Include("emails.value"),
+			},
+			expect: func(t *testing.T, raw []byte, err error) {
+				assert.Nil(t, err)
+				expect := `
+{
+   "schemas":[
+      "urn:ietf:params:scim:schemas:core:2.0:User"
+   ],
+   "id":"3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+   "emails":[
+      {
+         "value":"imulab@foo.com"
+      },
+      {
+         "value":"imulab@bar.com"
+      }
+   ]
+}
+`
+				assert.JSONEq(t, expect, string(raw))
+			},
+		},
+		{
+			name: "include sub attribute by full path",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				_, err := r.RootProperty().Replace(s.resourceData)
+				assert.Nil(t, err)
+				return r
+			},
+			options: []Options{
+				Include(`urn:ietf:params:scim:schemas:core:2.0:User:EMAILS[type eq "work"].Value`),
+			},
+			expect: func(t *testing.T, raw []byte, err error) {
+				assert.Nil(t, err)
+				expect := `
+{
+   "schemas":[
+      "urn:ietf:params:scim:schemas:core:2.0:User"
+   ],
+   "id":"3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+   "emails":[
+      {
+         "value":"imulab@foo.com"
+      },
+      {
+         "value":"imulab@bar.com"
+      }
+   ]
+}
+`
+				assert.JSONEq(t, expect, string(raw))
+			},
+		},
+		{
+			name: "include absent sub attribute omits parent",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				_, err := r.RootProperty().Replace(s.resourceData)
+				assert.Nil(t, err)
+				assert.False(t, r.Navigator().Dot("name").Dot("middleName").Delete().HasError())
+				assert.False(t, r.Navigator().Dot("emails").At(1).Dot("primary").Delete().HasError())
+				return r
+			},
+			options: []Options{
+				Include("name.middleName", "emails.primary"),
+			},
+			expect: func(t *testing.T, raw []byte, err error) {
+				assert.Nil(t, err)
+				expect := `
+{
+   "schemas":[
+      "urn:ietf:params:scim:schemas:core:2.0:User"
+   ],
+   "id":"3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+   "emails":[
+      {
+         "primary":true
+      }
+   ]
+}
 `
 				assert.JSONEq(t, expect, string(raw))
 			},
@@ -184,6 +304,43 @@ func (s *JsonSerializeTestSuite) TestSerialize() {
       }
    ]
 }
+`
+				assert.JSONEq(t, expect, string(raw))
+			},
+		},
+		{
+			name: "exclude all sub attributes omits parent",
+			getResource: func(t *testing.T) *prop.Resource {
+				r := prop.NewResource(s.resourceType)
+				_, err := r.RootProperty().Replace(s.resourceData)
+				assert.Nil(t, err)
+				return r
+			},
+			options: []Options{
+				Exclude("meta", "phoneNumbers", "ims", "groups", "entitlements", "roles", "photos", "x509Certificates"),
+				Exclude("displayName", "profileUrl", "userType", "preferredLanguage", "locale", "timezone", "active"),
+				Exclude("name.formatted", "name.familyName", "name.givenName", "name.honorificPrefix"),
+				Exclude("emails.value", "emails.display", "addresses"),
+			},
+			expect: func(t *testing.T, raw []byte, err error) {
+				assert.Nil(t, err)
+				expect := `
+{
+   "schemas":[
+      "urn:ietf:params:scim:schemas:core:2.0:User"
+   ],
+   "id":"3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+   "userName":"imulab",
+   "emails":[
+      {
+         "type":"work",
+         "primary":true
+      },
+      {
+         "type":"home"
+      }
+   ]
+}
 `
 				assert.JSONEq(t, expect, string(raw))
 			},
@@ -199,47 +356,354 @@ func (s *JsonSerializeTestSuite) TestSerialize() {
 	}
 }
 
-func (s *JsonSerializeTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
+func (s *JsonSerializeTestSuite) TestSerializeNumbers() {
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberSchema), schema))
+	spec.Schemas().Register(schema)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testNumberResourceType), resourceType))
+
+	tests := []struct {
+		name   string
+		count  interface{}
+		score  interface{}
+		expect []string
 	}{
 		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:   "integer from int64",
+			count:  int64(42),
+			score:  float64(4.2),
+			expect: []string{`"count":42`, `"score":4.2`},
+		},
+		{
+			name:   "integral decimal",
+			count:  int64(1234567),
+			score:  float64(1234567),
+			expect: []string{`"count":1234567`, `"score":1234567`},
+		},
+		{
+			name:   "large integer and small decimal",
+			count:  int64(1 << 53),
+			score:  float64(0.000001),
+			expect: []string{`"count":9007199254740992`, `"score":0.000001`},
+		},
+		{
+			name:   "decimal whose magnitude requires exponent",
+			count:  int64(-7),
+			score:  float64(1e21),
+			expect: []string{`"count":-7`, `"score":1e+21`},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(resourceType)
+			require.False(t, resource.Navigator().Dot("count").Replace(test.count).HasError())
+			require.False(t, resource.Navigator().Dot("score").Replace(test.score).HasError())
+
+			raw, err := Serialize(resource)
+			require.Nil(t, err)
+			for _, each := range test.expect {
+				assert.Contains(t, string(raw), each)
+			}
+
+			roundTrip := prop.NewResource(resourceType)
+			require.Nil(t, Deserialize(raw, roundTrip))
+			assert.Equal(t, resource.Navigator().Dot("count").Current().Raw(), roundTrip.Navigator().Dot("count").Current().Raw())
+			assert.Equal(t, resource.Navigator().Dot("score").Current().Raw(), roundTrip.Navigator().Dot("score").Current().Raw())
+		})
+	}
+}
+
+func (s *JsonSerializeTestSuite) TestSerializeEmitEmpty() {
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testEmitEmptySchema), schema))
+	spec.Schemas().Register(schema)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testEmitEmptyResourceType), resourceType))
+
+	tests := []struct {
+		name     string
+		options  []Options
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "annotated attribute is emitted as empty array",
+			contains: []string{`"tags":[]`},
+			excludes: []string{`"labels"`},
+		},
+		{
+			name:     "annotated attribute is still subject to attributes",
+			options:  []Options{Include("name")},
+			contains: []string{`"name":"foo"`},
+			excludes: []string{`"tags"`, `"labels"`},
+		},
+		{
+			name:     "annotated attribute is still subject to excludedAttributes",
+			options:  []Options{Exclude("tags")},
+			contains: []string{`"name":"foo"`},
+			excludes: []string{`"tags"`, `"labels"`},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(resourceType)
+			require.False(t, resource.Navigator().Dot("name").Replace("foo").HasError())
+
+			raw, err := Serialize(resource, test.options...)
+			require.Nil(t, err)
+			for _, each := range test.contains {
+				assert.Contains(t, string(raw), each)
+			}
+			for _, each := range test.excludes {
+				assert.NotContains(t, string(raw), each)
+			}
+		})
+	}
+}
+
+func (s *JsonSerializeTestSuite) TestSerializeReturnedRequest() {
+	schema, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:ReturnedRequest", "ReturnedRequest").
+		Attribute(spec.StringAttr("name")).
+		Attribute(spec.BinaryAttr("photo").Returned(spec.ReturnedRequest)).
+		Attribute(spec.ComplexAttr("profile",
+			spec.StringAttr("bio"),
+			spec.StringAttr("thumbnail").Returned(spec.ReturnedRequest),
+		)).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), spec.Schemas().Register(schema))
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "ReturnedRequest",
+  "name": "ReturnedRequest",
+  "endpoint": "/ReturnedRequest",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:ReturnedRequest"
+}
+`), resourceType))
+
+	tests := []struct {
+		name     string
+		options  []Options
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "request attributes are absent by default",
+			contains: []string{`"name":"foo"`, `"bio":"bar"`},
+			excludes: []string{`"photo"`, `"thumbnail"`},
+		},
+		{
+			name:     "request attribute is present when requested by name",
+			options:  []Options{Include("photo")},
+			contains: []string{`"photo":"Zm9v"`},
+			excludes: []string{`"name"`, `"profile"`},
+		},
+		{
+			name:     "request sub attribute is not present when its parent is requested",
+			options:  []Options{Include("profile")},
+			contains: []string{`"profile":{"bio":"bar"}`},
+			excludes: []string{`"photo"`, `"thumbnail"`},
+		},
+		{
+			name:     "request sub attribute is present when requested by name",
+			options:  []Options{Include("profile.thumbnail")},
+			contains: []string{`"profile":{"thumbnail":"baz"}`},
+			excludes: []string{`"photo"`, `"bio"`},
+		},
+		{
+			name:     "request attributes are absent with excludedAttributes",
+			options:  []Options{Exclude("name")},
+			contains: []string{`"bio":"bar"`},
+			excludes: []string{`"name"`, `"photo"`, `"thumbnail"`},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(resourceType)
+			require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+				"name":    "foo",
+				"photo":   "Zm9v",
+				"profile": map[string]interface{}{"bio": "bar", "thumbnail": "baz"},
+			}).Error())
+
+			raw, err := Serialize(resource, test.options...)
+			require.Nil(t, err)
+			for _, each := range test.contains {
+				assert.Contains(t, string(raw), each)
+			}
+			for _, each := range test.excludes {
+				assert.NotContains(t, string(raw), each)
+			}
+		})
+	}
+}
+
+func (s *JsonSerializeTestSuite) TestSerializeLocate() {
+	tests := []struct {
+		name     string
+		locator  *spec.Locator
+		contains []string
+	}{
+		{
+			name: "nil locator",
+			contains: []string{
+				`"location":"/Users/123"`,
+				`"$ref":"/Groups/1"`,
+				`"$ref":"https://other.org/Groups/2"`,
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:    "base url",
+			locator: &spec.Locator{BaseURL: "https://example.com/v2/"},
+			contains: []string{
+				`"location":"https://example.com/v2/Users/123"`,
+				`"$ref":"https://example.com/v2/Groups/1"`,
+				`"$ref":"https://other.org/Groups/2"`,
 			},
 		},
 		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
+			name:    "path only",
+			locator: &spec.Locator{BaseURL: "https://example.com/v2", PathOnly: true},
+			contains: []string{
+				`"location":"/v2/Users/123"`,
+				`"$ref":"/v2/Groups/1"`,
+				`"$ref":"https://other.org/Groups/2"`,
 			},
 		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+	}
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			resource := prop.NewResource(s.resourceType)
+			require.Nil(t, resource.Navigator().Replace(map[string]interface{}{
+				"id": "123",
+				"meta": map[string]interface{}{
+					"location": "/Users/123",
+				},
+				"groups": []interface{}{
+					map[string]interface{}{"value": "1", "$ref": "/Groups/1"},
+					map[string]interface{}{"value": "2", "$ref": "https://other.org/Groups/2"},
+				},
+			}).Error())
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+			raw, err := Serialize(resource, Locate(test.locator))
+			require.Nil(t, err)
+			for _, each := range test.contains {
+				assert.Contains(t, string(raw), each)
+			}
 
-		if each.post != nil {
-			each.post(each.structure)
-		}
+			m, err := ToMap(resource, Locate(test.locator))
+			require.Nil(t, err)
+			assert.Contains(t, string(raw), `"location":"`+m["meta"].(map[string]interface{})["location"].(string)+`"`)
+		})
 	}
+}
+
+func (s *JsonSerializeTestSuite) TestSerializeRedact() {
+	schema := new(spec.Schema)
+	require.Nil(s.T(), json.Unmarshal([]byte(testRedactSchema), schema))
+	spec.Schemas().Register(schema)
+
+	resourceType := new(spec.ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(testRedactResourceType), resourceType))
+
+	resource := prop.NewResource(resourceType)
+	require.Nil(s.T(), resource.Navigator().Replace(map[string]interface{}{
+		"id":     "1",
+		"name":   "foo",
+		"ssn":    "123-45-6789",
+		"secret": "s3cr3t",
+		"card": map[string]interface{}{
+			"number": "4111 1111 1111 1234",
+		},
+	}).Error())
+
+	tests := []struct {
+		name    string
+		options []Options
+		expect  map[string]interface{}
+	}{
+		{
+			name: "redacted by default",
+			expect: map[string]interface{}{
+				"id":     "1",
+				"name":   "foo",
+				"ssn":    "***-**-6789",
+				"secret": "******",
+				"card": map[string]interface{}{
+					"number": "**** **** **** 1234",
+				},
+			},
+		},
+		{
+			name:    "unredacted",
+			options: []Options{Unredacted()},
+			expect: map[string]interface{}{
+				"id":     "1",
+				"name":   "foo",
+				"ssn":    "123-45-6789",
+				"secret": "s3cr3t",
+				"card": map[string]interface{}{
+					"number": "4111 1111 1111 1234",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			raw, err := Serialize(resource, test.options...)
+			require.Nil(t, err)
+
+			actual := map[string]interface{}{}
+			require.Nil(t, json.Unmarshal(raw, &actual))
+			delete(actual, "schemas")
+			assert.Equal(t, test.expect, actual)
+
+			m, err := ToMap(resource, test.options...)
+			require.Nil(t, err)
+			delete(m, "schemas")
+			assert.Equal(t, test.expect, m)
+
+			// the stored value is never modified
+			assert.Equal(t, "123-45-6789", resource.Navigator().Dot("ssn").Current().Raw())
+		})
+	}
+}
+
+func (s *JsonSerializeTestSuite) TestSerializeReusesState() {
+	r := prop.NewResource(s.resourceType)
+	_, err := r.RootProperty().Replace(s.resourceData)
+	require.Nil(s.T(), err)
+
+	expect, err := Serialize(r)
+	require.Nil(s.T(), err)
+
+	// options of previous invocations must not leak into the pooled serializer state
+	_, err = Serialize(r, Include("userName"), Locate(&spec.Locator{BaseURL: "https://example.com"}))
+	require.Nil(s.T(), err)
+	_, err = Serialize(r, Include("userName"), Exclude("name"))
+	assert.NotNil(s.T(), err)
+
+	for i := 0; i < 10; i++ {
+		raw, err := Serialize(r)
+		require.Nil(s.T(), err)
+		assert.Equal(s.T(), string(expect), string(raw))
+	}
+}
+
+func (s *JsonSerializeTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
 
 	s.resourceData = map[string]interface{}{
 		"schemas": []interface{}{
@@ -295,3 +759,238 @@ func (s *JsonSerializeTestSuite) SetupSuite() {
 		},
 	}
 }
+
+const (
+	testNumberSchema = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Number",
+  "name": "Number",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Number:count",
+      "name": "count",
+      "type": "integer",
+      "_index": 100,
+      "_path": "count"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Number:score",
+      "name": "score",
+      "type": "decimal",
+      "_index": 101,
+      "_path": "score"
+    }
+  ]
+}
+`
+	testNumberResourceType = `
+{
+  "id": "Number",
+  "name": "Number",
+  "endpoint": "/Numbers",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Number"
+}
+`
+	testEmitEmptySchema = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:EmitEmpty",
+  "name": "EmitEmpty",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:EmitEmpty:name",
+      "name": "name",
+      "type": "string",
+      "_index": 100,
+      "_path": "name"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:EmitEmpty:tags",
+      "name": "tags",
+      "type": "string",
+      "multiValued": true,
+      "_index": 101,
+      "_path": "tags",
+      "_annotations": {
+        "@EmitEmpty": {}
+      }
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:EmitEmpty:labels",
+      "name": "labels",
+      "type": "string",
+      "multiValued": true,
+      "_index": 102,
+      "_path": "labels"
+    }
+  ]
+}
+`
+	testRedactSchema = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Redact",
+  "name": "Redact",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Redact:name",
+      "name": "name",
+      "type": "string",
+      "_index": 100,
+      "_path": "name"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Redact:ssn",
+      "name": "ssn",
+      "type": "string",
+      "_index": 101,
+      "_path": "ssn",
+      "_annotations": {
+        "@Redact": {
+          "keepLast": 4
+        }
+      }
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Redact:secret",
+      "name": "secret",
+      "type": "string",
+      "_index": 102,
+      "_path": "secret",
+      "_annotations": {
+        "@Redact": {}
+      }
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Redact:card",
+      "name": "card",
+      "type": "complex",
+      "_index": 103,
+      "_path": "card",
+      "subAttributes": [
+        {
+          "id": "urn:ietf:params:scim:schemas:test:2.0:Redact:card.number",
+          "name": "number",
+          "type": "string",
+          "_index": 0,
+          "_path": "card.number",
+          "_annotations": {
+            "@Redact": {
+              "keepLast": 4
+            }
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+	testRedactResourceType = `
+{
+  "id": "Redact",
+  "name": "Redact",
+  "endpoint": "/Redact",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Redact"
+}
+`
+	testEmitEmptyResourceType = `
+{
+  "id": "EmitEmpty",
+  "name": "EmitEmpty",
+  "endpoint": "/EmitEmpty",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:EmitEmpty"
+}
+`
+)
+
+func BenchmarkSerializeUser(b *testing.B) {
+	resource := benchmarkUser(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Serialize(resource); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSerializeListResponse(b *testing.B) {
+	resources := make([]*prop.Resource, 100)
+	for i := range resources {
+		resources[i] = benchmarkUser(b)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, resource := range resources {
+			if _, err := Serialize(resource, Exclude("groups")); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// Returns a fully populated User resource for benchmarks.
+func benchmarkUser(b *testing.B) *prop.Resource {
+	resourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(b, err)
+
+	resource := prop.NewResource(resourceType)
+	require.Nil(b, Deserialize([]byte(`
+{
+  "schemas": [
+    "urn:ietf:params:scim:schemas:core:2.0:User",
+    "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+  ],
+  "id": "3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+  "meta": {
+    "resourceType": "User",
+    "created": "2019-11-20T13:09:00",
+    "lastModified": "2019-11-20T13:09:00",
+    "location": "https://identity.imulab.io/Users/3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+    "version": "W/\"1\""
+  },
+  "userName": "imulab",
+  "name": {
+    "formatted": "Mr. Weinan Qiu",
+    "familyName": "Qiu",
+    "givenName": "Weinan",
+    "honorificPrefix": "Mr."
+  },
+  "displayName": "Weinan",
+  "profileUrl": "https://identity.imulab.io/profiles/3cc032f5-2361-417f-9e2f-bc80adddf4a3",
+  "userType": "Employee",
+  "preferredLanguage": "zh_CN",
+  "locale": "zh_CN",
+  "timezone": "Asia/Shanghai",
+  "active": true,
+  "emails": [
+    {"value": "imulab@foo.com", "type": "work", "primary": true, "display": "imulab@foo.com"},
+    {"value": "imulab@bar.com", "type": "home", "display": "imulab@bar.com"}
+  ],
+  "phoneNumbers": [
+    {"value": "123-45678", "type": "work", "primary": true, "display": "123-45678"}
+  ],
+  "addresses": [
+    {
+      "formatted": "123 Main St, Shanghai, China 200000",
+      "streetAddress": "123 Main St",
+      "locality": "Shanghai",
+      "postalCode": "200000",
+      "country": "China",
+      "type": "work",
+      "primary": true
+    }
+  ],
+  "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+    "employeeNumber": "6546579",
+    "costCenter": "4130",
+    "organization": "Universal Studios",
+    "division": "Theme Park",
+    "department": "Tour Operations",
+    "manager": {"value": "1", "$ref": "/Users/1", "displayName": "John Smith"}
+  }
+}
+`), resource))
+	return resource
+}
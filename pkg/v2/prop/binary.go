@@ -3,14 +3,22 @@ package prop
 import (
 	"encoding/base64"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"hash/fnv"
+	"strings"
 )
 
-// NewBinary creates a new binary property associated with attribute.
+// NewBinary creates a new binary property associated with attribute. If the attribute is annotated with @MaxSize, the
+// property rejects values whose decoded size exceeds the limit.
 func NewBinary(attr *spec.Attribute) Property {
 	ensureSingularBinaryType(attr)
-	p := binaryProperty{attr: attr, subscribers: []Subscriber{}}
+	p := binaryProperty{attr: attr, subscribers: []Subscriber{}, maxSize: -1}
+	if params, ok := attr.AnnotationParams(annotation.MaxSize); ok {
+		if n, ok := params.Int("bytes"); ok {
+			p.maxSize = n
+		}
+	}
 	attr.ForEachAnnotation(func(annotation string, params map[string]interface{}) {
 		if subscriber, ok := SubscriberFactory().Create(annotation, &p, params); ok {
 			p.subscribers = append(p.subscribers, subscriber)
@@ -40,6 +48,7 @@ type binaryProperty struct {
 	value       []byte
 	hash        uint64
 	dirty       bool
+	maxSize     int // maximum size of the decoded value, or -1 if not limited
 	subscribers []Subscriber
 }
 
@@ -91,12 +100,17 @@ func (p *binaryProperty) Clone() Property {
 		value:       make([]byte, len(p.value), len(p.value)),
 		hash:        p.hash,
 		dirty:       p.dirty,
+		maxSize:     p.maxSize,
 		subscribers: p.subscribers,
 	}
 	copy(c.value, p.value)
 	return &c
 }
 
+func (p *binaryProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *binaryProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -114,7 +128,12 @@ func (p *binaryProperty) Replace(value interface{}) (*Event, error) {
 		return nil, fmt.Errorf("%w: value is incompatible with '%s'", spec.ErrInvalidValue, p.attr.Path())
 	}
 
-	b64, err := base64.StdEncoding.DecodeString(s)
+	if p.maxSize >= 0 && decodedLen(s) > p.maxSize {
+		return nil, fmt.Errorf("%w: value for '%s' exceeds the maximum size of %d bytes", spec.ErrInvalidValue,
+			p.attr.Path(), p.maxSize)
+	}
+
+	b64, err := decodeBase64(s)
 	if err != nil {
 		return nil, fmt.Errorf("%w: value for '%s' is not base64 encoded", spec.ErrInvalidValue, p.attr.Path())
 	}
@@ -159,6 +178,10 @@ func (p *binaryProperty) ForEachChild(_ func(index int, child Property) error) e
 	return nil
 }
 
+func (p *binaryProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *binaryProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
@@ -190,7 +213,7 @@ func (p *binaryProperty) EqualsTo(value interface{}) bool {
 		return false
 	}
 
-	b64, err := base64.StdEncoding.DecodeString(s)
+	b64, err := decodeBase64(s)
 	if err != nil {
 		return false
 	}
@@ -202,6 +225,20 @@ func (p *binaryProperty) Present() bool {
 	return len(p.value) > 0
 }
 
+// Decodes the standard base64 encoded value. Values with missing or partial padding are also accepted, as long as
+// they are otherwise canonical. Since Raw always encodes with padding, such values are canonicalized once assigned.
+func decodeBase64(value string) ([]byte, error) {
+	if b, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return b, nil
+	}
+	return base64.RawStdEncoding.Strict().DecodeString(strings.TrimRight(value, "="))
+}
+
+// Returns the size of the decoded value of the base64 encoded value, without decoding it.
+func decodedLen(value string) int {
+	return base64.RawStdEncoding.DecodedLen(len(strings.TrimRight(value, "=")))
+}
+
 var (
 	_ EqCapable = (*binaryProperty)(nil)
 	_ PrCapable = (*binaryProperty)(nil)
@@ -26,6 +26,7 @@ type BinaryPropertyTestSuite struct {
 	PropertyTestSuite
 	OperatorTestSuite
 	standardAttr *spec.Attribute
+	maxSizeAttr  *spec.Attribute
 }
 
 func (s *BinaryPropertyTestSuite) SetupSuite() {
@@ -36,6 +37,19 @@ func (s *BinaryPropertyTestSuite) SetupSuite() {
   "type": "binary",
   "_path": "x509Certificates.value",
   "_index": 100
+}`))
+	s.maxSizeAttr = s.mustAttribute(s.T(), strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:2.0:User:x509Certificates.value",
+  "name": "value",
+  "type": "binary",
+  "_path": "x509Certificates.value",
+  "_index": 100,
+  "_annotations": {
+    "@MaxSize": {
+      "bytes": 5
+    }
+  }
 }`))
 }
 
@@ -360,6 +374,43 @@ func (s *BinaryPropertyTestSuite) TestReplace() {
 				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
 			},
 		},
+		{
+			name:  "replace with value missing padding",
+			prop:  NewBinary(s.standardAttr),
+			value: strings.TrimRight(s.base64("hello"), "="),
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, s.base64("hello"), raw)
+			},
+		},
+		{
+			name:  "replace with non canonical value missing padding",
+			prop:  NewBinary(s.standardAttr),
+			value: "aGVsbG9",
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.NotNil(t, err)
+				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
+			},
+		},
+		{
+			name:  "replace with value within maximum size",
+			prop:  NewBinary(s.maxSizeAttr),
+			value: s.base64("hello"),
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, s.base64("hello"), raw)
+			},
+		},
+		{
+			name:  "replace with value exceeding maximum size",
+			prop:  NewBinaryOf(s.maxSizeAttr, s.base64("hello")),
+			value: s.base64("hello world"),
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.NotNil(t, err)
+				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
+				assert.Equal(t, s.base64("hello"), raw)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -95,6 +95,10 @@ func (p *booleanProperty) Clone() Property {
 	return &c
 }
 
+func (p *booleanProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *booleanProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -149,6 +153,10 @@ func (p *booleanProperty) ForEachChild(_ func(index int, child Property) error)
 	return nil
 }
 
+func (p *booleanProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *booleanProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
@@ -42,7 +42,7 @@ func NewComplexOf(attr *spec.Attribute, value map[string]interface{}) Property {
 }
 
 func ensureSingularComplexType(attr *spec.Attribute) {
-	if attr.MultiValued() || attr.Type() != spec.TypeComplex {
+	if attr.IsMultiValued() || !attr.IsComplex() {
 		panic("invalid attribute for complex property")
 	}
 }
@@ -113,6 +113,9 @@ func (p *complexProperty) Hash() uint64 {
 		if _, ok := idSubAttr[child.Attribute()]; !ok && len(idSubAttr) > 0 {
 			return nil // do not include in computation if complex has identity attributes but this is not one of them.
 		}
+		if _, ok := child.Attribute().Annotation(annotation.Computed); ok {
+			return nil // computed values are derived by the server, they do not tell resources apart.
+		}
 
 		if _, err := h.Write([]byte(child.Attribute().Name())); err != nil {
 			return err
@@ -160,6 +163,10 @@ func (p *complexProperty) Clone() Property {
 	return &c
 }
 
+func (p *complexProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *complexProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return nil, nil
@@ -247,6 +254,15 @@ func (p *complexProperty) ForEachChild(callback func(index int, child Property)
 	return nil
 }
 
+func (p *complexProperty) ForEachSubProperty(callback func(name string, sub Property) error) error {
+	for _, sp := range p.subProps {
+		if err := callback(sp.Attribute().Name(), sp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *complexProperty) FindChild(criteria func(child Property) bool) Property {
 	for _, sp := range p.subProps {
 		if criteria(sp) {
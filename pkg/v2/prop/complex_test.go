@@ -125,6 +125,81 @@ attribute. ComplexPropertyTestSuite is a dummy implementation of Subscriber, whi
 	}
 }
 
+func (s *ComplexPropertyTestSuite) TestForEachSubProperty() {
+	// sub attributes are listed out of order, the declared order is given by "_index"
+	attr := s.mustAttribute(s.T(), strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:core:2.0:User:name",
+  "name": "name",
+  "type": "complex",
+  "_path": "name",
+  "_index": 10,
+  "subAttributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:name.middleName",
+      "name": "middleName",
+      "type": "string",
+      "_path": "name.middleName",
+      "_index": 3
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:name.formatted",
+      "name": "formatted",
+      "type": "string",
+      "_path": "name.formatted",
+      "_index": 0
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName",
+      "name": "givenName",
+      "type": "string",
+      "_path": "name.givenName",
+      "_index": 2
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:name.familyName",
+      "name": "familyName",
+      "type": "string",
+      "_path": "name.familyName",
+      "_index": 1
+    }
+  ]
+}`))
+
+	s.T().Run("stable order", func(t *testing.T) {
+		p := NewComplexOf(attr, map[string]interface{}{
+			"givenName":  "David",
+			"familyName": "Qiu",
+		})
+		for i := 0; i < 10; i++ {
+			var names []string
+			var values []interface{}
+			err := p.ForEachSubProperty(func(name string, sub Property) error {
+				names = append(names, name)
+				values = append(values, sub.Raw())
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"formatted", "familyName", "givenName", "middleName"}, names)
+			assert.Equal(t, []interface{}{nil, "Qiu", "David", nil}, values)
+		}
+	})
+
+	s.T().Run("stop at error", func(t *testing.T) {
+		stop := errors.New("stop")
+		var names []string
+		err := NewComplex(attr).ForEachSubProperty(func(name string, _ Property) error {
+			names = append(names, name)
+			if name == "familyName" {
+				return stop
+			}
+			return nil
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, []string{"formatted", "familyName"}, names)
+	})
+}
+
 func (s *ComplexPropertyTestSuite) TestRaw() {
 	tests := []struct {
 		name     string
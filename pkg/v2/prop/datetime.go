@@ -92,6 +92,10 @@ func (p *dateTimeProperty) Clone() Property {
 	return c
 }
 
+func (p *dateTimeProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *dateTimeProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -152,6 +156,10 @@ func (p *dateTimeProperty) ForEachChild(_ func(index int, child Property) error)
 	return nil
 }
 
+func (p *dateTimeProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *dateTimeProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
@@ -210,17 +218,18 @@ func (p *dateTimeProperty) compareThisAndValue(value interface{}, comparator fun
 		return false
 	}
 
-	s, ok := value.(string)
-	if !ok {
-		return false
-	}
-
-	t, err := p.fromISO8601(s)
-	if err != nil {
+	switch v := value.(type) {
+	case time.Time:
+		return comparator(*(p.value), v)
+	case string:
+		t, err := p.fromISO8601(v)
+		if err != nil {
+			return false
+		}
+		return comparator(*(p.value), t)
+	default:
 		return false
 	}
-
-	return comparator(*(p.value), t)
 }
 
 func (p *dateTimeProperty) Present() bool {
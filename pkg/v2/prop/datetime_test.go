@@ -361,6 +361,15 @@ func (s *DateTimePropertyTestSuite) TestReplace() {
 				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
 			},
 		},
+		{
+			name:  "replace with time zone",
+			prop:  NewDateTime(s.standardAttr),
+			value: "2020-01-16T07:30:00Z",
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.NotNil(t, err)
+				assert.Equal(t, spec.ErrInvalidValue, errors.Unwrap(err))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -8,7 +8,7 @@ import (
 // NewDecimal creates a new decimal property associated with attribute.
 func NewDecimal(attr *spec.Attribute) Property {
 	ensureSingularDecimalType(attr)
-	p := decimalProperty{attr: attr, subscribers: []Subscriber{}}
+	p := decimalProperty{attr: attr, subscribers: []Subscriber{}, valueRange: numericRangeOf(attr)}
 	attr.ForEachAnnotation(func(annotation string, params map[string]interface{}) {
 		if subscriber, ok := SubscriberFactory().Create(annotation, &p, params); ok {
 			p.subscribers = append(p.subscribers, subscriber)
@@ -37,6 +37,7 @@ type decimalProperty struct {
 	attr        *spec.Attribute
 	value       *float64
 	dirty       bool
+	valueRange  *numericRange // nil if not constrained
 	subscribers []Subscriber
 }
 
@@ -84,6 +85,7 @@ func (p *decimalProperty) Clone() Property {
 		attr:        p.attr,
 		value:       nil,
 		dirty:       p.dirty,
+		valueRange:  p.valueRange,
 		subscribers: p.subscribers,
 	}
 	if p.value != nil {
@@ -93,6 +95,10 @@ func (p *decimalProperty) Clone() Property {
 	return &c
 }
 
+func (p *decimalProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *decimalProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -109,6 +115,9 @@ func (p *decimalProperty) Replace(value interface{}) (*Event, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := p.valueRange.check(p.attr, f64); err != nil {
+		return nil, err
+	}
 
 	p.dirty = true
 	if !p.EqualsTo(f64) {
@@ -147,6 +156,10 @@ func (p *decimalProperty) ForEachChild(_ func(index int, child Property) error)
 	return nil
 }
 
+func (p *decimalProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *decimalProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
@@ -161,6 +174,12 @@ func (p *decimalProperty) tryFloat64(value interface{}) (float64, error) {
 		return v, nil
 	case float32:
 		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
 	default:
 		return 0, fmt.Errorf("%w: value is incompatible with '%s'", spec.ErrInvalidValue, p.attr.Path())
 	}
@@ -380,6 +380,50 @@ func (s *DecimalPropertyTestSuite) TestReplace() {
 	}
 }
 
+func (s *DecimalPropertyTestSuite) TestRange() {
+	attr := s.mustAttribute(s.T(), strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:extension:test:2.0:Test:discount",
+  "name": "discount",
+  "type": "decimal",
+  "_path": "discount",
+  "_index": 10,
+  "_annotations": {
+    "@Constraints": {
+      "minimum": 0,
+      "maximum": 0.5,
+      "exclusiveMinimum": true
+    }
+  }
+}`))
+
+	tests := []struct {
+		name  string
+		value float64
+		err   string
+	}{
+		{name: "within range", value: 0.25},
+		{name: "inclusive maximum", value: 0.5},
+		{name: "exclusive minimum", value: 0, err: "value 0 for 'discount' is outside of the allowed range (0, 0.5]"},
+		{name: "above maximum", value: 0.75, err: "value 0.75 for 'discount' is outside of the allowed range (0, 0.5]"},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			p := NewDecimal(attr)
+			_, err := p.Replace(test.value)
+			if len(test.err) == 0 {
+				assert.Nil(t, err)
+				assert.Equal(t, test.value, p.Raw())
+				return
+			}
+			assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			assert.Contains(t, err.Error(), test.err)
+			assert.True(t, p.IsUnassigned())
+		})
+	}
+}
+
 func (s *DecimalPropertyTestSuite) TestDelete() {
 	tests := []struct {
 		name   string
@@ -0,0 +1,136 @@
+package prop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// NoAttributeError is the error recorded by Navigator.Dot when the property in focus has no sub property by the name,
+// either because its attribute does not define such a sub attribute, or because it is not a singular complex property.
+// The error wraps spec.ErrInvalidPath, hence errors.Is continues to work against it, and the exact path is recoverable
+// with errors.As.
+type NoAttributeError struct {
+	// Path of the property in focus, i.e. name, or empty for the root of a resource
+	From string
+	// Name of the missing sub attribute, as given to Dot, i.e. nickName
+	Name string
+}
+
+// Path returns the full path of the missing attribute, i.e. name.nickName.
+func (e *NoAttributeError) Path() string {
+	if len(e.From) == 0 {
+		return e.Name
+	}
+	return e.From + "." + e.Name
+}
+
+func (e *NoAttributeError) Error() string {
+	return fmt.Sprintf("%s: no attribute named '%s' from '%s' (path:'%s')", spec.ErrInvalidPath.Error(), e.Name, e.From, e.Path())
+}
+
+func (e *NoAttributeError) Unwrap() error {
+	return spec.ErrInvalidPath
+}
+
+// NoTargetError is the error recorded by Navigator.At and Navigator.Where when the property in focus has no element at
+// the index, or no element meeting the criteria, either because there is no such element, or because it is not a
+// multiValued property. The error wraps spec.ErrNoTarget, hence errors.Is continues to work against it.
+type NoTargetError struct {
+	// Path of the property in focus, i.e. emails
+	From string
+	// Index given to At
+	Index int
+	// True if recorded by Where, in which case Index is meaningless
+	Criteria bool
+}
+
+func (e *NoTargetError) Error() string {
+	if e.Criteria {
+		return fmt.Sprintf("%s: no target meeting criteria from '%s'", spec.ErrNoTarget.Error(), e.From)
+	}
+	return fmt.Sprintf("%s: no target at index '%d' from '%s'", spec.ErrNoTarget.Error(), e.Index, e.From)
+}
+
+func (e *NoTargetError) Unwrap() error {
+	return spec.ErrNoTarget
+}
+
+// InvalidTypeError is the error reported by Resource.UnmarshalJSON when a JSON value does not conform to the type of
+// its attribute, i.e. a string for an integer attribute, a decimal for an integer attribute, or a string which is not
+// a dateTime for a dateTime attribute. The error wraps spec.ErrInvalidValue.
+type InvalidTypeError struct {
+	// Path of the attribute, i.e. emails.primary, or empty for the root of a resource
+	Path string
+	// Expected type, i.e. "boolean", or "array" for multiValued attributes
+	Expected string
+	// The offending value, as decoded by encoding/json with numbers as json.Number
+	Value interface{}
+}
+
+func (e *InvalidTypeError) Error() string {
+	return fmt.Sprintf("%s: value for '%s' must be %s, got %s", spec.ErrInvalidValue.Error(), e.Path, e.Expected, jsonKind(e.Value))
+}
+
+func (e *InvalidTypeError) Unwrap() error {
+	return spec.ErrInvalidValue
+}
+
+// Returns the kind of the decoded JSON value, for error messages.
+func jsonKind(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number " + v.String()
+	case string:
+		return fmt.Sprintf("string '%s'", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// UnmarshalErrors is the combined error returned by Resource.UnmarshalJSON. It contains every *NoAttributeError and
+// *InvalidTypeError found, depth first, by the sorted JSON keys of each object. errors.Is and errors.As test each of the
+// contained errors.
+type UnmarshalErrors []error
+
+func (e UnmarshalErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e UnmarshalErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e UnmarshalErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ error = (*NoAttributeError)(nil)
+	_ error = (*NoTargetError)(nil)
+	_ error = (*InvalidTypeError)(nil)
+	_ error = UnmarshalErrors(nil)
+)
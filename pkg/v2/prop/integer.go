@@ -8,7 +8,7 @@ import (
 // NewInteger creates a new integer property associated with attribute.
 func NewInteger(attr *spec.Attribute) Property {
 	ensureSingularIntegerType(attr)
-	p := integerProperty{attr: attr, subscribers: []Subscriber{}}
+	p := integerProperty{attr: attr, subscribers: []Subscriber{}, valueRange: numericRangeOf(attr)}
 	attr.ForEachAnnotation(func(annotation string, params map[string]interface{}) {
 		if subscriber, ok := SubscriberFactory().Create(annotation, &p, params); ok {
 			p.subscribers = append(p.subscribers, subscriber)
@@ -37,6 +37,7 @@ type integerProperty struct {
 	attr        *spec.Attribute
 	value       *int64
 	dirty       bool
+	valueRange  *numericRange // nil if not constrained
 	subscribers []Subscriber
 }
 
@@ -84,6 +85,7 @@ func (p *integerProperty) Clone() Property {
 		attr:        p.attr,
 		value:       nil,
 		dirty:       p.dirty,
+		valueRange:  p.valueRange,
 		subscribers: p.subscribers,
 	}
 	if p.value != nil {
@@ -93,6 +95,10 @@ func (p *integerProperty) Clone() Property {
 	return &c
 }
 
+func (p *integerProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *integerProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -109,6 +115,9 @@ func (p *integerProperty) Replace(value interface{}) (*Event, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := p.valueRange.check(p.attr, float64(i64)); err != nil {
+		return nil, err
+	}
 
 	p.dirty = true
 	if !p.EqualsTo(i64) {
@@ -147,6 +156,10 @@ func (p *integerProperty) ForEachChild(_ func(index int, child Property) error)
 	return nil
 }
 
+func (p *integerProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *integerProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
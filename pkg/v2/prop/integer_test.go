@@ -380,6 +380,54 @@ func (s *IntegerPropertyTestSuite) TestReplace() {
 	}
 }
 
+func (s *IntegerPropertyTestSuite) TestRange() {
+	attr := s.mustAttribute(s.T(), strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:extension:test:2.0:Test:seatCount",
+  "name": "seatCount",
+  "type": "integer",
+  "_path": "seatCount",
+  "_index": 10,
+  "_annotations": {
+    "@Constraints": {
+      "minimum": 1,
+      "maximum": 10000
+    }
+  }
+}`))
+
+	tests := []struct {
+		name  string
+		value int64
+		err   string
+	}{
+		{name: "lower bound", value: 1},
+		{name: "upper bound", value: 10000},
+		{name: "below minimum", value: 0, err: "value 0 for 'seatCount' is outside of the allowed range [1, 10000]"},
+		{name: "above maximum", value: 10001, err: "value 10001 for 'seatCount' is outside of the allowed range [1, 10000]"},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			p := NewInteger(attr)
+			_, err := p.Replace(test.value)
+			if len(test.err) == 0 {
+				assert.Nil(t, err)
+				assert.Equal(t, test.value, p.Raw())
+				return
+			}
+			assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			assert.Contains(t, err.Error(), test.err)
+			assert.True(t, p.IsUnassigned())
+		})
+	}
+
+	s.T().Run("clone keeps range", func(t *testing.T) {
+		_, err := NewIntegerOf(attr, 5).Clone().Replace(int64(0))
+		assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+	})
+}
+
 func (s *IntegerPropertyTestSuite) TestDelete() {
 	tests := []struct {
 		name   string
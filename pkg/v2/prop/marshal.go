@@ -0,0 +1,156 @@
+package prop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// MarshalJSON implements json.Marshaler, so that the resource can be embedded in structures encoded by encoding/json.
+// The resource is rendered by the marshaler registered with RegisterMarshaler, which the json package registers when
+// it is imported, so that the output is the same as in responses, except that values are never redacted.
+func (r *Resource) MarshalJSON() ([]byte, error) {
+	if marshaler == nil {
+		return nil, fmt.Errorf("%w: no marshaler registered for resources", spec.ErrInternal)
+	}
+	return marshaler(r)
+}
+
+// The function rendering resources in MarshalJSON
+var marshaler func(resource *Resource) ([]byte, error)
+
+// RegisterMarshaler registers the function rendering resources in MarshalJSON. It is registered by the json package,
+// which cannot be imported by this package, and should not be invoked otherwise.
+func RegisterMarshaler(m func(resource *Resource) ([]byte, error)) {
+	marshaler = m
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a SCIM resource into the resource, which must have been created
+// by NewResource. Attribute names are matched case insensitively, and the attributes of a schema extension are expected
+// in an object under the URN of the schema extension. Each value is checked against the type of its attribute before
+// anything is assigned: keys which do not name an attribute are reported as *NoAttributeError, and values which do not
+// conform to the type, i.e. a decimal or a string for an integer, a string for a boolean, a string which is neither in
+// the spec.ISO8601 format nor an RFC3339 timestamp for a dateTime, or a single value for a multiValued attribute, are
+// reported as *InvalidTypeError. All of them are returned together as UnmarshalErrors, in which case the resource is
+// left untouched. Otherwise, the decoded values replace the content of the resource.
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	if r.resourceType == nil {
+		return fmt.Errorf("%w: resource must be created by NewResource before unmarshalling", spec.ErrInternal)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("%w: %s", spec.ErrInvalidSyntax, err.Error())
+	}
+
+	var errs UnmarshalErrors
+	value := normalizeValue(r.RootAttribute(), raw, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	root := NewComplex(r.RootAttribute()).(*complexProperty)
+	if err := Navigate(root).Replace(value).Error(); err != nil {
+		return err
+	}
+	r.data = root
+	return nil
+}
+
+// Returns the decoded JSON value converted to the value of the attribute, with json.Number converted to int64 or
+// float64, or nil after appending the violations to errs.
+func normalizeValue(attr *spec.Attribute, value interface{}, errs *UnmarshalErrors) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if attr.MultiValued() {
+		array, ok := value.([]interface{})
+		if !ok {
+			*errs = append(*errs, &InvalidTypeError{Path: attr.Path(), Expected: "array", Value: value})
+			return nil
+		}
+		elemAttr := attr.DeriveElementAttribute()
+		elements := make([]interface{}, 0, len(array))
+		for _, elem := range array {
+			elements = append(elements, normalizeValue(elemAttr, elem, errs))
+		}
+		return elements
+	}
+
+	invalid := func() interface{} {
+		*errs = append(*errs, &InvalidTypeError{Path: attr.Path(), Expected: attr.Type().String(), Value: value})
+		return nil
+	}
+
+	switch attr.Type() {
+	case spec.TypeComplex:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid()
+		}
+		keys := make([]string, 0, len(object))
+		for k := range object {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		normalized := make(map[string]interface{}, len(object))
+		for _, k := range keys {
+			subAttr := attr.SubAttributeForName(k)
+			if subAttr == nil {
+				*errs = append(*errs, &NoAttributeError{From: attr.Path(), Name: k})
+				continue
+			}
+			normalized[k] = normalizeValue(subAttr, object[k], errs)
+		}
+		return normalized
+	case spec.TypeInteger:
+		if n, ok := value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				return i
+			}
+		}
+		return invalid()
+	case spec.TypeDecimal:
+		if n, ok := value.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				return f
+			}
+		}
+		return invalid()
+	case spec.TypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b
+		}
+		return invalid()
+	case spec.TypeDateTime:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(spec.ISO8601, s); err == nil {
+				return s
+			}
+			// RFC3339 timestamps, as in the examples of RFC7643, are converted to UTC, which values are stored in.
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				return t.UTC().Format(spec.ISO8601)
+			}
+		}
+		return invalid()
+	case spec.TypeBinary:
+		if s, ok := value.(string); ok {
+			if _, err := decodeBase64(s); err == nil {
+				return s
+			}
+		}
+		return invalid()
+	default:
+		if s, ok := value.(string); ok {
+			return s
+		}
+		return invalid()
+	}
+}
@@ -0,0 +1,187 @@
+package prop
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResource_UnmarshalJSON(t *testing.T) {
+	userResourceType := badgeUserResourceType(t)
+
+	tests := []struct {
+		name   string
+		input  string
+		expect func(t *testing.T, resource *Resource, err error)
+	}{
+		{
+			name: "extension attributes under their URN",
+			input: `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User", "urn:example:params:scim:schemas:extension:2.0:Badge"],
+  "UserName": "bjensen@example.com",
+  "emails": [{"value": "bjensen@example.com", "primary": true}],
+  "meta": {"created": "2010-01-23T04:56:22Z"},
+  "urn:example:params:scim:schemas:extension:2.0:Badge": {"number": 42, "score": 4, "expired": false}
+}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "bjensen@example.com", resource.Navigator().Dot("userName").Current().Raw())
+				assert.Equal(t, true, resource.Navigator().Dot("emails").At(0).Dot("primary").Current().Raw())
+				assert.Equal(t, "2010-01-23T04:56:22", resource.Navigator().Dot("meta").Dot("created").Current().Raw())
+				nav := resource.Navigator().Dot("urn:example:params:scim:schemas:extension:2.0:Badge")
+				assert.Equal(t, int64(42), nav.Dot("number").Current().Raw())
+				nav.Retract()
+				assert.Equal(t, float64(4), nav.Dot("score").Current().Raw())
+				nav.Retract()
+				assert.Equal(t, false, nav.Dot("expired").Current().Raw())
+			},
+		},
+		{
+			name: "malformed integer, boolean and dateTime",
+			input: `
+{
+  "userName": "bjensen@example.com",
+  "active": "yes",
+  "meta": {"created": "2010-13-45T04:56:22Z"},
+  "urn:example:params:scim:schemas:extension:2.0:Badge": {"number": "42", "expired": 1}
+}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				require.NotNil(t, err)
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+
+				var errs UnmarshalErrors
+				require.True(t, errors.As(err, &errs))
+				var paths []string
+				for _, each := range errs {
+					var typeErr *InvalidTypeError
+					require.True(t, errors.As(each, &typeErr))
+					paths = append(paths, typeErr.Path)
+				}
+				assert.Equal(t, []string{
+					"active",
+					"meta.created",
+					"urn:example:params:scim:schemas:extension:2.0:Badge:expired",
+					"urn:example:params:scim:schemas:extension:2.0:Badge:number",
+				}, paths)
+
+				// nothing is assigned
+				assert.True(t, resource.RootProperty().IsUnassigned())
+			},
+		},
+		{
+			name:  "decimal for integer",
+			input: `{"urn:example:params:scim:schemas:extension:2.0:Badge": {"number": 4.5}}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				var typeErr *InvalidTypeError
+				require.True(t, errors.As(err, &typeErr))
+				assert.Equal(t, "integer", typeErr.Expected)
+				assert.Equal(t, json.Number("4.5"), typeErr.Value)
+			},
+		},
+		{
+			name:  "single value for multiValued attribute",
+			input: `{"emails": {"value": "bjensen@example.com"}}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				var typeErr *InvalidTypeError
+				require.True(t, errors.As(err, &typeErr))
+				assert.Equal(t, "emails", typeErr.Path)
+				assert.Equal(t, "array", typeErr.Expected)
+			},
+		},
+		{
+			name:  "unknown attributes",
+			input: `{"nickname": "Babs", "foo": "bar", "name": {"firstName": "Barbara"}, "emails": [{"label": "work"}]}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+
+				var errs UnmarshalErrors
+				require.True(t, errors.As(err, &errs))
+				var paths []string
+				for _, each := range errs {
+					var noAttr *NoAttributeError
+					require.True(t, errors.As(each, &noAttr))
+					paths = append(paths, noAttr.Path())
+				}
+				assert.Equal(t, []string{"emails.label", "foo", "name.firstName"}, paths)
+			},
+		},
+		{
+			name:  "unpadded base64 binary",
+			input: `{"x509Certificates": [{"value": "YWJjZA"}]}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "YWJjZA==", resource.Navigator().Dot("x509Certificates").At(0).Dot("value").Current().Raw())
+			},
+		},
+		{
+			name:  "malformed base64 binary",
+			input: `{"x509Certificates": [{"value": "YWJjZA!"}]}`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				var typeErr *InvalidTypeError
+				require.True(t, errors.As(err, &typeErr))
+				assert.Equal(t, "x509Certificates.value", typeErr.Path)
+			},
+		},
+		{
+			name:  "malformed JSON",
+			input: `{"userName": }`,
+			expect: func(t *testing.T, resource *Resource, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := NewResource(userResourceType)
+			err := resource.UnmarshalJSON([]byte(test.input))
+			test.expect(t, resource, err)
+		})
+	}
+}
+
+// Returns the standard User resource type, with a schema extension of integer, decimal and boolean attributes.
+func badgeUserResourceType(t *testing.T) *spec.ResourceType {
+	userResourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+
+	extension, err := spec.ParseSchema(strings.NewReader(`
+{
+  "id": "urn:example:params:scim:schemas:extension:2.0:Badge",
+  "name": "Badge",
+  "attributes": [
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:number",
+      "name": "number",
+      "type": "integer",
+      "_index": 0,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:number"
+    },
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:score",
+      "name": "score",
+      "type": "decimal",
+      "_index": 1,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:score"
+    },
+    {
+      "id": "urn:example:params:scim:schemas:extension:2.0:Badge:expired",
+      "name": "expired",
+      "type": "boolean",
+      "_index": 2,
+      "_path": "urn:example:params:scim:schemas:extension:2.0:Badge:expired"
+    }
+  ]
+}
+`))
+	require.Nil(t, err)
+	require.Nil(t, spec.Schemas().Register(extension))
+	require.Nil(t, userResourceType.AddExtension(extension, false))
+	return userResourceType
+}
@@ -132,6 +132,10 @@ func (p *multiValuedProperty) Clone() Property {
 	return &c
 }
 
+func (p *multiValuedProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *multiValuedProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return nil, nil
@@ -231,6 +235,10 @@ func (p *multiValuedProperty) ForEachChild(callback func(index int, child Proper
 	return nil
 }
 
+func (p *multiValuedProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *multiValuedProperty) FindChild(criteria func(child Property) bool) Property {
 	for _, elem := range p.elements {
 		if criteria(elem) {
@@ -50,11 +50,13 @@ type Navigator interface {
 	// Retract goes back to the last focused property. The source property that
 	// this navigator was created with cannot be retracted
 	Retract() Navigator
-	// Dot focuses on the sub property that goes by the given name (case insensitive)
+	// Dot focuses on the sub property that goes by the given name (case insensitive). If there is none, the
+	// error is a *NoAttributeError.
 	Dot(name string) Navigator
-	// At focuses on the element property at given index
+	// At focuses on the element property at given index. If there is none, the error is a *NoTargetError.
 	At(index int) Navigator
-	// Where focuses on the first child property meeting given criteria
+	// Where focuses on the first child property meeting given criteria. If there is none, the error is a
+	// *NoTargetError.
 	Where(criteria func(child Property) bool) Navigator
 	// Add delegates for Add of the Current property and propagates events to upstream properties.
 	Add(value interface{}) Navigator
@@ -62,6 +64,15 @@ type Navigator interface {
 	Replace(value interface{}) Navigator
 	// Delete delegates for Delete of the Current property and propagates events to upstream properties.
 	Delete() Navigator
+	// Unassign clears the value of the Current property and propagates events to upstream properties. It is the
+	// equivalent of a SCIM remove operation, and works uniformly for singular, complex and multiValued properties.
+	// Unlike Delete, Unassign is a no-op when the Current property is already unassigned (see Property#IsUnassigned),
+	// hence it does not mark an untouched property as Dirty.
+	Unassign() Navigator
+	// Respell replaces the value of the Current string property with another spelling that only differs from it by
+	// case, i.e. its canonical spelling, and propagates the event to upstream properties. Unlike Replace, the spelling
+	// is adopted even if the attribute is not caseExact. The Current property must be Respellable.
+	Respell(value string) Navigator
 	// ForEachChild iterates each child property of the current property and invokes callback.
 	// The method returns any error generated previously or generated by any of the callbacks.
 	ForEachChild(callback func(index int, child Property) error) error
@@ -108,9 +119,12 @@ func (n *defaultNavigator) Dot(name string) Navigator {
 		return n
 	}
 
-	child, err := n.Current().ChildAtIndex(name)
-	if err != nil {
-		n.err = fmt.Errorf("%w: no attribute named '%s' from '%s'", spec.ErrInvalidPath, name, n.Current().Attribute().Path())
+	var child Property
+	if attr := n.Current().Attribute(); attr.IsComplex() && !attr.IsMultiValued() {
+		child, _ = n.Current().ChildAtIndex(name)
+	}
+	if child == nil {
+		n.err = &NoAttributeError{From: n.Current().Attribute().Path(), Name: name}
 		return n
 	}
 
@@ -123,9 +137,12 @@ func (n *defaultNavigator) At(index int) Navigator {
 		return n
 	}
 
-	child, err := n.Current().ChildAtIndex(index)
-	if err != nil {
-		n.err = fmt.Errorf("%w: no target at index '%d' from '%s'", spec.ErrNoTarget, index, n.Current().Attribute().Path())
+	var child Property
+	if n.Current().Attribute().MultiValued() {
+		child, _ = n.Current().ChildAtIndex(index)
+	}
+	if child == nil {
+		n.err = &NoTargetError{From: n.Current().Attribute().Path(), Index: index}
 		return n
 	}
 
@@ -140,7 +157,7 @@ func (n *defaultNavigator) Where(criteria func(child Property) bool) Navigator {
 
 	child := n.Current().FindChild(criteria)
 	if child == nil {
-		n.err = fmt.Errorf("%w: no target meeting criteria from '%s'", spec.ErrNoTarget, n.Current().Attribute().Path())
+		n.err = &NoTargetError{From: n.Current().Attribute().Path(), Criteria: true}
 		return n
 	}
 
@@ -179,6 +196,27 @@ func (n *defaultNavigator) Delete() Navigator {
 	return n
 }
 
+// Unassign clears the value of the Current property, unless it is already unassigned, and propagates events to
+// upstream properties.
+func (n *defaultNavigator) Unassign() Navigator {
+	if n.err == nil && n.Current().IsUnassigned() {
+		return n
+	}
+	return n.Delete()
+}
+
+// Respell delegates for Respell of the Current property and propagates events to upstream properties.
+func (n *defaultNavigator) Respell(value string) Navigator {
+	n.err = n.delegateMod(func() (event *Event, err error) {
+		r, ok := n.Current().(Respellable)
+		if !ok {
+			return nil, fmt.Errorf("%w: value of '%s' cannot be respelled", spec.ErrInvalidValue, n.Current().Attribute().Path())
+		}
+		return r.Respell(value)
+	})
+	return n
+}
+
 func (n *defaultNavigator) delegateMod(mod func() (*Event, error)) error {
 	if n.err != nil {
 		return n.err
@@ -1,5 +1,15 @@
 package prop
 
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 func ExampleNavigator() {
 	getResource := func() *Resource {
 		return &Resource{}
@@ -15,3 +25,215 @@ func ExampleNavigator() {
 	// access the property at the top of the trace stack
 	println(nav.Current().Raw())
 }
+
+// A root attribute with sub attributes of each kind to navigate.
+const navigatorTestAttribute = `
+{
+  "id": "root",
+  "name": "root",
+  "type": "complex",
+  "_path": "root",
+  "subAttributes": [
+    {"id": "userName", "name": "userName", "type": "string", "_path": "userName", "_index": 0},
+    {"id": "age", "name": "age", "type": "integer", "_path": "age", "_index": 1},
+    {"id": "active", "name": "active", "type": "boolean", "_path": "active", "_index": 2},
+    {
+      "id": "name",
+      "name": "name",
+      "type": "complex",
+      "_path": "name",
+      "_index": 3,
+      "subAttributes": [
+        {"id": "name.givenName", "name": "givenName", "type": "string", "_path": "name.givenName", "_index": 0}
+      ]
+    },
+    {"id": "tags", "name": "tags", "type": "string", "multiValued": true, "_path": "tags", "_index": 4},
+    {
+      "id": "emails",
+      "name": "emails",
+      "type": "complex",
+      "multiValued": true,
+      "_path": "emails",
+      "_index": 5,
+      "subAttributes": [
+        {"id": "emails.value", "name": "value", "type": "string", "_path": "emails.value", "_index": 0}
+      ]
+    }
+  ]
+}
+`
+
+func TestNavigatorUnassign(t *testing.T) {
+	attr := new(spec.Attribute)
+	require.Nil(t, json.Unmarshal([]byte(navigatorTestAttribute), attr))
+
+	tests := []struct {
+		name     string
+		navigate func(nav Navigator) Navigator
+		// data of the resource whose navigated property is unassigned, if some structure is necessary to navigate
+		unassigned map[string]interface{}
+	}{
+		{
+			name:     "string",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("userName") },
+		},
+		{
+			name:     "integer",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("age") },
+		},
+		{
+			name:     "boolean",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("active") },
+		},
+		{
+			name:     "complex",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("name") },
+		},
+		{
+			name:     "multiValued",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("tags") },
+		},
+		{
+			name:     "multiValued complex",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("emails") },
+		},
+		{
+			name:       "sub property of multiValued complex element",
+			navigate:   func(nav Navigator) Navigator { return nav.Dot("emails").At(0).Dot("value") },
+			unassigned: map[string]interface{}{"emails": []interface{}{map[string]interface{}{}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+" assigned", func(t *testing.T) {
+			root := NewComplexOf(attr, map[string]interface{}{
+				"userName": "imulab",
+				"age":      int64(30),
+				"active":   true,
+				"name":     map[string]interface{}{"givenName": "Weinan"},
+				"tags":     []interface{}{"foo", "bar"},
+				"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+			})
+			nav := test.navigate(Navigate(root))
+			require.Nil(t, nav.Error())
+			require.False(t, nav.Current().IsUnassigned())
+
+			assert.Nil(t, nav.Unassign().Error())
+			assert.True(t, nav.Current().IsUnassigned())
+			assert.True(t, nav.Current().Dirty())
+			assert.False(t, root.IsUnassigned())
+		})
+
+		t.Run(test.name+" unassigned", func(t *testing.T) {
+			root := NewComplexOf(attr, test.unassigned)
+			nav := test.navigate(Navigate(root))
+			require.Nil(t, nav.Error())
+			require.True(t, nav.Current().IsUnassigned())
+
+			assert.Nil(t, nav.Unassign().Error())
+			assert.True(t, nav.Current().IsUnassigned())
+			assert.False(t, nav.Current().Dirty())
+		})
+	}
+
+	t.Run("navigator in error", func(t *testing.T) {
+		nav := Navigate(NewComplex(attr)).Dot("foo")
+		require.NotNil(t, nav.Error())
+		assert.Equal(t, nav.Error(), nav.Unassign().Error())
+	})
+}
+
+func TestNavigatorErrors(t *testing.T) {
+	attr := new(spec.Attribute)
+	require.Nil(t, json.Unmarshal([]byte(navigatorTestAttribute), attr))
+
+	tests := []struct {
+		name     string
+		navigate func(nav Navigator) Navigator
+		expect   func(t *testing.T, err error)
+	}{
+		{
+			name:     "missing sub attribute",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("name").Dot("nickName") },
+			expect: func(t *testing.T, err error) {
+				var noAttr *NoAttributeError
+				require.True(t, errors.As(err, &noAttr))
+				assert.Equal(t, "name.nickName", noAttr.Path())
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+			},
+		},
+		{
+			name:     "missing sub attribute of element",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("emails").At(0).Dot("type") },
+			expect: func(t *testing.T, err error) {
+				var noAttr *NoAttributeError
+				require.True(t, errors.As(err, &noAttr))
+				assert.Equal(t, "emails.type", noAttr.Path())
+			},
+		},
+		{
+			name:     "sub attribute of simple property",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("userName").Dot("value") },
+			expect: func(t *testing.T, err error) {
+				var noAttr *NoAttributeError
+				require.True(t, errors.As(err, &noAttr))
+				assert.Equal(t, "userName.value", noAttr.Path())
+			},
+		},
+		{
+			name:     "sub attribute of multiValued property",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("emails").Dot("value") },
+			expect: func(t *testing.T, err error) {
+				var noAttr *NoAttributeError
+				require.True(t, errors.As(err, &noAttr))
+				assert.Equal(t, "emails.value", noAttr.Path())
+			},
+		},
+		{
+			name:     "index out of range",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("emails").At(1) },
+			expect: func(t *testing.T, err error) {
+				var noTarget *NoTargetError
+				require.True(t, errors.As(err, &noTarget))
+				assert.Equal(t, "emails", noTarget.From)
+				assert.Equal(t, 1, noTarget.Index)
+				assert.True(t, errors.Is(err, spec.ErrNoTarget))
+				assert.Equal(t, "noTarget: no target at index '1' from 'emails'", err.Error())
+			},
+		},
+		{
+			name:     "index of singular property",
+			navigate: func(nav Navigator) Navigator { return nav.Dot("name").At(0) },
+			expect: func(t *testing.T, err error) {
+				var noTarget *NoTargetError
+				require.True(t, errors.As(err, &noTarget))
+				assert.Equal(t, "name", noTarget.From)
+			},
+		},
+		{
+			name: "no element meeting criteria",
+			navigate: func(nav Navigator) Navigator {
+				return nav.Dot("tags").Where(func(child Property) bool { return child.Raw() == "baz" })
+			},
+			expect: func(t *testing.T, err error) {
+				var noTarget *NoTargetError
+				require.True(t, errors.As(err, &noTarget))
+				assert.True(t, noTarget.Criteria)
+				assert.Equal(t, "noTarget: no target meeting criteria from 'tags'", err.Error())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := NewComplexOf(attr, map[string]interface{}{
+				"userName": "imulab",
+				"name":     map[string]interface{}{"givenName": "Weinan"},
+				"tags":     []interface{}{"foo", "bar"},
+				"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+			})
+			nav := test.navigate(Navigate(root))
+			test.expect(t, nav.Error())
+		})
+	}
+}
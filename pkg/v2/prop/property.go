@@ -10,8 +10,8 @@ import (
 //
 // A Property may enclose other properties. Such property is known to be a container property. Default cases of container
 // property are the singleValued complex property and the multiValued property, as defined in SCIM. A non-container property
-// must return 0 to CountChildren and is generally indifferent to CountChildren, ForEachChild, FindChild and ChildAtIndex
-// methods.
+// must return 0 to CountChildren and is generally indifferent to CountChildren, ForEachChild, ForEachSubProperty,
+// FindChild and ChildAtIndex methods.
 type Property interface {
 	// Attribute always returns a non-nil attribute to describe this property.
 	Attribute() *spec.Attribute
@@ -74,8 +74,19 @@ type Property interface {
 	CountChildren() int
 	// ForEachChild iterates all children properties and invoke callback function.
 	ForEachChild(callback func(index int, child Property) error) error
+	// ForEachSubProperty iterates the sub properties of a complex property and invoke callback function with the name
+	// of the sub attribute. The iteration follows the declared order of the sub attributes in the schema. It stops at
+	// the first error returned by the callback. Other properties, including multiValued properties whose children are
+	// element properties, have no sub properties.
+	ForEachSubProperty(callback func(name string, sub Property) error) error
 	// FindChild returns the first children property that satisfies the criteria, or nil if none satisfies.
 	FindChild(criteria func(child Property) bool) Property
 	// ChildAtIndex returns the children property at given index. The type of index vary across implementations.
 	ChildAtIndex(index interface{}) (Property, error)
 }
+
+// Respellable is implemented by string properties, whose value may be respelled with different case. See
+// Navigator#Respell.
+type Respellable interface {
+	Respell(value string) (*Event, error)
+}
@@ -0,0 +1,79 @@
+package prop
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Range of integer and decimal values, as set by the minimum and maximum parameters of the @Constraints annotation.
+type numericRange struct {
+	min          *float64 // nil if not limited
+	max          *float64 // nil if not limited
+	exclusiveMin bool
+	exclusiveMax bool
+}
+
+func numericRangeOf(attr *spec.Attribute) *numericRange {
+	params, ok := attr.AnnotationParams(annotation.Constraints)
+	if !ok {
+		return nil
+	}
+
+	r := numericRange{}
+	if f, ok := params.Float("minimum"); ok {
+		r.min = &f
+		r.exclusiveMin, _ = params.Bool("exclusiveMinimum")
+	}
+	if f, ok := params.Float("maximum"); ok {
+		r.max = &f
+		r.exclusiveMax, _ = params.Bool("exclusiveMaximum")
+	}
+	if r.min == nil && r.max == nil {
+		return nil
+	}
+	return &r
+}
+
+// Returns ErrInvalidValue stating the allowed range, if the value is out of it. A nil range allows all values.
+func (r *numericRange) check(attr *spec.Attribute, value float64) error {
+	if r == nil {
+		return nil
+	}
+	if (r.min != nil && (value < *r.min || (r.exclusiveMin && value == *r.min))) ||
+		(r.max != nil && (value > *r.max || (r.exclusiveMax && value == *r.max))) {
+		return fmt.Errorf("%w: value %s for '%s' is outside of the allowed range %s", spec.ErrInvalidValue,
+			strconv.FormatFloat(value, 'f', -1, 64), attr.Path(), r.String())
+	}
+	return nil
+}
+
+// String returns the range in interval notation, i.e. [1, 10000] or (0, +inf).
+func (r *numericRange) String() string {
+	var sb strings.Builder
+	if r.min == nil {
+		sb.WriteString("(-inf")
+	} else {
+		if r.exclusiveMin {
+			sb.WriteString("(")
+		} else {
+			sb.WriteString("[")
+		}
+		sb.WriteString(strconv.FormatFloat(*r.min, 'f', -1, 64))
+	}
+	sb.WriteString(", ")
+	if r.max == nil {
+		sb.WriteString("+inf)")
+	} else {
+		sb.WriteString(strconv.FormatFloat(*r.max, 'f', -1, 64))
+		if r.exclusiveMax {
+			sb.WriteString(")")
+		} else {
+			sb.WriteString("]")
+		}
+	}
+	return sb.String()
+}
@@ -104,6 +104,10 @@ func (p *referenceProperty) Clone() Property {
 	return &c
 }
 
+func (p *referenceProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *referenceProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -161,6 +165,10 @@ func (p *referenceProperty) ForEachChild(_ func(index int, child Property) error
 	return nil
 }
 
+func (p *referenceProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *referenceProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
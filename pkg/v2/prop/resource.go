@@ -46,6 +46,15 @@ func (r *Resource) Clone() *Resource {
 	}
 }
 
+// Copy returns a deep copy of this resource whose properties own their subscribers, unlike those of Clone, so that the
+// state kept by subscribers, i.e. of @StateSummary, is not shared with the original. Like Clone, the values are copied
+// as they are, without being validated or normalized again, and without emitting any event.
+func (r *Resource) Copy() *Resource {
+	c := r.Clone()
+	mountSubscribers(c.data)
+	return c
+}
+
 // Navigator returns a navigator on the root property.
 func (r *Resource) Navigator() Navigator {
 	return Navigate(r.data)
@@ -2,15 +2,20 @@ package prop
 
 import (
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"hash/fnv"
+	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
-// NewString creates a new string property associated with attribute.
+// NewString creates a new string property associated with attribute. If the attribute is annotated with @Constraints,
+// the property rejects values violating the minLength, maxLength or pattern constraint.
 func NewString(attr *spec.Attribute) Property {
 	ensureSingularStringType(attr)
-	p := stringProperty{attr: attr, subscribers: []Subscriber{}}
+	p := stringProperty{attr: attr, subscribers: []Subscriber{}, constraints: stringConstraintsOf(attr)}
 	attr.ForEachAnnotation(func(annotation string, params map[string]interface{}) {
 		if subscriber, ok := SubscriberFactory().Create(annotation, &p, params); ok {
 			p.subscribers = append(p.subscribers, subscriber)
@@ -40,9 +45,63 @@ type stringProperty struct {
 	value       *string
 	hash        uint64
 	dirty       bool
+	constraints *stringConstraints // nil if not constrained
 	subscribers []Subscriber
 }
 
+// Constraints on string values, as set by the @Constraints annotation.
+type stringConstraints struct {
+	minLength int // minimum number of characters, or -1 if not limited
+	maxLength int // maximum number of characters, or -1 if not limited
+	pattern   *regexp.Regexp
+}
+
+// Compiled patterns of @Constraints, by their source. Patterns are compiled once, instead of for each property.
+var constraintPatterns sync.Map
+
+func stringConstraintsOf(attr *spec.Attribute) *stringConstraints {
+	params, ok := attr.AnnotationParams(annotation.Constraints)
+	if !ok {
+		return nil
+	}
+
+	c := stringConstraints{minLength: -1, maxLength: -1}
+	if n, ok := params.Int("minLength"); ok {
+		c.minLength = n
+	}
+	if n, ok := params.Int("maxLength"); ok {
+		c.maxLength = n
+	}
+	if pattern, ok := params.String("pattern"); ok {
+		if re, ok := constraintPatterns.Load(pattern); ok {
+			c.pattern = re.(*regexp.Regexp)
+		} else if re, err := regexp.Compile(pattern); err == nil { // invalid patterns are rejected when the schema is loaded
+			constraintPatterns.Store(pattern, re)
+			c.pattern = re
+		}
+	}
+	return &c
+}
+
+// Returns ErrInvalidValue stating the violated constraint, if any.
+func (p *stringProperty) checkConstraints(s string) error {
+	if p.constraints == nil {
+		return nil
+	}
+	if n := utf8.RuneCountInString(s); p.constraints.minLength >= 0 && n < p.constraints.minLength {
+		return fmt.Errorf("%w: value for '%s' is shorter than the minLength of %d characters", spec.ErrInvalidValue,
+			p.attr.Path(), p.constraints.minLength)
+	} else if p.constraints.maxLength >= 0 && n > p.constraints.maxLength {
+		return fmt.Errorf("%w: value for '%s' is longer than the maxLength of %d characters", spec.ErrInvalidValue,
+			p.attr.Path(), p.constraints.maxLength)
+	}
+	if p.constraints.pattern != nil && !p.constraints.pattern.MatchString(s) {
+		return fmt.Errorf("%w: value for '%s' does not match the pattern '%s'", spec.ErrInvalidValue,
+			p.attr.Path(), p.constraints.pattern.String())
+	}
+	return nil
+}
+
 func (p *stringProperty) Attribute() *spec.Attribute {
 	return p.attr
 }
@@ -95,6 +154,7 @@ func (p *stringProperty) Clone() Property {
 		value:       nil,
 		hash:        p.hash,
 		dirty:       p.dirty,
+		constraints: p.constraints,
 		subscribers: p.subscribers,
 	}
 	if p.value != nil {
@@ -104,6 +164,10 @@ func (p *stringProperty) Clone() Property {
 	return c
 }
 
+func (p *stringProperty) mountSubscribers() {
+	p.subscribers = subscribersOf(p)
+}
+
 func (p *stringProperty) Add(value interface{}) (*Event, error) {
 	if value == nil {
 		return p.Delete()
@@ -118,9 +182,12 @@ func (p *stringProperty) Replace(value interface{}) (*Event, error) {
 
 	if s, ok := value.(string); !ok {
 		return nil, p.errIncompatibleValue()
+	} else if err := p.checkConstraints(s); err != nil {
+		return nil, err
 	} else {
 		p.dirty = true
-		if !p.EqualsTo(s) {
+		// A value which only differs by case is still adopted, so that the client gets the spelling it asked for.
+		if p.value == nil || *(p.value) != s {
 			ev := Event{typ: EventAssigned, source: p, pre: p.Raw()}
 			p.value = &s
 			p.computeHash()
@@ -130,6 +197,24 @@ func (p *stringProperty) Replace(value interface{}) (*Event, error) {
 	}
 }
 
+// Respell replaces the value with another spelling of it, which only differs from it by case, i.e. its canonical
+// spelling, and emits an event if it differs from the current one. Unlike Replace, it fails with spec.ErrInvalidValue
+// when the value is not another spelling of the current one.
+func (p *stringProperty) Respell(value string) (*Event, error) {
+	if p.value == nil || !strings.EqualFold(*(p.value), value) {
+		return nil, fmt.Errorf("%w: '%s' is not a spelling of the value of '%s'", spec.ErrInvalidValue, value, p.attr.Path())
+	}
+	if *(p.value) == value {
+		return nil, nil
+	}
+
+	p.dirty = true
+	ev := Event{typ: EventAssigned, source: p, pre: p.Raw()}
+	p.value = &value
+	p.computeHash()
+	return &ev, nil
+}
+
 func (p *stringProperty) Delete() (*Event, error) {
 	p.dirty = true
 	if p.value != nil {
@@ -158,6 +243,10 @@ func (p *stringProperty) ForEachChild(_ func(index int, child Property) error) e
 	return nil
 }
 
+func (p *stringProperty) ForEachSubProperty(_ func(name string, sub Property) error) error {
+	return nil
+}
+
 func (p *stringProperty) FindChild(_ func(child Property) bool) Property {
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"strings"
 	"testing"
@@ -375,6 +376,15 @@ func (s *StringPropertyTestSuite) TestReplace() {
 				assert.Equal(t, "bar", raw)
 			},
 		},
+		{
+			name:  "replace with different case adopts the spelling",
+			prop:  NewStringOf(s.standardAttr, "foo"),
+			value: "FOO",
+			expect: func(t *testing.T, raw interface{}, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "FOO", raw)
+			},
+		},
 		{
 			name:  "replace incompatible value",
 			prop:  NewString(s.standardAttr),
@@ -393,6 +403,112 @@ func (s *StringPropertyTestSuite) TestReplace() {
 	}
 }
 
+func (s *StringPropertyTestSuite) TestRespell() {
+	tests := []struct {
+		name   string
+		prop   Property
+		value  string
+		expect func(t *testing.T, raw interface{}, ev *Event, err error)
+	}{
+		{
+			name:  "respell with different case",
+			prop:  NewStringOf(s.standardAttr, "foo"),
+			value: "FOO",
+			expect: func(t *testing.T, raw interface{}, ev *Event, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "FOO", raw)
+				require.NotNil(t, ev)
+				assert.Equal(t, EventAssigned, ev.Type())
+				assert.Equal(t, "foo", ev.PreModData())
+			},
+		},
+		{
+			name:  "respell with same spelling",
+			prop:  NewStringOf(s.standardAttr, "foo"),
+			value: "foo",
+			expect: func(t *testing.T, raw interface{}, ev *Event, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "foo", raw)
+				assert.Nil(t, ev)
+			},
+		},
+		{
+			name:  "respell with different value",
+			prop:  NewStringOf(s.standardAttr, "foo"),
+			value: "bar",
+			expect: func(t *testing.T, raw interface{}, ev *Event, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Equal(t, "foo", raw)
+			},
+		},
+		{
+			name:  "respell unassigned",
+			prop:  NewString(s.standardAttr),
+			value: "foo",
+			expect: func(t *testing.T, raw interface{}, ev *Event, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Nil(t, raw)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			ev, err := test.prop.(Respellable).Respell(test.value)
+			test.expect(t, test.prop.Raw(), ev, err)
+		})
+	}
+}
+
+func (s *StringPropertyTestSuite) TestConstraints() {
+	attr := s.mustAttribute(s.T(), strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:core:2.0:User:employeeId",
+  "name": "employeeId",
+  "type": "string",
+  "_path": "employeeId",
+  "_index": 10,
+  "_annotations": {
+    "@Constraints": {
+      "minLength": 3,
+      "maxLength": 6,
+      "pattern": "^E[0-9]+$"
+    }
+  }
+}`))
+
+	tests := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{name: "satisfies constraints", value: "E1234"},
+		{name: "too short", value: "E1", err: "value for 'employeeId' is shorter than the minLength of 3 characters"},
+		{name: "too long", value: "E123456", err: "value for 'employeeId' is longer than the maxLength of 6 characters"},
+		{name: "pattern mismatch", value: "X1234", err: "value for 'employeeId' does not match the pattern '^E[0-9]+$'"},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			p := NewString(attr)
+			_, err := p.Replace(test.value)
+			if len(test.err) == 0 {
+				assert.Nil(t, err)
+				assert.Equal(t, test.value, p.Raw())
+				return
+			}
+			assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			assert.Contains(t, err.Error(), test.err)
+			assert.True(t, p.IsUnassigned())
+		})
+	}
+
+	s.T().Run("clone keeps constraints", func(t *testing.T) {
+		_, err := NewStringOf(attr, "E1234").Clone().Replace("E")
+		assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+	})
+}
+
 func (s *StringPropertyTestSuite) TestDelete() {
 	tests := []struct {
 		name   string
@@ -2,7 +2,6 @@ package prop
 
 import (
 	"github.com/imulab/go-scim/pkg/v2/annotation"
-	"github.com/imulab/go-scim/pkg/v2/spec"
 	"sync"
 )
 
@@ -148,14 +147,16 @@ func (s *ExclusivePrimarySubscriber) Notify(publisher Property, events *Events)
 		if err != nil {
 			return err
 		}
-		events.Append(dev)
+		if dev != nil {
+			events.Append(dev)
+		}
 
 		return nil
 	})
 }
 
 func (s *ExclusivePrimarySubscriber) validPublisher(publisher Property) bool {
-	return publisher.Attribute().MultiValued() && publisher.Attribute().Type() == spec.TypeComplex
+	return publisher.Attribute().IsMultiValued() && publisher.Attribute().IsComplex()
 }
 
 func (s *ExclusivePrimarySubscriber) findPrimaryAssignedToTrueEvent(events *Events) *Event {
@@ -291,7 +292,69 @@ func (s *ComplexStateSummarySubscriber) Notify(publisher Property, events *Event
 }
 
 func (s *ComplexStateSummarySubscriber) validPublisher(publisher Property) bool {
-	return !publisher.Attribute().MultiValued() && publisher.Attribute().Type() == spec.TypeComplex
+	return !publisher.Attribute().IsMultiValued() && publisher.Attribute().IsComplex()
+}
+
+// Creates the subscribers of the property from the annotations of its attribute, as the property constructors do.
+func subscribersOf(publisher Property) []Subscriber {
+	subscribers := []Subscriber{}
+	publisher.Attribute().ForEachAnnotation(func(annotation string, params map[string]interface{}) {
+		if subscriber, ok := SubscriberFactory().Create(annotation, publisher, params); ok {
+			subscribers = append(subscribers, subscriber)
+		}
+	})
+	return subscribers
+}
+
+// Replaces the subscribers of the property and its children, recursively, with newly created ones. The children are
+// mounted first, so that subscribers initialized from the state of the publisher see the final state.
+func mountSubscribers(property Property) {
+	_ = property.ForEachChild(func(_ int, child Property) error {
+		mountSubscribers(child)
+		return nil
+	})
+	if m, ok := property.(interface{ mountSubscribers() }); ok {
+		m.mountSubscribers()
+	}
+}
+
+// RegisterNormalizer registers a normalizer with the annotation, i.e. to lowercase email addresses or to format phone
+// numbers in E.164. The normalizer is mounted onto properties whose attribute is annotated with the annotation, and is
+// invoked with the raw value whenever a value is assigned to the property, replacing the value with its result. Like
+// other subscribers, it reacts to events propagated through a Navigator, hence values assigned during deserialization
+// or by the crud operations are normalized. An error from the normalizer fails the assignment. The annotation is
+// registered with annotation.Register as well, so that schemas using it are accepted under any annotation policy.
+func RegisterNormalizer(name string, normalizer func(raw interface{}) (interface{}, error)) {
+	annotation.Register(name, nil)
+	ns := NormalizerSubscriber{normalize: normalizer}
+	SubscriberFactory().Register(name, func(_ Property, _ map[string]interface{}) Subscriber {
+		return &ns
+	})
+}
+
+// NormalizerSubscriber replaces the value of the property with its normalized form.
+//
+// It is mounted by annotations registered with RegisterNormalizer. The subscriber reacts to the assigned event from
+// the publisher itself. The value is replaced directly on the publisher, without generating new events, as the
+// upstream properties are yet to be notified of the assignment.
+type NormalizerSubscriber struct {
+	normalize func(raw interface{}) (interface{}, error)
+}
+
+func (s *NormalizerSubscriber) Notify(publisher Property, events *Events) error {
+	if events.FindEvent(func(ev *Event) bool {
+		return ev.Source() == publisher && ev.Type() == EventAssigned
+	}) == nil {
+		return nil
+	}
+
+	normalized, err := s.normalize(publisher.Raw())
+	if err != nil {
+		return err
+	}
+
+	_, err = publisher.Replace(normalized)
+	return err
 }
 
 func init() {
@@ -3,9 +3,11 @@ package prop
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
@@ -91,7 +93,8 @@ func TestExclusivePrimarySubscriber(t *testing.T) {
   "_path": "emails",
   "_index": 0,
   "_annotations": {
-    "@ExclusivePrimary": {}
+    "@ExclusivePrimary": {},
+    "@AutoCompact": {}
   }
 }
 `), attr))
@@ -133,6 +136,34 @@ func TestExclusivePrimarySubscriber(t *testing.T) {
 				}, raw)
 			},
 		},
+		{
+			name: "assigning primary when no element is primary",
+			getProperty: func(t *testing.T) Property {
+				return NewMultiOf(attrFunc(t), []interface{}{
+					map[string]interface{}{
+						"value": "foo",
+					},
+					map[string]interface{}{
+						"value": "bar",
+					},
+				})
+			},
+			modFunc: func(t *testing.T, p Property) {
+				assert.False(t, Navigate(p).At(0).Dot("primary").Replace(true).HasError())
+			},
+			expect: func(t *testing.T, raw interface{}) {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{
+						"value":   "foo",
+						"primary": true,
+					},
+					map[string]interface{}{
+						"value":   "bar",
+						"primary": nil,
+					},
+				}, raw)
+			},
+		},
 		{
 			name: "assigning old primary has no side effect",
 			getProperty: func(t *testing.T) Property {
@@ -227,6 +258,25 @@ func TestExclusivePrimarySubscriber(t *testing.T) {
 			test.expect(t, p.Raw())
 		})
 	}
+
+	t.Run("no event for elements which were not primary", func(t *testing.T) {
+		p := NewMultiOf(attrFunc(t), []interface{}{
+			map[string]interface{}{"value": "foo"},
+			map[string]interface{}{"value": "bar"},
+		})
+		primary, err := p.ChildAtIndex(0)
+		require.Nil(t, err)
+		primary, err = primary.ChildAtIndex("primary")
+		require.Nil(t, err)
+		ev, err := primary.Replace(true)
+		require.Nil(t, err)
+
+		events := ev.ToEvents()
+		require.Nil(t, new(ExclusivePrimarySubscriber).Notify(p, events))
+		assert.Nil(t, events.FindEvent(func(ev *Event) bool {
+			return ev == nil
+		}))
+	})
 }
 
 func TestSchemaSyncSubscriber(t *testing.T) {
@@ -516,6 +566,96 @@ func TestComplexStateSummarySubscriber(t *testing.T) {
 	}
 }
 
+func TestNormalizerSubscriber(t *testing.T) {
+	RegisterNormalizer("@LowerCase", func(raw interface{}) (interface{}, error) {
+		if s, ok := raw.(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return raw, nil
+	})
+	RegisterNormalizer("@Reject", func(raw interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("%w: rejected", spec.ErrInvalidValue)
+	})
+	assert.True(t, annotation.IsRegistered("@LowerCase"))
+	assert.True(t, annotation.IsRegistered("@Reject"))
+
+	attrFunc := func(t *testing.T, annotation string) *spec.Attribute {
+		attr := new(spec.Attribute)
+		require.Nil(t, json.Unmarshal([]byte(fmt.Sprintf(`
+{
+  "id": "emails",
+  "name": "emails",
+  "type": "complex",
+  "multiValued": true,
+  "caseExact": true,
+  "_path": "emails",
+  "_index": 0,
+  "_annotations": {
+    "@ElementAnnotations": {
+      "@StateSummary": {}
+    }
+  },
+  "subAttributes": [
+    {
+      "id": "emails.value",
+      "name": "value",
+      "type": "string",
+      "caseExact": true,
+      "_path": "emails.value",
+      "_index": 0,
+      "_annotations": {
+        "%s": {}
+      }
+    }
+  ]
+}
+`, annotation)), attr))
+		return attr
+	}
+
+	tests := []struct {
+		name       string
+		annotation string
+		modFunc    func(t *testing.T, p Property) error
+		expect     func(t *testing.T, p Property, err error)
+	}{
+		{
+			name:       "value is normalized upon assignment",
+			annotation: "@LowerCase",
+			modFunc: func(t *testing.T, p Property) error {
+				return Navigate(p).Add(map[string]interface{}{"value": "foo"}).
+					At(0).Dot("value").Replace("Foo@Bar.COM").Error()
+			},
+			expect: func(t *testing.T, p Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"value": "foo@bar.com"},
+				}, p.Raw())
+			},
+		},
+		{
+			name:       "normalizer error fails the assignment",
+			annotation: "@Reject",
+			modFunc: func(t *testing.T, p Property) error {
+				return Navigate(NewComplex(p.Attribute().DeriveElementAttribute())).
+					Dot("value").Replace("Foo@Bar.COM").Error()
+			},
+			expect: func(t *testing.T, p Property, err error) {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), "rejected")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewMulti(attrFunc(t, test.annotation))
+			err := test.modFunc(t, p)
+			test.expect(t, p, err)
+		})
+	}
+}
+
 // Internal implementation of Subscriber used in tests.
 type recordingSubscriber struct {
 	events *Events
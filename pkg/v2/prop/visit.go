@@ -1,7 +1,5 @@
 package prop
 
-import "github.com/imulab/go-scim/pkg/v2/spec"
-
 // Visitor defines behaviour for implementations to react to a passive Property structure traversal. It shall be used
 // in cases where caller does not have knowledge of resource structure and has to rely on a spontaneous DFS traversal.
 // By implementing this interface, caller will have some control over whether a property should be visited and be notified
@@ -31,7 +29,7 @@ func Visit(property Property, visitor Visitor) error {
 		return err
 	}
 
-	if property.Attribute().MultiValued() || property.Attribute().Type() == spec.TypeComplex {
+	if !property.Attribute().IsScalar() {
 		visitor.BeginChildren(property)
 		if err := property.ForEachChild(func(_ int, child Property) error {
 			return Visit(child, visitor)
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+const (
+	// Schema URN of the bulk request message
+	BulkRequestSchema = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	// Schema URN of the bulk response message
+	BulkResponseSchema = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	// Prefix of the values referring to a resource created by an earlier operation of the same bulk request
+	bulkIdPrefix = "bulkId:"
+)
+
+// BulkService returns a bulk service, which executes the operations of a bulk request against the services of the
+// endpoint their path refers to, i.e. "/Users" or "/Groups/{id}". The maxOperations and maxPayloadSize limits are
+// those of the bulk feature in the service provider config.
+func BulkService(config *spec.ServiceProviderConfig, endpoints ...BulkEndpoint) Bulk {
+	return &bulkService{
+		config:    config,
+		endpoints: endpoints,
+	}
+}
+
+type (
+	// Bulk service
+	Bulk interface {
+		Do(ctx context.Context, req *BulkRequest) (resp *BulkResponse, err error)
+	}
+	// BulkEndpoint are the services serving the resources of a resource type. A service may be nil, in which case
+	// the corresponding method fails for the endpoint.
+	BulkEndpoint struct {
+		ResourceType *spec.ResourceType
+		Create       Create
+		Replace      Replace
+		Patch        Patch
+		Delete       Delete
+	}
+	// Bulk request
+	BulkRequest struct {
+		PayloadSource io.Reader // reader source to read the bulk request message from
+	}
+	// Bulk response
+	BulkResponse struct {
+		Operations []*BulkOperationResponse // responses of the executed operations, in the order of the request
+	}
+	// BulkOperationResponse is the outcome of a single operation of the bulk request.
+	BulkOperationResponse struct {
+		Method   string         // method of the operation
+		BulkID   string         // bulkId of the operation, if any
+		Status   int            // HTTP status of the operation: 201, 200, 204 if not modified or deleted, or of Err
+		Version  string         // version of the resource after the operation, if any
+		Location string         // location of the resource, if known
+		Resource *prop.Resource // the resource after the operation, or nil if not modified, deleted or failed
+		Err      error          // error of the operation, or nil if succeeded
+	}
+	// BulkPayload is the bulk request message, as defined in RFC7644 section 3.7.
+	BulkPayload struct {
+		Schemas      []string        `json:"schemas"`
+		FailOnErrors int             `json:"failOnErrors"`
+		Operations   []BulkOperation `json:"Operations"`
+	}
+	// BulkOperation is a single operation of the bulk request message.
+	BulkOperation struct {
+		Method  string          `json:"method"`
+		BulkID  string          `json:"bulkId"`
+		Version string          `json:"version"`
+		Path    string          `json:"path"`
+		Data    json.RawMessage `json:"data"`
+	}
+)
+
+type bulkService struct {
+	config    *spec.ServiceProviderConfig
+	endpoints []BulkEndpoint
+}
+
+// Do executes the operations in order. The operations of the request are not atomic: a failed operation is reported
+// in its response and the following operations are still executed, until the number of failures reaches failOnErrors,
+// if positive. Failures of the request as a whole, i.e. a payload exceeding maxPayloadSize, or more operations than
+// maxOperations (spec.ErrTooMany), are returned as error.
+//
+// A value of "bulkId:qwerty" anywhere in the data or path of an operation is replaced by the id of the resource
+// created by the earlier operation with the bulkId "qwerty". A reference to a bulkId which was not created, i.e.
+// because its operation failed or comes later, fails the referring operation with spec.ErrInvalidValue.
+func (s *bulkService) Do(ctx context.Context, req *BulkRequest) (resp *BulkResponse, err error) {
+	if !s.config.Bulk.Supported {
+		err = fmt.Errorf("%w: bulk is not supported", spec.ErrInternal)
+		return
+	}
+
+	payload, err := s.parseRequest(req)
+	if err != nil {
+		return
+	}
+	if err = payload.Validate(); err != nil {
+		return
+	}
+	if len(payload.Operations) > s.config.Bulk.MaxOp {
+		err = fmt.Errorf("%w: bulk request has %d operations, but at most %d are allowed", spec.ErrTooMany,
+			len(payload.Operations), s.config.Bulk.MaxOp)
+		return
+	}
+
+	resp = &BulkResponse{Operations: []*BulkOperationResponse{}}
+	ids := map[string]string{}
+	failures := 0
+	for _, op := range payload.Operations {
+		opResp := s.execute(ctx, op, ids)
+		resp.Operations = append(resp.Operations, opResp)
+
+		if opResp.Err != nil {
+			failures++
+			if payload.FailOnErrors > 0 && failures >= payload.FailOnErrors {
+				break
+			}
+			continue
+		}
+		if len(op.BulkID) > 0 && opResp.Resource != nil {
+			ids[op.BulkID] = opResp.Resource.IdOrEmpty()
+		}
+	}
+	return
+}
+
+func (s *bulkService) parseRequest(req *BulkRequest) (*BulkPayload, error) {
+	if req == nil || req.PayloadSource == nil {
+		return nil, fmt.Errorf("%w: no payload for bulk service", spec.ErrInternal)
+	}
+
+	raw, err := ioutil.ReadAll(io.LimitReader(req.PayloadSource, int64(s.config.Bulk.MaxPayload)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read request body", spec.ErrInternal)
+	}
+	if len(raw) > s.config.Bulk.MaxPayload {
+		return nil, fmt.Errorf("%w: bulk request exceeds the maximum payload size of %d bytes", spec.ErrTooMany,
+			s.config.Bulk.MaxPayload)
+	}
+
+	if err := scimjson.CheckDuplicateKeys(raw); err != nil {
+		return nil, err
+	}
+
+	var payload BulkPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("%w: invalid bulk request", spec.ErrInvalidSyntax)
+	}
+	return &payload, nil
+}
+
+// Validate checks the bulk request message has the BulkRequest schema and a non negative failOnErrors.
+func (p *BulkPayload) Validate() error {
+	if len(p.Schemas) != 1 || p.Schemas[0] != BulkRequestSchema {
+		return fmt.Errorf("%w: bulk request must have the schema '%s'", spec.ErrInvalidSyntax, BulkRequestSchema)
+	}
+	if p.FailOnErrors < 0 {
+		return fmt.Errorf("%w: failOnErrors must not be negative", spec.ErrInvalidValue)
+	}
+	return nil
+}
+
+// Executes a single operation, resolving its bulkId references with the ids created so far.
+func (s *bulkService) execute(ctx context.Context, op BulkOperation, ids map[string]string) *BulkOperationResponse {
+	resp := &BulkOperationResponse{
+		Method:  strings.ToUpper(op.Method),
+		BulkID:  op.BulkID,
+		Version: op.Version,
+	}
+	fail := func(err error) *BulkOperationResponse {
+		resp.Err = err
+		resp.Status = spec.DetailsOf(err).Status
+		return resp
+	}
+
+	endpoint, id, err := s.route(op, ids)
+	if err != nil {
+		return fail(err)
+	}
+	if len(id) > 0 {
+		resp.Location = strings.TrimSuffix(endpoint.ResourceType.Endpoint(), "/") + "/" + id
+	}
+
+	var data io.Reader
+	if resp.Method != http.MethodDelete {
+		if len(op.Data) == 0 {
+			return fail(fmt.Errorf("%w: operation requires data", spec.ErrInvalidSyntax))
+		}
+		// resolveBulkIds decodes the data into a map, which would keep the last of duplicate keys
+		if err := scimjson.CheckDuplicateKeys(op.Data); err != nil {
+			return fail(err)
+		}
+		raw, err := resolveBulkIds(op.Data, ids)
+		if err != nil {
+			return fail(err)
+		}
+		data = bytes.NewReader(raw)
+	}
+
+	// the resource whose location and version are reported
+	var current *prop.Resource
+	switch resp.Method {
+	case http.MethodPost:
+		if endpoint.Create == nil {
+			return fail(fmt.Errorf("%w: create is not supported on '%s'", spec.ErrInvalidSyntax, op.Path))
+		}
+		created, err := endpoint.Create.Do(ctx, &CreateRequest{PayloadSource: data})
+		if err != nil {
+			return fail(err)
+		}
+		resp.Status, resp.Resource, current = http.StatusCreated, created.Resource, created.Resource
+	case http.MethodPut:
+		if endpoint.Replace == nil {
+			return fail(fmt.Errorf("%w: replace is not supported on '%s'", spec.ErrInvalidSyntax, op.Path))
+		}
+		replaced, err := endpoint.Replace.Do(ctx, &ReplaceRequest{ResourceID: id, PayloadSource: data, ExpectedVersion: op.Version})
+		if err != nil {
+			return fail(err)
+		}
+		resp.Status, resp.Resource, current = http.StatusOK, replaced.Resource, replaced.Resource
+		if !replaced.Replaced {
+			resp.Status, current = http.StatusNoContent, replaced.Ref
+		}
+	case http.MethodPatch:
+		if endpoint.Patch == nil {
+			return fail(fmt.Errorf("%w: patch is not supported on '%s'", spec.ErrInvalidSyntax, op.Path))
+		}
+		patched, err := endpoint.Patch.Do(ctx, &PatchRequest{ResourceID: id, PayloadSource: data, ExpectedVersion: op.Version})
+		if err != nil {
+			return fail(err)
+		}
+		resp.Status, resp.Resource, current = http.StatusOK, patched.Resource, patched.Resource
+		if !patched.Patched {
+			resp.Status, resp.Resource, current = http.StatusNoContent, nil, patched.Ref
+		}
+	case http.MethodDelete:
+		if endpoint.Delete == nil {
+			return fail(fmt.Errorf("%w: delete is not supported on '%s'", spec.ErrInvalidSyntax, op.Path))
+		}
+		if _, err := endpoint.Delete.Do(ctx, &DeleteRequest{ResourceID: id, ExpectedVersion: op.Version}); err != nil {
+			return fail(err)
+		}
+		resp.Status = http.StatusNoContent
+		resp.Version = ""
+	}
+
+	if current != nil {
+		if location := current.MetaLocationOrEmpty(); len(location) > 0 {
+			resp.Location = location
+		}
+		resp.Version = current.MetaVersionOrEmpty()
+	}
+	return resp
+}
+
+// Returns the endpoint and the resource id the path of the operation refers to. POST operations refer to the endpoint,
+// i.e. "/Users", and the other operations to a resource of the endpoint, i.e. "/Users/{id}" or "/Users/bulkId:qwerty".
+func (s *bulkService) route(op BulkOperation, ids map[string]string) (*BulkEndpoint, string, error) {
+	method := strings.ToUpper(op.Method)
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported bulk operation method '%s'", spec.ErrInvalidSyntax, op.Method)
+	}
+	if method == http.MethodPost && len(op.BulkID) == 0 {
+		return nil, "", fmt.Errorf("%w: bulkId is required for POST operations", spec.ErrInvalidSyntax)
+	}
+
+	for i, endpoint := range s.endpoints {
+		prefix := strings.TrimSuffix(endpoint.ResourceType.Endpoint(), "/")
+		if !strings.HasPrefix(op.Path, prefix) {
+			continue
+		}
+
+		rest := op.Path[len(prefix):]
+		switch {
+		case method == http.MethodPost && (len(rest) == 0 || rest == "/"):
+			return &s.endpoints[i], "", nil
+		case method != http.MethodPost && strings.HasPrefix(rest, "/") && len(rest) > 1 && !strings.Contains(rest[1:], "/"):
+			id := rest[1:]
+			if strings.HasPrefix(id, bulkIdPrefix) {
+				resolved, ok := ids[strings.TrimPrefix(id, bulkIdPrefix)]
+				if !ok {
+					return nil, "", fmt.Errorf("%w: cannot resolve '%s'", spec.ErrInvalidValue, id)
+				}
+				id = resolved
+			}
+			return &s.endpoints[i], id, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: path '%s' is not a valid endpoint for %s operations", spec.ErrInvalidPath, op.Path, method)
+}
+
+// Returns the data with every string value "bulkId:xxx" replaced by the id created for the bulkId "xxx".
+func resolveBulkIds(data json.RawMessage, ids map[string]string) ([]byte, error) {
+	if !bytes.Contains(data, []byte(bulkIdPrefix)) {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: invalid operation data", spec.ErrInvalidSyntax)
+	}
+
+	var resolve func(v interface{}) (interface{}, error)
+	resolve = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case string:
+			if !strings.HasPrefix(v, bulkIdPrefix) {
+				return v, nil
+			}
+			id, ok := ids[strings.TrimPrefix(v, bulkIdPrefix)]
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot resolve '%s'", spec.ErrInvalidValue, v)
+			}
+			return id, nil
+		case []interface{}:
+			for i, elem := range v {
+				var err error
+				if v[i], err = resolve(elem); err != nil {
+					return nil, err
+				}
+			}
+			return v, nil
+		case map[string]interface{}:
+			for k, elem := range v {
+				var err error
+				if v[k], err = resolve(elem); err != nil {
+					return nil, err
+				}
+			}
+			return v, nil
+		default:
+			return v, nil
+		}
+	}
+
+	resolved, err := resolve(value)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to serialize operation data", spec.ErrInternal)
+	}
+	return raw, nil
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/db"
+	"github.com/imulab/go-scim/pkg/v2/service/filter"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBulkService(t *testing.T) {
+	s := new(BulkServiceTestSuite)
+	suite.Run(t, s)
+}
+
+type BulkServiceTestSuite struct {
+	suite.Suite
+	userResourceType  *spec.ResourceType
+	groupResourceType *spec.ResourceType
+	config            *spec.ServiceProviderConfig
+}
+
+func (s *BulkServiceTestSuite) TestDo() {
+	tests := []struct {
+		name    string
+		payload string
+		expect  func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB)
+	}{
+		{
+			name: "create a user and a group referring to it by bulkId",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "Operations": [
+    {
+      "method": "POST",
+      "path": "/Users",
+      "bulkId": "qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+        "userName": "alice"
+      }
+    },
+    {
+      "method": "POST",
+      "path": "/Groups",
+      "bulkId": "ytrewq",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+        "displayName": "Tour Guides",
+        "members": [{"value": "bulkId:qwerty"}]
+      }
+    }
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				require.Nil(t, err)
+				require.Len(t, resp.Operations, 2)
+
+				user := resp.Operations[0]
+				assert.Nil(t, user.Err)
+				assert.Equal(t, 201, user.Status)
+				assert.Equal(t, "POST", user.Method)
+				assert.Equal(t, "qwerty", user.BulkID)
+				assert.Equal(t, "/Users/"+user.Resource.IdOrEmpty(), user.Location)
+				assert.NotEmpty(t, user.Version)
+
+				group := resp.Operations[1]
+				assert.Nil(t, group.Err)
+				assert.Equal(t, 201, group.Status)
+				assert.Equal(t, user.Resource.IdOrEmpty(),
+					group.Resource.Navigator().Dot("members").At(0).Dot("value").Current().Raw())
+
+				n, err := groups.Count(context.Background(), "")
+				assert.Nil(t, err)
+				assert.Equal(t, 1, n)
+			},
+		},
+		{
+			name: "patch, replace and delete a resource created by bulkId",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "Operations": [
+    {
+      "method": "POST",
+      "path": "/Users",
+      "bulkId": "qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+        "userName": "bob"
+      }
+    },
+    {
+      "method": "PATCH",
+      "path": "/Users/bulkId:qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+        "Operations": [{"op": "replace", "path": "displayName", "value": "Bob"}]
+      }
+    },
+    {
+      "method": "PUT",
+      "path": "/Users/bulkId:qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+        "userName": "bob",
+        "displayName": "Robert"
+      }
+    },
+    {
+      "method": "DELETE",
+      "path": "/Users/bulkId:qwerty"
+    }
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				require.Nil(t, err)
+				require.Len(t, resp.Operations, 4)
+				for _, op := range resp.Operations {
+					assert.Nil(t, op.Err)
+				}
+
+				id := resp.Operations[0].Resource.IdOrEmpty()
+				assert.Equal(t, 200, resp.Operations[1].Status)
+				assert.Equal(t, "Bob", resp.Operations[1].Resource.Navigator().Dot("displayName").Current().Raw())
+				assert.Equal(t, 200, resp.Operations[2].Status)
+				assert.Equal(t, "Robert", resp.Operations[2].Resource.Navigator().Dot("displayName").Current().Raw())
+				assert.Equal(t, 204, resp.Operations[3].Status)
+				assert.Equal(t, "/Users/"+id, resp.Operations[3].Location)
+
+				_, err = users.Get(context.Background(), id, nil)
+				assert.True(t, errors.Is(err, spec.ErrNotFound))
+			},
+		},
+		{
+			name: "failed operations are reported and do not stop the others",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "Operations": [
+    {
+      "method": "PATCH",
+      "path": "/Users/bulkId:unknown",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+        "Operations": [{"op": "replace", "path": "displayName", "value": "Bob"}]
+      }
+    },
+    {
+      "method": "DELETE",
+      "path": "/Users/does-not-exist"
+    },
+    {
+      "method": "POST",
+      "path": "/Devices",
+      "bulkId": "device"
+    },
+    {
+      "method": "POST",
+      "path": "/Users",
+      "bulkId": "qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+        "userName": "carol"
+      }
+    }
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				require.Nil(t, err)
+				require.Len(t, resp.Operations, 4)
+				assert.True(t, errors.Is(resp.Operations[0].Err, spec.ErrInvalidValue))
+				assert.Equal(t, 400, resp.Operations[0].Status)
+				assert.True(t, errors.Is(resp.Operations[1].Err, spec.ErrNotFound))
+				assert.Equal(t, 404, resp.Operations[1].Status)
+				assert.True(t, errors.Is(resp.Operations[2].Err, spec.ErrInvalidPath))
+				assert.Nil(t, resp.Operations[3].Err)
+				assert.Equal(t, 201, resp.Operations[3].Status)
+			},
+		},
+		{
+			name: "failOnErrors stops after the given number of errors",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "failOnErrors": 1,
+  "Operations": [
+    {
+      "method": "DELETE",
+      "path": "/Users/does-not-exist"
+    },
+    {
+      "method": "POST",
+      "path": "/Users",
+      "bulkId": "qwerty",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+        "userName": "dave"
+      }
+    }
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				require.Nil(t, err)
+				require.Len(t, resp.Operations, 1)
+				assert.Equal(t, 404, resp.Operations[0].Status)
+
+				n, err := users.Count(context.Background(), "")
+				assert.Nil(t, err)
+				assert.Equal(t, 0, n)
+			},
+		},
+		{
+			name: "more operations than maxOperations",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "Operations": [
+    {"method": "DELETE", "path": "/Users/1"},
+    {"method": "DELETE", "path": "/Users/2"},
+    {"method": "DELETE", "path": "/Users/3"},
+    {"method": "DELETE", "path": "/Users/4"},
+    {"method": "DELETE", "path": "/Users/5"}
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				assert.True(t, errors.Is(err, spec.ErrTooMany))
+			},
+		},
+		{
+			name:    "payload larger than maxPayloadSize",
+			payload: `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"], "Operations": []}` + strings.Repeat(" ", 4096),
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				assert.True(t, errors.Is(err, spec.ErrTooMany))
+			},
+		},
+		{
+			name:    "duplicate keys in the bulk request",
+			payload: `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"], "Operations": [], "operations": []}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+		{
+			name: "duplicate keys in the data of an operation referring to a bulkId",
+			payload: `
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+  "Operations": [
+    {
+      "method": "POST",
+      "path": "/Groups",
+      "bulkId": "ytrewq",
+      "data": {
+        "schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+        "displayName": "Tour Guides",
+        "members": [{"value": "bulkId:qwerty"}],
+        "displayName": "Guides"
+      }
+    }
+  ]
+}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				n, err := groups.Count(context.Background(), "")
+				assert.Nil(t, err)
+				assert.Equal(t, 0, n)
+			},
+		},
+		{
+			name:    "missing bulk request schema",
+			payload: `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": []}`,
+			expect: func(t *testing.T, resp *BulkResponse, err error, users db.DB, groups db.DB) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			users, groups := db.Memory(), db.Memory()
+			service := BulkService(s.config, s.endpoint(s.userResourceType, users), s.endpoint(s.groupResourceType, groups))
+			resp, err := service.Do(context.Background(), &BulkRequest{PayloadSource: strings.NewReader(test.payload)})
+			test.expect(t, resp, err, users, groups)
+		})
+	}
+}
+
+func (s *BulkServiceTestSuite) endpoint(resourceType *spec.ResourceType, database db.DB) BulkEndpoint {
+	filters := []filter.ByResource{
+		filter.ByPropertyToByResource(
+			filter.ReadOnlyFilter(),
+			filter.UUIDFilter(),
+		),
+		filter.MetaFilter(),
+	}
+	return BulkEndpoint{
+		ResourceType: resourceType,
+		Create:       CreateService(resourceType, database, filters),
+		Replace:      ReplaceService(s.config, resourceType, database, filters),
+		Patch:        PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()}),
+		Delete:       DeleteService(s.config, database),
+	}
+}
+
+func (s *BulkServiceTestSuite) SetupSuite() {
+	var err error
+	s.userResourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	s.groupResourceType, err = spec.RegisterStandardGroupResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.userResourceType)
+	crud.Register(s.groupResourceType)
+
+	s.config, err = spec.NewServiceProviderConfigBuilder().Patch().Bulk(4, 1024).Build()
+	require.Nil(s.T(), err)
+}
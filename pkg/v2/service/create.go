@@ -12,13 +12,23 @@ import (
 	"io/ioutil"
 )
 
-// Create returns a create resource service.
-func CreateService(resourceType *spec.ResourceType, database db.DB, filters []filter.ByResource) Create {
-	return &createService{
+// Create returns a create resource service. options may be used to customize the behaviour of the service, i.e.
+// Intercept.
+func CreateService(resourceType *spec.ResourceType, database db.DB, filters []filter.ByResource, options ...CreateOptions) Create {
+	s := &createService{
 		resourceType: resourceType,
 		filters:      filters,
 		database:     database,
 	}
+	for _, opt := range options {
+		opt.applyCreate(s)
+	}
+	return s
+}
+
+// CreateOptions customizes the behaviour of the create service.
+type CreateOptions interface {
+	applyCreate(s *createService)
 }
 
 type (
@@ -40,6 +50,7 @@ type createService struct {
 	resourceType *spec.ResourceType
 	filters      []filter.ByResource
 	database     db.DB
+	interceptors Interceptors
 }
 
 func (s *createService) Do(ctx context.Context, req *CreateRequest) (resp *CreateResponse, err error) {
@@ -54,9 +65,14 @@ func (s *createService) Do(ctx context.Context, req *CreateRequest) (resp *Creat
 		}
 	}
 
+	if err = s.interceptors.Before(ctx, OperationCreate, resource); err != nil {
+		return
+	}
+
 	if err = s.database.Insert(ctx, resource); err != nil {
 		return
 	}
+	s.interceptors.After(ctx, OperationCreate, nil, resource)
 
 	resp = &CreateResponse{Resource: resource}
 	return
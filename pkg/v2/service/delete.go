@@ -2,18 +2,31 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
-// DeleteService returns a delete resource service
-func DeleteService(config *spec.ServiceProviderConfig, database db.DB) Delete {
-	return &deleteService{
+// DeleteService returns a delete resource service. When ETag is supported, the stored resource must have the expected
+// version, and meet the match criteria, i.e. those of an If-Match header, or the request fails with
+// spec.ErrVersionMismatch. The database deletes the resource only if it still has the version that was checked, so that
+// a resource modified concurrently by another request fails with the same error instead of being deleted.
+//
+// options may be used to customize the behaviour of the service, i.e. Intercept.
+func DeleteService(config *spec.ServiceProviderConfig, database db.DB, options ...DeleteOptions) Delete {
+	s := &deleteService{
 		Database: database,
 		Config:   config,
 	}
+	for _, opt := range options {
+		opt.applyDelete(s)
+	}
+	return s
+}
+
+// DeleteOptions customizes the behaviour of the delete service.
+type DeleteOptions interface {
+	applyDelete(s *deleteService)
 }
 
 type (
@@ -23,8 +36,9 @@ type (
 	}
 	// Delete resource request
 	DeleteRequest struct {
-		ResourceID    string                             // id of the resource to be deleted
-		MatchCriteria func(resource *prop.Resource) bool // extra criteria the resource has to meet in order to be deleted
+		ResourceID      string                             // id of the resource to be deleted
+		MatchCriteria   func(resource *prop.Resource) bool // extra criteria the resource has to meet in order to be deleted
+		ExpectedVersion string                             // expected meta.version of the resource, or empty to skip the check
 	}
 	// Delete resource response
 	DeleteResponse struct {
@@ -33,8 +47,9 @@ type (
 )
 
 type deleteService struct {
-	Database db.DB
-	Config   *spec.ServiceProviderConfig
+	Database     db.DB
+	Config       *spec.ServiceProviderConfig
+	interceptors Interceptors
 }
 
 func (s *deleteService) Do(ctx context.Context, req *DeleteRequest) (resp *DeleteResponse, err error) {
@@ -42,18 +57,23 @@ func (s *deleteService) Do(ctx context.Context, req *DeleteRequest) (resp *Delet
 	if err != nil {
 		return
 	}
+	if err = checkResourceType(resource); err != nil {
+		return
+	}
 
-	if s.Config.ETag.Supported && req.MatchCriteria != nil {
-		if !req.MatchCriteria(resource) {
-			err = fmt.Errorf("%w: resource does not meet pre condition", spec.ErrConflict)
-			return
-		}
+	if err = checkVersion(s.Config, resource, req.ExpectedVersion, req.MatchCriteria); err != nil {
+		return
+	}
+
+	if err = s.interceptors.Before(ctx, OperationDelete, resource); err != nil {
+		return
 	}
 
 	err = s.Database.Delete(ctx, resource)
 	if err != nil {
 		return
 	}
+	s.interceptors.After(ctx, OperationDelete, resource, nil)
 
 	resp = &DeleteResponse{Deleted: resource}
 	return
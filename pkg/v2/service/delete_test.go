@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
@@ -11,8 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -67,6 +64,82 @@ func (s *DeleteServiceTestSuite) TestDo() {
 				assert.Equal(t, spec.ErrNotFound, errors.Unwrap(err))
 			},
 		},
+		{
+			name: "delete with expected version",
+			setup: func(t *testing.T) Delete {
+				return s.versionedSetup(t)
+			},
+			getRequest: func() *DeleteRequest {
+				return &DeleteRequest{
+					ResourceID:      "foobar",
+					ExpectedVersion: "W/\"1\"",
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "delete with outdated version",
+			setup: func(t *testing.T) Delete {
+				return s.versionedSetup(t)
+			},
+			getRequest: func() *DeleteRequest {
+				return &DeleteRequest{
+					ResourceID:      "foobar",
+					ExpectedVersion: "W/\"0\"",
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrVersionMismatch))
+				assert.Equal(t, spec.ErrorDetails{Status: 412, ScimType: "invalidVers"}, spec.DetailsOf(err))
+			},
+		},
+		{
+			name: "delete not meeting match criteria",
+			setup: func(t *testing.T) Delete {
+				return s.versionedSetup(t)
+			},
+			getRequest: func() *DeleteRequest {
+				return &DeleteRequest{
+					ResourceID: "foobar",
+					MatchCriteria: func(resource *prop.Resource) bool {
+						return false
+					},
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrVersionMismatch))
+			},
+		},
+		{
+			name: "delete resource modified concurrently",
+			setup: func(t *testing.T) Delete {
+				database := db.Memory()
+				require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"id":   "foobar",
+					"meta": map[string]interface{}{"version": "W/\"1\""},
+				})))
+				config, err := spec.NewServiceProviderConfigBuilder().ETag().Build()
+				require.Nil(t, err)
+				return DeleteService(config, &modifyingDB{
+					DB: database,
+					modification: s.resourceOf(t, map[string]interface{}{
+						"id":   "foobar",
+						"meta": map[string]interface{}{"version": "W/\"2\""},
+					}),
+				})
+			},
+			getRequest: func() *DeleteRequest {
+				return &DeleteRequest{
+					ResourceID:      "foobar",
+					ExpectedVersion: "W/\"1\"",
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrVersionMismatch))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -78,6 +151,34 @@ func (s *DeleteServiceTestSuite) TestDo() {
 	}
 }
 
+func (s *DeleteServiceTestSuite) versionedSetup(t *testing.T) Delete {
+	database := db.Memory()
+	err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+		"id": "foobar",
+		"meta": map[string]interface{}{
+			"version": "W/\"1\"",
+		},
+	}))
+	require.Nil(t, err)
+
+	config, err := spec.NewServiceProviderConfigBuilder().ETag().Build()
+	require.Nil(t, err)
+	return DeleteService(config, database)
+}
+
+// A database which replaces the resource right before deleting it, as if another request modified it concurrently.
+type modifyingDB struct {
+	db.DB
+	modification *prop.Resource
+}
+
+func (d *modifyingDB) Delete(ctx context.Context, resource *prop.Resource) error {
+	if err := d.DB.Replace(ctx, resource, d.modification); err != nil {
+		return err
+	}
+	return d.DB.Delete(ctx, resource)
+}
+
 func (s *DeleteServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
 	r := prop.NewResource(s.resourceType)
 	require.Nil(t, r.Navigator().Replace(data).Error())
@@ -85,52 +186,8 @@ func (s *DeleteServiceTestSuite) resourceOf(t *testing.T, data interface{}) *pro
 }
 
 func (s *DeleteServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 }
@@ -8,7 +8,6 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"golang.org/x/crypto/bcrypt"
-	"strconv"
 )
 
 // BCryptFilter returns a ByProperty filter that hashes data using the BCrypt algorithm for string or binary properties
@@ -76,10 +75,9 @@ func (f bCryptPropertyFilter) bCryptAndReplace(nav prop.Navigator) error {
 		panic("unsupported type")
 	}
 
-	var cost int
-	params, _ := attr.Annotation(annotation.BCrypt)
-	cost, err := strconv.Atoi(fmt.Sprintf("%v", params["cost"]))
-	if err != nil || cost < 1 {
+	params, _ := attr.AnnotationParams(annotation.BCrypt)
+	cost, ok := params.Int("cost")
+	if !ok || cost < 1 {
 		cost = 10
 	}
 
@@ -0,0 +1,181 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Deprecation describes the assignment of an attribute annotated with @Deprecated.
+type Deprecation struct {
+	Attribute *spec.Attribute // the deprecated attribute
+	Message   string          // the "message" parameter of @Deprecated, or empty
+	Sunset    time.Time       // the "sunset" parameter of @Deprecated, or the zero time
+}
+
+// Warning returns the deprecation as the value of an HTTP Warning header, with the warn-code 299 for persistent
+// warnings, as defined in RFC7234 section 5.5, i.e.
+//
+//	299 - "attribute 'costCenter' is deprecated: use 'department' instead; sunset on 2027-01-01"
+func (d Deprecation) Warning() string {
+	text := fmt.Sprintf("attribute '%s' is deprecated", d.Attribute.Path())
+	if len(d.Message) > 0 {
+		text += ": " + d.Message
+	}
+	if !d.Sunset.IsZero() {
+		text += "; sunset on " + d.Sunset.UTC().Format("2006-01-02")
+	}
+	return fmt.Sprintf(`299 - "%s"`, strings.ReplaceAll(text, `"`, `\"`))
+}
+
+// DeprecationFilter returns a ByProperty filter that reports the assignment of attributes annotated with @Deprecated.
+// On create, assigned deprecated properties are reported; on replace and patch, only those whose value differs from
+// the reference property, so that values carried over from the stored resource are not reported. Unassigning a
+// deprecated property is never reported.
+//
+// Each report invokes the callback set by the OnDeprecation option, if any, and appends the Warning of the deprecation
+// to the warnings of the context, if the context was prepared with WithWarnings, so that the handler can surface them
+// as HTTP Warning headers. With the RejectAfterSunset option, assignments after the sunset of the attribute fail with
+// spec.ErrInvalidValue instead.
+func DeprecationFilter(options ...DeprecationOptions) ByProperty {
+	f := &deprecationPropertyFilter{
+		onDeprecation: func(context.Context, Deprecation) {},
+		clock:         time.Now,
+	}
+	for _, opt := range options {
+		opt.apply(f)
+	}
+	return f
+}
+
+// DeprecationOptions customizes the behaviour of DeprecationFilter.
+type DeprecationOptions interface {
+	apply(f *deprecationPropertyFilter)
+}
+
+// OnDeprecation returns a DeprecationOptions that invokes the callback on each assignment of a deprecated attribute,
+// i.e. to log it or to count it in metrics. The callback is not invoked for rejected assignments.
+func OnDeprecation(callback func(ctx context.Context, deprecation Deprecation)) DeprecationOptions {
+	return onDeprecation{callback: callback}
+}
+
+type onDeprecation struct {
+	callback func(ctx context.Context, deprecation Deprecation)
+}
+
+func (o onDeprecation) apply(f *deprecationPropertyFilter) {
+	if o.callback != nil {
+		f.onDeprecation = o.callback
+	}
+}
+
+// RejectAfterSunset returns a DeprecationOptions that rejects the assignment of a deprecated attribute whose sunset
+// has passed, according to the clock, which is time.Now if nil.
+func RejectAfterSunset(clock func() time.Time) DeprecationOptions {
+	return rejectAfterSunset{clock: clock}
+}
+
+type rejectAfterSunset struct {
+	clock func() time.Time
+}
+
+func (o rejectAfterSunset) apply(f *deprecationPropertyFilter) {
+	f.rejectAfterSunset = true
+	if o.clock != nil {
+		f.clock = o.clock
+	}
+}
+
+type deprecationPropertyFilter struct {
+	onDeprecation     func(ctx context.Context, deprecation Deprecation)
+	rejectAfterSunset bool
+	clock             func() time.Time
+}
+
+func (f *deprecationPropertyFilter) Supports(attribute *spec.Attribute) bool {
+	_, ok := attribute.Annotation(annotation.Deprecated)
+	return ok
+}
+
+func (f *deprecationPropertyFilter) Filter(ctx context.Context, _ *spec.ResourceType, nav prop.Navigator) error {
+	if nav.HasError() {
+		return nav.Error()
+	}
+	if nav.Current().IsUnassigned() {
+		return nil
+	}
+	return f.report(ctx, nav.Current().Attribute())
+}
+
+func (f *deprecationPropertyFilter) FilterRef(ctx context.Context, _ *spec.ResourceType, nav prop.Navigator, refNav prop.Navigator) error {
+	if nav.HasError() {
+		return nav.Error()
+	}
+	if nav.Current().IsUnassigned() {
+		return nil
+	}
+	if refNav != nil && !IsOutOfSync(refNav.Current()) && nav.Current().Matches(refNav.Current()) {
+		return nil
+	}
+	return f.report(ctx, nav.Current().Attribute())
+}
+
+func (f *deprecationPropertyFilter) report(ctx context.Context, attr *spec.Attribute) error {
+	params, _ := attr.AnnotationParams(annotation.Deprecated)
+	d := Deprecation{Attribute: attr}
+	d.Message, _ = params.String("message")
+	d.Sunset, _ = params.Time("sunset")
+
+	if f.rejectAfterSunset && !d.Sunset.IsZero() && !f.clock().Before(d.Sunset) {
+		return fmt.Errorf("%w: attribute '%s' is deprecated and was sunset on %s", spec.ErrInvalidValue,
+			attr.Path(), d.Sunset.UTC().Format("2006-01-02"))
+	}
+
+	f.onDeprecation(ctx, d)
+	if w, ok := ctx.Value(warningsKey{}).(*warnings); ok {
+		w.add(d.Warning())
+	}
+	return nil
+}
+
+// WithWarnings returns a context which collects the warnings reported by the filters, i.e. DeprecationFilter, while
+// serving the request. They are returned by Warnings.
+func WithWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsKey{}, new(warnings))
+}
+
+// Warnings returns the distinct warnings collected in the context prepared by WithWarnings, in the order they were
+// reported, or nil.
+func Warnings(ctx context.Context) []string {
+	w, ok := ctx.Value(warningsKey{}).(*warnings)
+	if !ok {
+		return nil
+	}
+	w.Lock()
+	defer w.Unlock()
+	return append([]string(nil), w.values...)
+}
+
+type warningsKey struct{}
+
+type warnings struct {
+	sync.Mutex
+	values []string
+}
+
+func (w *warnings) add(warning string) {
+	w.Lock()
+	defer w.Unlock()
+	for _, each := range w.values {
+		if each == warning {
+			return
+		}
+	}
+	w.values = append(w.values, warning)
+}
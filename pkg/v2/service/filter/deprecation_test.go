@@ -0,0 +1,141 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationFilter(t *testing.T) {
+	attr := new(spec.Attribute)
+	require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "urn:ietf:params:scim:schemas:extension:test:2.0:User:costCenter",
+  "name": "costCenter",
+  "type": "string",
+  "_path": "costCenter",
+  "_annotations": {
+    "@Deprecated": {
+      "message": "use \"department\" instead",
+      "sunset": "2027-01-01"
+    }
+  }
+}
+`), attr))
+	const warning = `299 - "attribute 'costCenter' is deprecated: use \"department\" instead; sunset on 2027-01-01"`
+
+	propertyOf := func(value interface{}) prop.Property {
+		p := prop.NewProperty(attr)
+		if value != nil {
+			_, err := p.Replace(value)
+			require.Nil(t, err)
+		}
+		return p
+	}
+	before := func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	after := func() time.Time { return time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		options   []DeprecationOptions
+		property  prop.Property
+		reference prop.Property
+		expect    func(t *testing.T, deprecations []Deprecation, warnings []string, err error)
+	}{
+		{
+			name:     "assigned on create",
+			property: propertyOf("4130"),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.Nil(t, err)
+				if assert.Len(t, deprecations, 1) {
+					assert.Equal(t, `use "department" instead`, deprecations[0].Message)
+					assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), deprecations[0].Sunset)
+				}
+				assert.Equal(t, []string{warning}, warnings)
+			},
+		},
+		{
+			name:     "unassigned on create",
+			property: propertyOf(nil),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.Nil(t, err)
+				assert.Empty(t, deprecations)
+				assert.Empty(t, warnings)
+			},
+		},
+		{
+			name:      "unchanged on replace",
+			property:  propertyOf("4130"),
+			reference: propertyOf("4130"),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.Nil(t, err)
+				assert.Empty(t, deprecations)
+				assert.Empty(t, warnings)
+			},
+		},
+		{
+			name:      "changed on replace",
+			property:  propertyOf("4140"),
+			reference: propertyOf("4130"),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, deprecations, 1)
+				assert.Equal(t, []string{warning}, warnings)
+			},
+		},
+		{
+			name:     "assigned before sunset in strict mode",
+			options:  []DeprecationOptions{RejectAfterSunset(before)},
+			property: propertyOf("4130"),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.Nil(t, err)
+				assert.Len(t, deprecations, 1)
+				assert.Equal(t, []string{warning}, warnings)
+			},
+		},
+		{
+			name:     "assigned after sunset in strict mode",
+			options:  []DeprecationOptions{RejectAfterSunset(after)},
+			property: propertyOf("4130"),
+			expect: func(t *testing.T, deprecations []Deprecation, warnings []string, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'costCenter' is deprecated and was sunset on 2027-01-01")
+				assert.Empty(t, deprecations)
+				assert.Empty(t, warnings)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var deprecations []Deprecation
+			filter := DeprecationFilter(append(test.options, OnDeprecation(func(_ context.Context, d Deprecation) {
+				deprecations = append(deprecations, d)
+			}))...)
+			require.True(t, filter.Supports(attr))
+
+			ctx := WithWarnings(context.Background())
+			var err error
+			if test.reference == nil {
+				err = filter.Filter(ctx, nil, prop.Navigate(test.property))
+			} else {
+				err = filter.FilterRef(ctx, nil, prop.Navigate(test.property), prop.Navigate(test.reference))
+			}
+
+			test.expect(t, deprecations, Warnings(ctx), err)
+		})
+	}
+
+	t.Run("without warnings in context", func(t *testing.T) {
+		err := DeprecationFilter().Filter(context.Background(), nil, prop.Navigate(propertyOf("4130")))
+		assert.Nil(t, err)
+		assert.Nil(t, Warnings(context.Background()))
+	})
+}
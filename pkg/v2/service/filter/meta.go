@@ -5,19 +5,64 @@ import (
 	"crypto/sha1"
 	"encoding/binary"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
-	"math/rand"
-	"strings"
 	"time"
 )
 
-// MetaFilter returns a ByResource filter that assigns and updates the meta core attribute.
-func MetaFilter() ByResource {
-	return metaFilter{}
+// MetaFilter returns a ByResource filter that assigns and updates the meta core attribute. The meta.created and
+// meta.lastModified timestamps are taken from the system clock, unless another one is supplied with the Clock option.
+//
+// Every write that changes the resource bumps meta.version, which is derived from the id and the hash of the resource,
+// including the timestamps and the previous version, so that it changes with every modification. The version is a weak
+// ETag, i.e. W/"0c1e...", unless the StrongETag option is supplied.
+func MetaFilter(options ...MetaOptions) ByResource {
+	f := metaFilter{clock: time.Now}
+	for _, opt := range options {
+		opt.apply(&f)
+	}
+	return f
 }
 
-type metaFilter struct{}
+// MetaOptions customizes the behaviour of MetaFilter.
+type MetaOptions interface {
+	apply(f *metaFilter)
+}
+
+// Clock returns MetaOptions to take the meta timestamps from the given clock instead of time.Now. Tests use it to
+// assert exact timestamps, and servers may use it to plug in a monotonic or synchronized time source.
+func Clock(clock func() time.Time) MetaOptions {
+	return metaClock{clock: clock}
+}
+
+type metaClock struct {
+	clock func() time.Time
+}
+
+func (c metaClock) apply(f *metaFilter) {
+	if c.clock != nil {
+		f.clock = c.clock
+	}
+}
+
+// StrongETag returns MetaOptions to render meta.version as a strong ETag, i.e. "0c1e...", instead of a weak one. Since
+// the version changes with the stored resource, it may be used for byte-range requests and caches that require strong
+// validators, as long as the representation of the resource does not depend on anything else.
+func StrongETag() MetaOptions {
+	return strongETag{}
+}
+
+type strongETag struct{}
+
+func (o strongETag) apply(f *metaFilter) {
+	f.strongETag = true
+}
+
+type metaFilter struct {
+	clock      func() time.Time
+	strongETag bool
+}
 
 func (f metaFilter) Filter(_ context.Context, resource *prop.Resource) error {
 	nav := resource.Navigator()
@@ -25,13 +70,16 @@ func (f metaFilter) Filter(_ context.Context, resource *prop.Resource) error {
 		return nav.Error()
 	}
 
+	// created and lastModified share the same instant
+	now := f.clock()
+
 	if err := f.assignResourceType(nav, resource.ResourceType()); err != nil {
 		return err
 	}
-	if err := f.assignCreatedTimeToNow(nav); err != nil {
+	if err := f.assignCreated(nav, now); err != nil {
 		return err
 	}
-	if err := f.assignLastModifiedToNow(nav); err != nil {
+	if err := f.assignLastModified(nav, now); err != nil {
 		return err
 	}
 	if err := f.assignLocation(nav, resource); err != nil {
@@ -45,7 +93,7 @@ func (f metaFilter) Filter(_ context.Context, resource *prop.Resource) error {
 }
 
 func (f metaFilter) FilterRef(_ context.Context, resource *prop.Resource, ref *prop.Resource) error {
-	if resource.Hash() == ref.Hash() {
+	if crud.Identical(resource, ref) {
 		return nil
 	}
 
@@ -54,7 +102,7 @@ func (f metaFilter) FilterRef(_ context.Context, resource *prop.Resource, ref *p
 		return nav.Error()
 	}
 
-	if err := f.assignLastModifiedToNow(nav); err != nil {
+	if err := f.assignLastModified(nav, f.clock()); err != nil {
 		return err
 	}
 	if err := f.assignNewVersion(nav, resource); err != nil {
@@ -73,22 +121,22 @@ func (f metaFilter) assignResourceType(nav prop.Navigator, resourceType *spec.Re
 	return nav.Replace(resourceType.ID()).Error()
 }
 
-func (f metaFilter) assignCreatedTimeToNow(nav prop.Navigator) error {
+func (f metaFilter) assignCreated(nav prop.Navigator, now time.Time) error {
 	if nav.Dot("created").HasError() {
 		return nav.Error()
 	}
 	defer nav.Retract()
 
-	return nav.Replace(time.Now().Format(spec.ISO8601)).Error()
+	return nav.Replace(now.Format(spec.ISO8601)).Error()
 }
 
-func (f metaFilter) assignLastModifiedToNow(nav prop.Navigator) error {
+func (f metaFilter) assignLastModified(nav prop.Navigator, now time.Time) error {
 	if nav.Dot("lastModified").HasError() {
 		return nav.Error()
 	}
 	defer nav.Retract()
 
-	return nav.Replace(time.Now().Format(spec.ISO8601)).Error()
+	return nav.Replace(now.Format(spec.ISO8601)).Error()
 }
 
 func (f metaFilter) assignLocation(nav prop.Navigator, resource *prop.Resource) error {
@@ -102,8 +150,7 @@ func (f metaFilter) assignLocation(nav prop.Navigator, resource *prop.Resource)
 		return fmt.Errorf("%w: empty id", spec.ErrInternal)
 	}
 
-	location := strings.TrimSuffix(resource.ResourceType().Endpoint(), "/") + "/" + id
-	return nav.Replace(location).Error()
+	return nav.Replace(resource.ResourceType().Location(id)).Error()
 }
 
 func (f metaFilter) assignNewVersion(nav prop.Navigator, resource *prop.Resource) error {
@@ -117,14 +164,16 @@ func (f metaFilter) assignNewVersion(nav prop.Navigator, resource *prop.Resource
 		return fmt.Errorf("%w: empty id", spec.ErrInternal)
 	}
 
-	ts := rand.Uint64()
-	tsBuf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(tsBuf, ts)
+	hashBuf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(hashBuf, resource.Hash())
 
 	sha := sha1.New()
 	sha.Write([]byte(id))
-	sha.Write(tsBuf)
+	sha.Write(hashBuf)
 	sum := sha.Sum(nil)
 
+	if f.strongETag {
+		return nav.Replace(fmt.Sprintf("\"%x\"", sum)).Error()
+	}
 	return nav.Replace(fmt.Sprintf("W/\"%x\"", sum)).Error()
 }
@@ -2,16 +2,14 @@ package filter
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
+	"time"
 )
 
 func TestMetaFilter(t *testing.T) {
@@ -167,53 +165,84 @@ func (s *MetaFilterTestSuite) TestMetaFilter() {
 	}
 }
 
-func (s *MetaFilterTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
+func (s *MetaFilterTestSuite) TestMetaFilterClock() {
+	now := time.Date(2020, 1, 19, 15, 15, 0, 0, time.UTC)
+	filter := MetaFilter(Clock(func() time.Time {
+		return now
+	}))
+
+	resource := prop.NewResource(s.resourceType)
+	require.False(s.T(), resource.Navigator().Replace(map[string]interface{}{
+		"id":       "c37527a1-b60f-4e30-8fd9-162a1740bdb6",
+		"userName": "foobar",
+	}).HasError())
+	require.Nil(s.T(), filter.Filter(context.Background(), resource))
+
+	nav := resource.Navigator().Dot("meta")
+	assert.Equal(s.T(), "2020-01-19T15:15:00", nav.Dot("created").Current().Raw())
+	nav.Retract()
+	assert.Equal(s.T(), "2020-01-19T15:15:00", nav.Dot("lastModified").Current().Raw())
+	nav.Retract()
+
+	// the clock is consulted again when the resource is updated
+	now = now.Add(time.Hour)
+	updated := prop.NewResource(s.resourceType)
+	require.False(s.T(), updated.Navigator().Replace(map[string]interface{}{
+		"id":       "c37527a1-b60f-4e30-8fd9-162a1740bdb6",
+		"userName": "changed!!!",
+		"meta": map[string]interface{}{
+			"created":      "2020-01-19T15:15:00",
+			"lastModified": "2020-01-19T15:15:00",
 		},
-		{
-			filepath:  "../../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+	}).HasError())
+	require.Nil(s.T(), filter.FilterRef(context.Background(), updated, resource))
+
+	nav = updated.Navigator().Dot("meta")
+	assert.Equal(s.T(), "2020-01-19T15:15:00", nav.Dot("created").Current().Raw())
+	nav.Retract()
+	assert.Equal(s.T(), "2020-01-19T16:15:00", nav.Dot("lastModified").Current().Raw())
+	nav.Retract()
+}
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+func (s *MetaFilterTestSuite) TestMetaFilterETag() {
+	now := time.Date(2020, 1, 19, 15, 15, 0, 0, time.UTC)
+	clock := Clock(func() time.Time {
+		return now
+	})
+	newResource := func() *prop.Resource {
+		resource := prop.NewResource(s.resourceType)
+		require.False(s.T(), resource.Navigator().Replace(map[string]interface{}{
+			"id":       "c37527a1-b60f-4e30-8fd9-162a1740bdb6",
+			"userName": "foobar",
+		}).HasError())
+		return resource
+	}
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+	weak, strong := newResource(), newResource()
+	require.Nil(s.T(), MetaFilter(clock).Filter(context.Background(), weak))
+	require.Nil(s.T(), MetaFilter(clock, StrongETag()).Filter(context.Background(), strong))
+
+	// the version is derived from the resource, hence stable for the same resource at the same time
+	assert.Regexp(s.T(), `^W/"[0-9a-f]{40}"$`, weak.MetaVersionOrEmpty())
+	assert.Regexp(s.T(), `^"[0-9a-f]{40}"$`, strong.MetaVersionOrEmpty())
+	assert.Equal(s.T(), weak.MetaVersionOrEmpty(), "W/"+strong.MetaVersionOrEmpty())
+
+	// every modification bumps the version, even if reverted within the same instant
+	modified := weak.Clone()
+	require.False(s.T(), modified.Navigator().Dot("userName").Replace("changed").HasError())
+	require.Nil(s.T(), MetaFilter(clock).FilterRef(context.Background(), modified, weak))
+	assert.NotEqual(s.T(), weak.MetaVersionOrEmpty(), modified.MetaVersionOrEmpty())
+
+	reverted := modified.Clone()
+	require.False(s.T(), reverted.Navigator().Dot("userName").Replace("foobar").HasError())
+	require.Nil(s.T(), MetaFilter(clock).FilterRef(context.Background(), reverted, modified))
+	assert.NotEqual(s.T(), weak.MetaVersionOrEmpty(), reverted.MetaVersionOrEmpty())
+	assert.NotEqual(s.T(), modified.MetaVersionOrEmpty(), reverted.MetaVersionOrEmpty())
+}
 
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+func (s *MetaFilterTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 }
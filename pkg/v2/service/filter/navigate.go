@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
@@ -151,6 +152,24 @@ func (n *flexNavigator) Delete() prop.Navigator {
 	return n
 }
 
+func (n *flexNavigator) Unassign() prop.Navigator {
+	if n.err == nil && n.Current().IsUnassigned() {
+		return n
+	}
+	return n.Delete()
+}
+
+func (n *flexNavigator) Respell(value string) prop.Navigator {
+	n.err = n.delegateMod(func() (event *prop.Event, err error) {
+		r, ok := n.Current().(prop.Respellable)
+		if !ok {
+			return nil, fmt.Errorf("%w: value of '%s' cannot be respelled", spec.ErrInvalidValue, n.Current().Attribute().Path())
+		}
+		return r.Respell(value)
+	})
+	return n
+}
+
 func (n *flexNavigator) delegateMod(mod func() (*prop.Event, error)) error {
 	if n.err != nil {
 		return n.err
@@ -241,6 +260,10 @@ func (p outOfSyncProperty) ForEachChild(_ func(index int, child prop.Property) e
 	return nil
 }
 
+func (p outOfSyncProperty) ForEachSubProperty(_ func(name string, sub prop.Property) error) error {
+	return nil
+}
+
 func (p outOfSyncProperty) FindChild(_ func(child prop.Property) bool) prop.Property {
 	return nil
 }
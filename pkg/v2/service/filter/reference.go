@@ -0,0 +1,142 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/db"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// ReferenceResolver enforces the referenceTypes of reference attributes, i.e. that the "$ref" of a group member
+// points at a User or a Group. It resolves the resource type of a reference among the registered resource types:
+//
+// A reference with a path, i.e. "https://example.com/v2/Users/2819c223" or "/Groups/e9e30dba", is resolved by matching
+// the endpoint of the resource types against the path segment preceding the id.
+//
+// A reference without path is taken as a resource id, i.e. the "value" of a group member whose "$ref" is not supplied.
+// The id is looked up in the database of each registered resource type.
+//
+// The reference types "external" and "uri" do not refer to a resource of the service provider. When declared by the
+// attribute, a reference that is a syntactically valid absolute URI is accepted without resolution.
+//
+// The resolver can be used by ValidationFilter with the EnforceReferenceTypes option, or on its own.
+type ReferenceResolver struct {
+	resourceTypes []*spec.ResourceType
+	databases     []db.DB
+}
+
+// NewReferenceResolver returns a ReferenceResolver without any resource types registered.
+func NewReferenceResolver() *ReferenceResolver {
+	return &ReferenceResolver{
+		resourceTypes: []*spec.ResourceType{},
+		databases:     []db.DB{},
+	}
+}
+
+// Register adds a resource type that references may resolve to, along with the database which stores its resources.
+// The database may be nil, in which case ids are never looked up for the resource type.
+func (r *ReferenceResolver) Register(resourceType *spec.ResourceType, database db.DB) *ReferenceResolver {
+	r.resourceTypes = append(r.resourceTypes, resourceType)
+	r.databases = append(r.databases, database)
+	return r
+}
+
+// Enforce checks that the reference, which is the value of the attribute, points at a resource of one of the
+// referenceTypes of the attribute. Attributes without referenceTypes are not checked. A reference that does not
+// resolve to any registered resource type, or resolves to one that is not allowed, is rejected with
+// spec.ErrInvalidValue.
+func (r *ReferenceResolver) Enforce(ctx context.Context, attr *spec.Attribute, reference string) error {
+	referenceTypes := attr.ReferenceTypes()
+	if len(referenceTypes) == 0 {
+		return nil
+	}
+
+	if allowsURI(referenceTypes) {
+		if u, err := url.Parse(reference); err == nil && u.IsAbs() {
+			return nil
+		}
+		if !allowsResource(referenceTypes) {
+			return fmt.Errorf("%w: '%s' is not a valid URI", spec.ErrInvalidValue, attr.Path())
+		}
+	}
+
+	resourceType, err := r.resolve(ctx, reference)
+	if err != nil {
+		return err
+	} else if resourceType == nil {
+		return fmt.Errorf("%w: '%s' does not reference any resource of %s", spec.ErrInvalidValue,
+			attr.Path(), strings.Join(referenceTypes, ", "))
+	}
+
+	for _, referenceType := range referenceTypes {
+		if strings.EqualFold(referenceType, resourceType.Name()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: '%s' references a resource of %s, but only %s are allowed", spec.ErrInvalidValue,
+		attr.Path(), resourceType.Name(), strings.Join(referenceTypes, ", "))
+}
+
+// Returns the resource type of the resource referenced by path, or by id if the reference has no path. A nil resource
+// type is returned if the reference does not resolve.
+func (r *ReferenceResolver) resolve(ctx context.Context, reference string) (*spec.ResourceType, error) {
+	u, err := url.Parse(reference)
+	if err != nil {
+		return nil, nil
+	}
+
+	if !strings.Contains(u.Path, "/") {
+		return r.lookup(ctx, reference)
+	}
+
+	segments := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	if len(segments) < 2 {
+		return nil, nil
+	}
+	endpoint := "/" + segments[len(segments)-2]
+	for _, resourceType := range r.resourceTypes {
+		if strings.EqualFold(resourceType.Endpoint(), endpoint) {
+			return resourceType, nil
+		}
+	}
+	return nil, nil
+}
+
+// Returns the first registered resource type whose database has a resource of the id.
+func (r *ReferenceResolver) lookup(ctx context.Context, id string) (*spec.ResourceType, error) {
+	filter := fmt.Sprintf("id eq %s", strconv.Quote(id))
+	for i, resourceType := range r.resourceTypes {
+		if r.databases[i] == nil {
+			continue
+		}
+		n, err := r.databases[i].Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		} else if n > 0 {
+			return resourceType, nil
+		}
+	}
+	return nil, nil
+}
+
+func allowsURI(referenceTypes []string) bool {
+	for _, referenceType := range referenceTypes {
+		if referenceType == spec.ReferenceTypeExternal || referenceType == spec.ReferenceTypeURI {
+			return true
+		}
+	}
+	return false
+}
+
+func allowsResource(referenceTypes []string) bool {
+	for _, referenceType := range referenceTypes {
+		if referenceType != spec.ReferenceTypeExternal && referenceType != spec.ReferenceTypeURI {
+			return true
+		}
+	}
+	return false
+}
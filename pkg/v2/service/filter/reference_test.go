@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/db"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceResolver(t *testing.T) {
+	userResourceType, err := spec.RegisterStandardUserResourceType()
+	require.Nil(t, err)
+	crud.Register(userResourceType)
+	groupResourceType, err := spec.RegisterStandardGroupResourceType()
+	require.Nil(t, err)
+	crud.Register(groupResourceType)
+
+	newResource := func(t *testing.T, resourceType *spec.ResourceType, data map[string]interface{}) *prop.Resource {
+		resource := prop.NewResource(resourceType)
+		_, err := resource.RootProperty().Replace(data)
+		require.Nil(t, err)
+		return resource
+	}
+
+	userDB, groupDB := db.Memory(), db.Memory()
+	require.Nil(t, userDB.Insert(context.Background(), newResource(t, userResourceType, map[string]interface{}{
+		"id":       "u1",
+		"userName": "foo",
+	})))
+	require.Nil(t, groupDB.Insert(context.Background(), newResource(t, groupResourceType, map[string]interface{}{
+		"id":          "g1",
+		"displayName": "bar",
+	})))
+
+	resolver := NewReferenceResolver().
+		Register(userResourceType, userDB).
+		Register(groupResourceType, groupDB)
+
+	t.Run("enforce", func(t *testing.T) {
+		memberRef := groupResourceType.SuperAttribute(false).SubAttributeForName("members").SubAttributeForName("$ref")
+		profileUrl := userResourceType.SuperAttribute(false).SubAttributeForName("profileUrl")
+		userOnly, err := spec.NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Owned", "Owned").
+			Attribute(spec.ReferenceAttr("owner", "User")).
+			Build()
+		require.Nil(t, err)
+		var owner *spec.Attribute
+		_ = userOnly.ForEachAttribute(func(attr *spec.Attribute) error {
+			owner = attr
+			return nil
+		})
+
+		for _, test := range []struct {
+			name      string
+			attr      *spec.Attribute
+			reference string
+			expectErr bool
+		}{
+			{name: "absolute url of allowed type", attr: memberRef, reference: "https://example.com/v2/Users/u1"},
+			{name: "relative url of allowed type", attr: memberRef, reference: "/Groups/g1"},
+			{name: "id of allowed type", attr: memberRef, reference: "g1"},
+			{name: "url of disallowed type", attr: owner, reference: "/Groups/g1", expectErr: true},
+			{name: "id of disallowed type", attr: owner, reference: "g1", expectErr: true},
+			{name: "url of unknown endpoint", attr: memberRef, reference: "/Schemas/g1", expectErr: true},
+			{name: "unknown id", attr: memberRef, reference: "nobody", expectErr: true},
+			{name: "external uri", attr: profileUrl, reference: "https://example.com/profiles/foo"},
+			{name: "invalid external uri", attr: profileUrl, reference: "profiles/foo", expectErr: true},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				err := resolver.Enforce(context.Background(), test.attr, test.reference)
+				if test.expectErr {
+					assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				} else {
+					assert.Nil(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("validation filter", func(t *testing.T) {
+		newGroup := func(t *testing.T, members ...interface{}) *prop.Resource {
+			return newResource(t, groupResourceType, map[string]interface{}{
+				"schemas":     []interface{}{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+				"id":          "g2",
+				"displayName": "baz",
+				"members":     members,
+			})
+		}
+
+		for _, test := range []struct {
+			name      string
+			members   []interface{}
+			expectErr bool
+		}{
+			{
+				name:    "member by value",
+				members: []interface{}{map[string]interface{}{"value": "u1"}},
+			},
+			{
+				name:    "member by value and $ref",
+				members: []interface{}{map[string]interface{}{"value": "g1", "$ref": "/Groups/g1"}},
+			},
+			{
+				name:      "unknown member by value",
+				members:   []interface{}{map[string]interface{}{"value": "nobody"}},
+				expectErr: true,
+			},
+			{
+				name:      "member $ref of unknown endpoint",
+				members:   []interface{}{map[string]interface{}{"value": "u1", "$ref": "/Devices/u1"}},
+				expectErr: true,
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				err := Visit(context.Background(), newGroup(t, test.members...),
+					ValidationFilter(db.Memory(), EnforceReferenceTypes(resolver)))
+				if test.expectErr {
+					assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				} else {
+					assert.Nil(t, err)
+				}
+			})
+		}
+
+		t.Run("not enforced without option", func(t *testing.T) {
+			err := Visit(context.Background(), newGroup(t, map[string]interface{}{"value": "nobody"}), ValidationFilter(db.Memory()))
+			assert.Nil(t, err)
+		})
+	})
+}
@@ -55,8 +55,8 @@ func (f readOnlyPropertyFilter) FilterRef(_ context.Context, _ *spec.ResourceTyp
 
 func (f readOnlyPropertyFilter) tryReset(nav prop.Navigator) error {
 	attr := nav.Current().Attribute()
-	params, _ := attr.Annotation(annotation.ReadOnly)
-	if wantReset, ok := params["reset"].(bool); !ok || !wantReset {
+	params, _ := attr.AnnotationParams(annotation.ReadOnly)
+	if wantReset, ok := params.Bool("reset"); !ok || !wantReset {
 		return nil
 	}
 
@@ -65,8 +65,8 @@ func (f readOnlyPropertyFilter) tryReset(nav prop.Navigator) error {
 
 func (f readOnlyPropertyFilter) tryCopy(nav prop.Navigator, refNav prop.Navigator) error {
 	attr := nav.Current().Attribute()
-	params, _ := attr.Annotation(annotation.ReadOnly)
-	if wantCopy, ok := params["copy"].(bool); !ok || !wantCopy {
+	params, _ := attr.AnnotationParams(annotation.ReadOnly)
+	if wantCopy, ok := params.Bool("copy"); !ok || !wantCopy {
 		return nil
 	}
 
@@ -3,10 +3,9 @@ package filter
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
@@ -15,11 +14,14 @@ import (
 // ValidationFilter returns a ByProperty that performs validation on each property. The validation carried out are
 // required check, canonical check, mutability check and uniqueness check.
 //
-// The required check fails when attribute is required but property is unassigned.
+// The required check fails when attribute is required but property is unassigned. For a schema extension declared as
+// required by the resource type, the check also fails when "schemas" does not include the extension URN.
 //
 // The canonical check fails when @Enum is annotated with the attribute, indicating that the canonicalValues
 // defined should be treated as the only valid values of holding property, and the property value is not among
-// the canonicalValues.
+// the canonicalValues. If normalization is enabled, either by the "normalize" parameter of @Enum or globally by the
+// NormalizeCanonicalValues option, values differing from a canonical value only by case are replaced by the canonical
+// spelling instead, so that later filters on the attribute match the stored value.
 //
 // The mutability check only fails when attribute is immutable, and the property value differs from the reference
 // property value, if one exists. It does not check for readOnly attributes because the logic is largely handled
@@ -28,15 +30,81 @@ import (
 // The uniqueness check fails when the property value already exists in the database. It formulates the query
 // (id ne <id>) and (<path> eq <value>), where <id> is the resource id, <path> is the unique attribute path, and
 // <value> is the property value. The database returns the number of records matching this filter. If the count is
-// greater than 0, the check fails. Note this check only handles the uniqueness=server case.
+// greater than 0, the check fails. Values of attributes with uniqueness=global are checked in the database as well, and
+// then, with the GlobalUniqueness option, by an authority beyond this server.
 //
-// Error is returned to caller if any of these check fails.
-func ValidationFilter(database db.DB) ByProperty {
-	return &validationPropertyFilter{database: database}
+// The reference check fails when a reference value is not a valid URI, or is not absolute while "external" is the only
+// referenceTypes of the attribute. With the EnforceReferenceTypes option, it also fails when an assigned reference
+// attribute declaring referenceTypes does not point at a resource of an allowed type. For a complex property whose
+// "$ref" sub attribute declares referenceTypes but is unassigned, i.e. a group member with only "value", the "value"
+// is checked as the id of the referenced resource instead. Properties not changed from the reference property are not
+// checked again.
+//
+// The required, canonical, uniqueness=server and reference checks are those of crud.Validate, see crud.CheckRequired,
+// crud.CheckCanonical, crud.CheckUniqueness and crud.CheckReference. Error is returned to caller if any of these check
+// fails.
+func ValidationFilter(database db.DB, options ...ValidationOptions) ByProperty {
+	f := &validationPropertyFilter{database: database}
+	for _, opt := range options {
+		opt.apply(f)
+	}
+	return f
+}
+
+// ValidationOptions customizes the behaviour of ValidationFilter.
+type ValidationOptions interface {
+	apply(f *validationPropertyFilter)
+}
+
+// NormalizeCanonicalValues returns a ValidationOptions that enables the normalization of canonical values for all
+// attributes annotated with @Enum, as if they all had the "normalize" parameter set to true.
+func NormalizeCanonicalValues() ValidationOptions {
+	return normalizeCanonicalValues{}
+}
+
+type normalizeCanonicalValues struct{}
+
+func (o normalizeCanonicalValues) apply(f *validationPropertyFilter) {
+	f.normalize = true
+}
+
+// EnforceReferenceTypes returns a ValidationOptions that enables the reference check with the resolver.
+func EnforceReferenceTypes(resolver *ReferenceResolver) ValidationOptions {
+	return enforceReferenceTypes{resolver: resolver}
+}
+
+type enforceReferenceTypes struct {
+	resolver *ReferenceResolver
+}
+
+func (o enforceReferenceTypes) apply(f *validationPropertyFilter) {
+	f.references = o.resolver
+}
+
+// GlobalUniquenessCheck checks with an authority beyond this server, i.e. a central registry, whether the value of an
+// attribute with uniqueness=global is available to the resource identified by resourceId. It returns false if the value
+// is taken by another resource, along with the id of that resource if it is known, or an empty string.
+type GlobalUniquenessCheck func(ctx context.Context, attr *spec.Attribute, value interface{}, resourceId string) (unique bool, conflictId string, err error)
+
+// GlobalUniqueness returns a ValidationOptions that enables the uniqueness check of attributes with uniqueness=global,
+// by invoking check on each of their assigned properties. Note that "id" is also uniqueness=global.
+func GlobalUniqueness(check GlobalUniquenessCheck) ValidationOptions {
+	return globalUniqueness{check: check}
+}
+
+type globalUniqueness struct {
+	check GlobalUniquenessCheck
+}
+
+func (o globalUniqueness) apply(f *validationPropertyFilter) {
+	f.globalUniqueness = o.check
 }
 
 type validationPropertyFilter struct {
-	database db.DB
+	database         db.DB
+	normalize        bool
+	references       *ReferenceResolver
+	globalUniqueness GlobalUniquenessCheck
 }
 
 func (f *validationPropertyFilter) Supports(_ *spec.Attribute) bool {
@@ -49,15 +117,21 @@ func (f *validationPropertyFilter) Filter(ctx context.Context, _ *spec.ResourceT
 	}
 
 	property := nav.Current()
-	if err := f.validateRequired(property); err != nil {
+	if err := f.validateRequiredExtension(nav); err != nil {
 		return err
 	}
-	if err := f.validateCanonical(property); err != nil {
+	if err := crud.CheckRequired(property); err != nil {
+		return err
+	}
+	if err := f.validateCanonical(nav); err != nil {
 		return err
 	}
 	if err := f.validateUniqueness(ctx, nav); err != nil {
 		return err
 	}
+	if err := f.validateReference(ctx, property, nil); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -67,10 +141,13 @@ func (f *validationPropertyFilter) FilterRef(ctx context.Context, _ *spec.Resour
 		return nav.Error()
 	}
 
-	if err := f.validateRequired(nav.Current()); err != nil {
+	if err := f.validateRequiredExtension(nav); err != nil {
 		return err
 	}
-	if err := f.validateCanonical(nav.Current()); err != nil {
+	if err := crud.CheckRequired(nav.Current()); err != nil {
+		return err
+	}
+	if err := f.validateCanonical(nav); err != nil {
 		return err
 	}
 	if err := f.validateMutability(nav.Current(), refNav.Current()); err != nil {
@@ -79,45 +156,41 @@ func (f *validationPropertyFilter) FilterRef(ctx context.Context, _ *spec.Resour
 	if err := f.validateUniqueness(ctx, nav); err != nil {
 		return err
 	}
+	if err := f.validateReference(ctx, nav.Current(), refNav.Current()); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (f *validationPropertyFilter) validateRequired(property prop.Property) error {
-	if !property.Attribute().Required() || !property.IsUnassigned() {
+func (f *validationPropertyFilter) validateRequiredExtension(nav prop.Navigator) error {
+	attr := nav.Current().Attribute()
+	if _, ok := attr.Annotation(annotation.SchemaExtensionRoot); !ok || !attr.Required() {
 		return nil
 	}
-	return fmt.Errorf("%w: '%s' is required", spec.ErrInvalidValue, property.Attribute().Path())
-}
 
-func (f *validationPropertyFilter) validateCanonical(property prop.Property) error {
-	if property.Attribute().CountCanonicalValues() == 0 {
-		return nil
+	if nav.Current().IsUnassigned() {
+		return fmt.Errorf("%w: required schema extension '%s' is missing", spec.ErrInvalidValue, attr.ID())
 	}
 
-	if property.IsUnassigned() {
-		return nil
+	schemas, err := nav.Source().ChildAtIndex("schemas")
+	if err != nil || schemas == nil || schemas.FindChild(func(child prop.Property) bool {
+		return child.Raw() == attr.ID()
+	}) == nil {
+		return fmt.Errorf("%w: 'schemas' does not include required schema extension '%s'", spec.ErrInvalidValue, attr.ID())
 	}
 
-	if _, ok := property.Attribute().Annotation(annotation.Enum); !ok {
-		return nil
-	}
+	return nil
+}
 
-	v, ok := property.Raw().(string)
-	if !ok {
-		return nil
+func (f *validationPropertyFilter) validateCanonical(nav prop.Navigator) error {
+	canonical, err := crud.CheckCanonical(nav.Current(), f.normalize)
+	if err != nil {
+		return err
 	}
-
-	if ok := property.Attribute().ExistsCanonicalValue(func(canonicalValue string) bool {
-		if property.Attribute().CaseExact() {
-			return v == canonicalValue
-		} else {
-			return strings.ToLower(v) == strings.ToLower(canonicalValue)
-		}
-	}); !ok {
-		return fmt.Errorf("%w: value of '%s' does not conform to canonicalValues", spec.ErrInvalidValue, property.Attribute().Path())
+	if len(canonical) > 0 {
+		return nav.Respell(canonical).Error()
 	}
-
 	return nil
 }
 
@@ -141,38 +214,75 @@ func (f *validationPropertyFilter) validateMutability(property prop.Property, re
 }
 
 func (f *validationPropertyFilter) validateUniqueness(ctx context.Context, nav prop.Navigator) error {
+	if err := crud.CheckUniqueness(nav.Current(), resourceId(nav), func(filter string) (int, error) {
+		return f.database.Count(ctx, filter)
+	}); err != nil {
+		return err
+	}
+
+	if nav.Current().Attribute().Uniqueness() == spec.UniquenessGlobal {
+		return f.validateGlobalUniqueness(ctx, nav)
+	}
+	return nil
+}
+
+func (f *validationPropertyFilter) validateGlobalUniqueness(ctx context.Context, nav prop.Navigator) error {
 	property := nav.Current()
-	switch property.Attribute().Uniqueness() {
-	case spec.UniquenessNone, spec.UniquenessGlobal:
+	if f.globalUniqueness == nil || property.IsUnassigned() {
+		return nil
+	}
+
+	unique, conflictId, err := f.globalUniqueness(ctx, property.Attribute(), property.Raw(), resourceId(nav))
+	switch {
+	case err != nil:
+		return err
+	case unique:
 		return nil
+	case len(conflictId) > 0:
+		return fmt.Errorf("%w: value of '%s' is not globally unique, it is taken by resource '%s'",
+			spec.ErrUniqueness, property.Attribute().Path(), conflictId)
+	default:
+		return fmt.Errorf("%w: value of '%s' is not globally unique", spec.ErrUniqueness, property.Attribute().Path())
 	}
+}
 
-	if property.IsUnassigned() {
+func (f *validationPropertyFilter) validateReference(ctx context.Context, property prop.Property, ref prop.Property) error {
+	if err := crud.CheckReference(property); err != nil {
+		return err
+	}
+
+	if f.references == nil || property.IsUnassigned() {
 		return nil
 	}
 
-	var id string
-	{
-		idProperty, err := nav.Source().ChildAtIndex("id")
-		if err != nil || idProperty.Attribute().ID() != "id" || idProperty.IsUnassigned() {
-			return fmt.Errorf("%w: no id", spec.ErrInternal)
-		}
-		id = idProperty.Raw().(string)
+	if ref != nil && !IsOutOfSync(ref) && property.Matches(ref) {
+		return nil
 	}
 
-	// We may run into problem where the uniqueness=server attribute is 'id' itself. However, as of
-	// now, 'id' is defined as uniqueness=global by assigning a UUID to it.
-	filter := fmt.Sprintf("(id ne %s) and (%s eq %s)",
-		strconv.Quote(id),
-		property.Attribute().Path(),
-		strconv.Quote(fmt.Sprintf("%v", property.Raw())),
-	)
-	n, err := f.database.Count(ctx, filter)
-	if err != nil {
-		return err
-	} else if n > 0 {
-		return fmt.Errorf("%w: value of '%s' is not unique", spec.ErrUniqueness, property.Attribute().Path())
+	attr := property.Attribute()
+	switch {
+	case attr.IsReference():
+		return f.references.Enforce(ctx, attr, fmt.Sprintf("%v", property.Raw()))
+	case attr.IsComplex() && !attr.IsMultiValued():
+		refProp, err := property.ChildAtIndex("$ref")
+		if err != nil || refProp == nil || !refProp.IsUnassigned() || refProp.Attribute().CountReferenceTypes() == 0 {
+			return nil
+		}
+		valueProp, err := property.ChildAtIndex("value")
+		if err != nil || valueProp == nil || valueProp.IsUnassigned() || valueProp.Attribute().IsReference() {
+			return nil
+		}
+		return f.references.Enforce(ctx, refProp.Attribute(), fmt.Sprintf("%v", valueProp.Raw()))
+	default:
+		return nil
 	}
+}
 
-	return nil
+// Returns the id of the resource being validated, or an empty string if it has none yet.
+func resourceId(nav prop.Navigator) string {
+	if idProperty, err := nav.Source().ChildAtIndex("id"); err == nil && idProperty != nil && !idProperty.IsUnassigned() {
+		id, _ := idProperty.Raw().(string)
+		return id
+	}
+	return ""
 }
@@ -12,8 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
-	"io/ioutil"
-	"os"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -21,43 +21,9 @@ func TestValidationFilter(t *testing.T) {
 	getResourceType := func() *spec.ResourceType {
 		var resourceType *spec.ResourceType
 		{
-			for _, each := range []struct {
-				filepath  string
-				structure interface{}
-				post      func(parsed interface{})
-			}{
-				{
-					filepath:  "../../../../public/schemas/core_schema.json",
-					structure: new(spec.Schema),
-					post: func(parsed interface{}) {
-						spec.Schemas().Register(parsed.(*spec.Schema))
-					},
-				},
-				{
-					filepath:  "../../../../public/schemas/user_schema.json",
-					structure: new(spec.Schema),
-					post: func(parsed interface{}) {
-						spec.Schemas().Register(parsed.(*spec.Schema))
-					},
-				},
-				{
-					filepath:  "../../../../public/resource_types/user_resource_type.json",
-					structure: new(spec.ResourceType),
-					post: func(parsed interface{}) {
-						resourceType = parsed.(*spec.ResourceType)
-					},
-				},
-			} {
-				f, err := os.Open(each.filepath)
-				require.Nil(t, err)
-				raw, err := ioutil.ReadAll(f)
-				require.Nil(t, err)
-				err = json.Unmarshal(raw, each.structure)
-				require.Nil(t, err)
-				if each.post != nil {
-					each.post(each.structure)
-				}
-			}
+			var err error
+			resourceType, err = spec.RegisterStandardUserResourceType()
+			require.Nil(t, err)
 		}
 		return resourceType
 	}
@@ -251,6 +217,379 @@ func TestValidationFilter(t *testing.T) {
 	}
 }
 
+func TestValidationFilterNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrJson string
+		options  []ValidationOptions
+		expect   func(t *testing.T, property prop.Property, err error)
+	}{
+		{
+			name: "value is normalized when annotated",
+			attrJson: `
+{
+  "id": "type",
+  "name": "type",
+  "_path": "type",
+  "type": "string",
+  "caseExact": true,
+  "canonicalValues": ["work", "home"],
+  "_annotations": {
+    "@Enum": {"normalize": true}
+  }
+}
+`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "work", property.Raw())
+			},
+		},
+		{
+			name: "value is normalized with option",
+			attrJson: `
+{
+  "id": "type",
+  "name": "type",
+  "_path": "type",
+  "type": "string",
+  "canonicalValues": ["work", "home"],
+  "_annotations": {
+    "@Enum": {}
+  }
+}
+`,
+			options: []ValidationOptions{NormalizeCanonicalValues()},
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "work", property.Raw())
+			},
+		},
+		{
+			name: "value is kept without normalization",
+			attrJson: `
+{
+  "id": "type",
+  "name": "type",
+  "_path": "type",
+  "type": "string",
+  "canonicalValues": ["work", "home"],
+  "_annotations": {
+    "@Enum": {}
+  }
+}
+`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "Work", property.Raw())
+			},
+		},
+		{
+			name: "value is rejected listing canonical values",
+			attrJson: `
+{
+  "id": "type",
+  "name": "type",
+  "_path": "type",
+  "type": "string",
+  "caseExact": true,
+  "canonicalValues": ["work", "home"],
+  "_annotations": {
+    "@Enum": {}
+  }
+}
+`,
+			expect: func(t *testing.T, property prop.Property, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), `allowed values are "work", "home"`)
+				assert.Equal(t, "Work", property.Raw())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attr := new(spec.Attribute)
+			require.Nil(t, json.Unmarshal([]byte(test.attrJson), attr))
+
+			property := prop.NewProperty(attr)
+			_, err := property.Replace("Work")
+			require.Nil(t, err)
+
+			err = ValidationFilter(nil, test.options...).Filter(context.Background(), nil, prop.Navigate(property))
+			test.expect(t, property, err)
+		})
+	}
+}
+
+func TestValidationFilterGlobalUniqueness(t *testing.T) {
+	attr := new(spec.Attribute)
+	require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "externalId",
+  "name": "externalId",
+  "_path": "externalId",
+  "type": "string",
+  "uniqueness": "global"
+}
+`), attr))
+
+	registry := map[string]string{"taken": "other", "anonymous": ""}
+	var checked []string
+	check := func(_ context.Context, attr *spec.Attribute, value interface{}, _ string) (bool, string, error) {
+		checked = append(checked, value.(string))
+		if value == "unreachable" {
+			return false, "", errors.New("registry unreachable")
+		}
+		owner, taken := registry[value.(string)]
+		return !taken, owner, nil
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		options []ValidationOptions
+		expect  func(t *testing.T, err error)
+	}{
+		{
+			name:  "not checked beyond the server without option",
+			value: "taken",
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:    "value taken in the database fails check before consulting the authority",
+			value:   "local",
+			options: []ValidationOptions{GlobalUniqueness(check)},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrUniqueness))
+				assert.Contains(t, err.Error(), "value of 'externalId' is not unique")
+				assert.Empty(t, checked)
+			},
+		},
+		{
+			name:    "unique value passes check",
+			value:   "free",
+			options: []ValidationOptions{GlobalUniqueness(check)},
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:    "taken value fails check naming conflicting resource",
+			value:   "taken",
+			options: []ValidationOptions{GlobalUniqueness(check)},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrUniqueness))
+				assert.Contains(t, err.Error(), "value of 'externalId' is not globally unique, it is taken by resource 'other'")
+			},
+		},
+		{
+			name:    "taken value fails check",
+			value:   "anonymous",
+			options: []ValidationOptions{GlobalUniqueness(check)},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrUniqueness))
+				assert.NotContains(t, err.Error(), "taken by resource")
+			},
+		},
+		{
+			name:    "error of check is returned",
+			value:   "unreachable",
+			options: []ValidationOptions{GlobalUniqueness(check)},
+			expect: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "registry unreachable")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			property := prop.NewProperty(attr)
+			_, err := property.Replace(test.value)
+			require.Nil(t, err)
+
+			checked = nil
+			err = ValidationFilter(&globalUniquenessTestDatabase{taken: "local"}, test.options...).
+				Filter(context.Background(), nil, prop.Navigate(property))
+			test.expect(t, err)
+		})
+	}
+}
+
+func TestValidationFilterReferenceSyntax(t *testing.T) {
+	attr := new(spec.Attribute)
+	require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "profileUrl",
+  "name": "profileUrl",
+  "_path": "profileUrl",
+  "type": "reference",
+  "referenceTypes": ["external"]
+}
+`), attr))
+
+	tests := []struct {
+		name   string
+		value  string
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name:  "absolute external reference passes check",
+			value: "https://example.com/bjensen",
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:  "relative external reference fails check without option",
+			value: "/bjensen",
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "not an absolute URI")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			property := prop.NewProperty(attr)
+			_, err := property.Replace(test.value)
+			require.Nil(t, err)
+
+			err = ValidationFilter(nil).Filter(context.Background(), nil, prop.Navigate(property))
+			test.expect(t, err)
+		})
+	}
+}
+
+func TestValidationFilterRequiredExtension(t *testing.T) {
+	const enterprise = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+	var resourceType *spec.ResourceType
+	{
+		// registers the schemas, while the resource type below requires the extension
+		_, err := spec.RegisterStandardUserResourceType()
+		require.Nil(t, err)
+
+		resourceType = new(spec.ResourceType)
+		require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "User",
+  "name": "User",
+  "endpoint": "/Users",
+  "schema": "urn:ietf:params:scim:schemas:core:2.0:User",
+  "schemaExtensions": [
+    {
+      "schema": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+      "required": true
+    }
+  ]
+}
+`), resourceType))
+		crud.Register(resourceType)
+	}
+
+	newResource := func(t *testing.T, data map[string]interface{}) *prop.Resource {
+		resource := prop.NewResource(resourceType)
+		_, err := resource.RootProperty().Replace(data)
+		require.Nil(t, err)
+		return resource
+	}
+
+	tests := []struct {
+		name        string
+		getResource func(t *testing.T) *prop.Resource
+		getRef      func(t *testing.T) *prop.Resource
+		expect      func(t *testing.T, err error)
+	}{
+		{
+			name: "required extension is present",
+			getResource: func(t *testing.T) *prop.Resource {
+				return newResource(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User", enterprise},
+					"id":       "a1",
+					"userName": "foo",
+					"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+					enterprise: map[string]interface{}{"employeeNumber": "1"},
+				})
+			},
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "required extension is missing",
+			getResource: func(t *testing.T) *prop.Resource {
+				return newResource(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User", enterprise},
+					"id":       "a1",
+					"userName": "foo",
+					"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+				})
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "required schema extension '"+enterprise+"' is missing")
+			},
+		},
+		{
+			name: "required extension is not in schemas",
+			getResource: func(t *testing.T) *prop.Resource {
+				return newResource(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "a1",
+					"userName": "foo",
+					"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+					enterprise: map[string]interface{}{"employeeNumber": "1"},
+				})
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "'schemas' does not include required schema extension '"+enterprise+"'")
+			},
+		},
+		{
+			name: "patch removing required extension",
+			getResource: func(t *testing.T) *prop.Resource {
+				resource := newResource(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User", enterprise},
+					"id":       "a1",
+					"userName": "foo",
+					"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+					enterprise: map[string]interface{}{"employeeNumber": "1"},
+				})
+				require.Nil(t, resource.Navigator().Dot(enterprise).Delete().Error())
+				return resource
+			},
+			getRef: func(t *testing.T) *prop.Resource {
+				return newResource(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User", enterprise},
+					"id":       "a1",
+					"userName": "foo",
+					"emails":   []interface{}{map[string]interface{}{"value": "foo@bar.com"}},
+					enterprise: map[string]interface{}{"employeeNumber": "1"},
+				})
+			},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), enterprise)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := test.getResource(t)
+			if test.getRef == nil {
+				test.expect(t, Visit(context.Background(), resource, ValidationFilter(db.Memory())))
+			} else {
+				test.expect(t, VisitWithRef(context.Background(), resource, test.getRef(t), ValidationFilter(db.Memory())))
+			}
+		})
+	}
+}
+
 type uniquenessTestMockDatabase struct {
 	mock.Mock
 }
@@ -291,3 +630,16 @@ func (d *uniquenessTestMockDatabase) Delete(_ context.Context, _ *prop.Resource)
 func (d *uniquenessTestMockDatabase) Query(_ context.Context, _ string, _ *crud.Sort, _ *crud.Pagination, _ *crud.Projection) ([]*prop.Resource, error) {
 	return []*prop.Resource{}, nil
 }
+
+// Database of the global uniqueness tests, in which the taken value is used by another resource.
+type globalUniquenessTestDatabase struct {
+	db.DB
+	taken string
+}
+
+func (d *globalUniquenessTestDatabase) Count(_ context.Context, filter string) (int, error) {
+	if strings.Contains(filter, strconv.Quote(d.taken)) {
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -3,7 +3,6 @@ package filter
 import (
 	"context"
 	"github.com/imulab/go-scim/pkg/v2/prop"
-	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
 // Visit performs a DFS visit on the resource and sequentially invokes the ByProperty filters on each visited property
@@ -80,7 +79,7 @@ func (v *syncVisitor) Visit(property prop.Property) error {
 	}
 
 	// Simple properties retract at the end of Visit, container properties retract in EndChildren
-	if !property.Attribute().MultiValued() && property.Attribute().Type() != spec.TypeComplex {
+	if property.Attribute().IsScalar() {
 		defer func() {
 			v.resourceNav.Retract()
 			if v.referenceNav != nil {
@@ -2,14 +2,26 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
-// GetService returns a get resource service.
-func GetService(database db.DB) Get {
-	return &getService{database: database}
+// GetService returns a get resource service. options may be used to customize the behaviour of the service, i.e.
+// Intercept.
+func GetService(database db.DB, options ...GetOptions) Get {
+	s := &getService{database: database}
+	for _, opt := range options {
+		opt.applyGet(s)
+	}
+	return s
+}
+
+// GetOptions customizes the behaviour of the get service.
+type GetOptions interface {
+	applyGet(s *getService)
 }
 
 type (
@@ -24,20 +36,73 @@ type (
 	}
 	// Get resource response
 	GetResponse struct {
-		Resource *prop.Resource // resource got from database
+		Resource   *prop.Resource   // resource got from database
+		Projection *crud.Projection // included so that caller may render properly
 	}
 )
 
 type getService struct {
-	database db.DB
+	database     db.DB
+	interceptors Interceptors
 }
 
 func (s *getService) Do(ctx context.Context, req *GetRequest) (resp *GetResponse, err error) {
+	if err = req.Projection.Validate(); err != nil {
+		return
+	}
+
 	resource, err := s.database.Get(ctx, req.ResourceID, req.Projection)
 	if err != nil {
 		return
 	}
 
-	resp = &GetResponse{Resource: resource}
+	// The projection may have left out the attributes compared by the discriminating filter.
+	if len(resource.ResourceType().Filter()) > 0 && req.Projection != nil {
+		if resource, err = s.database.Get(ctx, req.ResourceID, nil); err != nil {
+			return
+		}
+	}
+	if err = checkResourceType(resource); err != nil {
+		return
+	}
+
+	if err = s.interceptors.Before(ctx, OperationGet, resource); err != nil {
+		return
+	}
+	s.interceptors.After(ctx, OperationGet, resource, resource)
+
+	resp = &GetResponse{Resource: resource, Projection: req.Projection}
 	return
 }
+
+// Returns spec.ErrNotFound if the resource does not satisfy the discriminating filter of its resource type, so that a
+// resource stored in a collection shared by several resource types cannot be reached through the endpoint of another
+// resource type.
+func checkResourceType(resource *prop.Resource) error {
+	ok, err := crud.BelongsToResourceType(resource)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: resource not found by id '%s'", spec.ErrNotFound, resource.IdOrEmpty())
+	}
+	return nil
+}
+
+// Returns spec.ErrVersionMismatch if ETag is supported and the resource does not have the expected version, or does
+// not meet the match criteria derived from the If-Match and If-None-Match headers. The expected version "*" matches any
+// version. Versions are compared as is, hence a weak ETag does not match the strong ETag with the same value.
+func checkVersion(config *spec.ServiceProviderConfig, resource *prop.Resource, expectedVersion string,
+	matchCriteria func(resource *prop.Resource) bool) error {
+	if !config.ETag.Supported {
+		return nil
+	}
+	if version := resource.MetaVersionOrEmpty(); len(expectedVersion) > 0 && expectedVersion != "*" && expectedVersion != version {
+		return fmt.Errorf("%w: resource '%s' has version %s, but %s was expected", spec.ErrVersionMismatch,
+			resource.IdOrEmpty(), version, expectedVersion)
+	}
+	if matchCriteria != nil && !matchCriteria(resource) {
+		return fmt.Errorf("%w: resource '%s' does not meet pre condition", spec.ErrVersionMismatch, resource.IdOrEmpty())
+	}
+	return nil
+}
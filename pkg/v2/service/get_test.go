@@ -11,8 +11,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -68,6 +66,81 @@ func (s *GetServiceTestSuite) TestDo() {
 				assert.Equal(t, spec.ErrNotFound, errors.Unwrap(err))
 			},
 		},
+		{
+			name: "get resource not satisfying discriminating filter",
+			setup: func(t *testing.T) Get {
+				serviceAccountType := new(spec.ResourceType)
+				require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "ServiceAccount",
+  "name": "ServiceAccount",
+  "endpoint": "/ServiceAccounts",
+  "schema": "urn:ietf:params:scim:schemas:core:2.0:User",
+  "_filter": "userType eq \"service\""
+}
+`), serviceAccountType))
+				crud.Register(serviceAccountType)
+
+				database := db.Memory()
+				for _, userData := range []interface{}{
+					map[string]interface{}{"id": "foo", "userType": "service"},
+					map[string]interface{}{"id": "bar", "userType": "employee"},
+				} {
+					r := prop.NewResource(serviceAccountType)
+					require.Nil(t, r.Navigator().Replace(userData).Error())
+					require.Nil(t, database.Insert(context.TODO(), r))
+				}
+				return GetService(database)
+			},
+			getRequest: func() *GetRequest {
+				return &GetRequest{
+					ResourceID: "bar",
+					Projection: &crud.Projection{Attributes: []string{"userName"}},
+				}
+			},
+			expect: func(t *testing.T, resp *GetResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrNotFound))
+			},
+		},
+		{
+			name: "get with projection",
+			setup: func(t *testing.T) Get {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"id": "foobar",
+				}))
+				require.Nil(t, err)
+				return GetService(database)
+			},
+			getRequest: func() *GetRequest {
+				return &GetRequest{
+					ResourceID: "foobar",
+					Projection: &crud.Projection{Attributes: []string{"urn:ietf:params:scim:schemas:core:2.0:User:name.givenName"}},
+				}
+			},
+			expect: func(t *testing.T, resp *GetResponse, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"urn:ietf:params:scim:schemas:core:2.0:User:name.givenName"}, resp.Projection.Attributes)
+			},
+		},
+		{
+			name: "get with both attributes and excludedAttributes",
+			setup: func(t *testing.T) Get {
+				return GetService(db.Memory())
+			},
+			getRequest: func() *GetRequest {
+				return &GetRequest{
+					ResourceID: "foobar",
+					Projection: &crud.Projection{
+						Attributes:         []string{"userName"},
+						ExcludedAttributes: []string{"emails"},
+					},
+				}
+			},
+			expect: func(t *testing.T, resp *GetResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -86,52 +159,8 @@ func (s *GetServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.R
 }
 
 func (s *GetServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 }
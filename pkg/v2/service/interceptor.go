@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+)
+
+// Operation is the kind of operation performed by a service, as seen by the interceptors.
+type Operation string
+
+// Operations of the services
+const (
+	OperationCreate  Operation = "create"
+	OperationGet     Operation = "get"
+	OperationQuery   Operation = "query"
+	OperationReplace Operation = "replace"
+	OperationPatch   Operation = "patch"
+	OperationDelete  Operation = "delete"
+)
+
+// Interceptor is a hook invoked by the services around their operations, i.e. to check the authorization of the
+// subject carried by the request context before writes, or to emit domain events after them. Interceptors are
+// registered with the Intercept option.
+//
+// Before is invoked with the affected resource before the operation takes effect: the resource to be created, the
+// replacement, or the patched resource, after the filters and before it is saved; the resource to be deleted; or the
+// resource fetched by get, and each resource of the page fetched by query, before it is returned. An error aborts the
+// operation, and is returned by the service as is.
+//
+// After is invoked once the operation succeeded, with the resource before and after the operation: nil and the created
+// resource; the stored resource and the replacement, or the patched resource; the deleted resource and nil; or the
+// fetched resource twice for get and query. After is not invoked when the operation, or any Before, failed, nor for a
+// replace or a patch which did not change the resource, as nothing happened to report. Before is still invoked for
+// them, as their response discloses the resource.
+type Interceptor interface {
+	Before(ctx context.Context, op Operation, resource *prop.Resource) error
+	After(ctx context.Context, op Operation, before *prop.Resource, after *prop.Resource)
+}
+
+// Interceptors composes several interceptors into one: Before invokes them in order until one returns an error, and
+// After invokes all of them in order.
+type Interceptors []Interceptor
+
+func (i Interceptors) Before(ctx context.Context, op Operation, resource *prop.Resource) error {
+	for _, each := range i {
+		if err := each.Before(ctx, op, resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i Interceptors) After(ctx context.Context, op Operation, before *prop.Resource, after *prop.Resource) {
+	for _, each := range i {
+		each.After(ctx, op, before, after)
+	}
+}
+
+// Intercept returns an option of the create, get, query, replace, patch and delete services which registers the
+// interceptors. Interceptors are invoked in the order they are registered, including across several Intercept options.
+func Intercept(interceptors ...Interceptor) InterceptOptions {
+	return InterceptOptions{interceptors: interceptors}
+}
+
+// InterceptOptions is the CreateOptions, GetOptions, QueryOptions, ReplaceOptions, PatchOptions and DeleteOptions
+// returned by Intercept.
+type InterceptOptions struct {
+	interceptors Interceptors
+}
+
+func (o InterceptOptions) applyCreate(s *createService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
+
+func (o InterceptOptions) applyGet(s *getService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
+
+func (o InterceptOptions) applyQuery(s *queryService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
+
+func (o InterceptOptions) applyReplace(s *replaceService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
+
+func (o InterceptOptions) applyPatch(s *patchService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
+
+func (o InterceptOptions) applyDelete(s *deleteService) {
+	s.interceptors = append(s.interceptors, o.interceptors...)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/db"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/service/filter"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestInterceptor(t *testing.T) {
+	s := new(InterceptorTestSuite)
+	suite.Run(t, s)
+}
+
+type InterceptorTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+	config       *spec.ServiceProviderConfig
+}
+
+func (s *InterceptorTestSuite) TestOperations() {
+	var (
+		ctx      = context.WithValue(context.Background(), subjectKey{}, "alice")
+		database = db.Memory()
+		events   []string
+	)
+	intercept := Intercept(&recordingInterceptor{name: "first", events: &events}, &recordingInterceptor{name: "second", events: &events})
+	filters := []filter.ByResource{
+		filter.ByPropertyToByResource(filter.ReadOnlyFilter(), filter.UUIDFilter()),
+		filter.MetaFilter(),
+	}
+
+	created, err := CreateService(s.resourceType, database, filters, intercept).Do(ctx, &CreateRequest{
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "emails": [{"value": "bjensen@example.com"}]}`),
+	})
+	require.Nil(s.T(), err)
+	id := created.Resource.IdOrEmpty()
+
+	_, err = GetService(database, intercept).Do(ctx, &GetRequest{ResourceID: id})
+	require.Nil(s.T(), err)
+
+	_, err = QueryService(s.config, s.resourceType, database, intercept).Do(ctx, &QueryRequest{Filter: "id pr"})
+	require.Nil(s.T(), err)
+
+	_, err = ReplaceService(s.config, s.resourceType, database, filters, intercept).Do(ctx, &ReplaceRequest{
+		ResourceID:    id,
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "displayName": "Babs", "emails": [{"value": "bjensen@example.com"}]}`),
+	})
+	require.Nil(s.T(), err)
+
+	_, err = PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()}, intercept).Do(ctx, &PatchRequest{
+		ResourceID:    id,
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "displayName", "value": "Barbara"}]}`),
+	})
+	require.Nil(s.T(), err)
+
+	_, err = DeleteService(s.config, database, intercept).Do(ctx, &DeleteRequest{ResourceID: id})
+	require.Nil(s.T(), err)
+
+	assert.Equal(s.T(), []string{
+		"first before create bjensen by alice", "second before create bjensen by alice",
+		"first after create <nil> -> bjensen", "second after create <nil> -> bjensen",
+		"first before get bjensen by alice", "second before get bjensen by alice",
+		"first after get bjensen -> bjensen", "second after get bjensen -> bjensen",
+		"first before query bjensen by alice", "second before query bjensen by alice",
+		"first after query bjensen -> bjensen", "second after query bjensen -> bjensen",
+		"first before replace Babs by alice", "second before replace Babs by alice",
+		"first after replace bjensen -> Babs", "second after replace bjensen -> Babs",
+		"first before patch Barbara by alice", "second before patch Barbara by alice",
+		"first after patch Babs -> Barbara", "second after patch Babs -> Barbara",
+		"first before delete Barbara by alice", "second before delete Barbara by alice",
+		"first after delete Barbara -> <nil>", "second after delete Barbara -> <nil>",
+	}, events)
+}
+
+func (s *InterceptorTestSuite) TestBeforeAborts() {
+	var (
+		database = db.Memory()
+		events   []string
+		denied   = errors.New("denied")
+	)
+	service := CreateService(s.resourceType, database, []filter.ByResource{
+		filter.ByPropertyToByResource(filter.UUIDFilter()),
+	}, Intercept(
+		&recordingInterceptor{name: "first", events: &events, err: denied},
+		&recordingInterceptor{name: "second", events: &events},
+	))
+
+	_, err := service.Do(context.Background(), &CreateRequest{
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "emails": [{"value": "bjensen@example.com"}]}`),
+	})
+	assert.Equal(s.T(), denied, err)
+	assert.Equal(s.T(), []string{"first before create bjensen by <nil>"}, events)
+
+	n, err := database.Count(context.Background(), "")
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, n)
+}
+
+func (s *InterceptorTestSuite) TestUnchanged() {
+	var (
+		database = db.Memory()
+		events   []string
+	)
+	intercept := Intercept(&recordingInterceptor{name: "first", events: &events})
+	filters := []filter.ByResource{
+		filter.ByPropertyToByResource(filter.ReadOnlyFilter(), filter.UUIDFilter()),
+		filter.MetaFilter(),
+	}
+
+	created, err := CreateService(s.resourceType, database, filters).Do(context.Background(), &CreateRequest{
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "displayName": "Babs", "emails": [{"value": "bjensen@example.com"}]}`),
+	})
+	require.Nil(s.T(), err)
+	id := created.Resource.IdOrEmpty()
+
+	replaced, err := ReplaceService(s.config, s.resourceType, database, filters, intercept).Do(context.Background(), &ReplaceRequest{
+		ResourceID:    id,
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen", "displayName": "Babs", "emails": [{"value": "bjensen@example.com"}]}`),
+	})
+	require.Nil(s.T(), err)
+	assert.False(s.T(), replaced.Replaced)
+
+	patched, err := PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()}, intercept).Do(context.Background(), &PatchRequest{
+		ResourceID:    id,
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "displayName", "value": "Babs"}]}`),
+	})
+	require.Nil(s.T(), err)
+	assert.False(s.T(), patched.Patched)
+
+	assert.Equal(s.T(), []string{
+		"first before replace Babs by <nil>",
+		"first before patch Babs by <nil>",
+	}, events)
+}
+
+func (s *InterceptorTestSuite) TestPatchBeforeAfterFilters() {
+	var (
+		database = db.Memory()
+		filters  = []filter.ByResource{
+			filter.ByPropertyToByResource(filter.ReadOnlyFilter(), filter.UUIDFilter()),
+			filter.MetaFilter(),
+		}
+		versions = &versionInterceptor{}
+	)
+
+	created, err := CreateService(s.resourceType, database, filters).Do(context.Background(), &CreateRequest{
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen"}`),
+	})
+	require.Nil(s.T(), err)
+
+	resp, err := PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()}, Intercept(versions)).Do(context.Background(), &PatchRequest{
+		ResourceID:    created.Resource.IdOrEmpty(),
+		PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "displayName", "value": "Barbara"}]}`),
+	})
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), created.Resource.MetaVersionOrEmpty(), resp.Resource.MetaVersionOrEmpty())
+	assert.Equal(s.T(), []string{resp.Resource.MetaVersionOrEmpty()}, versions.before)
+}
+
+// Records the meta.version of the resources seen by Before.
+type versionInterceptor struct {
+	before []string
+}
+
+func (i *versionInterceptor) Before(_ context.Context, _ Operation, resource *prop.Resource) error {
+	i.before = append(i.before, resource.MetaVersionOrEmpty())
+	return nil
+}
+
+func (i *versionInterceptor) After(context.Context, Operation, *prop.Resource, *prop.Resource) {}
+
+// Records the invocations, naming the resources by their displayName, or userName when absent.
+type recordingInterceptor struct {
+	name   string
+	events *[]string
+	err    error
+}
+
+func (i *recordingInterceptor) Before(ctx context.Context, op Operation, resource *prop.Resource) error {
+	*i.events = append(*i.events, i.name+" before "+string(op)+" "+displayName(resource)+" by "+subjectOf(ctx))
+	return i.err
+}
+
+func (i *recordingInterceptor) After(_ context.Context, op Operation, before *prop.Resource, after *prop.Resource) {
+	*i.events = append(*i.events, i.name+" after "+string(op)+" "+displayName(before)+" -> "+displayName(after))
+}
+
+func displayName(resource *prop.Resource) string {
+	if resource == nil {
+		return "<nil>"
+	}
+	if name, ok := resource.Navigator().Dot("displayName").Current().Raw().(string); ok {
+		return name
+	}
+	return resource.Navigator().Dot("userName").Current().Raw().(string)
+}
+
+func subjectOf(ctx context.Context) string {
+	if subject, ok := ctx.Value(subjectKey{}).(string); ok {
+		return subject
+	}
+	return "<nil>"
+}
+
+func (s *InterceptorTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
+
+	s.config, err = spec.NewServiceProviderConfigBuilder().Patch().Filter(10).Build()
+	require.Nil(s.T(), err)
+}
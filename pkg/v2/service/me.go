@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// MeLocation is the URI of the /Me endpoint, which is rendered as the meta.location of the resources it returns.
+const MeLocation = "/Me"
+
+// SubjectResolver resolves the id of the resource of the authenticated subject from the request context, i.e. one
+// carrying the claims of the bearer token. It should return an error wrapping spec.ErrNotFound if the subject has no
+// resource.
+type SubjectResolver func(ctx context.Context) (id string, err error)
+
+// MeOptions customizes the behaviour of MeService.
+type MeOptions interface {
+	apply(s *meService)
+}
+
+// SelfRegistration returns a MeOptions that allows POST /Me, creating the resource of the subject through the create
+// service of the endpoint. Otherwise, or when the resolver is nil, the create service of the returned endpoint fails
+// with spec.ErrNotImplemented.
+func SelfRegistration() MeOptions {
+	return selfRegistration{}
+}
+
+type selfRegistration struct{}
+
+func (o selfRegistration) apply(s *meService) {
+	s.selfRegistration = true
+}
+
+// MeService returns the services of the /Me endpoint defined in RFC7644 section 3.11, which is an alias for the
+// resource of the authenticated subject. The returned services delegate to those of the endpoint, with the id resolved
+// by the resolver in place of the ResourceID of the request, and rewrite the meta.location of the returned resource
+// to MeLocation, hence the Location header rendered from it refers to /Me as well.
+//
+// When the resolver is nil, all services fail with spec.ErrNotImplemented, so that a deployment without a way to tell
+// who the subject is responds with 501, and does not advertise support it does not have.
+func MeService(endpoint MeEndpoint, resolver SubjectResolver, options ...MeOptions) MeEndpoint {
+	s := &meService{endpoint: endpoint, resolver: resolver}
+	for _, opt := range options {
+		opt.apply(s)
+	}
+	return MeEndpoint{
+		Get:     meGet{s},
+		Create:  meCreate{s},
+		Replace: meReplace{s},
+		Patch:   mePatch{s},
+		Delete:  meDelete{s},
+	}
+}
+
+// MeEndpoint are the services serving the resource of the authenticated subject. A service may be nil, in which case
+// the corresponding method fails with spec.ErrNotImplemented.
+type MeEndpoint struct {
+	Get     Get
+	Create  Create
+	Replace Replace
+	Patch   Patch
+	Delete  Delete
+}
+
+type meService struct {
+	endpoint         MeEndpoint
+	resolver         SubjectResolver
+	selfRegistration bool
+}
+
+// Returns the id of the resource of the subject, or spec.ErrNotImplemented if the service is not available.
+func (s *meService) resolve(ctx context.Context, available bool, method string) (string, error) {
+	if s.resolver == nil || !available {
+		return "", fmt.Errorf("%w: %s /Me is not supported", spec.ErrNotImplemented, method)
+	}
+
+	id, err := s.resolver(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(id) == 0 {
+		return "", fmt.Errorf("%w: no resource for the authenticated subject", spec.ErrNotFound)
+	}
+	return id, nil
+}
+
+// Returns a clone of the resource whose meta.location is MeLocation, or the resource itself if it has no location. The
+// resource is cloned because it may be shared with the database.
+func relocateToMe(resource *prop.Resource) (*prop.Resource, error) {
+	if resource == nil || len(resource.MetaLocationOrEmpty()) == 0 {
+		return resource, nil
+	}
+
+	clone := resource.Clone()
+	if err := clone.Navigator().Dot("meta").Dot("location").Replace(MeLocation).Error(); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+type meGet struct{ *meService }
+
+func (s meGet) Do(ctx context.Context, req *GetRequest) (resp *GetResponse, err error) {
+	id, err := s.resolve(ctx, s.endpoint.Get != nil, "GET")
+	if err != nil {
+		return
+	}
+
+	delegated := *req
+	delegated.ResourceID = id
+	if resp, err = s.endpoint.Get.Do(ctx, &delegated); err != nil {
+		return
+	}
+
+	resp.Resource, err = relocateToMe(resp.Resource)
+	return
+}
+
+type meCreate struct{ *meService }
+
+func (s meCreate) Do(ctx context.Context, req *CreateRequest) (resp *CreateResponse, err error) {
+	if s.resolver == nil || !s.selfRegistration || s.endpoint.Create == nil {
+		err = fmt.Errorf("%w: POST /Me is not supported", spec.ErrNotImplemented)
+		return
+	}
+
+	if resp, err = s.endpoint.Create.Do(ctx, req); err != nil {
+		return
+	}
+
+	resp.Resource, err = relocateToMe(resp.Resource)
+	return
+}
+
+type meReplace struct{ *meService }
+
+func (s meReplace) Do(ctx context.Context, req *ReplaceRequest) (resp *ReplaceResponse, err error) {
+	id, err := s.resolve(ctx, s.endpoint.Replace != nil, "PUT")
+	if err != nil {
+		return
+	}
+
+	delegated := *req
+	delegated.ResourceID = id
+	if resp, err = s.endpoint.Replace.Do(ctx, &delegated); err != nil {
+		return
+	}
+
+	resp.Resource, err = relocateToMe(resp.Resource)
+	return
+}
+
+type mePatch struct{ *meService }
+
+func (s mePatch) Do(ctx context.Context, req *PatchRequest) (resp *PatchResponse, err error) {
+	id, err := s.resolve(ctx, s.endpoint.Patch != nil, "PATCH")
+	if err != nil {
+		return
+	}
+
+	delegated := *req
+	delegated.ResourceID = id
+	if resp, err = s.endpoint.Patch.Do(ctx, &delegated); err != nil {
+		return
+	}
+
+	resp.Resource, err = relocateToMe(resp.Resource)
+	return
+}
+
+type meDelete struct{ *meService }
+
+func (s meDelete) Do(ctx context.Context, req *DeleteRequest) (resp *DeleteResponse, err error) {
+	id, err := s.resolve(ctx, s.endpoint.Delete != nil, "DELETE")
+	if err != nil {
+		return
+	}
+
+	delegated := *req
+	delegated.ResourceID = id
+	return s.endpoint.Delete.Do(ctx, &delegated)
+}
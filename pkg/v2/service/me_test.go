@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/db"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/service/filter"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMeService(t *testing.T) {
+	s := new(MeServiceTestSuite)
+	suite.Run(t, s)
+}
+
+type MeServiceTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+	config       *spec.ServiceProviderConfig
+}
+
+type subjectKey struct{}
+
+func (s *MeServiceTestSuite) TestDo() {
+	resolver := func(ctx context.Context) (string, error) {
+		subject, _ := ctx.Value(subjectKey{}).(string)
+		return subject, nil
+	}
+	withSubject := context.WithValue(context.Background(), subjectKey{}, "bjensen")
+
+	tests := []struct {
+		name   string
+		do     func(t *testing.T, me MeEndpoint, database db.DB)
+		noMe   bool
+		option []MeOptions
+	}{
+		{
+			name: "get the resource of the subject",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				resp, err := me.Get.Do(withSubject, &GetRequest{ResourceID: "ignored"})
+				require.Nil(t, err)
+				assert.Equal(t, "bjensen", resp.Resource.IdOrEmpty())
+				assert.Equal(t, MeLocation, resp.Resource.MetaLocationOrEmpty())
+
+				stored, err := database.Get(context.Background(), "bjensen", nil)
+				require.Nil(t, err)
+				assert.Equal(t, "/Users/bjensen", stored.MetaLocationOrEmpty())
+			},
+		},
+		{
+			name: "replace the resource of the subject",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				resp, err := me.Replace.Do(withSubject, &ReplaceRequest{PayloadSource: strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "bjensen",
+  "displayName": "Babs Jensen",
+  "emails": [{"value": "bjensen@example.com"}]
+}`)})
+				require.Nil(t, err)
+				assert.True(t, resp.Replaced)
+				assert.Equal(t, MeLocation, resp.Resource.MetaLocationOrEmpty())
+				assert.Equal(t, "Babs Jensen", resp.Resource.Navigator().Dot("displayName").Current().Raw())
+			},
+		},
+		{
+			name: "patch the resource of the subject",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				resp, err := me.Patch.Do(withSubject, &PatchRequest{PayloadSource: strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+  "Operations": [{"op": "replace", "path": "displayName", "value": "Babs"}]
+}`)})
+				require.Nil(t, err)
+				assert.True(t, resp.Patched)
+				assert.Equal(t, MeLocation, resp.Resource.MetaLocationOrEmpty())
+			},
+		},
+		{
+			name: "delete the resource of the subject",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				_, err := me.Delete.Do(withSubject, &DeleteRequest{})
+				require.Nil(t, err)
+
+				_, err = database.Get(context.Background(), "bjensen", nil)
+				assert.True(t, errors.Is(err, spec.ErrNotFound))
+			},
+		},
+		{
+			name: "subject without resource",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				_, err := me.Get.Do(context.Background(), &GetRequest{})
+				assert.True(t, errors.Is(err, spec.ErrNotFound))
+			},
+		},
+		{
+			name: "no resolver",
+			noMe: true,
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				_, err := me.Get.Do(withSubject, &GetRequest{})
+				assert.True(t, errors.Is(err, spec.ErrNotImplemented))
+				_, status := scimjson.SerializeError(err)
+				assert.Equal(t, 501, status)
+				_, err = me.Delete.Do(withSubject, &DeleteRequest{})
+				assert.True(t, errors.Is(err, spec.ErrNotImplemented))
+			},
+		},
+		{
+			name: "self registration disabled",
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				_, err := me.Create.Do(context.Background(), &CreateRequest{PayloadSource: strings.NewReader(`{}`)})
+				assert.True(t, errors.Is(err, spec.ErrNotImplemented))
+			},
+		},
+		{
+			name:   "self registration without resolver",
+			noMe:   true,
+			option: []MeOptions{SelfRegistration()},
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				_, err := me.Create.Do(context.Background(), &CreateRequest{PayloadSource: strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "ajensen",
+  "emails": [{"value": "ajensen@example.com"}]
+}`)})
+				assert.True(t, errors.Is(err, spec.ErrNotImplemented))
+
+				n, err := database.Count(context.Background(), `userName eq "ajensen"`)
+				require.Nil(t, err)
+				assert.Equal(t, 0, n)
+			},
+		},
+		{
+			name:   "self registration enabled",
+			option: []MeOptions{SelfRegistration()},
+			do: func(t *testing.T, me MeEndpoint, database db.DB) {
+				resp, err := me.Create.Do(context.Background(), &CreateRequest{PayloadSource: strings.NewReader(`
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "ajensen",
+  "emails": [{"value": "ajensen@example.com"}]
+}`)})
+				require.Nil(t, err)
+				assert.Equal(t, MeLocation, resp.Resource.MetaLocationOrEmpty())
+
+				n, err := database.Count(context.Background(), `userName eq "ajensen"`)
+				require.Nil(t, err)
+				assert.Equal(t, 1, n)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			database := db.Memory()
+			filters := []filter.ByResource{
+				filter.ByPropertyToByResource(
+					filter.ReadOnlyFilter(),
+					filter.UUIDFilter(),
+				),
+				filter.MetaFilter(),
+			}
+			user := s.resourceOf(t, map[string]interface{}{
+				"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+				"id":       "bjensen",
+				"userName": "bjensen",
+				"emails":   []interface{}{map[string]interface{}{"value": "bjensen@example.com"}},
+			})
+			require.Nil(t, filters[1].Filter(context.Background(), user))
+			require.Nil(t, database.Insert(context.Background(), user))
+
+			var r SubjectResolver
+			if !test.noMe {
+				r = resolver
+			}
+			me := MeService(MeEndpoint{
+				Get:     GetService(database),
+				Create:  CreateService(s.resourceType, database, filters),
+				Replace: ReplaceService(s.config, s.resourceType, database, filters),
+				Patch:   PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()}),
+				Delete:  DeleteService(s.config, database),
+			}, r, test.option...)
+			test.do(t, me, database)
+		})
+	}
+}
+
+func (s *MeServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(t, r.Navigator().Replace(data).Error())
+	return r
+}
+
+func (s *MeServiceTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
+
+	s.config, err = spec.NewServiceProviderConfigBuilder().Patch().Build()
+	require.Nil(s.T(), err)
+}
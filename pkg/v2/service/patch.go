@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/imulab/go-scim/pkg/v2/crud"
-	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	scimjson "github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
@@ -19,18 +18,30 @@ import (
 
 // PatchService returns a patch resource service. preFilters will run after resource fetched from database and before
 // resource is patched. postFilters will run after resource has been patched and before resource is saved back to database.
+// options may be used to customize the behaviour of the service, i.e. AzureCompat, CoerceValues or Intercept.
+//
+// A patch which does not change the resource, i.e. replacing a value with the same value, is detected by comparing
+// the resource before and after the operations with crud.Identical: postFilters are skipped, nothing is saved, the
+// After of the interceptors is not invoked, and the response is not Patched. The Minimal flag of the response tells whether the resource should be rendered, i.e. with 200 OK,
+// or not, i.e. with 204 No Content. It follows the Return preference of the request, or else is true for patches which
+// did not change the resource, and for all patches with the MinimalResponse option.
 func PatchService(
 	config *spec.ServiceProviderConfig,
 	database db.DB,
 	preFilters []filter.ByResource,
 	postFilters []filter.ByResource,
+	options ...PatchOptions,
 ) Patch {
-	return &patchService{
+	s := &patchService{
 		preFilters:  preFilters,
 		postFilters: postFilters,
 		database:    database,
 		config:      config,
 	}
+	for _, opt := range options {
+		opt.applyPatch(s)
+	}
+	return s
 }
 
 type (
@@ -51,23 +62,49 @@ type (
 	}
 	// Patch resource request
 	PatchRequest struct {
-		ResourceID    string                             // id of the resource to patch
-		MatchCriteria func(resource *prop.Resource) bool // extra criteria to meet for the resource to be patched
-		PayloadSource io.Reader                          // source to read the patch payload from
+		ResourceID      string                             // id of the resource to patch
+		MatchCriteria   func(resource *prop.Resource) bool // extra criteria to meet for the resource to be patched
+		ExpectedVersion string                             // expected meta.version of the resource, or empty to skip the check
+		Return          string                             // return preference of the client, ReturnMinimal, ReturnRepresentation, or empty
+		PayloadSource   io.Reader                          // source to read the patch payload from
 	}
 	// Patch resource response
 	PatchResponse struct {
-		Patched  bool           // true if the resource was patched; false if the resource was not patched but there was no error
+		Patched  bool           // true if the resource was patched; false if the patch did not change the resource
+		Minimal  bool           // true if the resource should not be rendered, i.e. with 204 No Content
 		Ref      *prop.Resource // reference resource (the before state)
-		Resource *prop.Resource // patched resource (the after state)
+		Resource *prop.Resource // patched resource (the after state), which is the reference resource if not patched
+		Quirks   []string       // non-standard payload shapes normalized in compatibility mode; always empty in strict mode
 	}
 )
 
+// Return preferences of the Prefer header, as defined in RFC7240 section 4.2.
+const (
+	ReturnMinimal        = "minimal"
+	ReturnRepresentation = "representation"
+)
+
+// MinimalResponse returns a PatchOptions that makes minimal responses, i.e. 204 No Content, the default for patches
+// which changed the resource, unless the request prefers the representation.
+func MinimalResponse() PatchOptions {
+	return minimalResponse{}
+}
+
+type minimalResponse struct{}
+
+func (o minimalResponse) applyPatch(s *patchService) {
+	s.minimal = true
+}
+
 type patchService struct {
-	preFilters  []filter.ByResource
-	postFilters []filter.ByResource
-	database    db.DB
-	config      *spec.ServiceProviderConfig
+	preFilters   []filter.ByResource
+	postFilters  []filter.ByResource
+	database     db.DB
+	config       *spec.ServiceProviderConfig
+	azureCompat  bool
+	coerceValues bool
+	minimal      bool
+	interceptors Interceptors
 }
 
 func (s *patchService) Do(ctx context.Context, req *PatchRequest) (resp *PatchResponse, err error) {
@@ -75,7 +112,8 @@ func (s *patchService) Do(ctx context.Context, req *PatchRequest) (resp *PatchRe
 		return
 	}
 
-	patch, err := s.parseRequest(req)
+	var q quirks
+	patch, err := s.parseRequest(req, &q)
 	if err != nil {
 		return
 	}
@@ -87,10 +125,32 @@ func (s *patchService) Do(ctx context.Context, req *PatchRequest) (resp *PatchRe
 	if err != nil {
 		return
 	}
+	if err = checkResourceType(ref); err != nil {
+		return
+	}
 
-	if s.config.ETag.Supported && req.MatchCriteria != nil {
-		if !req.MatchCriteria(ref) {
-			err = fmt.Errorf("%w: resource does not meet pre condition", spec.ErrConflict)
+	if err = checkVersion(s.config, ref, req.ExpectedVersion, req.MatchCriteria); err != nil {
+		return
+	}
+
+	// Values of all operations are parsed against their target attributes before any operation is applied, so that
+	// invalid values are reported without touching the resource.
+	values := make([]interface{}, len(patch.Operations))
+	for i, patchOp := range patch.Operations {
+		if len(patchOp.Value) == 0 {
+			continue
+		}
+		if s.azureCompat {
+			if attr, err := crud.TargetAttribute(ref, patchOp.Path); err == nil {
+				patchOp.Value = q.normalizeValue(attr, patchOp.Value)
+			}
+		}
+		if s.coerceValues {
+			if patchOp.Value, err = coerceValue(ref, &patchOp); err != nil {
+				return
+			}
+		}
+		if values[i], err = patchOp.ParseValue(ref); err != nil {
 			return
 		}
 	}
@@ -106,25 +166,26 @@ func (s *patchService) Do(ctx context.Context, req *PatchRequest) (resp *PatchRe
 		}
 	}
 
-	for _, patchOp := range patch.Operations {
-		switch strings.ToLower(patchOp.Op) {
-		case "add":
-			if valueToAdd, err := patchOp.ParseValue(resource); err != nil {
-				return nil, err
-			} else if err := crud.Add(resource, patchOp.Path, valueToAdd); err != nil {
-				return nil, err
-			}
-		case "replace":
-			if valueToReplace, err := patchOp.ParseValue(resource); err != nil {
-				return nil, err
-			} else if err := crud.Replace(resource, patchOp.Path, valueToReplace); err != nil {
-				return nil, err
-			}
-		case "remove":
-			if err := crud.Delete(resource, patchOp.Path); err != nil {
-				return nil, err
-			}
+	operations := make([]crud.PatchOperation, len(patch.Operations))
+	for i, patchOp := range patch.Operations {
+		operations[i] = crud.PatchOperation{Op: patchOp.Op, Path: patchOp.Path, Value: values[i]}
+	}
+	if _, err = crud.Patch(resource, operations); err != nil {
+		return nil, err
+	}
+
+	if crud.Identical(resource, ref) {
+		if err = s.interceptors.Before(ctx, OperationPatch, resource); err != nil {
+			return
+		}
+		resp = &PatchResponse{
+			Patched:  false,
+			Minimal:  s.minimalResponse(req, false),
+			Ref:      ref,
+			Resource: ref,
+			Quirks:   q,
 		}
+		return
 	}
 
 	for _, f := range s.postFilters {
@@ -133,30 +194,38 @@ func (s *patchService) Do(ctx context.Context, req *PatchRequest) (resp *PatchRe
 		}
 	}
 
-	var (
-		newVersion = resource.MetaVersionOrEmpty()
-		oldVersion = ref.MetaVersionOrEmpty()
-	)
-	if newVersion == oldVersion {
-		resp = &PatchResponse{
-			Patched: false,
-			Ref:     ref,
-		}
+	if err = s.interceptors.Before(ctx, OperationPatch, resource); err != nil {
 		return
 	}
 
 	if err = s.database.Replace(ctx, ref, resource); err != nil {
 		return
 	}
+	s.interceptors.After(ctx, OperationPatch, ref, resource)
 
 	resp = &PatchResponse{
 		Patched:  true,
+		Minimal:  s.minimalResponse(req, true),
 		Resource: resource,
 		Ref:      ref,
+		Quirks:   q,
 	}
 	return
 }
 
+// Returns true if the response should not render the resource, according to the return preference of the request,
+// or else to the MinimalResponse option and whether the resource was patched.
+func (s *patchService) minimalResponse(req *PatchRequest, patched bool) bool {
+	switch req.Return {
+	case ReturnMinimal:
+		return true
+	case ReturnRepresentation:
+		return false
+	default:
+		return s.minimal || !patched
+	}
+}
+
 func (s *patchService) checkSupport() error {
 	if !s.config.Patch.Supported {
 		return fmt.Errorf("%w: patch operation is not supported", spec.ErrInternal)
@@ -164,7 +233,7 @@ func (s *patchService) checkSupport() error {
 	return nil
 }
 
-func (s *patchService) parseRequest(req *PatchRequest) (*PatchPayload, error) {
+func (s *patchService) parseRequest(req *PatchRequest, q *quirks) (*PatchPayload, error) {
 	if req == nil || req.PayloadSource == nil {
 		return nil, fmt.Errorf("%w: no payload for patch service", spec.ErrInternal)
 	}
@@ -174,11 +243,19 @@ func (s *patchService) parseRequest(req *PatchRequest) (*PatchPayload, error) {
 		return nil, fmt.Errorf("%w: failed to read request body", spec.ErrInternal)
 	}
 
+	if err := scimjson.CheckDuplicateKeys(raw); err != nil {
+		return nil, err
+	}
+
 	patch := new(PatchPayload)
 	if err := json.Unmarshal(raw, patch); err != nil {
 		return nil, err
 	}
 
+	if s.azureCompat {
+		q.normalizePayload(raw, patch)
+	}
+
 	return patch, nil
 }
 
@@ -211,48 +288,26 @@ func (p *PatchPayload) Validate() error {
 	return nil
 }
 
+// ParseValue parses the value of this operation against the attribute targeted by the path of this operation. The
+// returned value is ready to be assigned to the target, and errors name the path and the expected type.
 func (o *PatchOperation) ParseValue(resource *prop.Resource) (interface{}, error) {
-	var (
-		head *expr.Expression
-		err  error
-	)
-	{
-		if len(o.Path) > 0 {
-			head, err = expr.CompilePath(o.Path)
-			if err != nil {
-				return nil, err
-			}
-			if head.IsPath() && head.Token() == resource.ResourceType().ID() {
-				head = head.Next()
-			}
-		}
-	}
-
-	attr := o.getTargetAttribute(resource.RootAttribute(), head)
-	if attr == nil {
-		return nil, fmt.Errorf("%w: path '%s' is invalid", spec.ErrInvalidPath, o.Path)
-	}
-
-	p := prop.NewProperty(attr)
-	if err := scimjson.DeserializeProperty(o.Value, p, strings.ToLower(o.Op) == "add"); err != nil {
+	attr, err := crud.TargetAttribute(resource, o.Path)
+	if err != nil {
 		return nil, err
 	}
 
-	return p.Raw(), nil
-}
-
-func (o *PatchOperation) getTargetAttribute(parentAttr *spec.Attribute, cursor *expr.Expression) *spec.Attribute {
-	if cursor == nil {
-		return parentAttr
+	value, err := scimjson.DeserializeValue(o.Value, attr)
+	if err != nil {
+		return nil, fmt.Errorf("%w (op path:'%s', expects:'%s')", err, o.Path, expectedType(attr))
 	}
 
-	if parentAttr == nil {
-		return nil
-	}
+	return value, nil
+}
 
-	if cursor.IsRootOfFilter() {
-		return o.getTargetAttribute(parentAttr, cursor.Next())
+// Describes the type of value expected by the attribute, i.e. string, complex, or array of string.
+func expectedType(attr *spec.Attribute) string {
+	if attr.MultiValued() {
+		return "array of " + attr.Type().String()
 	}
-
-	return o.getTargetAttribute(parentAttr.SubAttributeForName(cursor.Token()), cursor.Next())
+	return attr.Type().String()
 }
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Quirks that the Azure AD compatibility mode knows how to normalize. They are reported in PatchResponse.Quirks
+// when applied to a request. The case quirks are accepted in the strict mode as well, hence are report only.
+const (
+	// QuirkOperationsKeyCase indicates the "Operations" key of the patch payload was not cased as in the specification.
+	QuirkOperationsKeyCase = "operations_key_case"
+	// QuirkOpNameCase indicates the name of a patch operation (i.e. "Add") was not in lower case.
+	QuirkOpNameCase = "op_name_case"
+	// QuirkBooleanString indicates a boolean value was sent as the string "True" or "False".
+	QuirkBooleanString = "boolean_string"
+	// QuirkEncodedObject indicates an object value was sent as a JSON encoded string.
+	QuirkEncodedObject = "encoded_object"
+)
+
+// PatchOptions customizes the behaviour of the patch service.
+type PatchOptions interface {
+	applyPatch(s *patchService)
+}
+
+// AzureCompat returns a PatchOptions that enables the compatibility mode for the non-standard patch payloads sent by
+// Azure Active Directory. In this mode, the service accepts boolean values represented as the strings "True" and
+// "False", and JSON encoded strings in place of objects, which the strict mode rejects. These shapes are normalized
+// before the payload is processed, and the applied quirks are reported in PatchResponse.
+//
+// The case of the "Operations" key and of the op names is not enforced by either mode, since attribute names are case
+// insensitive as defined in RFC7643 section 2.1, and the service has always accepted op names in any case. The
+// compatibility mode only reports them, as QuirkOperationsKeyCase and QuirkOpNameCase.
+func AzureCompat() PatchOptions {
+	return azureCompat{}
+}
+
+type azureCompat struct{}
+
+func (o azureCompat) applyPatch(s *patchService) {
+	s.azureCompat = true
+}
+
+// CoerceValues returns a PatchOptions that converts the string values of the operations to the integer, decimal or
+// boolean type of their target attribute, when unambiguous, as done by crud.CoerceValues. Strings that cannot be
+// converted are rejected with spec.ErrInvalidValue. Without this option, such values are rejected as type mismatches.
+func CoerceValues() PatchOptions {
+	return coerceValues{}
+}
+
+type coerceValues struct{}
+
+func (o coerceValues) applyPatch(s *patchService) {
+	s.coerceValues = true
+}
+
+// coerceValue rewrites the raw value of the operation with crud.CoerceOperation. The raw value is returned unmodified
+// when it cannot be parsed, or its path cannot be resolved, so that the error is reported by ParseValue.
+func coerceValue(resource *prop.Resource, op *PatchOperation) (json.RawMessage, error) {
+	if _, err := crud.TargetAttribute(resource, op.Path); err != nil {
+		return op.Value, nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(op.Value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return op.Value, nil
+	}
+
+	coerced, err := crud.CoerceOperation(resource, crud.PatchOperation{Op: op.Op, Path: op.Path, Value: value})
+	if err != nil {
+		return nil, fmt.Errorf("%w (op path:'%s')", err, op.Path)
+	}
+	return json.Marshal(coerced)
+}
+
+// quirks collects the distinct compatibility quirks applied to a single patch request.
+type quirks []string
+
+func (q *quirks) add(quirk string) {
+	for _, each := range *q {
+		if each == quirk {
+			return
+		}
+	}
+	*q = append(*q, quirk)
+}
+
+// normalizePayload detects the quirks on the patch payload and operation names, and rewrites the operation names
+// into their canonical lower case form.
+func (q *quirks) normalizePayload(raw []byte, patch *PatchPayload) {
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &keys); err == nil {
+		for k := range keys {
+			if k != "Operations" && strings.EqualFold(k, "Operations") {
+				q.add(QuirkOperationsKeyCase)
+			}
+		}
+	}
+
+	for i, each := range patch.Operations {
+		if lowered := strings.ToLower(each.Op); lowered != each.Op {
+			patch.Operations[i].Op = lowered
+			q.add(QuirkOpNameCase)
+		}
+	}
+}
+
+// normalizeValue rewrites the boolean strings and JSON encoded objects in the raw value so it conforms to the target
+// attribute. The raw value is returned unmodified when no quirk was detected or when the raw value cannot be parsed.
+func (q *quirks) normalizeValue(attr *spec.Attribute, raw json.RawMessage) json.RawMessage {
+	var value interface{}
+	{
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return raw
+		}
+	}
+
+	changed := false
+	value = q.normalize(attr, value, &changed)
+	if !changed {
+		return raw
+	}
+
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return raw
+	}
+	return normalized
+}
+
+func (q *quirks) normalize(attr *spec.Attribute, value interface{}, changed *bool) interface{} {
+	if attr == nil || value == nil {
+		return value
+	}
+
+	if attr.MultiValued() {
+		elemAttr := attr.DeriveElementAttribute()
+		if array, ok := value.([]interface{}); ok {
+			for i := range array {
+				array[i] = q.normalize(elemAttr, array[i], changed)
+			}
+			return array
+		}
+		// single element may be used in place of an array
+		return q.normalize(elemAttr, value, changed)
+	}
+
+	switch attr.Type() {
+	case spec.TypeBoolean:
+		if s, ok := value.(string); ok {
+			switch {
+			case strings.EqualFold(s, "true"):
+				q.add(QuirkBooleanString)
+				*changed = true
+				return true
+			case strings.EqualFold(s, "false"):
+				q.add(QuirkBooleanString)
+				*changed = true
+				return false
+			}
+		}
+	case spec.TypeComplex:
+		if s, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "{") {
+			var decoded interface{}
+			decoder := json.NewDecoder(strings.NewReader(s))
+			decoder.UseNumber()
+			if err := decoder.Decode(&decoded); err == nil {
+				if _, ok := decoded.(map[string]interface{}); ok {
+					q.add(QuirkEncodedObject)
+					*changed = true
+					value = decoded
+				}
+			}
+		}
+		if object, ok := value.(map[string]interface{}); ok {
+			for k, v := range object {
+				object[k] = q.normalize(attr.SubAttributeForName(k), v, changed)
+			}
+			return object
+		}
+	}
+
+	return value
+}
@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/service/filter"
 	"github.com/imulab/go-scim/pkg/v2/spec"
@@ -12,8 +14,6 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/crypto/bcrypt"
-	"io/ioutil"
-	"os"
 	"strings"
 	"testing"
 )
@@ -41,8 +41,8 @@ func (s *PatchServiceTestSuite) TestDo() {
 			setup: func(t *testing.T) Patch {
 				database := db.Memory()
 				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
-					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
-					"id":       "foo",
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
 					"meta": map[string]interface{}{
 						"resourceType": "User",
 						"created":      "2019-11-20T13:09:00",
@@ -99,6 +99,7 @@ func (s *PatchServiceTestSuite) TestDo() {
 				assert.Nil(t, err)
 				require.NotNil(t, resp)
 				assert.True(t, resp.Patched)
+				assert.False(t, resp.Minimal)
 				assert.NotEmpty(t, resp.Resource.MetaVersionOrEmpty())
 				assert.NotEqual(t, resp.Ref.MetaVersionOrEmpty(), resp.Resource.MetaVersionOrEmpty())
 				assert.Equal(t, "foobar", resp.Resource.Navigator().Dot("userName").Current().Raw())
@@ -111,8 +112,8 @@ func (s *PatchServiceTestSuite) TestDo() {
 			setup: func(t *testing.T) Patch {
 				database := db.Memory()
 				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
-					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
-					"id":       "foo",
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
 					"meta": map[string]interface{}{
 						"resourceType": "User",
 						"created":      "2019-11-20T13:09:00",
@@ -160,15 +161,92 @@ func (s *PatchServiceTestSuite) TestDo() {
 				assert.Nil(t, err)
 				require.NotNil(t, resp)
 				assert.False(t, resp.Patched)
+				assert.True(t, resp.Minimal)
+				assert.True(t, resp.Ref == resp.Resource)
 			},
 		},
 		{
-			name: "patch to make a difference with upper case OP",
+			name: "patch to not make a difference is neither filtered nor saved",
 			setup: func(t *testing.T) Patch {
 				database := db.Memory()
-				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+				require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+					"emails": []interface{}{
+						map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+					},
+				})))
+				return PatchService(s.config, replaceFailingDB{database}, nil, []filter.ByResource{failingFilter{}})
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					Return:     ReturnRepresentation,
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "replace",
+			"path": "emails[value eq \"foo@bar.com\"].type",
+			"value": "work"
+		},
+		{
+			"op": "add",
+			"path": "emails",
+			"value": [{"value": "foo@bar.com", "type": "work"}]
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.False(t, resp.Patched)
+				assert.False(t, resp.Minimal)
+				assert.Equal(t, "foo", resp.Resource.IdOrEmpty())
+			},
+		},
+		{
+			name: "patch to make a difference with minimal response",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
 					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
 					"id":       "foo",
+					"userName": "foo",
+				})))
+				return PatchService(s.config, database, nil, nil, MinimalResponse())
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [{"op": "replace", "path": "userName", "value": "bar"}]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.True(t, resp.Patched)
+				assert.True(t, resp.Minimal)
+				assert.Equal(t, "bar", resp.Resource.Navigator().Dot("userName").Current().Raw())
+			},
+		},
+		{
+			name: "patch to make a difference with upper case OP",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
 					"meta": map[string]interface{}{
 						"resourceType": "User",
 						"created":      "2019-11-20T13:09:00",
@@ -237,8 +315,8 @@ func (s *PatchServiceTestSuite) TestDo() {
 			setup: func(t *testing.T) Patch {
 				database := db.Memory()
 				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
-					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
-					"id":       "foo",
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
 					"meta": map[string]interface{}{
 						"resourceType": "User",
 						"created":      "2019-11-20T13:09:00",
@@ -304,7 +382,7 @@ func (s *PatchServiceTestSuite) TestDo() {
 						"urn:ietf:params:scim:schemas:core:2.0:User",
 						"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
 					},
-					"id":       "foo",
+					"id": "foo",
 					"meta": map[string]interface{}{
 						"resourceType": "User",
 						"created":      "2019-11-20T13:09:00",
@@ -357,6 +435,366 @@ func (s *PatchServiceTestSuite) TestDo() {
 				assert.Equal(t, "6546579", resp.Resource.Navigator().Dot("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User").Dot("employeeNumber").Current().Raw())
 			},
 		},
+		{
+			name: "patch with azure quirks in compatibility mode",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
+					"meta": map[string]interface{}{
+						"resourceType": "User",
+						"created":      "2019-11-20T13:09:00",
+						"lastModified": "2019-11-20T13:09:00",
+						"location":     "https://identity.imulab.io/Users/foo",
+						"version":      "W/\"1\"",
+					},
+					"userName": "foo",
+					"active":   true,
+					"emails": []interface{}{
+						map[string]interface{}{
+							"value": "foo@bar.com",
+							"type":  "home",
+						},
+					},
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, []filter.ByResource{
+					filter.ByPropertyToByResource(filter.ValidationFilter(database)),
+					filter.MetaFilter(),
+				}, AzureCompat())
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"operations": [
+		{
+			"op": "Replace",
+			"path": "active",
+			"value": "False"
+		},
+		{
+			"op": "Add",
+			"path": "name",
+			"value": "{\"givenName\":\"Foo\",\"familyName\":\"Bar\"}"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.True(t, resp.Patched)
+				assert.Equal(t, false, resp.Resource.Navigator().Dot("active").Current().Raw())
+				assert.Equal(t, "Foo", resp.Resource.Navigator().Dot("name").Dot("givenName").Current().Raw())
+				assert.Equal(t, "Bar", resp.Resource.Navigator().Dot("name").Dot("familyName").Current().Raw())
+				assert.ElementsMatch(t, []string{
+					QuirkOperationsKeyCase,
+					QuirkOpNameCase,
+					QuirkBooleanString,
+					QuirkEncodedObject,
+				}, resp.Quirks)
+			},
+		},
+		{
+			name: "patch with azure quirks in strict mode",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas": []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":      "foo",
+					"meta": map[string]interface{}{
+						"resourceType": "User",
+						"created":      "2019-11-20T13:09:00",
+						"lastModified": "2019-11-20T13:09:00",
+						"location":     "https://identity.imulab.io/Users/foo",
+						"version":      "W/\"1\"",
+					},
+					"userName": "foo",
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, []filter.ByResource{
+					filter.ByPropertyToByResource(filter.ValidationFilter(database)),
+					filter.MetaFilter(),
+				})
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "add",
+			"path": "name",
+			"value": "{\"givenName\":\"Foo\"}"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.NotNil(t, err)
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name: "patch with case quirks in strict mode",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, []filter.ByResource{
+					filter.MetaFilter(),
+				})
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"operations": [
+		{
+			"op": "Replace",
+			"path": "displayName",
+			"value": "Foo"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.True(t, resp.Patched)
+				assert.Equal(t, "Foo", resp.Resource.Navigator().Dot("displayName").Current().Raw())
+				assert.Empty(t, resp.Quirks)
+			},
+		},
+		{
+			name: "patch with string values coerced",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+					"active":   false,
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, []filter.ByResource{
+					filter.MetaFilter(),
+				}, CoerceValues())
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "replace",
+			"path": "active",
+			"value": "true"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.True(t, resp.Patched)
+				assert.Equal(t, true, resp.Resource.Navigator().Dot("active").Current().Raw())
+				assert.Empty(t, resp.Quirks)
+			},
+		},
+		{
+			name: "patch with ambiguous string value coerced",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, nil, CoerceValues())
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "replace",
+			"path": "active",
+			"value": "yes"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+				assert.Contains(t, err.Error(), "value 'yes' for 'active' cannot be coerced to boolean (op path:'active')")
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name: "patch to replace selected elements and fully qualified path",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+					"emails": []interface{}{
+						map[string]interface{}{
+							"value": "foo@bar.com",
+							"type":  "home",
+						},
+						map[string]interface{}{
+							"value": "foo@work.com",
+							"type":  "work",
+						},
+					},
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, []filter.ByResource{
+					filter.MetaFilter(),
+				})
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "replace",
+			"path": "emails[type eq \"work\"]",
+			"value": {"value": "bar@work.com", "type": "work"}
+		},
+		{
+			"op": "replace",
+			"path": "urn:ietf:params:scim:schemas:core:2.0:User:userName",
+			"value": "bar"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, err)
+				require.NotNil(t, resp)
+				assert.True(t, resp.Patched)
+				assert.Equal(t, "bar", resp.Resource.Navigator().Dot("userName").Current().Raw())
+				assert.Equal(t, "foo@bar.com", resp.Resource.Navigator().Dot("emails").At(0).Dot("value").Current().Raw())
+				assert.Equal(t, "bar@work.com", resp.Resource.Navigator().Dot("emails").At(1).Dot("value").Current().Raw())
+			},
+		},
+		{
+			name: "patch with value incompatible with the target",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, nil)
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "replace",
+			"path": "userName",
+			"value": "bar"
+		},
+		{
+			"op": "add",
+			"path": "emails",
+			"value": [{"value": "foo@bar.com", "primary": "yes"}]
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.Nil(t, resp)
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+
+				var de *scimjson.DeserializeError
+				require.True(t, errors.As(err, &de))
+				assert.Equal(t, "/0/primary", de.Pointer)
+				assert.Contains(t, err.Error(), "op path:'emails'")
+				assert.Contains(t, err.Error(), "expects:'array of complex'")
+			},
+		},
+		{
+			name: "patch with duplicate keys",
+			setup: func(t *testing.T) Patch {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+				}))
+				require.Nil(t, err)
+				return PatchService(s.config, database, nil, nil)
+			},
+			getRequest: func() *PatchRequest {
+				return &PatchRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+	"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+	"Operations": [
+		{
+			"op": "remove",
+			"op": "replace",
+			"path": "userName",
+			"value": "bar"
+		}
+	]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *PatchResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				assert.Contains(t, err.Error(), "/Operations/0/op")
+				assert.Nil(t, resp)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -368,61 +806,109 @@ func (s *PatchServiceTestSuite) TestDo() {
 	}
 }
 
-func (s *PatchServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
-	r := prop.NewResource(s.resourceType)
-	require.Nil(t, r.Navigator().Replace(data).Error())
-	return r
-}
-
-func (s *PatchServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
+// A database which fails to replace resources, so that tests can assert nothing is saved.
+// Patches which only change sub attributes not contributing to the hash of the resource, or the case of a value, are
+// patches nonetheless.
+func (s *PatchServiceTestSuite) TestDoDetectsChanges() {
+	tests := []struct {
+		name      string
+		operation string
+		expect    func(t *testing.T, resource *prop.Resource)
 	}{
 		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:      "primary of an email",
+			operation: `{"op": "replace", "path": "emails[value eq \"foo@bar.com\"].primary", "value": true}`,
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, true, resource.Navigator().Dot("emails").At(0).Dot("primary").Current().Raw())
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:      "display of an email",
+			operation: `{"op": "replace", "path": "emails[value eq \"foo@bar.com\"].display", "value": "Foo at work"}`,
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, "Foo at work", resource.Navigator().Dot("emails").At(0).Dot("display").Current().Raw())
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:      "givenName",
+			operation: `{"op": "replace", "path": "name.givenName", "value": "Bar"}`,
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, "Bar", resource.Navigator().Dot("name").Dot("givenName").Current().Raw())
 			},
 		},
 		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
+			name:      "case of displayName",
+			operation: `{"op": "replace", "path": "displayName", "value": "FOO"}`,
+			expect: func(t *testing.T, resource *prop.Resource) {
+				assert.Equal(t, "FOO", resource.Navigator().Dot("displayName").Current().Raw())
 			},
 		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+	}
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			database := db.Memory()
+			require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+				"schemas":     []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+				"id":          "foo",
+				"userName":    "foo",
+				"displayName": "foo",
+				"name":        map[string]interface{}{"givenName": "Foo"},
+				"emails": []interface{}{
+					map[string]interface{}{"value": "foo@bar.com", "type": "work"},
+				},
+				"meta": map[string]interface{}{
+					"created":      "2020-01-01T00:00:00",
+					"lastModified": "2020-01-01T00:00:00",
+				},
+			})))
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+			service := PatchService(s.config, database, nil, []filter.ByResource{filter.MetaFilter()})
+			resp, err := service.Do(context.TODO(), &PatchRequest{
+				ResourceID:    "foo",
+				PayloadSource: strings.NewReader(`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [` + test.operation + `]}`),
+			})
+			require.Nil(t, err)
+			assert.True(t, resp.Patched)
+			assert.NotEqual(t, "2020-01-01T00:00:00", resp.Resource.Navigator().Dot("meta").Dot("lastModified").Current().Raw())
 
-		if each.post != nil {
-			each.post(each.structure)
-		}
+			saved, err := database.Get(context.TODO(), "foo", nil)
+			require.Nil(t, err)
+			test.expect(t, saved)
+		})
 	}
+}
+
+type replaceFailingDB struct {
+	db.DB
+}
+
+func (d replaceFailingDB) Replace(_ context.Context, _ *prop.Resource, _ *prop.Resource) error {
+	return errors.New("resource should not be replaced")
+}
+
+// A filter which always fails, so that tests can assert filters are skipped.
+type failingFilter struct{}
+
+func (f failingFilter) Filter(_ context.Context, _ *prop.Resource) error {
+	return errors.New("resource should not be filtered")
+}
+
+func (f failingFilter) FilterRef(_ context.Context, _ *prop.Resource, _ *prop.Resource) error {
+	return errors.New("resource should not be filtered")
+}
+
+func (s *PatchServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(t, r.Navigator().Replace(data).Error())
+	return r
+}
+
+func (s *PatchServiceTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 
 	s.config = new(spec.ServiceProviderConfig)
 	require.Nil(s.T(), json.Unmarshal([]byte(`
@@ -7,18 +7,44 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/crud/expr"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
 	"github.com/imulab/go-scim/pkg/v2/spec"
 )
 
 // QueryService returns a query resource service. This service is only capable of performing querying on a single type
-// of resource. This does not handle root query.
-func QueryService(config *spec.ServiceProviderConfig, database db.DB) Query {
-	return &queryService{
-		database: database,
-		config:   config,
-	}
+// of resource. This does not handle root query. The discriminating filter of the resource type, if any, is AND-ed
+// onto the filter of each query.
+//
+// Results are sorted according to the sortBy and sortOrder of the request, ascending by default, before pagination
+// is applied. The sort is pushed to the database when it implements db.SortCapable and reports that it can perform
+// it; otherwise, all matching resources are fetched and sorted in memory with crud.Sort.
+//
+// The pagination of the request is the effective one, i.e. with the default and maximum page sizes applied by
+// handlerutil.QueryRequestFromGet and handlerutil.QueryRequestFromPost; requests without pagination are served all
+// matching resources. The totalResults of the response is always the number of resources matching the filter,
+// regardless of the page returned.
+//
+// Interceptors registered with the Intercept option are invoked with each resource of the page.
+func QueryService(config *spec.ServiceProviderConfig, resourceType *spec.ResourceType, database db.DB, options ...QueryOptions) Query {
+	s := &queryService{
+		resourceType: resourceType,
+		database:     database,
+		config:       config,
+	}
+	for _, opt := range options {
+		opt.applyQuery(s)
+	}
+	return s
+}
+
+// QueryOptions customizes the behaviour of the query service.
+type QueryOptions interface {
+	applyQuery(s *queryService)
 }
 
+// Schema URN of the search request message
+const SearchRequestSchema = "urn:ietf:params:scim:api:messages:2.0:SearchRequest"
+
 type (
 	// Query resource service
 	Query interface {
@@ -31,6 +57,18 @@ type (
 		Pagination *crud.Pagination
 		Projection *crud.Projection
 	}
+	// SearchPayload is the search request message of a query by HTTP POST, as defined in RFC7644 section 3.4.3. The
+	// startIndex and count are nil when absent.
+	SearchPayload struct {
+		Schemas            []string `json:"schemas"`
+		Attributes         []string `json:"attributes"`
+		ExcludedAttributes []string `json:"excludedAttributes"`
+		Filter             string   `json:"filter"`
+		SortBy             string   `json:"sortBy"`
+		SortOrder          string   `json:"sortOrder"`
+		StartIndex         *int     `json:"startIndex"`
+		Count              *int     `json:"count"`
+	}
 	// Query resource response
 	QueryResponse struct {
 		TotalResults int
@@ -42,8 +80,10 @@ type (
 )
 
 type queryService struct {
-	database db.DB
-	config   *spec.ServiceProviderConfig
+	resourceType *spec.ResourceType
+	database     db.DB
+	config       *spec.ServiceProviderConfig
+	interceptors Interceptors
 }
 
 func (s *queryService) Do(ctx context.Context, req *QueryRequest) (resp *QueryResponse, err error) {
@@ -54,34 +94,43 @@ func (s *queryService) Do(ctx context.Context, req *QueryRequest) (resp *QueryRe
 	if err = req.ValidateAndDefault(); err != nil {
 		return
 	}
+	if err = s.validateSort(req.Sort); err != nil {
+		return
+	}
 
 	resp = new(QueryResponse)
 	resp.Projection = req.Projection
 
-	if req.Pagination != nil {
-		resp.StartIndex = req.Pagination.StartIndex
+	filter := crud.DiscriminateFilter(s.resourceType, req.Filter)
+
+	pagination := req.Pagination
+	if pagination != nil {
+		resp.StartIndex = pagination.StartIndex
 	}
 
-	if resp.TotalResults, err = s.database.Count(ctx, req.Filter); err != nil {
+	if resp.TotalResults, err = s.database.Count(ctx, filter); err != nil {
 		return
 	}
-	if req.Pagination != nil && req.Pagination.Count == 0 {
+	if pagination != nil && (pagination.Count == 0 || pagination.StartIndex > resp.TotalResults) {
 		return
 	}
 
-	if s.config.Filter.MaxResults > 0 {
-		if (req.Pagination == nil && resp.TotalResults > s.config.Filter.MaxResults) ||
-			(req.Pagination != nil && req.Pagination.Count > s.config.Filter.MaxResults) {
-			err = spec.ErrTooMany
-			return
-		}
+	var resources []*prop.Resource
+	if req.Sort == nil || canSort(s.database, req.Sort) {
+		resources, err = s.database.Query(ctx, filter, req.Sort, pagination, req.Projection)
+	} else {
+		resources, err = s.sortInMemory(ctx, filter, req.Sort, pagination)
 	}
-
-	resources, err := s.database.Query(ctx, req.Filter, req.Sort, req.Pagination, req.Projection)
 	if err != nil {
 		return
 	}
 	for _, r := range resources {
+		if err = s.interceptors.Before(ctx, OperationQuery, r); err != nil {
+			return
+		}
+	}
+	for _, r := range resources {
+		s.interceptors.After(ctx, OperationQuery, r, r)
 		resp.Resources = append(resp.Resources, r)
 	}
 
@@ -89,14 +138,64 @@ func (s *queryService) Do(ctx context.Context, req *QueryRequest) (resp *QueryRe
 	return
 }
 
+// Checks that the sortBy of the validated sort resolves to an attribute of the resource type, which has a value to be
+// sorted on: a singular or multiValued attribute of simple type, but not a complex one.
+func (s *queryService) validateSort(sort *crud.Sort) error {
+	if sort == nil {
+		return nil
+	}
+	attr, err := s.resourceType.AttributeByPath(sort.By)
+	if err != nil {
+		return err
+	}
+	if attr.IsComplex() {
+		return fmt.Errorf("%w: sortBy '%s' refers to a complex attribute, which cannot be sorted", spec.ErrInvalidValue, sort.By)
+	}
+	return nil
+}
+
+// Queries all matching resources without sorting them, sorts them with crud.Sort, and then applies the pagination.
+// The projection is not passed to the database, as it may exclude the sortBy attribute.
+func (s *queryService) sortInMemory(ctx context.Context, filter string, sort *crud.Sort, pagination *crud.Pagination) ([]*prop.Resource, error) {
+	resources, err := s.database.Query(ctx, filter, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := sort.Sort(resources); err != nil {
+		return nil, err
+	}
+	if pagination == nil {
+		return resources, nil
+	}
+	lb := pagination.StartIndex - 1
+	if lb > len(resources) {
+		lb = len(resources)
+	}
+	ub := lb + pagination.Count
+	if ub > len(resources) {
+		ub = len(resources)
+	}
+	return resources[lb:ub], nil
+}
+
+// Returns true if the database reports, through db.SortCapable, that it can perform the sort.
+func canSort(database db.DB, sort *crud.Sort) bool {
+	sc, ok := database.(db.SortCapable)
+	return ok && sc.CanSort(sort)
+}
+
 func (s *queryService) checkSupport(request *QueryRequest) error {
-	if !s.config.Filter.Supported {
+	return checkQuerySupport(s.config, request)
+}
+
+func checkQuerySupport(config *spec.ServiceProviderConfig, request *QueryRequest) error {
+	if !config.Filter.Supported {
 		if len(request.Filter) > 0 {
 			return fmt.Errorf("%w: filter is not supported", spec.ErrInvalidSyntax)
 		}
 	}
 
-	if !s.config.Sort.Supported {
+	if !config.Sort.Supported {
 		if request.Sort != nil && len(request.Sort.By) > 0 {
 			return fmt.Errorf("%w: sorting is not supported", spec.ErrInvalidSyntax)
 		}
@@ -117,39 +216,28 @@ func (q *QueryRequest) ValidateAndDefault() error {
 		if q.Pagination.StartIndex <= 0 {
 			q.Pagination.StartIndex = 1
 		}
+		if q.Pagination.Count < 0 {
+			q.Pagination.Count = 0
+		}
 	}
 	if q.Sort != nil {
 		if len(q.Sort.By) == 0 {
 			q.Sort.By = "id"
 		} else {
-			if _, err := expr.CompilePath(q.Sort.By); err != nil {
+			if _, err := expr.CompileSortPath(q.Sort.By); err != nil {
 				return err
 			}
 		}
 		switch q.Sort.Order {
-		case "", crud.SortAsc, crud.SortDesc:
+		case crud.SortDefault:
+			q.Sort.Order = crud.SortAsc
+		case crud.SortAsc, crud.SortDesc:
 		default:
 			return fmt.Errorf("%w: invalid sortOrder", spec.ErrInvalidSyntax)
 		}
 	}
-	if q.Projection != nil {
-		if len(q.Projection.Attributes) > 0 && len(q.Projection.ExcludedAttributes) > 0 {
-			return fmt.Errorf("%w: only one of attributes and excludedAttributes may be used", spec.ErrInvalidSyntax)
-		}
-		if len(q.Projection.Attributes) > 0 {
-			for _, p := range q.Projection.Attributes {
-				if _, err := expr.CompilePath(p); err != nil {
-					return err
-				}
-			}
-		}
-		if len(q.Projection.ExcludedAttributes) > 0 {
-			for _, p := range q.Projection.ExcludedAttributes {
-				if _, err := expr.CompilePath(p); err != nil {
-					return err
-				}
-			}
-		}
+	if err := q.Projection.Validate(); err != nil {
+		return err
 	}
 	return nil
 }
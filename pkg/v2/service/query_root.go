@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// RootQueryService returns a query service for the root endpoint, as defined in RFC7644 section 3.4.2.1, which searches
+// the resources of all resource types through their query services, i.e. those returned by QueryService. The filter
+// and the sortBy of the request are passed to each of the query services, hence must be valid for all resource types.
+// Interceptors are those of the query services.
+//
+// Without sortBy, the results are those of the query services in order, as if concatenated, and only the query
+// services covering the requested page are asked for resources. With sortBy, each query service returns its first
+// startIndex+count-1 sorted resources, which are merged and sorted again with crud.Sort; such a page must end within
+// the first maxResults resources, or the request fails with spec.ErrTooMany. The totalResults of the response is the
+// sum of those of the query services.
+func RootQueryService(config *spec.ServiceProviderConfig, services []Query) Query {
+	return &rootQueryService{
+		config:   config,
+		services: services,
+	}
+}
+
+type rootQueryService struct {
+	config   *spec.ServiceProviderConfig
+	services []Query
+}
+
+func (s *rootQueryService) Do(ctx context.Context, req *QueryRequest) (resp *QueryResponse, err error) {
+	if err = checkQuerySupport(s.config, req); err != nil {
+		return
+	}
+	if err = req.ValidateAndDefault(); err != nil {
+		return
+	}
+
+	resp = new(QueryResponse)
+	resp.Projection = req.Projection
+
+	pagination := req.Pagination
+	if pagination != nil {
+		resp.StartIndex = pagination.StartIndex
+	}
+
+	totals := make([]int, len(s.services))
+	for i, service := range s.services {
+		var counted *QueryResponse
+		counted, err = service.Do(ctx, &QueryRequest{
+			Filter:     req.Filter,
+			Sort:       copySort(req.Sort),
+			Pagination: &crud.Pagination{StartIndex: 1, Count: 0},
+		})
+		if err != nil {
+			return
+		}
+		totals[i] = counted.TotalResults
+		resp.TotalResults += counted.TotalResults
+	}
+	if pagination != nil && (pagination.Count == 0 || pagination.StartIndex > resp.TotalResults) {
+		return
+	}
+
+	if req.Sort == nil {
+		resp.Resources, err = s.concatenate(ctx, req, pagination, totals)
+	} else {
+		resp.Resources, err = s.merge(ctx, req, pagination, totals)
+	}
+	if err != nil {
+		return
+	}
+
+	resp.ItemsPerPage = len(resp.Resources)
+	return
+}
+
+// Returns the page of the resources of the query services in order, asking only those covering the page.
+func (s *rootQueryService) concatenate(ctx context.Context, req *QueryRequest, pagination *crud.Pagination, totals []int) ([]json.Serializable, error) {
+	offset, remaining := 0, -1
+	if pagination != nil {
+		offset, remaining = pagination.StartIndex-1, pagination.Count
+	}
+
+	var resources []json.Serializable
+	for i, service := range s.services {
+		if remaining == 0 {
+			break
+		}
+		if offset >= totals[i] {
+			offset -= totals[i]
+			continue
+		}
+
+		count := totals[i] - offset
+		if remaining > 0 && remaining < count {
+			count = remaining
+		}
+		page, err := service.Do(ctx, &QueryRequest{
+			Filter:     req.Filter,
+			Pagination: &crud.Pagination{StartIndex: offset + 1, Count: count},
+			Projection: req.Projection,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, page.Resources...)
+		if remaining > 0 {
+			remaining -= len(page.Resources)
+		}
+		offset = 0
+	}
+	return resources, nil
+}
+
+// Returns the page of the resources of the query services, merged and sorted. The projection is not passed to the
+// query services, as it may exclude the sortBy attribute; the response is projected when rendered.
+func (s *rootQueryService) merge(ctx context.Context, req *QueryRequest, pagination *crud.Pagination, totals []int) ([]json.Serializable, error) {
+	end := -1
+	if pagination != nil {
+		end = pagination.StartIndex - 1 + pagination.Count
+		if maxResults := s.config.Filter.MaxResults; maxResults > 0 && end > maxResults {
+			return nil, fmt.Errorf("%w: sorted root queries cannot page beyond the first %d resources", spec.ErrTooMany, maxResults)
+		}
+	}
+
+	var sorted []*prop.Resource
+	for i, service := range s.services {
+		if totals[i] == 0 {
+			continue
+		}
+
+		count := totals[i]
+		if end > 0 && end < count {
+			count = end
+		}
+		page, err := service.Do(ctx, &QueryRequest{
+			Filter:     req.Filter,
+			Sort:       copySort(req.Sort),
+			Pagination: &crud.Pagination{StartIndex: 1, Count: count},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, each := range page.Resources {
+			resource, ok := each.(*prop.Resource)
+			if !ok {
+				return nil, fmt.Errorf("%w: query service returned a non resource", spec.ErrInternal)
+			}
+			sorted = append(sorted, resource)
+		}
+	}
+
+	if err := req.Sort.Sort(sorted); err != nil {
+		return nil, err
+	}
+
+	lb, ub := 0, len(sorted)
+	if pagination != nil {
+		lb = pagination.StartIndex - 1
+		if end < ub {
+			ub = end
+		}
+	}
+	if lb > ub {
+		lb = ub
+	}
+
+	resources := make([]json.Serializable, 0, ub-lb)
+	for _, resource := range sorted[lb:ub] {
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// Returns a copy of the sort, so that the query services validating it do not modify that of the root request.
+func copySort(sort *crud.Sort) *crud.Sort {
+	if sort == nil {
+		return nil
+	}
+	c := *sort
+	return &c
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/prop"
@@ -10,8 +11,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"testing"
 )
 
@@ -46,7 +45,7 @@ func (s *QueryServiceTestSuite) TestDo() {
 				} {
 					require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
 				}
-				return QueryService(s.config, database)
+				return QueryService(s.config, s.resourceType, database)
 			},
 			getRequest: func() *QueryRequest {
 				return &QueryRequest{
@@ -76,7 +75,7 @@ func (s *QueryServiceTestSuite) TestDo() {
 				} {
 					require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
 				}
-				return QueryService(s.config, database)
+				return QueryService(s.config, s.resourceType, database)
 			},
 			getRequest: func() *QueryRequest {
 				return &QueryRequest{
@@ -103,7 +102,7 @@ func (s *QueryServiceTestSuite) TestDo() {
 				} {
 					require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
 				}
-				return QueryService(s.config, database)
+				return QueryService(s.config, s.resourceType, database)
 			},
 			getRequest: func() *QueryRequest {
 				return &QueryRequest{
@@ -136,7 +135,7 @@ func (s *QueryServiceTestSuite) TestDo() {
 				} {
 					require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
 				}
-				return QueryService(s.config, database)
+				return QueryService(s.config, s.resourceType, database)
 			},
 			getRequest: func() *QueryRequest {
 				return &QueryRequest{
@@ -160,6 +159,129 @@ func (s *QueryServiceTestSuite) TestDo() {
 				}
 			},
 		},
+		{
+			name: "discriminating filter of resource type",
+			setup: func(t *testing.T) Query {
+				serviceAccountType := new(spec.ResourceType)
+				require.Nil(t, json.Unmarshal([]byte(`
+{
+  "id": "ServiceAccount",
+  "name": "ServiceAccount",
+  "endpoint": "/ServiceAccounts",
+  "schema": "urn:ietf:params:scim:schemas:core:2.0:User",
+  "_filter": "userType eq \"service\""
+}
+`), serviceAccountType))
+				crud.Register(serviceAccountType)
+
+				database := db.Memory()
+				for _, userData := range []interface{}{
+					map[string]interface{}{"id": "user001", "userName": "user001", "userType": "employee"},
+					map[string]interface{}{"id": "user002", "userName": "user002", "userType": "service"},
+					map[string]interface{}{"id": "user003", "userName": "user003"},
+					map[string]interface{}{"id": "user004", "userName": "user004", "userType": "service"},
+				} {
+					require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
+				}
+				return QueryService(s.config, serviceAccountType, database)
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Filter: "userName pr",
+					Sort: &crud.Sort{
+						By:    "userName",
+						Order: crud.SortAsc,
+					},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 2, resp.TotalResults)
+				assert.Len(t, resp.Resources, 2)
+				for i, expected := range []string{"user002", "user004"} {
+					assert.Equal(t, expected, resp.Resources[i].(*prop.Resource).Navigator().Dot("id").Current().Raw())
+				}
+			},
+		},
+		{
+			name: "sort descending then paginate",
+			setup: func(t *testing.T) Query {
+				return QueryService(s.config, s.resourceType, s.sortDatabase(t, db.Memory()))
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Sort:       &crud.Sort{By: "name.familyName", Order: crud.SortDesc},
+					Pagination: &crud.Pagination{StartIndex: 1, Count: 2},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 4, resp.TotalResults)
+				s.assertIds(t, resp, "user003", "user001")
+			},
+		},
+		{
+			name: "sort in memory when the database cannot sort",
+			setup: func(t *testing.T) Query {
+				return QueryService(s.config, s.resourceType, unsortedDB{s.sortDatabase(t, db.Memory())})
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Sort:       &crud.Sort{By: "name.familyName"},
+					Pagination: &crud.Pagination{StartIndex: 2, Count: 3},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, 4, resp.TotalResults)
+				assert.Equal(t, 2, resp.StartIndex)
+				// ascending by default, with the unassigned familyName of user004 last
+				s.assertIds(t, resp, "user001", "user003", "user004")
+			},
+		},
+		{
+			name: "sort in memory on multiValued attribute by primary or first element",
+			setup: func(t *testing.T) Query {
+				return QueryService(s.config, s.resourceType, unsortedDB{s.sortDatabase(t, db.Memory())})
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Sort: &crud.Sort{By: "emails.value", Order: crud.SortAsc},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.Nil(t, err)
+				s.assertIds(t, resp, "user003", "user002", "user001", "user004")
+			},
+		},
+		{
+			name: "sort on complex attribute",
+			setup: func(t *testing.T) Query {
+				return QueryService(s.config, s.resourceType, db.Memory())
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Sort: &crud.Sort{By: "name"},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidValue))
+			},
+		},
+		{
+			name: "sort on unknown attribute",
+			setup: func(t *testing.T) Query {
+				return QueryService(s.config, s.resourceType, db.Memory())
+			},
+			getRequest: func() *QueryRequest {
+				return &QueryRequest{
+					Sort: &crud.Sort{By: "name.nickName"},
+				}
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidPath))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -171,61 +293,224 @@ func (s *QueryServiceTestSuite) TestDo() {
 	}
 }
 
-func (s *QueryServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
-	r := prop.NewResource(s.resourceType)
-	require.Nil(t, r.Navigator().Replace(data).Error())
-	return r
+func (s *QueryServiceTestSuite) TestDoPagination() {
+	config, err := spec.NewServiceProviderConfigBuilder().Filter(3).Sort().Build()
+	require.Nil(s.T(), err)
+
+	tests := []struct {
+		name        string
+		pagination  *crud.Pagination
+		expectStart int
+		expectIds   []string
+	}{
+		{
+			name:      "without pagination, all resources",
+			expectIds: []string{"user001", "user002", "user003", "user004", "user005"},
+		},
+		{
+			name:        "paginated",
+			pagination:  &crud.Pagination{StartIndex: 2, Count: 2},
+			expectStart: 2,
+			expectIds:   []string{"user002", "user003"},
+		},
+		{
+			name:        "startIndex less than 1 interpreted as 1",
+			pagination:  &crud.Pagination{StartIndex: -5, Count: 1},
+			expectStart: 1,
+			expectIds:   []string{"user001"},
+		},
+		{
+			name:        "negative count interpreted as 0",
+			pagination:  &crud.Pagination{StartIndex: 1, Count: -1},
+			expectStart: 1,
+		},
+		{
+			name:        "startIndex beyond the results",
+			pagination:  &crud.Pagination{StartIndex: 6, Count: 2},
+			expectStart: 6,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			database := db.Memory()
+			for _, id := range []string{"user001", "user002", "user003", "user004", "user005"} {
+				require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{"id": id})))
+			}
+
+			service := QueryService(config, s.resourceType, database)
+			resp, err := service.Do(context.TODO(), &QueryRequest{
+				Sort:       &crud.Sort{By: "id"},
+				Pagination: test.pagination,
+			})
+			require.Nil(t, err)
+			assert.Equal(t, 5, resp.TotalResults)
+			assert.Equal(t, test.expectStart, resp.StartIndex)
+			assert.Equal(t, len(test.expectIds), resp.ItemsPerPage)
+			s.assertIds(t, resp, test.expectIds...)
+		})
+	}
 }
 
-func (s *QueryServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
+func (s *QueryServiceTestSuite) TestDoRoot() {
+	config, err := spec.NewServiceProviderConfigBuilder().Filter(3).Sort().Build()
+	require.Nil(s.T(), err)
+	groupResourceType, err := spec.RegisterStandardGroupResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(groupResourceType)
+
+	tests := []struct {
+		name    string
+		request *QueryRequest
+		expect  func(t *testing.T, resp *QueryResponse, err error)
 	}{
 		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:    "without sortBy, the pages are concatenated",
+			request: &QueryRequest{Pagination: &crud.Pagination{StartIndex: 2, Count: 3}},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, 5, resp.TotalResults)
+				assert.Equal(t, 2, resp.StartIndex)
+				s.assertResourceTypes(t, resp, "User", "User", "Group")
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name:    "without pagination, all resources",
+			request: &QueryRequest{},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				require.Nil(t, err)
+				s.assertResourceTypes(t, resp, "User", "User", "User", "Group", "Group")
 			},
 		},
 		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
+			name: "with sortBy, the results are merged",
+			request: &QueryRequest{
+				Sort:       &crud.Sort{By: "id"},
+				Pagination: &crud.Pagination{StartIndex: 1, Count: 2},
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, 5, resp.TotalResults)
+				s.assertIds(t, resp, "group001", "group002")
 			},
 		},
 		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
+			name: "with sortBy in descending order",
+			request: &QueryRequest{
+				Sort:       &crud.Sort{By: "id", Order: crud.SortDesc},
+				Pagination: &crud.Pagination{StartIndex: 2, Count: 2},
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				require.Nil(t, err)
+				s.assertIds(t, resp, "user002", "user001")
 			},
 		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
+		{
+			name: "with sortBy, a page ending beyond maxResults",
+			request: &QueryRequest{
+				Sort:       &crud.Sort{By: "id"},
+				Pagination: &crud.Pagination{StartIndex: 3, Count: 2},
+			},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				assert.True(t, errors.Is(err, spec.ErrTooMany))
+			},
+		},
+		{
+			name:    "count only",
+			request: &QueryRequest{Filter: "id sw \"group\"", Pagination: &crud.Pagination{StartIndex: 1, Count: 0}},
+			expect: func(t *testing.T, resp *QueryResponse, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, 2, resp.TotalResults)
+				assert.Empty(t, resp.Resources)
+			},
+		},
+	}
 
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			users, groups := db.Memory(), db.Memory()
+			for _, id := range []string{"user001", "user002", "user003"} {
+				require.Nil(t, users.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{"id": id})))
+			}
+			for _, id := range []string{"group001", "group002"} {
+				group := prop.NewResource(groupResourceType)
+				require.Nil(t, group.Navigator().Replace(map[string]interface{}{"id": id}).Error())
+				require.Nil(t, groups.Insert(context.TODO(), group))
+			}
 
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
+			service := RootQueryService(config, []Query{
+				QueryService(config, s.resourceType, users),
+				QueryService(config, groupResourceType, groups),
+			})
+			resp, err := service.Do(context.TODO(), test.request)
+			test.expect(t, resp, err)
+		})
+	}
+}
 
-		if each.post != nil {
-			each.post(each.structure)
+// Inserts the users sorted on by the sort tests into the database.
+func (s *QueryServiceTestSuite) sortDatabase(t *testing.T, database db.DB) db.DB {
+	for _, userData := range []interface{}{
+		map[string]interface{}{
+			"id":     "user001",
+			"name":   map[string]interface{}{"familyName": "Foo"},
+			"emails": []interface{}{map[string]interface{}{"value": "c@example.com"}},
+		},
+		map[string]interface{}{
+			"id":   "user002",
+			"name": map[string]interface{}{"familyName": "Bar"},
+			"emails": []interface{}{
+				map[string]interface{}{"value": "a@example.com"},
+				map[string]interface{}{"value": "b@example.com", "primary": true},
+			},
+		},
+		map[string]interface{}{
+			"id":     "user003",
+			"name":   map[string]interface{}{"familyName": "Quz"},
+			"emails": []interface{}{map[string]interface{}{"value": "a@example.com"}},
+		},
+		map[string]interface{}{
+			"id": "user004",
+		},
+	} {
+		require.Nil(t, database.Insert(context.TODO(), s.resourceOf(t, userData)))
+	}
+	return database
+}
+
+func (s *QueryServiceTestSuite) assertIds(t *testing.T, resp *QueryResponse, ids ...string) {
+	if assert.Len(t, resp.Resources, len(ids)) {
+		for i, expected := range ids {
+			assert.Equal(t, expected, resp.Resources[i].(*prop.Resource).IdOrEmpty())
+		}
+	}
+}
+
+// Asserts the resource types of the resources, as the order of the resources is not defined without sortBy.
+func (s *QueryServiceTestSuite) assertResourceTypes(t *testing.T, resp *QueryResponse, resourceTypeIds ...string) {
+	if assert.Len(t, resp.Resources, len(resourceTypeIds)) {
+		for i, expected := range resourceTypeIds {
+			assert.Equal(t, expected, resp.Resources[i].(*prop.Resource).ResourceType().ID())
 		}
 	}
+}
+
+// A database which does not implement db.SortCapable, and hence whose results must be sorted by the service.
+type unsortedDB struct {
+	db.DB
+}
+
+func (s *QueryServiceTestSuite) resourceOf(t *testing.T, data interface{}) *prop.Resource {
+	r := prop.NewResource(s.resourceType)
+	require.Nil(t, r.Navigator().Replace(data).Error())
+	return r
+}
+
+func (s *QueryServiceTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 
 	s.config = new(spec.ServiceProviderConfig)
 	require.Nil(s.T(), json.Unmarshal([]byte(`
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
 	"github.com/imulab/go-scim/pkg/v2/json"
 	"github.com/imulab/go-scim/pkg/v2/prop"
@@ -12,19 +13,32 @@ import (
 	"io/ioutil"
 )
 
-// ReplaceService returns a replace service.
+// ReplaceService returns a replace service. Since a compliant client omits readOnly attributes from the replacement,
+// readOnly values, i.e. groups and meta.created, are carried forward from the stored resource, and omitted immutable
+// values are kept, before the filters run. Only an attempt to change an assigned immutable value is rejected with
+// spec.ErrMutability. options may be used to customize the behaviour of the service, i.e. Intercept.
 func ReplaceService(
 	config *spec.ServiceProviderConfig,
 	resourceType *spec.ResourceType,
 	database db.DB,
 	filters []filter.ByResource,
+	options ...ReplaceOptions,
 ) Replace {
-	return &replaceService{
+	s := &replaceService{
 		resourceType: resourceType,
 		filters:      filters,
 		database:     database,
 		config:       config,
 	}
+	for _, opt := range options {
+		opt.applyReplace(s)
+	}
+	return s
+}
+
+// ReplaceOptions customizes the behaviour of the replace service.
+type ReplaceOptions interface {
+	applyReplace(s *replaceService)
 }
 
 type (
@@ -34,9 +48,10 @@ type (
 	}
 	// Replace resource request
 	ReplaceRequest struct {
-		ResourceID    string                             // id of the resource to be replaced
-		PayloadSource io.Reader                          // source to read replacement payload from
-		MatchCriteria func(resource *prop.Resource) bool // extra criteria to meet in order to be replaced
+		ResourceID      string                             // id of the resource to be replaced
+		PayloadSource   io.Reader                          // source to read replacement payload from
+		MatchCriteria   func(resource *prop.Resource) bool // extra criteria to meet in order to be replaced
+		ExpectedVersion string                             // expected meta.version of the resource, or empty to skip the check
 	}
 	// Replace resource response
 	ReplaceResponse struct {
@@ -51,6 +66,7 @@ type replaceService struct {
 	filters      []filter.ByResource
 	database     db.DB
 	config       *spec.ServiceProviderConfig
+	interceptors Interceptors
 }
 
 func (s *replaceService) Do(ctx context.Context, req *ReplaceRequest) (resp *ReplaceResponse, err error) {
@@ -58,12 +74,12 @@ func (s *replaceService) Do(ctx context.Context, req *ReplaceRequest) (resp *Rep
 	if err != nil {
 		return
 	}
+	if err = checkResourceType(ref); err != nil {
+		return
+	}
 
-	if s.config.ETag.Supported && req.MatchCriteria != nil {
-		if !req.MatchCriteria(ref) {
-			err = fmt.Errorf("%w: resource does not meet pre condition", spec.ErrConflict)
-			return
-		}
+	if err = checkVersion(s.config, ref, req.ExpectedVersion, req.MatchCriteria); err != nil {
+		return
 	}
 
 	replacement, err := s.parseResource(req)
@@ -71,12 +87,20 @@ func (s *replaceService) Do(ctx context.Context, req *ReplaceRequest) (resp *Rep
 		return
 	}
 
+	if err = crud.EnforceMutability(ref, replacement); err != nil {
+		return
+	}
+
 	for _, f := range s.filters {
 		if err = f.FilterRef(ctx, replacement, ref); err != nil {
 			return
 		}
 	}
 
+	if err = s.interceptors.Before(ctx, OperationReplace, replacement); err != nil {
+		return
+	}
+
 	var (
 		newVersion = replacement.MetaVersionOrEmpty()
 		oldVersion = ref.MetaVersionOrEmpty()
@@ -92,6 +116,7 @@ func (s *replaceService) Do(ctx context.Context, req *ReplaceRequest) (resp *Rep
 	if err = s.database.Replace(ctx, ref, replacement); err != nil {
 		return
 	}
+	s.interceptors.After(ctx, OperationReplace, ref, replacement)
 
 	resp = &ReplaceResponse{
 		Replaced: true,
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/imulab/go-scim/pkg/v2/crud"
 	"github.com/imulab/go-scim/pkg/v2/db"
@@ -12,8 +11,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"io/ioutil"
-	"os"
 	"strings"
 	"testing"
 )
@@ -127,6 +124,128 @@ func (s *ReplaceServiceTestSuite) TestDo() {
 				assert.False(t, resp.Replaced)
 			},
 		},
+		{
+			name: "replace with a minimal resource preserves readOnly values",
+			setup: func(t *testing.T) Replace {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+					"emails": []interface{}{
+						map[string]interface{}{
+							"value": "foo@bar.com",
+						},
+					},
+					"groups": []interface{}{
+						map[string]interface{}{
+							"value":   "e9e30dba-f08f-4109-8486-d5c6a331660a",
+							"$ref":    "https://example.com/v2/Groups/e9e30dba-f08f-4109-8486-d5c6a331660a",
+							"display": "Tour Guides",
+						},
+					},
+					"meta": map[string]interface{}{
+						"resourceType": "User",
+						"created":      "2010-01-23T04:56:22",
+						"lastModified": "2011-05-13T04:42:34",
+						"version":      "W/\"a330bc54f0671c9\"",
+					},
+				}))
+				require.Nil(t, err)
+				return ReplaceService(&spec.ServiceProviderConfig{}, s.resourceType, database, []filter.ByResource{
+					filter.ByPropertyToByResource(filter.ValidationFilter(database)),
+					filter.MetaFilter(),
+				})
+			},
+			getRequest: func() *ReplaceRequest {
+				return &ReplaceRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+  "schemas": [
+    "urn:ietf:params:scim:schemas:core:2.0:User"
+  ],
+  "userName": "bar",
+  "emails": [
+    {
+      "value": "foo@bar.com"
+    }
+  ]
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *ReplaceResponse, err error) {
+				require.Nil(t, err)
+				assert.True(t, resp.Replaced)
+				assert.Equal(t, "foo", resp.Resource.IdOrEmpty())
+				assert.Equal(t, "bar", resp.Resource.Navigator().Dot("userName").Current().Raw())
+				assert.Equal(t, "e9e30dba-f08f-4109-8486-d5c6a331660a",
+					resp.Resource.Navigator().Dot("groups").At(0).Dot("value").Current().Raw())
+				assert.Equal(t, "Tour Guides",
+					resp.Resource.Navigator().Dot("groups").At(0).Dot("display").Current().Raw())
+				assert.Equal(t, "2010-01-23T04:56:22",
+					resp.Resource.Navigator().Dot("meta").Dot("created").Current().Raw())
+				assert.NotEqual(t, "2011-05-13T04:42:34",
+					resp.Resource.Navigator().Dot("meta").Dot("lastModified").Current().Raw())
+			},
+		},
+		{
+			name: "replace with readOnly values different from the stored ones",
+			setup: func(t *testing.T) Replace {
+				database := db.Memory()
+				err := database.Insert(context.TODO(), s.resourceOf(t, map[string]interface{}{
+					"schemas":  []interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+					"id":       "foo",
+					"userName": "foo",
+					"emails": []interface{}{
+						map[string]interface{}{
+							"value": "foo@bar.com",
+						},
+					},
+					"meta": map[string]interface{}{
+						"created": "2010-01-23T04:56:22",
+					},
+				}))
+				require.Nil(t, err)
+				return ReplaceService(&spec.ServiceProviderConfig{}, s.resourceType, database, []filter.ByResource{
+					filter.ByPropertyToByResource(filter.ValidationFilter(database)),
+					filter.MetaFilter(),
+				})
+			},
+			getRequest: func() *ReplaceRequest {
+				return &ReplaceRequest{
+					ResourceID: "foo",
+					PayloadSource: strings.NewReader(`
+{
+  "schemas": [
+    "urn:ietf:params:scim:schemas:core:2.0:User"
+  ],
+  "id": "bar",
+  "userName": "foo",
+  "emails": [
+    {
+      "value": "foo@bar.com"
+    }
+  ],
+  "groups": [
+    {
+      "value": "e9e30dba-f08f-4109-8486-d5c6a331660a"
+    }
+  ],
+  "meta": {
+    "created": "2020-01-01T00:00:00"
+  }
+}
+`),
+				}
+			},
+			expect: func(t *testing.T, resp *ReplaceResponse, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "foo", resp.Ref.IdOrEmpty())
+				assert.False(t, resp.Replaced)
+			},
+		},
 		{
 			name: "replace with an invalid resource",
 			setup: func(t *testing.T) Replace {
@@ -191,52 +310,8 @@ func (s *ReplaceServiceTestSuite) resourceOf(t *testing.T, data interface{}) *pr
 }
 
 func (s *ReplaceServiceTestSuite) SetupSuite() {
-	for _, each := range []struct {
-		filepath  string
-		structure interface{}
-		post      func(parsed interface{})
-	}{
-		{
-			filepath:  "../../../public/schemas/core_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/schemas/user_enterprise_extension_schema.json",
-			structure: new(spec.Schema),
-			post: func(parsed interface{}) {
-				spec.Schemas().Register(parsed.(*spec.Schema))
-			},
-		},
-		{
-			filepath:  "../../../public/resource_types/user_resource_type.json",
-			structure: new(spec.ResourceType),
-			post: func(parsed interface{}) {
-				s.resourceType = parsed.(*spec.ResourceType)
-				crud.Register(s.resourceType)
-			},
-		},
-	} {
-		f, err := os.Open(each.filepath)
-		require.Nil(s.T(), err)
-
-		raw, err := ioutil.ReadAll(f)
-		require.Nil(s.T(), err)
-
-		err = json.Unmarshal(raw, each.structure)
-		require.Nil(s.T(), err)
-
-		if each.post != nil {
-			each.post(each.structure)
-		}
-	}
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
 }
@@ -0,0 +1,92 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+)
+
+// AnnotationPolicy decides how loading a schema treats annotations that are not registered with annotation.Register,
+// which are most likely misspelled, since an unknown annotation has no effect.
+type AnnotationPolicy int32
+
+const (
+	// AnnotationPolicyIgnore accepts unknown annotations silently. This is the default.
+	AnnotationPolicyIgnore AnnotationPolicy = iota
+	// AnnotationPolicyWarn accepts unknown annotations, but passes a warning naming each of them to the handler set by
+	// SetWarningHandler.
+	AnnotationPolicyWarn
+	// AnnotationPolicyReject fails loading the schema with ErrInvalidValue naming the first unknown annotation.
+	AnnotationPolicyReject
+)
+
+var annotationPolicy int32
+
+// SetAnnotationPolicy sets how schemas loaded afterwards treat unknown annotations. Regardless of the policy, the
+// parameters of registered annotations are always validated.
+func SetAnnotationPolicy(policy AnnotationPolicy) {
+	atomic.StoreInt32(&annotationPolicy, int32(policy))
+}
+
+// Checks the annotations of the schema and those of its attributes, recursively, against the annotation registry.
+// The annotations listed in the parameters of @ElementAnnotations are checked as well.
+func validateSchemaAnnotations(schemaId string, annotations map[string]map[string]interface{}, attributes []*Attribute) error {
+	if err := validateAnnotations(fmt.Sprintf("schema '%s'", schemaId), annotations); err != nil {
+		return err
+	}
+
+	var err error
+	for _, attr := range attributes {
+		attr.DFS(func(attr *Attribute) {
+			if err == nil {
+				err = validateAnnotations(fmt.Sprintf("attribute '%s'", attr.id), attr.annotations)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validates the annotations of the owner, which is described like "attribute 'urn:...:emails'" in errors, in the
+// lexical order of their names so that the reported error is deterministic.
+func validateAnnotations(owner string, annotations map[string]map[string]interface{}) error {
+	names := make([]string, 0, len(annotations))
+	for name := range annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policy := AnnotationPolicy(atomic.LoadInt32(&annotationPolicy))
+	for _, name := range names {
+		params := annotations[name]
+
+		if !annotation.IsRegistered(name) {
+			switch policy {
+			case AnnotationPolicyReject:
+				return fmt.Errorf("%w: %s has unknown annotation '%s'", ErrInvalidValue, owner, name)
+			case AnnotationPolicyWarn:
+				warn(fmt.Errorf("%w: %s has unknown annotation '%s'", ErrInvalidValue, owner, name))
+			}
+			continue
+		}
+
+		if err := annotation.Validate(name, params); err != nil {
+			return fmt.Errorf("%w: %s has invalid annotation '%s': %s", ErrInvalidValue, owner, name, err)
+		}
+
+		if name == annotation.ElementAnnotations {
+			elementAnnotations := map[string]map[string]interface{}{}
+			for elementName, elementParams := range params {
+				elementAnnotations[elementName], _ = elementParams.(map[string]interface{})
+			}
+			if err := validateAnnotations(owner+" (element)", elementAnnotations); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationValidation(t *testing.T) {
+	annotation.Register("@PII", func(params annotation.Params) error {
+		if level, ok := params.Int("level"); !ok || level < 1 || level > 3 {
+			return fmt.Errorf("parameter 'level' must be an integer between 1 and 3")
+		}
+		return nil
+	})
+
+	schemaOf := func(annotations string) string {
+		return fmt.Sprintf(`
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:ssn",
+      "name": "ssn",
+      "type": "string",
+      "_path": "ssn",
+      "_annotations": %s
+    }
+  ]
+}
+`, annotations)
+	}
+
+	tests := []struct {
+		name   string
+		policy AnnotationPolicy
+		raw    string
+		expect func(t *testing.T, schema *Schema, err error)
+	}{
+		{
+			name: "custom annotation with typed parameters",
+			raw:  schemaOf(`{"@PII": {"level": 2}, "@Redact": {"keepLast": 4}}`),
+			expect: func(t *testing.T, schema *Schema, err error) {
+				require.Nil(t, err)
+				params, ok := schema.attributes[0].AnnotationParams("@PII")
+				assert.True(t, ok)
+				level, ok := params.Int("level")
+				assert.True(t, ok)
+				assert.Equal(t, 2, level)
+			},
+		},
+		{
+			name: "invalid parameter of custom annotation",
+			raw:  schemaOf(`{"@PII": {"level": 5}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:ssn' has invalid annotation '@PII': parameter 'level' must be an integer between 1 and 3")
+			},
+		},
+		{
+			name: "invalid parameter of built-in annotation",
+			raw:  schemaOf(`{"@BCrypt": {"cost": 100}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has invalid annotation '@BCrypt': parameter 'cost' must not be greater than 31")
+			},
+		},
+		{
+			name: "invalid parameter of element annotation",
+			raw:  schemaOf(`{"@ElementAnnotations": {"@ReadOnly": {"reset": "yes"}}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has invalid annotation '@ReadOnly': parameter 'reset' must be a boolean")
+			},
+		},
+		{
+			name: "invalid pattern of constraints",
+			raw:  schemaOf(`{"@Constraints": {"maxLength": 9, "pattern": "^[0-9+$"}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has invalid annotation '@Constraints': parameter 'pattern' is not a valid regular expression")
+			},
+		},
+		{
+			name: "minLength greater than maxLength of constraints",
+			raw:  schemaOf(`{"@Constraints": {"minLength": 10, "maxLength": 9}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "parameter 'minLength' must not be greater than 'maxLength'")
+			},
+		},
+		{
+			name: "minimum greater than maximum of constraints",
+			raw:  schemaOf(`{"@Constraints": {"minimum": 10000, "maximum": 1}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "parameters 'minimum' and 'maximum' leave no value allowed")
+			},
+		},
+		{
+			name: "exclusive minimum equal to maximum of constraints",
+			raw:  schemaOf(`{"@Constraints": {"minimum": 1, "exclusiveMinimum": true, "maximum": 1}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "parameters 'minimum' and 'maximum' leave no value allowed")
+			},
+		},
+		{
+			name: "invalid sunset of deprecated",
+			raw:  schemaOf(`{"@Deprecated": {"message": "use employeeNumber", "sunset": "next year"}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has invalid annotation '@Deprecated': parameter 'sunset' must be a date")
+			},
+		},
+		{
+			name:   "unknown annotation ignored by default",
+			policy: AnnotationPolicyIgnore,
+			raw:    schemaOf(`{"@Redcat": {}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:   "unknown annotation warned",
+			policy: AnnotationPolicyWarn,
+			raw:    schemaOf(`{"@Redcat": {}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name:   "unknown annotation rejected",
+			policy: AnnotationPolicyReject,
+			raw:    schemaOf(`{"@Redcat": {}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:ssn' has unknown annotation '@Redcat'")
+			},
+		},
+		{
+			name:   "unknown element annotation rejected",
+			policy: AnnotationPolicyReject,
+			raw:    schemaOf(`{"@ElementAnnotations": {"@StateSumary": {}}}`),
+			expect: func(t *testing.T, _ *Schema, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has unknown annotation '@StateSumary'")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetAnnotationPolicy(test.policy)
+			defer SetAnnotationPolicy(AnnotationPolicyIgnore)
+
+			schema := new(Schema)
+			err := json.Unmarshal([]byte(test.raw), schema)
+			test.expect(t, schema, err)
+		})
+	}
+
+	t.Run("warning handler", func(t *testing.T) {
+		var warnings []error
+		SetWarningHandler(func(warning error) {
+			warnings = append(warnings, warning)
+		})
+		defer SetWarningHandler(nil)
+		SetAnnotationPolicy(AnnotationPolicyWarn)
+		defer SetAnnotationPolicy(AnnotationPolicyIgnore)
+
+		schema := new(Schema)
+		require.Nil(t, json.Unmarshal([]byte(schemaOf(`{"@Redcat": {}}`)), schema))
+		if assert.Len(t, warnings, 1) {
+			assert.True(t, errors.Is(warnings[0], ErrInvalidValue))
+			assert.Contains(t, warnings[0].Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:ssn' has unknown annotation '@Redcat'")
+		}
+	})
+
+	t.Run("builder", func(t *testing.T) {
+		SetAnnotationPolicy(AnnotationPolicyReject)
+		defer SetAnnotationPolicy(AnnotationPolicyIgnore)
+
+		_, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Test", "Test").
+			Annotation("@Intenal", nil).
+			Attribute(StringAttr("ssn")).
+			Build()
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		assert.Contains(t, err.Error(), "schema 'urn:ietf:params:scim:schemas:test:2.0:Test' has unknown annotation '@Intenal'")
+	})
+}
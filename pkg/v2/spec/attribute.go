@@ -7,6 +7,7 @@ import (
 	"github.com/imulab/go-scim/pkg/v2/spec/internal"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -112,6 +113,34 @@ func (attr *Attribute) MultiValued() bool {
 	return attr.multiValued
 }
 
+// IsMultiValued returns true when the attribute is multiValued. It is the same as MultiValued, and reads better next to
+// the other shape predicates.
+func (attr *Attribute) IsMultiValued() bool {
+	return attr.multiValued
+}
+
+// IsComplex returns true when the attribute is of type complex, whether it is singular or multiValued.
+func (attr *Attribute) IsComplex() bool {
+	return attr.typ == TypeComplex
+}
+
+// IsScalar returns true when the attribute is neither multiValued nor complex, hence its properties hold a single
+// simple value and have no children.
+func (attr *Attribute) IsScalar() bool {
+	return !attr.multiValued && attr.typ != TypeComplex
+}
+
+// IsReference returns true when the attribute is of type reference, whether it is singular or multiValued.
+func (attr *Attribute) IsReference() bool {
+	return attr.typ == TypeReference
+}
+
+// IsScalarString returns true when the attribute is a singular attribute of type string. References, dateTimes and
+// binaries, which are also represented by strings, are not included.
+func (attr *Attribute) IsScalarString() bool {
+	return !attr.multiValued && attr.typ == TypeString
+}
+
 // Required return true when the attribute is required.
 func (attr *Attribute) Required() bool {
 	return attr.required
@@ -137,6 +166,18 @@ func (attr *Attribute) Uniqueness() Uniqueness {
 	return attr.uniqueness
 }
 
+// GoType returns the Go type that values of this attribute are expected to be decoded into: string for string and
+// reference, int64 for integer, float64 for decimal, bool for boolean, time.Time for dateTime, []byte for binary, and
+// map[string]interface{} for complex. For multiValued attributes, the type is a slice of the element type. Note that
+// this is the decoding target for generic decoders, and not necessarily the internal representation of properties.
+func (attr *Attribute) GoType() reflect.Type {
+	t := attr.typ.goType()
+	if attr.multiValued {
+		return reflect.SliceOf(t)
+	}
+	return t
+}
+
 // ForEachCanonicalValues invokes callback function on each defined canonical values
 func (attr *Attribute) ForEachCanonicalValues(callback func(canonicalValue string)) {
 	for _, cv := range attr.canonicalValues {
@@ -154,11 +195,54 @@ func (attr *Attribute) ExistsCanonicalValue(criteria func(canonicalValue string)
 	return false
 }
 
+// ConformCanonicalValue returns the canonical value that the value conforms to. The value conforms to a canonical value
+// equal to it, compared with respect to caseExact. When normalize is true, or when the @Enum annotation of the attribute
+// has the boolean parameter "normalize" set to true, the comparison is case insensitive regardless of caseExact, and the
+// returned canonical value, which may differ from the value by case, is the spelling that should be stored. If the
+// value conforms to none of the canonical values, the error is ErrInvalidValue listing the canonical values.
+func (attr *Attribute) ConformCanonicalValue(value string, normalize bool) (string, error) {
+	if !normalize {
+		if params, ok := attr.AnnotationParams(annotation.Enum); ok {
+			normalize, _ = params.Bool("normalize")
+		}
+	}
+
+	var folded string
+	for _, cv := range attr.canonicalValues {
+		switch {
+		case cv == value:
+			return cv, nil
+		case (normalize || !attr.caseExact) && len(folded) == 0 && strings.EqualFold(cv, value):
+			folded = cv
+		}
+	}
+
+	if len(folded) > 0 {
+		if normalize {
+			return folded, nil
+		}
+		return value, nil
+	}
+
+	allowed := make([]string, 0, len(attr.canonicalValues))
+	for _, cv := range attr.canonicalValues {
+		allowed = append(allowed, strconv.Quote(cv))
+	}
+	return "", fmt.Errorf("%w: value of '%s' does not conform to canonicalValues, allowed values are %s",
+		ErrInvalidValue, attr.path, strings.Join(allowed, ", "))
+}
+
 // CountCanonicalValues returns the total number of canonical values defined.
 func (attr *Attribute) CountCanonicalValues() int {
 	return len(attr.canonicalValues)
 }
 
+// ReferenceTypes returns a copy of the reference types of a reference attribute, i.e. ["User", "Group"], or an empty
+// slice if none is defined.
+func (attr *Attribute) ReferenceTypes() []string {
+	return append([]string{}, attr.referenceTypes...)
+}
+
 // ForEachReferenceTypes invokes callback function on each defined reference types
 func (attr *Attribute) ForEachReferenceTypes(callback func(referenceType string)) {
 	for _, rt := range attr.referenceTypes {
@@ -241,6 +325,13 @@ func (attr *Attribute) Annotation(name string) (params map[string]interface{}, o
 	return
 }
 
+// AnnotationParams is like Annotation, but returns the parameters typed for access to individual parameters, i.e.
+// attr.AnnotationParams("@BCrypt") followed by params.Int("cost").
+func (attr *Attribute) AnnotationParams(name string) (params annotation.Params, ok bool) {
+	params, ok = attr.annotations[name]
+	return
+}
+
 // ForEachAnnotation iterates through annotations and invoke callback.
 func (attr *Attribute) ForEachAnnotation(callback func(annotation string, params map[string]interface{})) {
 	for k, v := range attr.annotations {
@@ -361,7 +452,11 @@ func (attr *Attribute) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// Converts the attribute to the marshaler, including the sub attributes in their order. Besides the characteristics
+// defined in RFC7643, the id, index, path and annotations are included, so that marshaling is the inverse of
+// unmarshalling. The /Schemas endpoint uses a different serializer which omits them.
 func (attr *Attribute) convertToMarshaler(m *internal.AttributeMarshaler) {
+	m.ID = attr.id
 	m.Name = attr.name
 	m.Description = attr.description
 	m.Type = attr.typ.String()
@@ -373,6 +468,9 @@ func (attr *Attribute) convertToMarshaler(m *internal.AttributeMarshaler) {
 	m.Returned = attr.returned.String()
 	m.Uniqueness = attr.uniqueness.String()
 	m.ReferenceTypes = attr.referenceTypes
+	m.Index = attr.index
+	m.Path = attr.path
+	m.Annotations = attr.annotations
 
 	for _, subAttr := range attr.subAttributes {
 		sm := internal.AttributeMarshaler{
@@ -389,24 +487,41 @@ func (attr *Attribute) UnmarshalJSON(raw []byte) error {
 		return err
 	}
 
-	attr.convertFromUnmarshaler(&um)
+	if err := attr.convertFromUnmarshaler(&um); err != nil {
+		return err
+	}
 	attr.sort() // sort the sub attributes recursively to maintain strong order
 
 	return nil
 }
 
-func (attr *Attribute) convertFromUnmarshaler(um *internal.AttributeUnmarshaler) {
+// Converts the unmarshaler to the attribute, including the sub attributes. The type, mutability, returned and
+// uniqueness values must be legal, otherwise the error is ErrInvalidValue naming the attribute by its id.
+func (attr *Attribute) convertFromUnmarshaler(um *internal.AttributeUnmarshaler) error {
+	var (
+		ok  bool
+		err error
+	)
+
 	attr.id = um.ID
 	attr.name = um.Name
 	attr.description = um.Description
-	attr.typ = mustParseType(um.Type)
+	if attr.typ, ok = parseType(um.Type); !ok {
+		return attr.errIllegalValue("type", um.Type)
+	}
 	attr.canonicalValues = um.CanonicalValues
 	attr.multiValued = um.MultiValued
 	attr.required = um.Required
 	attr.caseExact = um.CaseExact
-	attr.mutability = mustParseMutability(um.Mutability)
-	attr.returned = mustParseReturned(um.Returned)
-	attr.uniqueness = mustParseUniqueness(um.Uniqueness)
+	if attr.mutability, err = ParseMutability(um.Mutability); err != nil {
+		return attr.errIllegalValue("mutability", um.Mutability)
+	}
+	if attr.returned, err = ParseReturned(um.Returned); err != nil {
+		return attr.errIllegalValue("returned", um.Returned)
+	}
+	if attr.uniqueness, err = ParseUniqueness(um.Uniqueness); err != nil {
+		return attr.errIllegalValue("uniqueness", um.Uniqueness)
+	}
 	attr.referenceTypes = um.ReferenceTypes
 	attr.index = um.Index
 	attr.path = um.Path
@@ -415,9 +530,17 @@ func (attr *Attribute) convertFromUnmarshaler(um *internal.AttributeUnmarshaler)
 
 	for _, subum := range um.SubAttributes {
 		subAttr := new(Attribute)
-		subAttr.convertFromUnmarshaler(subum)
+		if err := subAttr.convertFromUnmarshaler(subum); err != nil {
+			return err
+		}
 		attr.subAttributes = append(attr.subAttributes, subAttr)
 	}
+
+	return nil
+}
+
+func (attr *Attribute) errIllegalValue(characteristic string, value string) error {
+	return fmt.Errorf("%w: attribute '%s' has illegal %s '%s'", ErrInvalidValue, attr.id, characteristic, value)
 }
 
 func (attr *Attribute) sort() {
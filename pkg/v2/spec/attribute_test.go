@@ -2,10 +2,14 @@ package spec
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestAttribute(t *testing.T) {
@@ -51,10 +55,12 @@ func (s *AttributeTestSuite) TestMarshal() {
 
 	expect := `
 {
+  "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails",
   "name": "emails",
   "type": "complex",
   "subAttributes": [
     {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails.value",
       "name": "value",
       "type": "string",
       "multiValued": false,
@@ -62,9 +68,12 @@ func (s *AttributeTestSuite) TestMarshal() {
       "caseExact": false,
       "mutability": "readWrite",
       "returned": "default",
-      "uniqueness": "none"
+      "uniqueness": "none",
+      "_index": 0,
+      "_path": "emails.value"
     },
     {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:emails.primary",
       "name": "primary",
       "type": "boolean",
       "multiValued": false,
@@ -72,7 +81,9 @@ func (s *AttributeTestSuite) TestMarshal() {
       "caseExact": false,
       "mutability": "readWrite",
       "returned": "default",
-      "uniqueness": "none"
+      "uniqueness": "none",
+      "_index": 1,
+      "_path": "emails.primary"
     }
   ],
   "multiValued": false,
@@ -80,7 +91,13 @@ func (s *AttributeTestSuite) TestMarshal() {
   "caseExact": false,
   "mutability": "readWrite",
   "returned": "default",
-  "uniqueness": "none"
+  "uniqueness": "none",
+  "_index": 110,
+  "_path": "emails",
+  "_annotations": {
+    "@AutoCompact": {},
+    "@ExclusivePrimary": {}
+  }
 }
 `
 	assert.JSONEq(s.T(), expect, string(raw))
@@ -186,6 +203,281 @@ func (s *AttributeTestSuite) TestGoesBy() {
 	}
 }
 
+func (s *AttributeTestSuite) TestGoType() {
+	tests := []struct {
+		name   string
+		attr   *Attribute
+		expect reflect.Type
+	}{
+		{
+			name:   "string",
+			attr:   &Attribute{typ: TypeString},
+			expect: reflect.TypeOf(""),
+		},
+		{
+			name:   "reference",
+			attr:   &Attribute{typ: TypeReference},
+			expect: reflect.TypeOf(""),
+		},
+		{
+			name:   "integer",
+			attr:   &Attribute{typ: TypeInteger},
+			expect: reflect.TypeOf(int64(0)),
+		},
+		{
+			name:   "decimal",
+			attr:   &Attribute{typ: TypeDecimal},
+			expect: reflect.TypeOf(float64(0)),
+		},
+		{
+			name:   "boolean",
+			attr:   &Attribute{typ: TypeBoolean},
+			expect: reflect.TypeOf(false),
+		},
+		{
+			name:   "dateTime",
+			attr:   &Attribute{typ: TypeDateTime},
+			expect: reflect.TypeOf(time.Time{}),
+		},
+		{
+			name:   "binary",
+			attr:   &Attribute{typ: TypeBinary},
+			expect: reflect.TypeOf([]byte{}),
+		},
+		{
+			name:   "complex",
+			attr:   &Attribute{typ: TypeComplex},
+			expect: reflect.TypeOf(map[string]interface{}{}),
+		},
+		{
+			name:   "multiValued string",
+			attr:   &Attribute{typ: TypeString, multiValued: true},
+			expect: reflect.TypeOf([]string{}),
+		},
+		{
+			name:   "multiValued complex",
+			attr:   &Attribute{typ: TypeComplex, multiValued: true},
+			expect: reflect.TypeOf([]map[string]interface{}{}),
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, test.attr.GoType())
+		})
+	}
+}
+
+func (s *AttributeTestSuite) TestParseCharacteristics() {
+	tests := []struct {
+		name   string
+		parse  func(value string) (interface{}, error)
+		valid  map[string]interface{}
+		values []string
+	}{
+		{
+			name: "mutability",
+			parse: func(value string) (interface{}, error) {
+				return ParseMutability(value)
+			},
+			valid: map[string]interface{}{
+				"":          MutabilityReadWrite,
+				"readWrite": MutabilityReadWrite,
+				"readOnly":  MutabilityReadOnly,
+				"writeOnly": MutabilityWriteOnly,
+				"immutable": MutabilityImmutable,
+			},
+			values: []string{"read-only", "readonly", "ReadOnly", "write"},
+		},
+		{
+			name: "returned",
+			parse: func(value string) (interface{}, error) {
+				return ParseReturned(value)
+			},
+			valid: map[string]interface{}{
+				"":        ReturnedDefault,
+				"default": ReturnedDefault,
+				"always":  ReturnedAlways,
+				"request": ReturnedRequest,
+				"never":   ReturnedNever,
+			},
+			values: []string{"sometimes", "Always", "on-request"},
+		},
+		{
+			name: "uniqueness",
+			parse: func(value string) (interface{}, error) {
+				return ParseUniqueness(value)
+			},
+			valid: map[string]interface{}{
+				"":       UniquenessNone,
+				"none":   UniquenessNone,
+				"server": UniquenessServer,
+				"global": UniquenessGlobal,
+			},
+			values: []string{"client", "Server", "unique"},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			for value, expect := range test.valid {
+				parsed, err := test.parse(value)
+				assert.Nil(t, err)
+				assert.Equal(t, expect, parsed)
+				if value != "" {
+					assert.Equal(t, value, parsed.(fmt.Stringer).String())
+				}
+			}
+			for _, value := range test.values {
+				_, err := test.parse(value)
+				assert.True(t, errors.Is(err, ErrInvalidValue), value)
+				assert.Contains(t, err.Error(), "'"+value+"' is not a valid "+test.name)
+			}
+		})
+	}
+}
+
+func (s *AttributeTestSuite) TestConformCanonicalValue() {
+	enum := map[string]map[string]interface{}{"@Enum": {}}
+	normalizing := map[string]map[string]interface{}{"@Enum": {"normalize": true}}
+
+	tests := []struct {
+		name      string
+		attr      *Attribute
+		value     string
+		normalize bool
+		expect    func(t *testing.T, canonical string, err error)
+	}{
+		{
+			name:  "exact match",
+			attr:  &Attribute{path: "type", canonicalValues: []string{"work", "home"}, caseExact: true, annotations: enum},
+			value: "work",
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "work", canonical)
+			},
+		},
+		{
+			name:  "case insensitive match keeps value",
+			attr:  &Attribute{path: "type", canonicalValues: []string{"work", "home"}, annotations: enum},
+			value: "Work",
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "Work", canonical)
+			},
+		},
+		{
+			name:  "case exact mismatch",
+			attr:  &Attribute{path: "type", canonicalValues: []string{"work", "home"}, caseExact: true, annotations: enum},
+			value: "Work",
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), `value of 'type' does not conform to canonicalValues, allowed values are "work", "home"`)
+			},
+		},
+		{
+			name:  "normalized by annotation",
+			attr:  &Attribute{path: "type", canonicalValues: []string{"work", "home"}, caseExact: true, annotations: normalizing},
+			value: "Work",
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "work", canonical)
+			},
+		},
+		{
+			name:      "normalized by argument",
+			attr:      &Attribute{path: "type", canonicalValues: []string{"work", "home"}, annotations: enum},
+			value:     "HOME",
+			normalize: true,
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "home", canonical)
+			},
+		},
+		{
+			name:      "normalization does not accept other values",
+			attr:      &Attribute{path: "type", canonicalValues: []string{"work", "home"}, annotations: normalizing},
+			value:     "office",
+			normalize: true,
+			expect: func(t *testing.T, canonical string, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			canonical, err := test.attr.ConformCanonicalValue(test.value, test.normalize)
+			test.expect(t, canonical, err)
+		})
+	}
+}
+
+func (s *AttributeTestSuite) TestShape() {
+	emails := &Attribute{name: "emails", typ: TypeComplex, multiValued: true, subAttributes: []*Attribute{
+		{name: "value", typ: TypeString},
+	}}
+
+	tests := []struct {
+		name           string
+		attr           *Attribute
+		isMultiValued  bool
+		isComplex      bool
+		isScalar       bool
+		isReference    bool
+		isScalarString bool
+	}{
+		{
+			name:           "singular string",
+			attr:           &Attribute{name: "userName", typ: TypeString},
+			isScalar:       true,
+			isScalarString: true,
+		},
+		{
+			name:          "multiValued string",
+			attr:          &Attribute{name: "tags", typ: TypeString, multiValued: true},
+			isMultiValued: true,
+		},
+		{
+			name:        "singular reference",
+			attr:        &Attribute{name: "profileUrl", typ: TypeReference},
+			isScalar:    true,
+			isReference: true,
+		},
+		{
+			name:     "singular dateTime",
+			attr:     &Attribute{name: "created", typ: TypeDateTime},
+			isScalar: true,
+		},
+		{
+			name:      "singular complex",
+			attr:      &Attribute{name: "name", typ: TypeComplex},
+			isComplex: true,
+		},
+		{
+			name:          "multiValued complex",
+			attr:          emails,
+			isMultiValued: true,
+			isComplex:     true,
+		},
+		{
+			name:      "element of multiValued complex",
+			attr:      emails.DeriveElementAttribute(),
+			isComplex: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.isMultiValued, test.attr.IsMultiValued())
+			assert.Equal(t, test.isComplex, test.attr.IsComplex())
+			assert.Equal(t, test.isScalar, test.attr.IsScalar())
+			assert.Equal(t, test.isReference, test.attr.IsReference())
+			assert.Equal(t, test.isScalarString, test.attr.IsScalarString())
+		})
+	}
+}
+
 func (s *AttributeTestSuite) TestDeriveElementAttribute() {
 	raw := `
 {
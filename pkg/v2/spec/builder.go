@@ -0,0 +1,373 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+)
+
+// SchemaBuilder builds a Schema programmatically, as an alternative to parsing its JSON definition. It is useful
+// when the attributes are generated, i.e. from a configuration model. The built schema is identical to the one parsed
+// from an equivalent JSON definition: attribute ids, paths and indexes are derived from the schema id, the attribute
+// names and the order of declaration.
+//
+// The attributes of a schema extension, marked by Extension, have their paths prefixed by the schema id, as in the
+// bundled enterprise User extension. For example:
+//
+//	schema, err := spec.NewSchemaBuilder("urn:example:schemas:2.0:Extension", "Extension").
+//		Extension().
+//		Attribute(spec.StringAttr("costCenter").Required().CaseExact()).
+//		Attribute(spec.ComplexAttr("badges", spec.StringAttr("value")).MultiValued()).
+//		Build()
+type SchemaBuilder struct {
+	id          string
+	name        string
+	description string
+	attributes  []*AttributeBuilder
+	annotations map[string]map[string]interface{}
+	extension   bool
+}
+
+// NewSchemaBuilder returns a SchemaBuilder for a schema of the given id and name.
+func NewSchemaBuilder(id string, name string) *SchemaBuilder {
+	return &SchemaBuilder{id: id, name: name}
+}
+
+// Description sets the human-readable text that describes the schema.
+func (b *SchemaBuilder) Description(description string) *SchemaBuilder {
+	b.description = description
+	return b
+}
+
+// Extension marks the schema as a schema extension. The paths of its attributes are prefixed by the schema id, i.e.
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value", because the attributes of a schema
+// extension are nested under the schema id in resources.
+func (b *SchemaBuilder) Extension() *SchemaBuilder {
+	b.extension = true
+	return b
+}
+
+// Attribute adds a top level attribute to the schema.
+func (b *SchemaBuilder) Attribute(attr *AttributeBuilder) *SchemaBuilder {
+	b.attributes = append(b.attributes, attr)
+	return b
+}
+
+// Annotation adds an annotation with its parameters to the schema, as the "_annotations" field in the JSON definition
+// does. Parameters may be nil.
+func (b *SchemaBuilder) Annotation(name string, params map[string]interface{}) *SchemaBuilder {
+	if b.annotations == nil {
+		b.annotations = map[string]map[string]interface{}{}
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	b.annotations[name] = params
+	return b
+}
+
+// Build returns the schema. The schema is not registered. Errors are ErrInvalidValue naming the offending attribute
+// by its id.
+func (b *SchemaBuilder) Build() (*Schema, error) {
+	if len(b.id) == 0 {
+		return nil, fmt.Errorf("%w: schema id is required", ErrInvalidValue)
+	}
+
+	schema := &Schema{
+		id:          b.id,
+		name:        b.name,
+		description: b.description,
+		attributes:  []*Attribute{},
+		annotations: b.annotations,
+	}
+
+	for i, ab := range b.attributes {
+		attr, err := ab.build(b.id, "", i)
+		if err != nil {
+			return nil, err
+		}
+		if b.extension {
+			prefixPaths(attr)
+		}
+		schema.attributes = append(schema.attributes, attr)
+	}
+
+	if err := validateAttributeNames(b.id, schema.attributes); err != nil {
+		return nil, err
+	}
+	if err := validateSchemaAnnotations(b.id, schema.annotations, schema.attributes); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// AttributeBuilder builds an Attribute for SchemaBuilder. Characteristics default to the same values as an omitted
+// field in the JSON definition: singular, optional, not case exact, readWrite, returned by default and not unique.
+type AttributeBuilder struct {
+	name            string
+	description     string
+	typ             Type
+	subAttributes   []*AttributeBuilder
+	canonicalValues []string
+	multiValued     bool
+	required        bool
+	caseExact       bool
+	mutability      Mutability
+	returned        Returned
+	uniqueness      Uniqueness
+	referenceTypes  []string
+	annotations     map[string]map[string]interface{}
+	noDefaults      bool
+}
+
+// NewAttributeBuilder returns an AttributeBuilder for an attribute of the given name and type.
+func NewAttributeBuilder(name string, typ Type) *AttributeBuilder {
+	return &AttributeBuilder{
+		name:       name,
+		typ:        typ,
+		mutability: MutabilityReadWrite,
+		returned:   ReturnedDefault,
+		uniqueness: UniquenessNone,
+	}
+}
+
+// StringAttr returns an AttributeBuilder for a string attribute.
+func StringAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeString)
+}
+
+// IntegerAttr returns an AttributeBuilder for an integer attribute.
+func IntegerAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeInteger)
+}
+
+// DecimalAttr returns an AttributeBuilder for a decimal attribute.
+func DecimalAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeDecimal)
+}
+
+// BooleanAttr returns an AttributeBuilder for a boolean attribute.
+func BooleanAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeBoolean)
+}
+
+// DateTimeAttr returns an AttributeBuilder for a dateTime attribute.
+func DateTimeAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeDateTime)
+}
+
+// ReferenceAttr returns an AttributeBuilder for a reference attribute of the given reference types.
+func ReferenceAttr(name string, referenceTypes ...string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeReference).ReferenceTypes(referenceTypes...)
+}
+
+// BinaryAttr returns an AttributeBuilder for a binary attribute.
+func BinaryAttr(name string) *AttributeBuilder {
+	return NewAttributeBuilder(name, TypeBinary)
+}
+
+// ComplexAttr returns an AttributeBuilder for a complex attribute of the given sub attributes.
+func ComplexAttr(name string, subAttributes ...*AttributeBuilder) *AttributeBuilder {
+	b := NewAttributeBuilder(name, TypeComplex)
+	b.subAttributes = append(b.subAttributes, subAttributes...)
+	return b
+}
+
+// Description sets the human-readable text that describes the attribute.
+func (b *AttributeBuilder) Description(description string) *AttributeBuilder {
+	b.description = description
+	return b
+}
+
+// MultiValued makes the attribute multiValued.
+func (b *AttributeBuilder) MultiValued() *AttributeBuilder {
+	b.multiValued = true
+	return b
+}
+
+// Required makes the attribute required.
+func (b *AttributeBuilder) Required() *AttributeBuilder {
+	b.required = true
+	return b
+}
+
+// CaseExact makes the attribute case sensitive.
+func (b *AttributeBuilder) CaseExact() *AttributeBuilder {
+	b.caseExact = true
+	return b
+}
+
+// Mutability sets the mutability of the attribute.
+func (b *AttributeBuilder) Mutability(mutability Mutability) *AttributeBuilder {
+	b.mutability = mutability
+	return b
+}
+
+// Returned sets the returned characteristic of the attribute.
+func (b *AttributeBuilder) Returned(returned Returned) *AttributeBuilder {
+	b.returned = returned
+	return b
+}
+
+// Uniqueness sets the uniqueness of the attribute.
+func (b *AttributeBuilder) Uniqueness(uniqueness Uniqueness) *AttributeBuilder {
+	b.uniqueness = uniqueness
+	return b
+}
+
+// CanonicalValues sets the canonical values of the attribute.
+func (b *AttributeBuilder) CanonicalValues(values ...string) *AttributeBuilder {
+	b.canonicalValues = values
+	return b
+}
+
+// ReferenceTypes sets the reference types of a reference attribute.
+func (b *AttributeBuilder) ReferenceTypes(referenceTypes ...string) *AttributeBuilder {
+	b.referenceTypes = referenceTypes
+	return b
+}
+
+// SubAttribute adds a sub attribute to a complex attribute.
+func (b *AttributeBuilder) SubAttribute(subAttribute *AttributeBuilder) *AttributeBuilder {
+	b.subAttributes = append(b.subAttributes, subAttribute)
+	return b
+}
+
+// Annotation adds an annotation with its parameters to the attribute, as the "_annotations" field in the JSON
+// definition does. Parameters may be nil.
+func (b *AttributeBuilder) Annotation(name string, params map[string]interface{}) *AttributeBuilder {
+	if b.annotations == nil {
+		b.annotations = map[string]map[string]interface{}{}
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	b.annotations[name] = params
+	return b
+}
+
+// WithoutDefaultSubAttributes stops the standard sub attributes from being added to a multiValued complex attribute.
+func (b *AttributeBuilder) WithoutDefaultSubAttributes() *AttributeBuilder {
+	b.noDefaults = true
+	return b
+}
+
+// Builds the attribute of the schema, whose parent path is empty for top level attributes. A multiValued complex
+// attribute receives the standard sub attributes defined in RFC7643 section 2.4 which are not declared.
+func (b *AttributeBuilder) build(schemaId string, parentPath string, index int) (*Attribute, error) {
+	path := b.name
+	if len(parentPath) > 0 {
+		path = parentPath + "." + b.name
+	}
+	id := attributeId(schemaId, path)
+
+	switch {
+	case len(b.name) == 0:
+		return nil, fmt.Errorf("%w: attribute at index %d under '%s' has no name", ErrInvalidValue, index, attributeId(schemaId, parentPath))
+	case b.typ == TypeComplex && len(parentPath) > 0:
+		return nil, fmt.Errorf("%w: attribute '%s' is complex, but sub attributes cannot be complex", ErrInvalidValue, id)
+	case b.typ != TypeComplex && len(b.subAttributes) > 0:
+		return nil, fmt.Errorf("%w: attribute '%s' has sub attributes, but is not complex", ErrInvalidValue, id)
+	case b.typ != TypeReference && len(b.referenceTypes) > 0:
+		return nil, fmt.Errorf("%w: attribute '%s' has referenceTypes, but is not a reference", ErrInvalidValue, id)
+	}
+
+	subAttributes := b.subAttributes
+	if b.typ == TypeComplex && b.multiValued && !b.noDefaults {
+		subAttributes = withStandardSubAttributes(subAttributes)
+	}
+	if b.typ == TypeComplex && len(subAttributes) == 0 {
+		return nil, fmt.Errorf("%w: attribute '%s' is complex, but has no sub attributes", ErrInvalidValue, id)
+	}
+
+	attr := &Attribute{
+		name:            b.name,
+		description:     b.description,
+		typ:             b.typ,
+		subAttributes:   []*Attribute{},
+		canonicalValues: b.canonicalValues,
+		multiValued:     b.multiValued,
+		required:        b.required,
+		caseExact:       b.caseExact,
+		mutability:      b.mutability,
+		returned:        b.returned,
+		uniqueness:      b.uniqueness,
+		referenceTypes:  b.referenceTypes,
+		id:              id,
+		index:           index,
+		path:            path,
+		annotations:     b.annotations,
+	}
+
+	for i, sb := range subAttributes {
+		subAttr, err := sb.build(schemaId, path, i)
+		if err != nil {
+			return nil, err
+		}
+		attr.subAttributes = append(attr.subAttributes, subAttr)
+	}
+
+	return attr, nil
+}
+
+// Prefixes the paths of the attribute of a schema extension, and of its sub attributes, by the schema id, which makes
+// them identical to the attribute ids.
+func prefixPaths(attr *Attribute) {
+	attr.path = attr.id
+	for _, subAttr := range attr.subAttributes {
+		prefixPaths(subAttr)
+	}
+}
+
+// Derives the attribute id from the schema id and the attribute path. Core attributes, and paths already prefixed by
+// the schema id, as those of schema extensions, are not prefixed. An empty path yields the schema id.
+func attributeId(schemaId string, path string) string {
+	switch {
+	case len(path) == 0:
+		return schemaId
+	case schemaId == CoreSchemaId, strings.HasPrefix(path, schemaId+":"):
+		return path
+	default:
+		return schemaId + ":" + path
+	}
+}
+
+// Appends the standard sub attributes of multiValued attributes, in the order listed by RFC7643 section 2.4, to the
+// declared ones, unless a sub attribute of the same name is declared. The annotations are those carried by the
+// standard sub attributes in the bundled schemas.
+func withStandardSubAttributes(declared []*AttributeBuilder) []*AttributeBuilder {
+	names := make([]string, 0, len(declared))
+	for _, d := range declared {
+		names = append(names, d.name)
+	}
+	return append(append([]*AttributeBuilder{}, declared...), missingStandardSubAttributes(names)...)
+}
+
+// Returns the standard sub attributes of multiValued attributes whose names, compared case insensitively, are not
+// among the declared names.
+func missingStandardSubAttributes(declared []string) []*AttributeBuilder {
+	standard := []*AttributeBuilder{
+		StringAttr("type").Annotation(annotation.Identity, nil),
+		BooleanAttr("primary").Annotation(annotation.Primary, nil),
+		StringAttr("display"),
+		StringAttr("value").Annotation(annotation.Identity, nil),
+		ReferenceAttr("$ref"),
+	}
+
+	var result []*AttributeBuilder
+	for _, sb := range standard {
+		found := false
+		for _, name := range declared {
+			if strings.EqualFold(name, sb.name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, sb)
+		}
+	}
+	return result
+}
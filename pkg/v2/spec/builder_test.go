@@ -0,0 +1,186 @@
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaBuilder(t *testing.T) {
+	t.Run("identical to parsed schema", func(t *testing.T) {
+		parsed, err := ParseSchema(strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Built",
+  "name": "Built",
+  "description": "Built schema",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Built:costCenter",
+      "name": "costCenter",
+      "type": "string",
+      "required": true,
+      "caseExact": true,
+      "_index": 0,
+      "_path": "costCenter"
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Built:manager",
+      "name": "manager",
+      "type": "complex",
+      "mutability": "immutable",
+      "_index": 1,
+      "_path": "manager",
+      "_annotations": {
+        "@StateSummary": {}
+      },
+      "subAttributes": [
+        {
+          "id": "urn:ietf:params:scim:schemas:test:2.0:Built:manager.value",
+          "name": "value",
+          "type": "string",
+          "_index": 0,
+          "_path": "manager.value"
+        },
+        {
+          "id": "urn:ietf:params:scim:schemas:test:2.0:Built:manager.$ref",
+          "name": "$ref",
+          "type": "reference",
+          "referenceTypes": ["User"],
+          "_index": 1,
+          "_path": "manager.$ref"
+        }
+      ]
+    }
+  ]
+}
+`))
+		require.Nil(t, err)
+
+		built, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+			Description("Built schema").
+			Attribute(StringAttr("costCenter").Required().CaseExact()).
+			Attribute(ComplexAttr("manager",
+				StringAttr("value"),
+				ReferenceAttr("$ref", "User"),
+			).Mutability(MutabilityImmutable).Annotation("@StateSummary", nil)).
+			Build()
+		require.Nil(t, err)
+
+		assert.Equal(t, parsed, built)
+	})
+
+	t.Run("identical to bundled enterprise user extension", func(t *testing.T) {
+		raw, err := fs.ReadFile(standardFS, standardEnterpriseUserSchema)
+		require.Nil(t, err)
+		parsed, err := ParseSchema(bytes.NewReader(raw))
+		require.Nil(t, err)
+
+		built, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User", "Enterprise User").
+			Description("Extension attributes for enterprises").
+			Extension().
+			Attribute(StringAttr("employeeNumber")).
+			Attribute(StringAttr("costCenter")).
+			Attribute(StringAttr("organization")).
+			Attribute(StringAttr("division")).
+			Attribute(StringAttr("department")).
+			Attribute(ComplexAttr("manager",
+				StringAttr("value"),
+				ReferenceAttr("$ref"),
+				StringAttr("displayName"),
+			).Annotation("@StateSummary", nil)).
+			Build()
+		require.Nil(t, err)
+
+		assert.Equal(t, parsed, built)
+	})
+
+	t.Run("standard sub attributes of multiValued complex attribute of schema extension", func(t *testing.T) {
+		built, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+			Extension().
+			Attribute(ComplexAttr("badges", IntegerAttr("value")).MultiValued()).
+			Build()
+		require.Nil(t, err)
+
+		badges := built.attributes[0]
+		assert.Equal(t, "urn:ietf:params:scim:schemas:test:2.0:Built:badges", badges.path)
+		for _, subAttr := range badges.subAttributes {
+			assert.Equal(t, "urn:ietf:params:scim:schemas:test:2.0:Built:badges."+subAttr.name, subAttr.id)
+			assert.Equal(t, subAttr.id, subAttr.path)
+		}
+	})
+
+	t.Run("standard sub attributes of multiValued complex attribute", func(t *testing.T) {
+		built, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+			Attribute(ComplexAttr("badges", IntegerAttr("value")).MultiValued()).
+			Build()
+		require.Nil(t, err)
+
+		badges := built.attributes[0]
+		var names []string
+		for _, subAttr := range badges.subAttributes {
+			names = append(names, subAttr.Name())
+		}
+		assert.Equal(t, []string{"value", "type", "primary", "display", "$ref"}, names)
+		assert.Equal(t, TypeInteger, badges.SubAttributeForName("value").Type())
+		assert.Equal(t, "urn:ietf:params:scim:schemas:test:2.0:Built:badges.primary", badges.SubAttributeForName("primary").ID())
+		assert.Equal(t, "badges.primary", badges.SubAttributeForName("primary").Path())
+		_, ok := badges.SubAttributeForName("primary").Annotation("@Primary")
+		assert.True(t, ok)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			builder *SchemaBuilder
+			message string
+		}{
+			{
+				name:    "missing schema id",
+				builder: NewSchemaBuilder("", "Built"),
+				message: "schema id is required",
+			},
+			{
+				name: "complex without sub attributes",
+				builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+					Attribute(ComplexAttr("manager")),
+				message: "'urn:ietf:params:scim:schemas:test:2.0:Built:manager' is complex, but has no sub attributes",
+			},
+			{
+				name: "nested complex",
+				builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+					Attribute(ComplexAttr("manager", ComplexAttr("name", StringAttr("formatted")))),
+				message: "'urn:ietf:params:scim:schemas:test:2.0:Built:manager.name' is complex",
+			},
+			{
+				name: "sub attributes of string",
+				builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+					Attribute(StringAttr("costCenter").SubAttribute(StringAttr("value"))),
+				message: "'urn:ietf:params:scim:schemas:test:2.0:Built:costCenter' has sub attributes",
+			},
+			{
+				name: "missing sub attribute name",
+				builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+					Attribute(ComplexAttr("manager", StringAttr("value"), StringAttr(""))),
+				message: "attribute at index 1 under 'urn:ietf:params:scim:schemas:test:2.0:Built:manager' has no name",
+			},
+			{
+				name: "duplicate attribute",
+				builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Built", "Built").
+					Attribute(StringAttr("costCenter")).
+					Attribute(StringAttr("CostCenter")),
+				message: "duplicate attribute 'CostCenter'",
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := test.builder.Build()
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), test.message)
+			})
+		}
+	})
+}
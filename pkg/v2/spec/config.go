@@ -1,6 +1,17 @@
 package spec
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Schema URN of the service provider config
+const ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
 // Service provider config
+//
+// The config advertises the features supported by the service provider. To keep the advertisement in line with the
+// features actually wired up, prefer creating it with ServiceProviderConfigBuilder over maintaining a JSON definition.
 type ServiceProviderConfig struct {
 	Schemas []string `json:"schemas"`
 	DocURI  string   `json:"documentationUri"`
@@ -25,11 +36,162 @@ type ServiceProviderConfig struct {
 	ETag struct {
 		Supported bool `json:"supported"`
 	} `json:"etag"`
-	AuthSchemes []struct {
-		Type        string `json:"type"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		SpecURI     string `json:"specUri"`
-		DocURI      string `json:"documentationUri"`
-	} `json:"authenticationSchemes"`
+	AuthSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// AuthenticationScheme is an authentication scheme supported by the service provider.
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SpecURI     string `json:"specUri"`
+	DocURI      string `json:"documentationUri"`
+}
+
+// ResourceTypeName returns the resource type of the ServiceProviderConfig resource. This value is formally defined and
+// hence fixed.
+func (c *ServiceProviderConfig) ResourceTypeName() string {
+	return "ServiceProviderConfig"
+}
+
+// ResourceLocation returns the relative URI at which the ServiceProviderConfig resource can be accessed. This value is
+// formally defined in the specification and hence fixed.
+func (c *ServiceProviderConfig) ResourceLocation() string {
+	return "/ServiceProviderConfig"
+}
+
+// Validate checks that the config makes sense: limits must not be negative, a supported bulk operation must allow at
+// least one operation and a positive payload size, and authentication schemes must have a type and a name. Errors are
+// ErrInvalidValue.
+func (c *ServiceProviderConfig) Validate() error {
+	switch {
+	case c.Bulk.MaxOp < 0 || c.Bulk.MaxPayload < 0:
+		return fmt.Errorf("%w: bulk limits cannot be negative", ErrInvalidValue)
+	case c.Bulk.Supported && c.Bulk.MaxOp <= 0:
+		return fmt.Errorf("%w: bulk is supported, but maxOperations is not positive", ErrInvalidValue)
+	case c.Bulk.Supported && c.Bulk.MaxPayload <= 0:
+		return fmt.Errorf("%w: bulk is supported, but maxPayloadSize is not positive", ErrInvalidValue)
+	case c.Filter.MaxResults < 0:
+		return fmt.Errorf("%w: filter maxResults cannot be negative", ErrInvalidValue)
+	}
+
+	for i, scheme := range c.AuthSchemes {
+		if len(scheme.Type) == 0 || len(scheme.Name) == 0 {
+			return fmt.Errorf("%w: authentication scheme at index %d requires type and name", ErrInvalidValue, i)
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON serializes the config as the /ServiceProviderConfig document, which includes the meta attribute
+// describing the resource. The schema URN is included even if absent from Schemas.
+func (c *ServiceProviderConfig) MarshalJSON() ([]byte, error) {
+	type document ServiceProviderConfig
+
+	copied := document(*c)
+	if len(copied.Schemas) == 0 {
+		copied.Schemas = []string{ServiceProviderConfigSchema}
+	}
+	if copied.AuthSchemes == nil {
+		copied.AuthSchemes = []AuthenticationScheme{}
+	}
+
+	d := struct {
+		document
+		Meta map[string]string `json:"meta"`
+	}{
+		document: copied,
+		Meta: map[string]string{
+			"resourceType": c.ResourceTypeName(),
+			"location":     c.ResourceLocation(),
+		},
+	}
+
+	return json.Marshal(d)
+}
+
+// ServiceProviderConfigBuilder builds a ServiceProviderConfig from the features wired up by the service provider. A
+// feature that is not declared is advertised as unsupported.
+//
+// For example:
+//
+//	config, err := spec.NewServiceProviderConfigBuilder().
+//		Patch().
+//		Filter(200).
+//		Sort().
+//		ETag().
+//		Build()
+type ServiceProviderConfigBuilder struct {
+	config ServiceProviderConfig
+}
+
+// NewServiceProviderConfigBuilder returns a ServiceProviderConfigBuilder that supports no feature.
+func NewServiceProviderConfigBuilder() *ServiceProviderConfigBuilder {
+	b := &ServiceProviderConfigBuilder{}
+	b.config.Schemas = []string{ServiceProviderConfigSchema}
+	b.config.AuthSchemes = []AuthenticationScheme{}
+	return b
+}
+
+// DocumentationURI sets the URI of the service provider's help documentation.
+func (b *ServiceProviderConfigBuilder) DocumentationURI(uri string) *ServiceProviderConfigBuilder {
+	b.config.DocURI = uri
+	return b
+}
+
+// Patch declares that PATCH is supported.
+func (b *ServiceProviderConfigBuilder) Patch() *ServiceProviderConfigBuilder {
+	b.config.Patch.Supported = true
+	return b
+}
+
+// Bulk declares that bulk is supported, with the maximum number of operations and the maximum payload size in bytes.
+func (b *ServiceProviderConfigBuilder) Bulk(maxOperations int, maxPayloadSize int) *ServiceProviderConfigBuilder {
+	b.config.Bulk.Supported = true
+	b.config.Bulk.MaxOp = maxOperations
+	b.config.Bulk.MaxPayload = maxPayloadSize
+	return b
+}
+
+// Filter declares that filtering is supported, with the maximum number of resources returned in a response.
+func (b *ServiceProviderConfigBuilder) Filter(maxResults int) *ServiceProviderConfigBuilder {
+	b.config.Filter.Supported = true
+	b.config.Filter.MaxResults = maxResults
+	return b
+}
+
+// ChangePassword declares that changing password is supported.
+func (b *ServiceProviderConfigBuilder) ChangePassword() *ServiceProviderConfigBuilder {
+	b.config.ChangePassword.Supported = true
+	return b
+}
+
+// Sort declares that sorting is supported.
+func (b *ServiceProviderConfigBuilder) Sort() *ServiceProviderConfigBuilder {
+	b.config.Sort.Supported = true
+	return b
+}
+
+// ETag declares that resource versioning with ETag is supported.
+func (b *ServiceProviderConfigBuilder) ETag() *ServiceProviderConfigBuilder {
+	b.config.ETag.Supported = true
+	return b
+}
+
+// AuthenticationScheme declares a supported authentication scheme.
+func (b *ServiceProviderConfigBuilder) AuthenticationScheme(scheme AuthenticationScheme) *ServiceProviderConfigBuilder {
+	b.config.AuthSchemes = append(b.config.AuthSchemes, scheme)
+	return b
+}
+
+// Build returns the validated config.
+func (b *ServiceProviderConfigBuilder) Build() (*ServiceProviderConfig, error) {
+	config := b.config
+	config.Schemas = append([]string{}, b.config.Schemas...)
+	config.AuthSchemes = append([]AuthenticationScheme{}, b.config.AuthSchemes...)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
 }
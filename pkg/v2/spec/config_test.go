@@ -0,0 +1,93 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceProviderConfig(t *testing.T) {
+	t.Run("build and serialize", func(t *testing.T) {
+		config, err := NewServiceProviderConfigBuilder().
+			DocumentationURI("https://example.com/help").
+			Patch().
+			Filter(200).
+			ETag().
+			AuthenticationScheme(AuthenticationScheme{
+				Type: "oauthbearertoken",
+				Name: "OAuth Bearer Token",
+			}).
+			Build()
+		require.Nil(t, err)
+
+		raw, err := json.Marshal(config)
+		require.Nil(t, err)
+		assert.JSONEq(t, `
+{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"],
+  "documentationUri": "https://example.com/help",
+  "patch": {"supported": true},
+  "bulk": {"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+  "filter": {"supported": true, "maxResults": 200},
+  "changePassword": {"supported": false},
+  "sort": {"supported": false},
+  "etag": {"supported": true},
+  "authenticationSchemes": [
+    {
+      "type": "oauthbearertoken",
+      "name": "OAuth Bearer Token",
+      "description": "",
+      "specUri": "",
+      "documentationUri": ""
+    }
+  ],
+  "meta": {
+    "resourceType": "ServiceProviderConfig",
+    "location": "/ServiceProviderConfig"
+  }
+}
+`, string(raw))
+
+		parsed := new(ServiceProviderConfig)
+		require.Nil(t, json.Unmarshal(raw, parsed))
+		assert.Equal(t, config, parsed)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			builder *ServiceProviderConfigBuilder
+			message string
+		}{
+			{
+				name:    "bulk without operations",
+				builder: NewServiceProviderConfigBuilder().Bulk(0, 1048576),
+				message: "maxOperations is not positive",
+			},
+			{
+				name:    "bulk without payload",
+				builder: NewServiceProviderConfigBuilder().Bulk(1000, 0),
+				message: "maxPayloadSize is not positive",
+			},
+			{
+				name:    "negative max results",
+				builder: NewServiceProviderConfigBuilder().Filter(-1),
+				message: "maxResults cannot be negative",
+			},
+			{
+				name:    "authentication scheme without name",
+				builder: NewServiceProviderConfigBuilder().AuthenticationScheme(AuthenticationScheme{Type: "httpbasic"}),
+				message: "authentication scheme at index 0 requires type and name",
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := test.builder.Build()
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), test.message)
+			})
+		}
+	})
+}
@@ -3,5 +3,15 @@ package spec
 // SCIM defined date format used to parse dateTime values.
 const ISO8601 = "2006-01-02T15:04:05"
 
+// Date only format accepted in filters for dateTime values. The value is interpreted as midnight UTC.
+const DateOnly = "2006-01-02"
+
 // SCIM defined standard content type
 const ApplicationScimJson = "application/scim+json"
+
+// Reference types defined by RFC7643 which do not refer to a resource of the service provider, but to an external
+// resource and to a URI (i.e. a schema URN) respectively.
+const (
+	ReferenceTypeExternal = "external"
+	ReferenceTypeURI      = "uri"
+)
@@ -0,0 +1,282 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a change reported by DiffSchemas and DiffResourceTypes.
+type ChangeKind int
+
+const (
+	// ChangeAdded is an attribute, or a schema extension, present in the new version only.
+	ChangeAdded ChangeKind = iota
+	// ChangeRemoved is an attribute, or a schema extension, present in the old version only.
+	ChangeRemoved
+	// ChangeModified is a characteristic of an attribute, or the required flag of a schema extension, that differs
+	// between the versions.
+	ChangeModified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// AttributeChange is a change of a single attribute between two versions of a schema.
+type AttributeChange struct {
+	// Path of the attribute, i.e. name.familyName
+	Path string
+	// Kind of the change
+	Kind ChangeKind
+	// The modified characteristic, i.e. "required" or "canonicalValues", if Kind is ChangeModified
+	Characteristic string
+	// The characteristic in the old and the new version, if Kind is ChangeModified
+	Old, New string
+	// Whether stored data or previously accepted requests may no longer be valid
+	Breaking bool
+}
+
+func (c AttributeChange) String() string {
+	var s string
+	if c.Kind == ChangeModified {
+		s = fmt.Sprintf("%s: %s changed from '%s' to '%s'", c.Path, c.Characteristic, c.Old, c.New)
+	} else {
+		s = fmt.Sprintf("%s: %s", c.Path, c.Kind.String())
+	}
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// SchemaDiff is the report of DiffSchemas.
+type SchemaDiff struct {
+	// Id of the schema
+	SchemaID string
+	// Changes of the attributes, sorted by path
+	Changes []AttributeChange
+}
+
+// Breaking returns true if any of the changes is breaking.
+func (d SchemaDiff) Breaking() bool {
+	for _, change := range d.Changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSchemas compares two versions of a schema, i.e. the schema served by the /Schemas endpoint of a running server
+// and the one about to be rolled out, and reports the attributes added, removed, and whose characteristics changed.
+// Attributes, including sub attributes, are matched by their path, case insensitive, hence a renamed attribute is
+// reported as removed and added. Descriptions and annotations are not compared.
+//
+// A change is breaking when data stored under the old version, or requests accepted by it, may no longer be valid:
+// removing an attribute; adding a required attribute; changing type or multiValued; making an attribute required;
+// making it any mutability but readWrite; returning it less often, i.e. from default to request; tightening its
+// uniqueness; and narrowing its canonicalValues or referenceTypes, i.e. removing a value, or declaring values where
+// there were none.
+func DiffSchemas(old, new *Schema) SchemaDiff {
+	d := SchemaDiff{SchemaID: new.id}
+	diffAttributes("", old.attributes, new.attributes, &d.Changes)
+	sort.SliceStable(d.Changes, func(i, j int) bool {
+		return strings.ToLower(d.Changes[i].Path) < strings.ToLower(d.Changes[j].Path)
+	})
+	return d
+}
+
+// Compares the attributes on the same level, and recursively their sub attributes. The paths are built from the path of
+// the parent and the names while walking, since schemas decoded from the /Schemas endpoint do not carry them.
+func diffAttributes(parent string, old, new []*Attribute, changes *[]AttributeChange) {
+	byName := map[string]*Attribute{}
+	for _, attr := range old {
+		byName[strings.ToLower(attr.name)] = attr
+	}
+
+	for _, newAttr := range new {
+		oldAttr, ok := byName[strings.ToLower(newAttr.name)]
+		if !ok {
+			*changes = append(*changes, AttributeChange{Path: childPath(parent, newAttr), Kind: ChangeAdded, Breaking: newAttr.required})
+			continue
+		}
+		delete(byName, strings.ToLower(newAttr.name))
+		diffAttribute(childPath(parent, newAttr), oldAttr, newAttr, changes)
+	}
+
+	for _, oldAttr := range old {
+		if _, ok := byName[strings.ToLower(oldAttr.name)]; ok {
+			*changes = append(*changes, AttributeChange{Path: childPath(parent, oldAttr), Kind: ChangeRemoved, Breaking: true})
+		}
+	}
+}
+
+func diffAttribute(path string, old, new *Attribute, changes *[]AttributeChange) {
+	modified := func(characteristic string, oldValue, newValue string, breaking bool) {
+		if oldValue != newValue {
+			*changes = append(*changes, AttributeChange{
+				Path:           path,
+				Kind:           ChangeModified,
+				Characteristic: characteristic,
+				Old:            oldValue,
+				New:            newValue,
+				Breaking:       breaking,
+			})
+		}
+	}
+
+	modified("type", old.typ.String(), new.typ.String(), true)
+	modified("multiValued", strconv.FormatBool(old.multiValued), strconv.FormatBool(new.multiValued), true)
+	modified("required", strconv.FormatBool(old.required), strconv.FormatBool(new.required), new.required)
+	modified("caseExact", strconv.FormatBool(old.caseExact), strconv.FormatBool(new.caseExact), false)
+	modified("mutability", old.mutability.String(), new.mutability.String(), new.mutability != MutabilityReadWrite)
+	modified("returned", old.returned.String(), new.returned.String(), returnedRank(new.returned) > returnedRank(old.returned))
+	modified("uniqueness", old.uniqueness.String(), new.uniqueness.String(), new.uniqueness > old.uniqueness)
+	modified("canonicalValues", strings.Join(old.canonicalValues, ","), strings.Join(new.canonicalValues, ","),
+		narrowed(old.canonicalValues, new.canonicalValues))
+	modified("referenceTypes", strings.Join(old.referenceTypes, ","), strings.Join(new.referenceTypes, ","),
+		narrowed(old.referenceTypes, new.referenceTypes))
+
+	diffAttributes(path, old.subAttributes, new.subAttributes, changes)
+}
+
+func childPath(parent string, attr *Attribute) string {
+	if len(parent) == 0 {
+		return attr.name
+	}
+	return parent + "." + attr.name
+}
+
+// Ranks the returned characteristic from the most to the least often returned.
+func returnedRank(r Returned) int {
+	switch r {
+	case ReturnedAlways:
+		return 0
+	case ReturnedDefault:
+		return 1
+	case ReturnedRequest:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Returns true if the new list of allowed values rejects values the old one allowed. An empty list allows any value.
+func narrowed(old, new []string) bool {
+	if len(new) == 0 {
+		return false
+	}
+	if len(old) == 0 {
+		return true
+	}
+	allowed := map[string]struct{}{}
+	for _, each := range new {
+		allowed[each] = struct{}{}
+	}
+	for _, each := range old {
+		if _, ok := allowed[each]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtensionChange is a change of a schema extension between two versions of a resource type.
+type ExtensionChange struct {
+	// Id of the schema extension
+	SchemaID string
+	// Kind of the change; ChangeModified means the required flag changed
+	Kind ChangeKind
+	// Whether the schema extension is required in the new version, or was in the old version if removed
+	Required bool
+	// Whether stored data or previously accepted requests may no longer be valid
+	Breaking bool
+}
+
+func (c ExtensionChange) String() string {
+	var s string
+	if c.Kind == ChangeModified {
+		s = fmt.Sprintf("%s: required changed to '%t'", c.SchemaID, c.Required)
+	} else {
+		s = fmt.Sprintf("%s: %s", c.SchemaID, c.Kind.String())
+	}
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// ResourceTypeDiff is the report of DiffResourceTypes.
+type ResourceTypeDiff struct {
+	// Id of the resource type
+	ResourceTypeID string
+	// Whether the main schema id changed, in which case stored resources no longer conform to the resource type
+	SchemaChanged bool
+	// Changes of the schema extensions, sorted by schema id
+	Extensions []ExtensionChange
+}
+
+// Breaking returns true if the main schema changed, or any of the extension changes is breaking.
+func (d ResourceTypeDiff) Breaking() bool {
+	if d.SchemaChanged {
+		return true
+	}
+	for _, change := range d.Extensions {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffResourceTypes compares two versions of a resource type, and reports whether the main schema changed, and the
+// schema extensions added, removed, and whose required flag changed. Schema extensions are matched by id, case
+// insensitive. The schemas themselves are compared by DiffSchemas.
+//
+// A change is breaking when data stored under the old version may no longer be valid: changing the main schema;
+// removing a schema extension; and adding a required schema extension, or making an optional one required.
+func DiffResourceTypes(old, new *ResourceType) ResourceTypeDiff {
+	d := ResourceTypeDiff{
+		ResourceTypeID: new.id,
+		SchemaChanged:  !strings.EqualFold(old.schema.id, new.schema.id),
+	}
+
+	oldRequired := map[string]bool{}
+	oldIds := map[string]string{}
+	_ = old.ForEachExtension(func(extension *Schema, required bool) error {
+		oldRequired[strings.ToLower(extension.id)] = required
+		oldIds[strings.ToLower(extension.id)] = extension.id
+		return nil
+	})
+
+	_ = new.ForEachExtension(func(extension *Schema, required bool) error {
+		key := strings.ToLower(extension.id)
+		wasRequired, ok := oldRequired[key]
+		switch {
+		case !ok:
+			d.Extensions = append(d.Extensions, ExtensionChange{SchemaID: extension.id, Kind: ChangeAdded, Required: required, Breaking: required})
+		case wasRequired != required:
+			d.Extensions = append(d.Extensions, ExtensionChange{SchemaID: extension.id, Kind: ChangeModified, Required: required, Breaking: required})
+		}
+		delete(oldIds, key)
+		return nil
+	})
+
+	for key, id := range oldIds {
+		d.Extensions = append(d.Extensions, ExtensionChange{SchemaID: id, Kind: ChangeRemoved, Required: oldRequired[key], Breaking: true})
+	}
+
+	sort.SliceStable(d.Extensions, func(i, j int) bool {
+		return strings.ToLower(d.Extensions[i].SchemaID) < strings.ToLower(d.Extensions[j].SchemaID)
+	})
+	return d
+}
@@ -0,0 +1,220 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	const id = "urn:ietf:params:scim:schemas:test:2.0:Diff"
+	build := func(attrs ...*AttributeBuilder) *Schema {
+		b := NewSchemaBuilder(id, "Diff")
+		for _, attr := range attrs {
+			b.Attribute(attr)
+		}
+		schema, err := b.Build()
+		require.Nil(t, err)
+		return schema
+	}
+
+	tests := []struct {
+		name     string
+		old      *Schema
+		new      *Schema
+		changes  []string
+		breaking bool
+	}{
+		{
+			name: "identical",
+			old:  build(StringAttr("userName").Required(), ComplexAttr("name", StringAttr("givenName"))),
+			new:  build(StringAttr("userName").Required(), ComplexAttr("name", StringAttr("givenName"))),
+		},
+		{
+			name:    "optional attribute added",
+			old:     build(StringAttr("userName")),
+			new:     build(StringAttr("userName"), StringAttr("nickName")),
+			changes: []string{"nickName: added"},
+		},
+		{
+			name:     "required sub attribute added",
+			old:      build(ComplexAttr("name", StringAttr("givenName"))),
+			new:      build(ComplexAttr("name", StringAttr("givenName"), StringAttr("familyName").Required())),
+			changes:  []string{"name.familyName: added (breaking)"},
+			breaking: true,
+		},
+		{
+			name:     "attribute removed",
+			old:      build(StringAttr("userName"), StringAttr("nickName")),
+			new:      build(StringAttr("userName")),
+			changes:  []string{"nickName: removed (breaking)"},
+			breaking: true,
+		},
+		{
+			name: "characteristics loosened",
+			old: build(StringAttr("userName").Required().Mutability(MutabilityImmutable).
+				Returned(ReturnedRequest).Uniqueness(UniquenessServer).CanonicalValues("a")),
+			new: build(StringAttr("userName").Returned(ReturnedAlways).CanonicalValues("a", "b")),
+			changes: []string{
+				"userName: required changed from 'true' to 'false'",
+				"userName: mutability changed from 'immutable' to 'readWrite'",
+				"userName: returned changed from 'request' to 'always'",
+				"userName: uniqueness changed from 'server' to 'none'",
+				"userName: canonicalValues changed from 'a' to 'a,b'",
+			},
+		},
+		{
+			name: "characteristics tightened",
+			old:  build(StringAttr("userName").CanonicalValues("a", "b")),
+			new: build(StringAttr("userName").Required().Mutability(MutabilityReadOnly).
+				Returned(ReturnedRequest).Uniqueness(UniquenessServer).CanonicalValues("a")),
+			changes: []string{
+				"userName: required changed from 'false' to 'true' (breaking)",
+				"userName: mutability changed from 'readWrite' to 'readOnly' (breaking)",
+				"userName: returned changed from 'default' to 'request' (breaking)",
+				"userName: uniqueness changed from 'none' to 'server' (breaking)",
+				"userName: canonicalValues changed from 'a,b' to 'a' (breaking)",
+			},
+			breaking: true,
+		},
+		{
+			name:     "canonicalValues declared",
+			old:      build(StringAttr("type")),
+			new:      build(StringAttr("type").CanonicalValues("work")),
+			changes:  []string{"type: canonicalValues changed from '' to 'work' (breaking)"},
+			breaking: true,
+		},
+		{
+			name:     "type and multiValued changed",
+			old:      build(StringAttr("tags")),
+			new:      build(IntegerAttr("tags").MultiValued()),
+			changes:  []string{"tags: type changed from 'string' to 'integer' (breaking)", "tags: multiValued changed from 'false' to 'true' (breaking)"},
+			breaking: true,
+		},
+		{
+			name:    "matched case insensitively",
+			old:     build(StringAttr("username")),
+			new:     build(StringAttr("userName").CaseExact()),
+			changes: []string{"userName: caseExact changed from 'false' to 'true'"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := DiffSchemas(test.old, test.new)
+			assert.Equal(t, id, diff.SchemaID)
+			var changes []string
+			for _, change := range diff.Changes {
+				changes = append(changes, change.String())
+			}
+			assert.Equal(t, test.changes, changes)
+			assert.Equal(t, test.breaking, diff.Breaking())
+		})
+	}
+}
+
+func TestDiffSchemasWithoutPaths(t *testing.T) {
+	// Schemas served by the /Schemas endpoint carry no "_path".
+	parse := func(attributes string) *Schema {
+		schema := new(Schema)
+		require.Nil(t, json.Unmarshal([]byte(`{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Diff",
+  "name": "Diff",
+  "attributes": `+attributes+`
+}`), schema))
+		return schema
+	}
+
+	old := parse(`[
+  {"name": "nickName", "type": "string"},
+  {"name": "name", "type": "complex", "subAttributes": [{"name": "givenName", "type": "string"}]}
+]`)
+	new := parse(`[
+  {"name": "title", "type": "string"},
+  {"name": "name", "type": "complex", "subAttributes": [
+    {"name": "givenName", "type": "string", "caseExact": true},
+    {"name": "familyName", "type": "string", "required": true}
+  ]}
+]`)
+
+	var changes []string
+	for _, change := range DiffSchemas(old, new).Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		"name.familyName: added (breaking)",
+		"name.givenName: caseExact changed from 'false' to 'true'",
+		"nickName: removed (breaking)",
+		"title: added",
+	}, changes)
+}
+
+func TestDiffResourceTypes(t *testing.T) {
+	schemaOf := func(id string) *Schema {
+		return &Schema{id: id}
+	}
+	resourceTypeOf := func(schema string, extensions map[string]bool) *ResourceType {
+		rt := &ResourceType{id: "Test", schema: schemaOf(schema)}
+		ext := &resourceTypeExtensions{required: map[string]bool{}}
+		for id, required := range extensions {
+			ext.schemas = append(ext.schemas, schemaOf(id))
+			ext.required[id] = required
+		}
+		rt.ext.Store(ext)
+		return rt
+	}
+
+	tests := []struct {
+		name     string
+		old      *ResourceType
+		new      *ResourceType
+		changes  []string
+		breaking bool
+	}{
+		{
+			name: "identical",
+			old:  resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": false}),
+			new:  resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": false}),
+		},
+		{
+			name:    "optional extension added and required extension made optional",
+			old:     resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": true}),
+			new:     resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": false, "urn:ext:b": false}),
+			changes: []string{"urn:ext:a: required changed to 'false'", "urn:ext:b: added"},
+		},
+		{
+			name:     "required extension added",
+			old:      resourceTypeOf("urn:main", nil),
+			new:      resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": true}),
+			changes:  []string{"urn:ext:a: added (breaking)"},
+			breaking: true,
+		},
+		{
+			name:     "extension removed",
+			old:      resourceTypeOf("urn:main", map[string]bool{"urn:ext:a": false}),
+			new:      resourceTypeOf("urn:main", nil),
+			changes:  []string{"urn:ext:a: removed (breaking)"},
+			breaking: true,
+		},
+		{
+			name:     "main schema changed",
+			old:      resourceTypeOf("urn:main", nil),
+			new:      resourceTypeOf("urn:main:v2", nil),
+			breaking: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := DiffResourceTypes(test.old, test.new)
+			var changes []string
+			for _, change := range diff.Extensions {
+				changes = append(changes, change.String())
+			}
+			assert.Equal(t, test.changes, changes)
+			assert.Equal(t, test.breaking, diff.Breaking())
+		})
+	}
+}
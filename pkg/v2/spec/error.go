@@ -1,42 +1,69 @@
 package spec
 
+import "errors"
+
+// scimType values defined in RFC7644 section 3.12
+const (
+	ScimTypeInvalidFilter  = "invalidFilter"
+	ScimTypeTooMany        = "tooMany"
+	ScimTypeUniqueness     = "uniqueness"
+	ScimTypeMutability     = "mutability"
+	ScimTypeInvalidSyntax  = "invalidSyntax"
+	ScimTypeInvalidPath    = "invalidPath"
+	ScimTypeNoTarget       = "noTarget"
+	ScimTypeInvalidValue   = "invalidValue"
+	ScimTypeInvalidVersion = "invalidVers"
+	ScimTypeSensitive      = "sensitive"
+)
+
 // Error prototypes
 var (
 	// The specified filter syntax was invalid, or the specified attribute and filter comparison combination is not supported.
-	ErrInvalidFilter = &Error{Status: 400, Type: "invalidFilter"}
+	ErrInvalidFilter = &Error{Status: 400, Type: ScimTypeInvalidFilter}
 
 	// The specified filter yields many more results than the server is willing to calculate or process.
-	ErrTooMany = &Error{Status: 400, Type: "tooMany"}
+	ErrTooMany = &Error{Status: 400, Type: ScimTypeTooMany}
 
 	// One or more of the attribute values are already in use or are reserved.
-	ErrUniqueness = &Error{Status: 409, Type: "uniqueness"}
+	ErrUniqueness = &Error{Status: 409, Type: ScimTypeUniqueness}
 
 	// The attempted modification is not compatible with the target attribute's mutability or current state (e.g.,
 	// modification of an "immutable" attribute with an existing value).
-	ErrMutability = &Error{Status: 400, Type: "mutability"}
+	ErrMutability = &Error{Status: 400, Type: ScimTypeMutability}
 
 	// The request body message structure was invalid or did not conform to the request schema.
-	ErrInvalidSyntax = &Error{Status: 400, Type: "invalidSyntax"}
+	ErrInvalidSyntax = &Error{Status: 400, Type: ScimTypeInvalidSyntax}
 
 	// The "path" attribute was invalid or malformed.
-	ErrInvalidPath = &Error{Status: 400, Type: "invalidPath"}
+	ErrInvalidPath = &Error{Status: 400, Type: ScimTypeInvalidPath}
 
 	// The specified "path" did not yield an attribute or attribute value that could be operated on. This occurs when
 	// the specified "path" value contains a filter that yields no match.
-	ErrNoTarget = &Error{Status: 400, Type: "noTarget"}
+	ErrNoTarget = &Error{Status: 400, Type: ScimTypeNoTarget}
 
 	// A required value was missing, or the value specified was not compatible with the operation or attribute type.
-	ErrInvalidValue = &Error{Status: 400, Type: "invalidValue"}
+	ErrInvalidValue = &Error{Status: 400, Type: ScimTypeInvalidValue}
+
+	// The specified SCIM protocol version is not supported.
+	ErrInvalidVersion = &Error{Status: 400, Type: ScimTypeInvalidVersion}
 
 	// The resource was not found from persistence store.
 	ErrNotFound = &Error{Status: 404, Type: "notFound"}
 
 	// The specified request cannot be completed, due to the passing of sensitive information in a request URI.
-	ErrSensitive = &Error{Status: 400, Type: "sensitive"}
+	ErrSensitive = &Error{Status: 400, Type: ScimTypeSensitive}
 
 	// The resource is in conflict with some pre conditions.
 	ErrConflict = &Error{Status: 412, Type: "conflict"}
 
+	// The version of the resource is not the one expected by the client, i.e. in the If-Match header, as the resource
+	// was modified in the meantime. The response has the 412 status of a failed pre condition and the invalidVers scimType.
+	ErrVersionMismatch = &Error{Status: 412, Type: ScimTypeInvalidVersion}
+
+	// The request is valid, but the server is not configured to fulfill it, i.e. /Me without a way to resolve the
+	// authenticated subject.
+	ErrNotImplemented = &Error{Status: 501, Type: "notImplemented"}
+
 	// Server encountered internal error.
 	ErrInternal = &Error{Status: 500, Type: "internal"}
 )
@@ -54,6 +81,58 @@ func (s Error) Error() string {
 	return s.Type
 }
 
+// ScimType returns the scimType of the error to be included in the error response, which is the type of the error if
+// defined by RFC7644 section 3.12, or empty, i.e. for ErrNotFound and ErrConflict that are described by the HTTP
+// status alone.
+func (s Error) ScimType() string {
+	if _, ok := rfcScimTypes[s.Type]; ok {
+		return s.Type
+	}
+	return ""
+}
+
+var rfcScimTypes = map[string]struct{}{
+	ScimTypeInvalidFilter:  {},
+	ScimTypeTooMany:        {},
+	ScimTypeUniqueness:     {},
+	ScimTypeMutability:     {},
+	ScimTypeInvalidSyntax:  {},
+	ScimTypeInvalidPath:    {},
+	ScimTypeNoTarget:       {},
+	ScimTypeInvalidValue:   {},
+	ScimTypeInvalidVersion: {},
+	ScimTypeSensitive:      {},
+}
+
+// ErrorDetails is the status and scimType of the error response for an error.
+type ErrorDetails struct {
+	// HTTP status of the response
+	Status int
+	// scimType of the response, or empty if not defined by RFC7644
+	ScimType string
+	// Whether the error is caused by the server, in which case the message should not be disclosed to the client
+	Internal bool
+}
+
+// DetailsOf returns the details of the error response for the error. If the cause of the error (determined using
+// errors.As) is an *Error other than ErrInternal, i.e. ErrNotImplemented, its status and scimType are returned.
+// Otherwise, i.e. for ErrInternal or errors not caused by an *Error, the details are those of ErrInternal and marked
+// as internal. This is the single source of truth for mapping errors to responses.
+func DetailsOf(err error) ErrorDetails {
+	var scimError *Error
+	if err != nil && errors.As(err, &scimError) && scimError.Status != ErrInternal.Status {
+		return ErrorDetails{
+			Status:   scimError.Status,
+			ScimType: scimError.ScimType(),
+		}
+	}
+	return ErrorDetails{
+		Status:   ErrInternal.Status,
+		ScimType: ErrInternal.ScimType(),
+		Internal: true,
+	}
+}
+
 var (
 	_ error = (*Error)(nil)
 )
@@ -0,0 +1,44 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetailsOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect ErrorDetails
+	}{
+		{name: "invalidFilter", err: ErrInvalidFilter, expect: ErrorDetails{Status: 400, ScimType: "invalidFilter"}},
+		{name: "invalidPath", err: ErrInvalidPath, expect: ErrorDetails{Status: 400, ScimType: "invalidPath"}},
+		{name: "invalidValue", err: ErrInvalidValue, expect: ErrorDetails{Status: 400, ScimType: "invalidValue"}},
+		{name: "invalidSyntax", err: ErrInvalidSyntax, expect: ErrorDetails{Status: 400, ScimType: "invalidSyntax"}},
+		{name: "mutability", err: ErrMutability, expect: ErrorDetails{Status: 400, ScimType: "mutability"}},
+		{name: "uniqueness", err: ErrUniqueness, expect: ErrorDetails{Status: 409, ScimType: "uniqueness"}},
+		{name: "noTarget", err: ErrNoTarget, expect: ErrorDetails{Status: 400, ScimType: "noTarget"}},
+		{name: "tooMany", err: ErrTooMany, expect: ErrorDetails{Status: 400, ScimType: "tooMany"}},
+		{name: "sensitive", err: ErrSensitive, expect: ErrorDetails{Status: 400, ScimType: "sensitive"}},
+		{name: "invalidVers", err: ErrInvalidVersion, expect: ErrorDetails{Status: 400, ScimType: "invalidVers"}},
+		{name: "not found without scimType", err: ErrNotFound, expect: ErrorDetails{Status: 404}},
+		{name: "conflict without scimType", err: ErrConflict, expect: ErrorDetails{Status: 412}},
+		{name: "not implemented", err: ErrNotImplemented, expect: ErrorDetails{Status: 501}},
+		{
+			name:   "wrapped",
+			err:    fmt.Errorf("%w: 'userName' is required", ErrInvalidValue),
+			expect: ErrorDetails{Status: 400, ScimType: "invalidValue"},
+		},
+		{name: "internal", err: ErrInternal, expect: ErrorDetails{Status: 500, Internal: true}},
+		{name: "foreign", err: errors.New("connection refused"), expect: ErrorDetails{Status: 500, Internal: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, DetailsOf(test.err))
+		})
+	}
+}
@@ -1,19 +1,24 @@
 package internal
 
-// adapter to marshal the attribute
+// adapter to marshal the attribute, including the fields used internally, so that the output can be unmarshalled
+// by AttributeUnmarshaler into the same attribute
 type AttributeMarshaler struct {
-	Name            string                `json:"name"`
-	Description     string                `json:"description,omitempty"`
-	Type            string                `json:"type"`
-	SubAttributes   []*AttributeMarshaler `json:"subAttributes,omitempty"`
-	CanonicalValues []string              `json:"canonicalValues,omitempty"`
-	MultiValued     bool                  `json:"multiValued"`
-	Required        bool                  `json:"required"`
-	CaseExact       bool                  `json:"caseExact"`
-	Mutability      string                `json:"mutability"`
-	Returned        string                `json:"returned"`
-	Uniqueness      string                `json:"uniqueness"`
-	ReferenceTypes  []string              `json:"referenceTypes,omitempty"`
+	ID              string                            `json:"id"`
+	Name            string                            `json:"name"`
+	Description     string                            `json:"description,omitempty"`
+	Type            string                            `json:"type"`
+	SubAttributes   []*AttributeMarshaler             `json:"subAttributes,omitempty"`
+	CanonicalValues []string                          `json:"canonicalValues,omitempty"`
+	MultiValued     bool                              `json:"multiValued"`
+	Required        bool                              `json:"required"`
+	CaseExact       bool                              `json:"caseExact"`
+	Mutability      string                            `json:"mutability"`
+	Returned        string                            `json:"returned"`
+	Uniqueness      string                            `json:"uniqueness"`
+	ReferenceTypes  []string                          `json:"referenceTypes,omitempty"`
+	Index           int                               `json:"_index"`
+	Path            string                            `json:"_path"`
+	Annotations     map[string]map[string]interface{} `json:"_annotations,omitempty"`
 }
 
 // adapter to unmarshal the attribute
@@ -7,6 +7,7 @@ type ResourceTypeJsonAdapter struct {
 	Endpoint    string             `json:"endpoint"`
 	Schema      string             `json:"schema"`
 	Extensions  []*SchemaExtension `json:"schemaExtensions,omitempty"`
+	Filter      string             `json:"_filter,omitempty"`
 }
 
 type SchemaExtension struct {
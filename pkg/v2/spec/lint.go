@@ -0,0 +1,136 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+)
+
+// LintPolicy decides how schema registration treats the contradictory attribute characteristics reported by
+// LintSchema.
+type LintPolicy int32
+
+const (
+	// LintPolicyWarn registers the schema, but passes each finding as a warning to the handler set by
+	// SetWarningHandler. This is the default.
+	LintPolicyWarn LintPolicy = iota
+	// LintPolicyReject fails the registration with the first finding.
+	LintPolicyReject
+	// LintPolicyIgnore registers the schema silently.
+	LintPolicyIgnore
+)
+
+var lintPolicy int32
+
+// SetLintPolicy sets how schemas registered afterwards treat the findings of LintSchema.
+func SetLintPolicy(policy LintPolicy) {
+	atomic.StoreInt32(&lintPolicy, int32(policy))
+}
+
+// Annotations by which the server assigns the value of an attribute, so that a required attribute can be readOnly.
+var serverAssignedAnnotations = []string{annotation.UUID, annotation.ReadOnly}
+
+// LintSchema reports the attributes of the schema whose characteristics contradict each other, and would only cause
+// confusing failures at runtime. Each finding is ErrInvalidValue naming the attribute by its id, and explaining the
+// contradiction. The findings are:
+//
+// A required attribute that is readOnly, since clients can never supply it, unless its value is assigned by the server
+// through one of the @UUID or @ReadOnly annotations.
+//
+// A caseExact attribute which is not a string, reference or binary, since only their values have case.
+//
+// A "primary" sub attribute, or one annotated with @Primary, which is not boolean.
+//
+// A multiValued complex attribute with uniqueness, since uniqueness applies to the values of simple attributes.
+//
+// A @Constraints parameter that does not apply to the type of the attribute, i.e. maxLength on an integer attribute, or
+// minimum on a string attribute.
+func LintSchema(schema *Schema) []error {
+	var findings []error
+	for _, attr := range schema.attributes {
+		attr.DFS(func(attr *Attribute) {
+			findings = append(findings, lintAttribute(attr)...)
+		})
+	}
+	return findings
+}
+
+func lintAttribute(attr *Attribute) []error {
+	var findings []error
+	report := func(format string, args ...interface{}) {
+		findings = append(findings, fmt.Errorf("%w: attribute '%s' %s", ErrInvalidValue, attr.id, fmt.Sprintf(format, args...)))
+	}
+
+	if attr.required && attr.mutability == MutabilityReadOnly {
+		assigned := false
+		for _, name := range serverAssignedAnnotations {
+			if _, ok := attr.annotations[name]; ok {
+				assigned = true
+			}
+		}
+		if !assigned {
+			report("is required but readOnly, hence clients can never supply it, and no annotation (%s) assigns it on the server",
+				strings.Join(serverAssignedAnnotations, ", "))
+		}
+	}
+
+	switch attr.typ {
+	case TypeString, TypeReference, TypeBinary:
+	default:
+		if attr.caseExact {
+			report("is caseExact, but only string, reference and binary values have case, not %s values", attr.typ.String())
+		}
+	}
+
+	for _, subAttr := range attr.subAttributes {
+		_, annotated := subAttr.annotations[annotation.Primary]
+		if (annotated || strings.EqualFold(subAttr.name, "primary")) && subAttr.typ != TypeBoolean {
+			findings = append(findings, fmt.Errorf("%w: attribute '%s' is a primary sub attribute, but is %s instead of boolean",
+				ErrInvalidValue, subAttr.id, subAttr.typ.String()))
+		}
+	}
+
+	if attr.typ == TypeComplex && attr.multiValued && attr.uniqueness != UniquenessNone {
+		report("is a multiValued complex attribute with uniqueness '%s', but uniqueness only applies to simple values",
+			attr.uniqueness.String())
+	}
+
+	if params, ok := attr.annotations[annotation.Constraints]; ok {
+		if attr.typ != TypeString {
+			for _, name := range []string{"minLength", "maxLength", "pattern"} {
+				if _, ok := params[name]; ok {
+					report("has the @Constraints parameter '%s', which only applies to string values, not %s values",
+						name, attr.typ.String())
+				}
+			}
+		}
+		if attr.typ != TypeInteger && attr.typ != TypeDecimal {
+			for _, name := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+				if _, ok := params[name]; ok {
+					report("has the @Constraints parameter '%s', which only applies to integer and decimal values, not %s values",
+						name, attr.typ.String())
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// Applies the lint policy to the findings of LintSchema for the schema being registered.
+func applyLintPolicy(schema *Schema) error {
+	policy := LintPolicy(atomic.LoadInt32(&lintPolicy))
+	if policy == LintPolicyIgnore {
+		return nil
+	}
+
+	for _, finding := range LintSchema(schema) {
+		if policy == LintPolicyReject {
+			return finding
+		}
+		warn(fmt.Errorf("schema '%s': %w", schema.id, finding))
+	}
+	return nil
+}
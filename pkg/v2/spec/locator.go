@@ -0,0 +1,53 @@
+package spec
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Locator renders the locations composed by the server, i.e. meta.location and the $ref of group members, on a per
+// request basis. The server stores these locations relative to the service root (i.e. /Users/{id}), so that the same
+// instance can be served behind different domains. At rendering time, Locator prefixes them with BaseURL, or with only
+// the path of BaseURL when PathOnly is set.
+//
+// For instance, with BaseURL "https://example.com/v2", the location "/Users/123" is rendered as
+// "https://example.com/v2/Users/123", or "/v2/Users/123" when PathOnly is set.
+type Locator struct {
+	// BaseURL is the URL of the service root, as seen by the client of the current request.
+	BaseURL string
+	// PathOnly instructs the Locator to emit path-only locations, without scheme and host.
+	PathOnly bool
+}
+
+// Render returns the location as seen by the client. Only locations relative to the service root, which starts
+// with "/", are rendered. Absolute locations, which were likely supplied by the client, are returned as is.
+func (l *Locator) Render(location string) string {
+	if l == nil || !strings.HasPrefix(location, "/") {
+		return location
+	}
+
+	base := l.BaseURL
+	if l.PathOnly {
+		if u, err := url.Parse(base); err == nil {
+			base = u.EscapedPath()
+		}
+	}
+
+	return strings.TrimSuffix(base, "/") + location
+}
+
+type locatorKey struct{}
+
+// ContextWithLocator returns a copy of the context which carries the locator. Handlers use it to thread the locator
+// for the current request, usually derived from its host and forwarded headers, to the rendering functions.
+func ContextWithLocator(ctx context.Context, locator *Locator) context.Context {
+	return context.WithValue(ctx, locatorKey{}, locator)
+}
+
+// LocatorFromContext returns the locator carried by the context, or nil if there is none. A nil Locator renders all
+// locations as they are stored.
+func LocatorFromContext(ctx context.Context) *Locator {
+	locator, _ := ctx.Value(locatorKey{}).(*Locator)
+	return locator
+}
@@ -0,0 +1,66 @@
+package spec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocator(t *testing.T) {
+	tests := []struct {
+		name     string
+		locator  *Locator
+		location string
+		expect   string
+	}{
+		{
+			name:     "nil locator",
+			location: "/Users/123",
+			expect:   "/Users/123",
+		},
+		{
+			name:     "base url",
+			locator:  &Locator{BaseURL: "https://example.com/v2"},
+			location: "/Users/123",
+			expect:   "https://example.com/v2/Users/123",
+		},
+		{
+			name:     "base url with trailing slash",
+			locator:  &Locator{BaseURL: "https://example.com/"},
+			location: "/Users/123",
+			expect:   "https://example.com/Users/123",
+		},
+		{
+			name:     "path only",
+			locator:  &Locator{BaseURL: "https://example.com/v2", PathOnly: true},
+			location: "/Users/123",
+			expect:   "/v2/Users/123",
+		},
+		{
+			name:     "path only without base url",
+			locator:  &Locator{PathOnly: true},
+			location: "/Users/123",
+			expect:   "/Users/123",
+		},
+		{
+			name:     "absolute location",
+			locator:  &Locator{BaseURL: "https://example.com/v2"},
+			location: "https://other.org/Users/123",
+			expect:   "https://other.org/Users/123",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, test.locator.Render(test.location))
+		})
+	}
+}
+
+func TestLocatorFromContext(t *testing.T) {
+	assert.Nil(t, LocatorFromContext(context.Background()))
+
+	locator := &Locator{BaseURL: "https://example.com"}
+	assert.Equal(t, locator, LocatorFromContext(ContextWithLocator(context.Background(), locator)))
+}
@@ -1,5 +1,7 @@
 package spec
 
+import "github.com/imulab/go-scim/pkg/v2/annotation"
+
 var (
 	metaAttributes = &metaAttr{}
 )
@@ -98,6 +100,9 @@ func (m *metaAttr) CoreMetaPartialAttribute() *Attribute {
 					typ:   TypeString,
 					index: 1,
 					path:  "meta.location",
+					annotations: map[string]map[string]interface{}{
+						annotation.Computed: {},
+					},
 				},
 			},
 		}
@@ -363,6 +368,30 @@ func (m *metaAttr) AttributeSubAttributesAttributeNoSub() *Attribute {
 	return m.attrSubAttributes
 }
 
+// AttributeConstraintsAttribute returns an attribute to describe the constraints set on an attribute by the
+// @Constraints annotation, which are not part of RFC7643, under the given vendor namespace, i.e. "urn:acme:constraints".
+// Since the namespace is chosen by the caller, a new attribute is returned for each call.
+func (m *metaAttr) AttributeConstraintsAttribute(namespace string) *Attribute {
+	id := "urn:ietf:params:scim:schemas:core:2.0:Schema:attributes." + namespace
+	path := "attributes." + namespace
+	return &Attribute{
+		id:    id,
+		name:  namespace,
+		typ:   TypeComplex,
+		index: 12,
+		path:  path,
+		subAttributes: []*Attribute{
+			{id: id + ".minLength", name: "minLength", typ: TypeInteger, index: 0, path: path + ".minLength"},
+			{id: id + ".maxLength", name: "maxLength", typ: TypeInteger, index: 1, path: path + ".maxLength"},
+			{id: id + ".pattern", name: "pattern", typ: TypeString, caseExact: true, index: 2, path: path + ".pattern"},
+			{id: id + ".minimum", name: "minimum", typ: TypeDecimal, index: 3, path: path + ".minimum"},
+			{id: id + ".maximum", name: "maximum", typ: TypeDecimal, index: 4, path: path + ".maximum"},
+			{id: id + ".exclusiveMinimum", name: "exclusiveMinimum", typ: TypeBoolean, index: 5, path: path + ".exclusiveMinimum"},
+			{id: id + ".exclusiveMaximum", name: "exclusiveMaximum", typ: TypeBoolean, index: 6, path: path + ".exclusiveMaximum"},
+		},
+	}
+}
+
 // ResourceTypeAttributeNoSub returns an attribute to act as the container attribute for ResourceType resources, but it does not
 // actually contain any sub attributes.
 func (m *metaAttr) ResourceTypeAttributeNoSub() *Attribute {
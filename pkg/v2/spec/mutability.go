@@ -1,5 +1,7 @@
 package spec
 
+import "fmt"
+
 // SCIM mutability definition
 type Mutability int
 
@@ -11,18 +13,21 @@ const (
 	MutabilityImmutable
 )
 
-func mustParseMutability(value string) Mutability {
+// ParseMutability parses the mutability characteristic of an attribute from its string form defined in RFC7643, which is
+// case sensitive. An empty value stands for the default mutability, as it does in the JSON definition of a schema. Any
+// other value is rejected with ErrInvalidValue.
+func ParseMutability(value string) (Mutability, error) {
 	switch value {
 	case "readWrite", "":
-		return MutabilityReadWrite
+		return MutabilityReadWrite, nil
 	case "readOnly":
-		return MutabilityReadOnly
+		return MutabilityReadOnly, nil
 	case "immutable":
-		return MutabilityImmutable
+		return MutabilityImmutable, nil
 	case "writeOnly":
-		return MutabilityWriteOnly
+		return MutabilityWriteOnly, nil
 	default:
-		panic("invalid mutability value")
+		return MutabilityReadWrite, fmt.Errorf("%w: '%s' is not a valid mutability", ErrInvalidValue, value)
 	}
 }
 
@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AttributeOverride is a characteristic overlaid onto a registered attribute by OverrideAttribute. Only the
+// characteristics that merely affect how the attribute is presented are overridable: a Returned value, or a
+// DescriptionOverride.
+type AttributeOverride interface {
+	// Checks the override is safe for the attribute as it was defined, and applies it.
+	override(attr *Attribute) error
+}
+
+// DescriptionOverride overrides the description of an attribute.
+type DescriptionOverride string
+
+func (d DescriptionOverride) override(attr *Attribute) error {
+	attr.description = string(d)
+	return nil
+}
+
+// A Returned override may only move an attribute among the default and request policies. Attributes defined to be
+// returned always, i.e. id, are relied upon by clients, and those defined to be returned never, i.e. password, must
+// not leak. For the same reasons, the override itself cannot be always or never.
+func (r Returned) override(attr *Attribute) error {
+	for _, returned := range []Returned{attr.returned, r} {
+		if returned != ReturnedDefault && returned != ReturnedRequest {
+			return fmt.Errorf("%w: cannot override returned of attribute '%s' from '%s' to '%s'",
+				ErrInvalidValue, attr.id, attr.returned.String(), r.String())
+		}
+	}
+	attr.returned = r
+	return nil
+}
+
+// OverrideAttribute records deployment specific overrides of the attribute, identified by its full id, i.e.
+// "urn:ietf:params:scim:schemas:core:2.0:User:groups". The overrides are applied when the schema defining the
+// attribute is registered, hence OverrideAttribute shall be called before loading the schemas. Being overlaid onto
+// the attribute itself, the overrides affect serialization of the resources, as well as the attribute served by the
+// /Schemas endpoint, so clients see the effective policy. Overriding an attribute again replaces the previous
+// overrides. Unsafe overrides fail the registration of the schema with ErrInvalidValue.
+//
+// For example:
+//
+//	spec.OverrideAttribute("urn:ietf:params:scim:schemas:core:2.0:User:groups", spec.ReturnedRequest)
+func OverrideAttribute(attributeId string, overrides ...AttributeOverride) {
+	attributeOverrides.Lock()
+	defer attributeOverrides.Unlock()
+	attributeOverrides.db[strings.ToLower(attributeId)] = overrides
+}
+
+var attributeOverrides = struct {
+	sync.RWMutex
+	db map[string][]AttributeOverride
+}{
+	db: map[string][]AttributeOverride{},
+}
+
+// Applies the recorded overrides to the attributes of the schema being registered.
+func applyAttributeOverrides(schema *Schema) error {
+	attributeOverrides.RLock()
+	defer attributeOverrides.RUnlock()
+	if len(attributeOverrides.db) == 0 {
+		return nil
+	}
+
+	for id, attr := range collectAttributes(schema.attributes, map[string]*Attribute{}) {
+		for _, each := range attributeOverrides.db[id] {
+			if err := each.override(attr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package spec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ParseSchema parses the JSON definition of a schema read from r. The schema is not registered.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	schema := new(Schema)
+	if err := json.NewDecoder(r).Decode(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// ParseResourceType parses the JSON definition of a resource type read from r. The main schema and schema extensions
+// referenced by the resource type must have been registered, otherwise the error is ErrInvalidValue.
+func ParseResourceType(r io.Reader) (*ResourceType, error) {
+	resourceType := new(ResourceType)
+	if err := json.NewDecoder(r).Decode(resourceType); err != nil {
+		return nil, err
+	}
+	return resourceType, nil
+}
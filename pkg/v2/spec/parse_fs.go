@@ -0,0 +1,86 @@
+//go:build go1.16
+// +build go1.16
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// LoadFS walks the file system, i.e. an embed.FS with the definitions compiled into the binary, and loads every JSON
+// file in it as either a schema or a resource type, told apart by the "schema" field only resource types have. All
+// schemas are registered first, then the resource types are parsed and returned. Both happen in the lexical order of
+// the file paths, so that the result is deterministic. Two schemas, or two resource types, with the same id among the
+// files are rejected with ErrInvalidValue naming both files.
+func LoadFS(fsys fs.FS) ([]*ResourceType, error) {
+	var schemaFiles, resourceTypeFiles []string
+	contents := map[string][]byte{}
+
+	// WalkDir visits files in lexical order
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+
+		raw, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		contents[path] = raw
+
+		var probe struct {
+			Schema *string `json:"schema"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return fmt.Errorf("%w: '%s' is not a valid JSON definition", ErrInvalidSyntax, path)
+		}
+		if probe.Schema != nil {
+			resourceTypeFiles = append(resourceTypeFiles, path)
+		} else {
+			schemaFiles = append(schemaFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := map[string]string{}
+	for _, path := range schemaFiles {
+		schema, err := ParseSchema(bytes.NewReader(contents[path]))
+		if err != nil {
+			return nil, fmt.Errorf("%w (file:'%s')", err, path)
+		}
+		if other, ok := schemas[schema.ID()]; ok {
+			return nil, fmt.Errorf("%w: schema '%s' is defined in both '%s' and '%s'", ErrInvalidValue, schema.ID(), other, path)
+		}
+		schemas[schema.ID()] = path
+
+		if err := Schemas().Register(schema); err != nil {
+			return nil, fmt.Errorf("%w (file:'%s')", err, path)
+		}
+	}
+
+	resourceTypes := make([]*ResourceType, 0, len(resourceTypeFiles))
+	seen := map[string]string{}
+	for _, path := range resourceTypeFiles {
+		resourceType, err := ParseResourceType(bytes.NewReader(contents[path]))
+		if err != nil {
+			return nil, fmt.Errorf("%w (file:'%s')", err, path)
+		}
+		if other, ok := seen[resourceType.ID()]; ok {
+			return nil, fmt.Errorf("%w: resource type '%s' is defined in both '%s' and '%s'", ErrInvalidValue, resourceType.ID(), other, path)
+		}
+		seen[resourceType.ID()] = path
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+
+	return resourceTypes, nil
+}
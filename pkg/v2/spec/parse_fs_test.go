@@ -0,0 +1,122 @@
+//go:build go1.16
+// +build go1.16
+
+package spec
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFS(t *testing.T) {
+	const (
+		main = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Device",
+  "name": "Device",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Device:serial", "name": "serial", "type": "string"}
+  ]
+}
+`
+		extension = `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:DeviceExtension",
+  "name": "Device Extension",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:DeviceExtension:owner", "name": "owner", "type": "string"}
+  ]
+}
+`
+		device = `
+{
+  "id": "Device",
+  "name": "Device",
+  "endpoint": "/Devices",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Device",
+  "schemaExtensions": [{"schema": "urn:ietf:params:scim:schemas:test:2.0:DeviceExtension"}]
+}
+`
+		tablet = `
+{
+  "id": "Tablet",
+  "name": "Tablet",
+  "endpoint": "/Tablets",
+  "schema": "urn:ietf:params:scim:schemas:test:2.0:Device"
+}
+`
+	)
+
+	tests := []struct {
+		name   string
+		fsys   fstest.MapFS
+		expect func(t *testing.T, resourceTypes []*ResourceType, err error)
+	}{
+		{
+			name: "schemas are registered before resource types",
+			fsys: fstest.MapFS{
+				// resource types sorted before the schemas they reference
+				"a/tablet.json":            {Data: []byte(tablet)},
+				"a/device.json":            {Data: []byte(device)},
+				"b/schemas/device.json":    {Data: []byte(main)},
+				"b/schemas/extension.json": {Data: []byte(extension)},
+				"README.md":                {Data: []byte("not a definition")},
+			},
+			expect: func(t *testing.T, resourceTypes []*ResourceType, err error) {
+				require.Nil(t, err)
+				require.Len(t, resourceTypes, 2)
+				assert.Equal(t, "Device", resourceTypes[0].ID())
+				assert.Equal(t, 1, resourceTypes[0].CountExtensions())
+				assert.Equal(t, "Tablet", resourceTypes[1].ID())
+
+				_, ok := Schemas().Get("urn:ietf:params:scim:schemas:test:2.0:DeviceExtension")
+				assert.True(t, ok)
+			},
+		},
+		{
+			name: "duplicate schema ids",
+			fsys: fstest.MapFS{
+				"device.json":       {Data: []byte(main)},
+				"device_again.json": {Data: []byte(main)},
+			},
+			expect: func(t *testing.T, resourceTypes []*ResourceType, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "schema 'urn:ietf:params:scim:schemas:test:2.0:Device' is defined in both 'device.json' and 'device_again.json'")
+			},
+		},
+		{
+			name: "duplicate resource type ids",
+			fsys: fstest.MapFS{
+				"schemas/device.json":    {Data: []byte(main)},
+				"schemas/extension.json": {Data: []byte(extension)},
+				"device.json":            {Data: []byte(device)},
+				"device_again.json":      {Data: []byte(device)},
+			},
+			expect: func(t *testing.T, resourceTypes []*ResourceType, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "resource type 'Device' is defined in both 'device.json' and 'device_again.json'")
+			},
+		},
+		{
+			name: "invalid JSON",
+			fsys: fstest.MapFS{
+				"broken.json": {Data: []byte(`{"id": `)},
+			},
+			expect: func(t *testing.T, resourceTypes []*ResourceType, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidSyntax))
+				assert.Contains(t, err.Error(), "'broken.json'")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resourceTypes, err := LoadFS(test.fsys)
+			test.expect(t, resourceTypes, err)
+		})
+	}
+}
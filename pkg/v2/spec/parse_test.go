@@ -0,0 +1,73 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceType(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(`
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Parsed",
+  "name": "Parsed",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Parsed:label", "name": "label", "type": "string"}
+  ]
+}
+`))
+	require.Nil(t, err)
+	assert.Equal(t, "urn:ietf:params:scim:schemas:test:2.0:Parsed", schema.ID())
+	require.Nil(t, Schemas().Register(schema))
+
+	tests := []struct {
+		name   string
+		raw    string
+		expect func(t *testing.T, rt *ResourceType, err error)
+	}{
+		{
+			name: "registered schema",
+			raw:  `{"id": "Parsed", "name": "Parsed", "schema": "urn:ietf:params:scim:schemas:test:2.0:Parsed"}`,
+			expect: func(t *testing.T, rt *ResourceType, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, "Parsed", rt.ID())
+				assert.Equal(t, schema, rt.Schema())
+			},
+		},
+		{
+			name: "unregistered schema",
+			raw:  `{"id": "Parsed", "name": "Parsed", "schema": "urn:ietf:params:scim:schemas:test:2.0:Unknown"}`,
+			expect: func(t *testing.T, rt *ResourceType, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "schema 'urn:ietf:params:scim:schemas:test:2.0:Unknown' of resource type 'Parsed' is not registered")
+			},
+		},
+		{
+			name: "unregistered schema extension",
+			raw: `{"id": "Parsed", "name": "Parsed", "schema": "urn:ietf:params:scim:schemas:test:2.0:Parsed",
+				"schemaExtensions": [{"schema": "urn:ietf:params:scim:schemas:test:2.0:Unknown"}]}`,
+			expect: func(t *testing.T, rt *ResourceType, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "schema extension 'urn:ietf:params:scim:schemas:test:2.0:Unknown'")
+			},
+		},
+		{
+			name: "malformed JSON",
+			raw:  `{"id": "Parsed"`,
+			expect: func(t *testing.T, rt *ResourceType, err error) {
+				assert.NotNil(t, err)
+				assert.Nil(t, rt)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt, err := ParseResourceType(strings.NewReader(test.raw))
+			test.expect(t, rt, err)
+		})
+	}
+}
@@ -2,8 +2,13 @@ package spec
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/imulab/go-scim/pkg/v2/spec/internal"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Resource type models the SCIM resource type. It is a collection of one main schema and zero or more schema extensions
@@ -20,6 +25,7 @@ import (
 //
 // For example, suppose we have a main schema containing attribute A1 and A2, a schema extension B containing attribute B1,
 // and another schema extension C containing attribute C1 and C2. The super attribute will be in the structure of:
+//
 //	{
 //		A1,
 //		A2,
@@ -35,15 +41,31 @@ import (
 // ResourceType is currently being parsed to and from JSON using special adapters. This design is subject to change
 // when we move to treat ResourceType as just another resource.
 // See also:
+//
 //	issue https://github.com/imulab/go-scim/issues/40
 type ResourceType struct {
 	id          string
 	name        string
 	description string
 	endpoint    string
+	filter      string
 	schema      *Schema
-	extensions  []*Schema
-	required    map[string]bool // schema id to boolean to indicate whether schema extension is required
+	ext         atomic.Value // *resourceTypeExtensions, replaced as a whole by AddExtension
+	extMu       sync.Mutex   // serializes AddExtension
+}
+
+// Schema extensions of a resource type. Once published, the structure is never modified, so that it can be read
+// without locking while an extension is being added.
+type resourceTypeExtensions struct {
+	schemas  []*Schema
+	required map[string]bool // schema id to boolean to indicate whether schema extension is required
+}
+
+func (t *ResourceType) extensions() *resourceTypeExtensions {
+	if ext, ok := t.ext.Load().(*resourceTypeExtensions); ok {
+		return ext
+	}
+	return &resourceTypeExtensions{}
 }
 
 // Return the id of the resource type
@@ -65,15 +87,24 @@ func (t *ResourceType) Endpoint() string {
 	return t.endpoint
 }
 
+// Filter returns the discriminating filter of the resource type, or an empty string. Resources of different types
+// stored in a shared collection, i.e. Users and ServiceAccounts distinguished by userType, only belong to the resource
+// type if they satisfy its filter, which is set by the "_filter" field of the configuration, i.e.
+// "userType eq \"service\"". The filter is an internal setting, hence it is not served by the /ResourceTypes endpoint.
+func (t *ResourceType) Filter() string {
+	return t.filter
+}
+
 // Return the main schema of the resource type
 func (t *ResourceType) Schema() *Schema {
-	return t.schema
+	return t.schema.latest()
 }
 
 // ForEachExtension iterates through all schema extensions and invoke the callback.
 func (t *ResourceType) ForEachExtension(callback func(extension *Schema, required bool) error) error {
-	for _, ext := range t.extensions {
-		if err := callback(ext, t.required[ext.id]); err != nil {
+	extensions := t.extensions()
+	for _, ext := range extensions.schemas {
+		if err := callback(ext.latest(), extensions.required[ext.id]); err != nil {
 			return err
 		}
 	}
@@ -82,7 +113,95 @@ func (t *ResourceType) ForEachExtension(callback func(extension *Schema, require
 
 // CountExtensions returns the total number of extensions
 func (t *ResourceType) CountExtensions() int {
-	return len(t.extensions)
+	return len(t.extensions().schemas)
+}
+
+// AddExtension amends the resource type with a schema extension at runtime, i.e. when a tenant brings its own extension
+// attributes. The extension must have been registered with Schemas, and must not be the main schema or an existing
+// extension of this resource type. Because resources persisted before the amendment do not have the extension, it
+// cannot be required. Errors are spec.ErrInvalidValue.
+//
+// The amendment is safe with concurrent readers: resources created from now on have the extension, while resources
+// created before keep their structure.
+func (t *ResourceType) AddExtension(extension *Schema, required bool) error {
+	registered, ok := Schemas().Get(extension.id)
+	if !ok || registered != extension {
+		return fmt.Errorf("%w: schema extension '%s' is not registered", ErrInvalidValue, extension.id)
+	}
+	if required {
+		return fmt.Errorf("%w: schema extension '%s' added to resource type '%s' cannot be required, as existing "+
+			"resources do not have it", ErrInvalidValue, extension.id, t.id)
+	}
+
+	t.extMu.Lock()
+	defer t.extMu.Unlock()
+
+	current := t.extensions()
+	if extension.id == t.schema.id {
+		return fmt.Errorf("%w: schema '%s' is the main schema of resource type '%s'", ErrInvalidValue, extension.id, t.id)
+	}
+	for _, ext := range current.schemas {
+		if ext.id == extension.id {
+			return fmt.Errorf("%w: schema extension '%s' is already in resource type '%s'", ErrInvalidValue, extension.id, t.id)
+		}
+	}
+	if err := checkAttributeCollisions(t.id, t.Schema(), []*Schema{extension}); err != nil {
+		return err
+	}
+
+	next := &resourceTypeExtensions{
+		schemas:  append(append([]*Schema{}, current.schemas...), extension),
+		required: map[string]bool{extension.id: required},
+	}
+	for id, req := range current.required {
+		next.required[id] = req
+	}
+	t.ext.Store(next)
+	return nil
+}
+
+// Validate checks that the resource type is self consistent, which is useful to catch misconfiguration before the
+// resource type is used, especially when it is constructed programmatically. The main schema must be set, the schema
+// extensions must have non-empty ids distinct from each other and from the main schema, their attributes must not
+// collide with those of the core schema and the main schema, and the endpoint must be an absolute path without query or
+// fragment, i.e. "/Users". Errors are spec.ErrInvalidValue.
+func (t *ResourceType) Validate() error {
+	if t.schema == nil {
+		return fmt.Errorf("%w: resource type '%s' has no main schema", ErrInvalidValue, t.id)
+	}
+
+	seen := map[string]struct{}{strings.ToLower(t.schema.id): {}}
+	for _, ext := range t.extensions().schemas {
+		if ext == nil || len(ext.id) == 0 {
+			return fmt.Errorf("%w: resource type '%s' has a schema extension without id", ErrInvalidValue, t.id)
+		}
+		if _, ok := seen[strings.ToLower(ext.id)]; ok {
+			return fmt.Errorf("%w: schema extension '%s' appears more than once in resource type '%s'", ErrInvalidValue, ext.id, t.id)
+		}
+		seen[strings.ToLower(ext.id)] = struct{}{}
+	}
+
+	var extensions []*Schema
+	_ = t.ForEachExtension(func(extension *Schema, _ bool) error {
+		extensions = append(extensions, extension)
+		return nil
+	})
+	if err := checkAttributeCollisions(t.id, t.Schema(), extensions); err != nil {
+		return err
+	}
+
+	if u, err := url.Parse(t.endpoint); err != nil || len(t.endpoint) < 2 || !strings.HasPrefix(t.endpoint, "/") ||
+		u.Path != t.endpoint || strings.ContainsAny(t.endpoint, " \t\n") {
+		return fmt.Errorf("%w: endpoint '%s' of resource type '%s' is not a valid path", ErrInvalidValue, t.endpoint, t.id)
+	}
+
+	return nil
+}
+
+// Location returns the location of the resource of this resource type with the given id, relative to the service root,
+// i.e. "/Users/123". This is the value of meta.location, which is computed by the server rather than persisted.
+func (t *ResourceType) Location(id string) string {
+	return strings.TrimSuffix(t.endpoint, "/") + "/" + id
 }
 
 // ResourceTypeName returns the resource type of the ResourceType resource. This value is formally defined and hence fixed.
@@ -107,14 +226,16 @@ func (t *ResourceType) convertToAdapter(p *internal.ResourceTypeJsonAdapter) {
 	p.Name = t.name
 	p.Description = t.description
 	p.Endpoint = t.endpoint
+	p.Filter = t.filter
 	p.Schema = t.schema.id
 	p.Extensions = []*internal.SchemaExtension{}
-	for _, ext := range t.extensions {
+	_ = t.ForEachExtension(func(extension *Schema, required bool) error {
 		p.Extensions = append(p.Extensions, &internal.SchemaExtension{
-			Schema:   ext.id,
-			Required: t.required[ext.id],
+			Schema:   extension.id,
+			Required: required,
 		})
-	}
+		return nil
+	})
 }
 
 func (t *ResourceType) UnmarshalJSON(raw []byte) error {
@@ -122,22 +243,77 @@ func (t *ResourceType) UnmarshalJSON(raw []byte) error {
 	if err := json.Unmarshal(raw, &adapter); err != nil {
 		return err
 	}
-	t.convertFromAdapter(&adapter)
-	return nil
+	return t.convertFromAdapter(&adapter)
 }
 
-func (t *ResourceType) convertFromAdapter(p *internal.ResourceTypeJsonAdapter) {
+func (t *ResourceType) convertFromAdapter(p *internal.ResourceTypeJsonAdapter) error {
+	schema, ok := Schemas().Get(p.Schema)
+	if !ok {
+		return fmt.Errorf("%w: schema '%s' of resource type '%s' is not registered", ErrInvalidValue, p.Schema, p.ID)
+	}
+	extensions := &resourceTypeExtensions{
+		schemas:  []*Schema{},
+		required: map[string]bool{},
+	}
+	for _, ext := range p.Extensions {
+		extension, ok := Schemas().Get(ext.Schema)
+		if !ok {
+			return fmt.Errorf("%w: schema extension '%s' of resource type '%s' is not registered", ErrInvalidValue, ext.Schema, p.ID)
+		}
+		extensions.schemas = append(extensions.schemas, extension)
+		extensions.required[ext.Schema] = ext.Required
+	}
+	if err := checkAttributeCollisions(p.ID, schema, extensions.schemas); err != nil {
+		return err
+	}
+
 	t.id = p.ID
 	t.name = p.Name
 	t.description = p.Description
 	t.endpoint = p.Endpoint
-	t.schema = Schemas().mustGet(p.Schema)
-	t.extensions = []*Schema{}
-	t.required = map[string]bool{}
-	for _, ext := range p.Extensions {
-		t.extensions = append(t.extensions, Schemas().mustGet(ext.Schema))
-		t.required[ext.Schema] = ext.Required
+	t.filter = p.Filter
+	t.schema = schema
+	t.ext.Store(extensions)
+	return nil
+}
+
+// Checks that the top level attributes of the main schema and of each schema extension do not collide with those of
+// the core schema or the main schema, comparing names case insensitively. The core schema and the main schema share
+// the top level of the resource, where colliding attributes would shadow each other. An extension attribute named after
+// one of them would make unqualified paths ambiguous, hence it is rejected as well. Two schema extensions may define
+// attributes of the same name, because their attributes can only be referenced with the schema URN prefix. The check
+// on the core schema is skipped if it is not registered.
+func checkAttributeCollisions(resourceTypeId string, main *Schema, extensions []*Schema) error {
+	defined := map[string]string{} // lower case attribute name to the id of the defining schema
+	if core, ok := Schemas().Get(CoreSchemaId); ok && main.id != CoreSchemaId {
+		for _, attr := range core.attributes {
+			defined[strings.ToLower(attr.name)] = core.id
+		}
+	}
+
+	check := func(schema *Schema) error {
+		for _, attr := range schema.attributes {
+			if owner, ok := defined[strings.ToLower(attr.name)]; ok {
+				return fmt.Errorf("%w: attribute '%s' of schema '%s' collides with the attribute of the same name in "+
+					"schema '%s' of resource type '%s'", ErrInvalidValue, attr.name, schema.id, owner, resourceTypeId)
+			}
+		}
+		return nil
+	}
+
+	if err := check(main); err != nil {
+		return err
 	}
+	for _, attr := range main.attributes {
+		defined[strings.ToLower(attr.name)] = main.id
+	}
+
+	for _, ext := range extensions {
+		if err := check(ext); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SuperAttribute return a virtual complex attribute that contains all schema attributes as its sub attributes.
@@ -159,30 +335,130 @@ func (t *ResourceType) SuperAttribute(includeCore bool) *Attribute {
 		super.annotations[annotation.SyncSchema] = map[string]interface{}{}
 	}
 
-	super.subAttributes = append(super.subAttributes, t.schema.attributes...)
+	super.subAttributes = append(super.subAttributes, t.Schema().attributes...)
 
 	var i = len(super.subAttributes)
 	_ = t.ForEachExtension(func(extension *Schema, required bool) error {
-		super.subAttributes = append(super.subAttributes, &Attribute{
-			id:            extension.id,
-			name:          extension.id,
-			description:   extension.description,
-			typ:           TypeComplex,
-			subAttributes: extension.attributes,
-			required:      required,
-			mutability:    MutabilityReadWrite,
-			returned:      ReturnedDefault,
-			uniqueness:    UniquenessNone,
-			index:         i,
-			path:          extension.id,
-			annotations: map[string]map[string]interface{}{
-				annotation.StateSummary:        {},
-				annotation.SchemaExtensionRoot: {},
-			},
-		})
+		super.subAttributes = append(super.subAttributes, extensionContainer(extension, required, i))
 		i++
 		return nil
 	})
 
 	return &super
 }
+
+// Returns a virtual complex attribute named by the id of the schema extension, containing its attributes, as the
+// attribute at index among the top level attributes of a resource.
+func extensionContainer(extension *Schema, required bool, index int) *Attribute {
+	return &Attribute{
+		id:            extension.id,
+		name:          extension.id,
+		description:   extension.description,
+		typ:           TypeComplex,
+		subAttributes: extension.attributes,
+		required:      required,
+		mutability:    MutabilityReadWrite,
+		returned:      ReturnedDefault,
+		uniqueness:    UniquenessNone,
+		index:         index,
+		path:          extension.id,
+		annotations: map[string]map[string]interface{}{
+			annotation.StateSummary:        {},
+			annotation.SchemaExtensionRoot: {},
+		},
+	}
+}
+
+// AttributeByPath returns the attribute that the SCIM path refers to in the resource type, i.e. "name.givenName", or
+// "emails[type eq \"work\"].value". Attribute names are matched case insensitively. Attributes of the core schema and
+// the main schema may be prefixed with the main schema URN, while attributes of a schema extension must be prefixed with
+// its URN. A path consisting of a schema extension URN refers to the container of the extension attributes. Value
+// filters are skipped, so that a path ending with a filter refers to the multiValued attribute itself. Paths that do
+// not resolve are spec.ErrInvalidPath naming the first segment that is not found.
+func (t *ResourceType) AttributeByPath(path string) (*Attribute, error) {
+	stripped, err := stripValueFilters(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, includeCore := Schemas().Get(CoreSchemaId)
+	var (
+		attr      = t.SuperAttribute(includeCore)
+		remaining = stripped
+		urn       = ""
+	)
+	_ = t.ForEachExtension(func(extension *Schema, _ bool) error {
+		if hasURNPrefix(stripped, extension.id) && len(extension.id) > len(urn) {
+			urn = extension.id
+		}
+		return nil
+	})
+	if len(urn) > 0 {
+		attr = attr.SubAttributeForName(urn)
+		remaining = strings.TrimPrefix(stripped[len(urn):], ":")
+	} else if hasURNPrefix(stripped, t.schema.id) {
+		remaining = strings.TrimPrefix(stripped[len(t.schema.id):], ":")
+	}
+
+	if len(remaining) == 0 {
+		if len(urn) > 0 {
+			return attr, nil
+		}
+		return nil, fmt.Errorf("%w: path '%s' does not refer to any attribute in resource type '%s'", ErrInvalidPath, path, t.id)
+	}
+
+	for _, name := range strings.Split(remaining, ".") {
+		if attr = attr.SubAttributeForName(name); attr == nil {
+			return nil, fmt.Errorf("%w: no attribute '%s' for path '%s' in resource type '%s'", ErrInvalidPath, name, path, t.id)
+		}
+	}
+	return attr, nil
+}
+
+// Returns true if the path is the URN, or starts with the URN followed by a colon, comparing case insensitively.
+func hasURNPrefix(path string, urn string) bool {
+	if len(path) < len(urn) || !strings.EqualFold(path[:len(urn)], urn) {
+		return false
+	}
+	return len(path) == len(urn) || path[len(urn)] == ':'
+}
+
+// Removes the value filters, the bracketed portions of the path, skipping brackets inside quoted comparison values.
+func stripValueFilters(path string) (string, error) {
+	var (
+		sb     strings.Builder
+		depth  = 0
+		quoted = false
+	)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+			continue
+		case c == '"' && depth > 0:
+			quoted = true
+			continue
+		case c == '[':
+			depth++
+			continue
+		case c == ']':
+			if depth == 0 {
+				return "", fmt.Errorf("%w: unbalanced brackets in path '%s'", ErrInvalidPath, path)
+			}
+			depth--
+			continue
+		}
+		if depth == 0 {
+			sb.WriteByte(c)
+		}
+	}
+	if depth > 0 || quoted {
+		return "", fmt.Errorf("%w: unbalanced brackets in path '%s'", ErrInvalidPath, path)
+	}
+	return sb.String(), nil
+}
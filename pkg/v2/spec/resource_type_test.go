@@ -2,8 +2,11 @@ package spec
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"strings"
 	"testing"
 )
 
@@ -57,5 +60,302 @@ func (s *ResourceTypeTestSuite) TestUnmarshal() {
 	assert.Equal(s.T(), "User", rt.Name())
 	assert.Equal(s.T(), "/v2/Users", rt.Endpoint())
 	assert.NotNil(s.T(), rt.Schema())
-	assert.Len(s.T(), rt.extensions, 1)
+	assert.Len(s.T(), rt.extensions().schemas, 1)
+}
+
+func (s *ResourceTypeTestSuite) TestFilter() {
+	Schemas().Register(&Schema{id: "test"})
+
+	raw := `{"id":"ServiceAccount","name":"ServiceAccount","description":"","endpoint":"/ServiceAccounts","schema":"test","_filter":"userType eq \"service\""}`
+
+	rt := new(ResourceType)
+	require.Nil(s.T(), json.Unmarshal([]byte(raw), rt))
+	assert.Equal(s.T(), `userType eq "service"`, rt.Filter())
+
+	marshaled, err := json.Marshal(rt)
+	assert.Nil(s.T(), err)
+	assert.JSONEq(s.T(), raw, string(marshaled))
+}
+
+func (s *ResourceTypeTestSuite) TestUnmarshalCollidingAttributes() {
+	for _, id := range []string{"collidingMain", "collidingExt"} {
+		schema, err := NewSchemaBuilder(id, id).Attribute(StringAttr("department")).Build()
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), Schemas().Register(schema))
+	}
+
+	err := json.Unmarshal([]byte(`
+{
+  "id": "Colliding",
+  "name": "Colliding",
+  "schema": "collidingMain",
+  "schemaExtensions": [
+    {
+      "schema": "collidingExt",
+      "required": false
+    }
+  ]
+}
+`), new(ResourceType))
+	assert.True(s.T(), errors.Is(err, ErrInvalidValue))
+	assert.Contains(s.T(), err.Error(), "attribute 'department' of schema 'collidingExt' collides")
+}
+
+func (s *ResourceTypeTestSuite) TestAddExtension() {
+	main := &Schema{id: "addExtensionMain"}
+	ext1 := &Schema{id: "addExtensionExt1"}
+	ext2 := &Schema{id: "addExtensionExt2", attributes: []*Attribute{
+		{id: "addExtensionExt2:foo", name: "foo", path: "addExtensionExt2:foo", typ: TypeString},
+	}}
+	for _, schema := range []*Schema{main, ext1, ext2} {
+		assert.Nil(s.T(), Schemas().Register(schema))
+	}
+
+	rt := new(ResourceType)
+	assert.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "AddExtension",
+  "name": "AddExtension",
+  "schema": "addExtensionMain",
+  "schemaExtensions": [
+    {
+      "schema": "addExtensionExt1",
+      "required": true
+    }
+  ]
+}
+`), rt))
+
+	// the super attribute obtained before the amendment is not affected
+	before := rt.SuperAttribute(false)
+
+	assert.True(s.T(), errors.Is(rt.AddExtension(ext2, true), ErrInvalidValue), "cannot be required")
+	assert.True(s.T(), errors.Is(rt.AddExtension(ext1, false), ErrInvalidValue), "already an extension")
+	assert.True(s.T(), errors.Is(rt.AddExtension(main, false), ErrInvalidValue), "main schema")
+	assert.True(s.T(), errors.Is(rt.AddExtension(&Schema{id: "addExtensionExt3"}, false), ErrInvalidValue), "not registered")
+	assert.Equal(s.T(), 1, rt.CountExtensions())
+
+	assert.Nil(s.T(), rt.AddExtension(ext2, false))
+	assert.Equal(s.T(), 2, rt.CountExtensions())
+	assert.NotNil(s.T(), rt.SuperAttribute(false).SubAttributeForName("addExtensionExt2"))
+	assert.Nil(s.T(), before.SubAttributeForName("addExtensionExt2"))
+
+	raw, err := json.Marshal(rt)
+	assert.Nil(s.T(), err)
+	assert.JSONEq(s.T(), `
+{
+  "id": "AddExtension",
+  "name": "AddExtension",
+  "description": "",
+  "endpoint": "",
+  "schema": "addExtensionMain",
+  "schemaExtensions": [
+    {
+      "schema": "addExtensionExt1",
+      "required": true
+    },
+    {
+      "schema": "addExtensionExt2",
+      "required": false
+    }
+  ]
+}
+`, string(raw))
+}
+
+func (s *ResourceTypeTestSuite) TestAttributeByPath() {
+	const (
+		mainId = "urn:example:params:scim:schemas:core:2.0:ByPath"
+		extId  = "urn:example:params:scim:schemas:extension:2.0:ByPath"
+	)
+	main := &Schema{id: mainId, attributes: []*Attribute{
+		{id: mainId + ":name", name: "name", path: "name", typ: TypeComplex, subAttributes: []*Attribute{
+			{id: mainId + ":name.givenName", name: "givenName", path: "name.givenName", typ: TypeString},
+		}},
+		{id: mainId + ":emails", name: "emails", path: "emails", typ: TypeComplex, multiValued: true, subAttributes: []*Attribute{
+			{id: mainId + ":emails.value", name: "value", path: "emails.value", typ: TypeString},
+			{id: mainId + ":emails.type", name: "type", path: "emails.type", typ: TypeString},
+		}},
+	}}
+	ext := &Schema{id: extId, attributes: []*Attribute{
+		{id: extId + ":serial", name: "serial", path: extId + ":serial", typ: TypeString},
+	}}
+	for _, schema := range []*Schema{main, ext} {
+		require.Nil(s.T(), Schemas().Register(schema))
+	}
+	rt := &ResourceType{id: "ByPath", name: "ByPath", schema: main}
+	rt.ext.Store(&resourceTypeExtensions{schemas: []*Schema{ext}, required: map[string]bool{}})
+
+	tests := []struct {
+		name   string
+		path   string
+		expect string
+		err    string
+	}{
+		{name: "top level", path: "emails", expect: "emails"},
+		{name: "sub attribute in any case", path: "NAME.givenname", expect: "name.givenName"},
+		{name: "main schema prefix", path: mainId + ":name.givenName", expect: "name.givenName"},
+		{name: "sub attribute after filter", path: `emails[type eq "work" and value ew "]"].value`, expect: "emails.value"},
+		{name: "path ending with filter", path: `emails[type eq "work"]`, expect: "emails"},
+		{name: "extension attribute", path: strings.ToLower(extId) + ":serial", expect: extId + ":serial"},
+		{name: "extension container", path: extId, expect: extId},
+		{name: "unknown sub attribute", path: "name.nickName", err: "no attribute 'nickName' for path 'name.nickName'"},
+		{name: "unqualified extension attribute", path: "serial", err: "no attribute 'serial'"},
+		{name: "unknown extension attribute", path: extId + ":model", err: "no attribute 'model'"},
+		{name: "unbalanced brackets", path: `emails[type eq "work".value`, err: "unbalanced brackets"},
+	}
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			attr, err := rt.AttributeByPath(test.path)
+			if len(test.err) > 0 {
+				assert.True(t, errors.Is(err, ErrInvalidPath))
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, test.expect, attr.Path())
+		})
+	}
+}
+
+func (s *ResourceTypeTestSuite) TestValidate() {
+	withExtensions := func(rt *ResourceType, extensions ...*Schema) *ResourceType {
+		rt.ext.Store(&resourceTypeExtensions{schemas: extensions, required: map[string]bool{}})
+		return rt
+	}
+
+	tests := []struct {
+		name   string
+		rt     *ResourceType
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "valid resource type",
+			rt:   withExtensions(&ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: "/Users"}, &Schema{id: "ext"}),
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "missing main schema",
+			rt:   &ResourceType{id: "User", endpoint: "/Users"},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "resource type 'User' has no main schema")
+			},
+		},
+		{
+			name: "empty extension id",
+			rt:   withExtensions(&ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: "/Users"}, &Schema{}),
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "schema extension without id")
+			},
+		},
+		{
+			name: "duplicate extensions",
+			rt:   withExtensions(&ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: "/Users"}, &Schema{id: "ext"}, &Schema{id: "EXT"}),
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "schema extension 'EXT' appears more than once in resource type 'User'")
+			},
+		},
+		{
+			name: "extension duplicating main schema",
+			rt:   withExtensions(&ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: "/Users"}, &Schema{id: "main"}),
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+			},
+		},
+		{
+			name: "extension attribute colliding with main schema",
+			rt: withExtensions(&ResourceType{
+				id:       "User",
+				schema:   &Schema{id: "main", attributes: []*Attribute{{name: "department"}}},
+				endpoint: "/Users",
+			}, &Schema{id: "ext", attributes: []*Attribute{{name: "Department"}}}),
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'Department' of schema 'ext' collides with the attribute of the same name in schema 'main'")
+			},
+		},
+		{
+			name: "extensions with attributes of the same name",
+			rt: withExtensions(&ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: "/Users"},
+				&Schema{id: "ext1", attributes: []*Attribute{{name: "department"}}},
+				&Schema{id: "ext2", attributes: []*Attribute{{name: "department"}}}),
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "invalid endpoints",
+			rt:   &ResourceType{id: "User", schema: &Schema{id: "main"}},
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				for _, endpoint := range []string{"/", "Users", "/Users?x=1", "http://example.com/Users", "/Us ers"} {
+					rt := &ResourceType{id: "User", schema: &Schema{id: "main"}, endpoint: endpoint}
+					assert.True(t, errors.Is(rt.Validate(), ErrInvalidValue), endpoint)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			test.expect(t, test.rt.Validate())
+		})
+	}
+}
+
+func (s *ResourceTypeTestSuite) TestReplaceSchema() {
+	main := &Schema{id: "replaceSchemaMain", attributes: []*Attribute{
+		{id: "replaceSchemaMain:foo", name: "foo", path: "foo", typ: TypeString},
+	}}
+	ext := &Schema{id: "replaceSchemaExt"}
+	for _, schema := range []*Schema{main, ext} {
+		assert.Nil(s.T(), Schemas().Register(schema))
+	}
+
+	rt := new(ResourceType)
+	assert.Nil(s.T(), json.Unmarshal([]byte(`
+{
+  "id": "ReplaceSchema",
+  "name": "ReplaceSchema",
+  "schema": "replaceSchemaMain",
+  "schemaExtensions": [
+    {
+      "schema": "replaceSchemaExt",
+      "required": false
+    }
+  ]
+}
+`), rt))
+
+	newMain := &Schema{id: "replaceSchemaMain", attributes: []*Attribute{
+		{id: "replaceSchemaMain:foo", name: "foo", path: "foo", typ: TypeString},
+		{id: "replaceSchemaMain:bar", name: "bar", path: "bar", typ: TypeString},
+	}}
+	newExt := &Schema{id: "replaceSchemaExt", attributes: []*Attribute{
+		{id: "replaceSchemaExt:baz", name: "baz", path: "replaceSchemaExt:baz", typ: TypeString},
+	}}
+	for _, schema := range []*Schema{newMain, newExt} {
+		assert.Nil(s.T(), Schemas().Register(schema))
+	}
+
+	assert.Same(s.T(), newMain, rt.Schema())
+	_ = rt.ForEachExtension(func(extension *Schema, _ bool) error {
+		assert.Same(s.T(), newExt, extension)
+		return nil
+	})
+	assert.NotNil(s.T(), rt.SuperAttribute(false).SubAttributeForName("bar"))
+	assert.NotNil(s.T(), rt.SuperAttribute(false).SubAttributeForName("replaceSchemaExt").SubAttributeForName("baz"))
+
+	// registering a replaced schema again takes over from its replacement
+	newer := &Schema{id: "replaceSchemaMain", attributes: newMain.attributes}
+	assert.Nil(s.T(), Schemas().Register(newer))
+	assert.Same(s.T(), newer, rt.Schema())
+	assert.Nil(s.T(), Schemas().Register(newMain))
+	assert.Same(s.T(), newMain, rt.Schema())
+	assert.Same(s.T(), newMain, newer.latest())
 }
@@ -1,5 +1,7 @@
 package spec
 
+import "fmt"
+
 // SCIM returned definition
 type Returned int
 
@@ -11,18 +13,21 @@ const (
 	ReturnedNever
 )
 
-func mustParseReturned(value string) Returned {
+// ParseReturned parses the returned characteristic of an attribute from its string form defined in RFC7643, which is
+// case sensitive. An empty value stands for the default returned, as it does in the JSON definition of a schema. Any
+// other value is rejected with ErrInvalidValue.
+func ParseReturned(value string) (Returned, error) {
 	switch value {
 	case "default", "":
-		return ReturnedDefault
+		return ReturnedDefault, nil
 	case "always":
-		return ReturnedAlways
+		return ReturnedAlways, nil
 	case "never":
-		return ReturnedNever
+		return ReturnedNever, nil
 	case "request":
-		return ReturnedRequest
+		return ReturnedRequest, nil
 	default:
-		panic("invalid returned value")
+		return ReturnedDefault, fmt.Errorf("%w: '%s' is not a valid returned", ErrInvalidValue, value)
 	}
 }
 
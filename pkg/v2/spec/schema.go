@@ -2,7 +2,11 @@ package spec
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Reserved Id for core schema
@@ -23,6 +27,8 @@ type Schema struct {
 	name        string
 	description string
 	attributes  []*Attribute
+	annotations map[string]map[string]interface{}
+	successor   atomic.Value // *Schema, the schema itself once registered, or the schema that replaced it
 }
 
 // ID returns the id of the schema.
@@ -50,6 +56,75 @@ func (s *Schema) ForEachAttribute(callback func(attr *Attribute) error) error {
 	return nil
 }
 
+// Returns the schema that replaced this schema in the registry, following successive replacements, or this schema if
+// it was never replaced. Resource types resolve their schemas through it, so that they pick up the replacements made at
+// runtime.
+func (s *Schema) latest() *Schema {
+	for {
+		next, ok := s.successor.Load().(*Schema)
+		if !ok || next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// MergeExtensions returns a combined view of this schema and the schema extensions, which offers a single surface to
+// look up attributes, i.e. for validation. The view has the id, name, description and annotations of this schema, its
+// attributes, followed by a complex attribute for each schema extension, named by the schema extension URN and
+// containing its attributes, as in resources. Hence, an attribute of a schema extension that has the same name as an
+// attribute of this schema does not collide with it, as it is qualified by the URN, i.e.
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager". Schema extensions that are nil, have the id of
+// this schema, or are repeated, are skipped.
+//
+// The attributes are shared with the original schemas, not copied. The view is not meant to be registered.
+func (s *Schema) MergeExtensions(extensions ...*Schema) *Schema {
+	merged := Schema{
+		id:          s.id,
+		name:        s.name,
+		description: s.description,
+		attributes:  append([]*Attribute{}, s.attributes...),
+		annotations: s.annotations,
+	}
+
+	seen := map[string]struct{}{strings.ToLower(s.id): {}}
+	for _, extension := range extensions {
+		if extension == nil {
+			continue
+		}
+		if _, ok := seen[strings.ToLower(extension.id)]; ok {
+			continue
+		}
+		seen[strings.ToLower(extension.id)] = struct{}{}
+		merged.attributes = append(merged.attributes, extensionContainer(extension, false, len(merged.attributes)))
+	}
+
+	return &merged
+}
+
+// Annotation returns the annotation parameters by the given name (case sensitive) and a boolean indicating whether
+// the schema has this annotation. Like those of attributes, schema annotations are defined in the "_annotations" field.
+func (s *Schema) Annotation(name string) (params map[string]interface{}, ok bool) {
+	params, ok = s.annotations[name]
+	return
+}
+
+// AnnotationParams is like Annotation, but returns the parameters typed for access to individual parameters.
+func (s *Schema) AnnotationParams(name string) (params annotation.Params, ok bool) {
+	params, ok = s.annotations[name]
+	return
+}
+
+// IsInternal returns true if the schema is not advertised to clients, either because it is the core schema, or
+// because it is annotated with @Internal.
+func (s *Schema) IsInternal() bool {
+	if s.id == CoreSchemaId {
+		return true
+	}
+	_, ok := s.Annotation(annotation.Internal)
+	return ok
+}
+
 // ResourceTypeName returns the resource type of the Schema resource. This value is formally defined and hence fixed.
 func (s *Schema) ResourceTypeName() string {
 	return "Schema"
@@ -67,6 +142,7 @@ func (s *Schema) MarshalJSON() ([]byte, error) {
 		Name:        s.name,
 		Description: s.description,
 		Attributes:  s.attributes,
+		Annotations: s.annotations,
 	})
 }
 
@@ -76,18 +152,124 @@ func (s *Schema) UnmarshalJSON(raw []byte) error {
 		return err
 	}
 
+	if err := validateAttributeNames(adapter.ID, adapter.Attributes); err != nil {
+		return err
+	}
+	if err := validateSchemaAnnotations(adapter.ID, adapter.Annotations, adapter.Attributes); err != nil {
+		return err
+	}
+
+	_, all := adapter.Annotations[annotation.StandardSubAttributes]
+	for _, attr := range adapter.Attributes {
+		if err := attr.addStandardSubAttributes(adapter.ID, all); err != nil {
+			return err
+		}
+	}
+
 	s.id = adapter.ID
 	s.name = adapter.Name
 	s.description = adapter.Description
 	s.attributes = adapter.Attributes
+	s.annotations = adapter.Annotations
+	return nil
+}
+
+// Adds the standard sub attributes missing from a multiValued complex attribute, if the attribute is annotated with
+// @StandardSubAttributes, or all is true because the schema is. The added sub attributes are indexed after the
+// declared ones.
+func (attr *Attribute) addStandardSubAttributes(schemaId string, all bool) error {
+	if attr.typ != TypeComplex || !attr.multiValued {
+		return nil
+	}
+	if _, ok := attr.annotations[annotation.StandardSubAttributes]; !ok && !all {
+		return nil
+	}
+
+	names := make([]string, 0, len(attr.subAttributes))
+	index := 0
+	for _, subAttr := range attr.subAttributes {
+		names = append(names, subAttr.name)
+		if subAttr.index >= index {
+			index = subAttr.index + 1
+		}
+	}
+
+	for i, sb := range missingStandardSubAttributes(names) {
+		subAttr, err := sb.build(schemaId, attr.path, index+i)
+		if err != nil {
+			return err
+		}
+		attr.subAttributes = append(attr.subAttributes, subAttr)
+	}
+	return nil
+}
+
+// Names that cannot be used as attribute names, because they are keywords of the SCIM filter and path syntax, and hence
+// such attributes could not be referenced unambiguously in filters and paths.
+var reservedAttributeNames = map[string]struct{}{
+	"and": {}, "or": {}, "not": {},
+	"eq": {}, "ne": {}, "sw": {}, "ew": {}, "co": {}, "pr": {}, "gt": {}, "ge": {}, "lt": {}, "le": {},
+	"true": {}, "false": {}, "null": {},
+}
+
+// Checks that attributes under the same parent have distinct names, compared case insensitively as are attribute names
+// in SCIM, that the names conform to the ATTRNAME rule of RFC7643 section 2.1, and that none of them uses a reserved
+// name. The check recurses into sub attributes. The parent is named in the error, which is the schema id for top level
+// attributes, and the id of the parent attribute, which includes the schema id and the path, for sub attributes.
+func validateAttributeNames(parent string, attributes []*Attribute) error {
+	seen := map[string]struct{}{}
+	for _, attr := range attributes {
+		if !isAttributeName(attr.name, attr.typ) {
+			return fmt.Errorf("%w: attribute '%s' under '%s' is not a valid attribute name", ErrInvalidValue, attr.name, parent)
+		}
+		name := strings.ToLower(attr.name)
+		if _, ok := reservedAttributeNames[name]; ok {
+			return fmt.Errorf("%w: attribute '%s' under '%s' uses a reserved name", ErrInvalidValue, attr.name, parent)
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("%w: duplicate attribute '%s' under '%s'", ErrInvalidValue, attr.name, parent)
+		}
+		seen[name] = struct{}{}
+
+		if err := validateAttributeNames(attr.id, attr.subAttributes); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Returns true if the name conforms to the ATTRNAME rule of RFC7643 section 2.1:
+//
+//	ATTRNAME = ALPHA *(nameChar)
+//	nameChar = "-" / "_" / DIGIT / ALPHA
+//
+// In addition, reference attributes may be prefixed by "$", as the "$ref" sub attribute defined in RFC7643 section 2.4.
+func isAttributeName(name string, typ Type) bool {
+	if typ == TypeReference && strings.HasPrefix(name, "$") {
+		name = name[1:]
+	}
+	if len(name) == 0 || !isAlpha(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if !isAlpha(c) && !('0' <= c && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
 type schemaJsonAdapter struct {
-	ID          string       `json:"id"`
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Attributes  []*Attribute `json:"attributes"`
+	ID          string                            `json:"id"`
+	Name        string                            `json:"name"`
+	Description string                            `json:"description"`
+	Attributes  []*Attribute                      `json:"attributes"`
+	Annotations map[string]map[string]interface{} `json:"_annotations,omitempty"`
 }
 
 var (
@@ -95,25 +277,73 @@ var (
 	schemaRegistryOnce sync.Once
 )
 
+// The schema registry is copy-on-write: registrations are serialized and publish a new snapshot of the registry,
+// while readers load the current snapshot without locking. Hence, schemas may be registered at runtime while requests
+// are being served, and a reader iterating the registry is never affected by a concurrent registration.
 type schemaRegistry struct {
-	db map[string]*Schema
+	mu   sync.Mutex   // serializes registrations
+	db   atomic.Value // map[string]*Schema, never modified once published
+	subs []func(schema *Schema)
 }
 
-// Register relates the schema with its id in the registry. This method does not check existence of the id and may
-// overwrite existing schemas if abused.
-func (r *schemaRegistry) Register(schema *Schema) {
-	r.db[schema.id] = schema
+// Register relates the schema with its id in the registry. Registering a schema with an existing id replaces the
+// registered schema, which is only allowed if the new schema retains every attribute of the registered one with the
+// same type and multiplicity, because existing resources may have been persisted with these attributes. Registering a
+// schema whose attribute ids conflict with those of another registered schema is rejected as well. Errors are
+// spec.ErrInvalidValue. The resource types holding the replaced schema, as their main schema or as an extension, use
+// the new schema from then on. Contradictory attribute characteristics, as reported by LintSchema, are treated
+// according to the policy set by SetLintPolicy. Upon successful registration, the subscribers are notified.
+func (r *schemaRegistry) Register(schema *Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.snapshot()
+	if err := r.checkRegistration(current, schema); err != nil {
+		return err
+	}
+	if err := applyAttributeOverrides(schema); err != nil {
+		return err
+	}
+	if err := applyLintPolicy(schema); err != nil {
+		return err
+	}
+
+	next := make(map[string]*Schema, len(current)+1)
+	for id, each := range current {
+		next[id] = each
+	}
+	next[schema.id] = schema
+	r.db.Store(next)
+	schema.successor.Store(schema)
+	if replaced, ok := current[schema.id]; ok {
+		replaced.successor.Store(schema)
+	}
+
+	for _, sub := range r.subs {
+		sub(schema)
+	}
+	return nil
+}
+
+// Subscribe registers a callback to be invoked after each successful registration, with the registered schema. This
+// hook allows the derived states, i.e. the cached /Schemas response, to reflect the registrations made at runtime.
+// The callback is invoked synchronously by Register and must not register schemas itself.
+func (r *schemaRegistry) Subscribe(callback func(schema *Schema)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, callback)
 }
 
 // Get returns the schema that is related to a schemaId, or nil, along with a boolean indicating if the schema exists.
 func (r *schemaRegistry) Get(schemaId string) (schema *Schema, ok bool) {
-	schema, ok = r.db[schemaId]
+	schema, ok = r.snapshot()[schemaId]
 	return
 }
 
-// ForEachSchema invokes the callback function on each registered schema.
+// ForEachSchema invokes the callback function on each registered schema. The iteration is performed on the snapshot
+// of the registry at the time of invocation.
 func (r *schemaRegistry) ForEachSchema(callback func(schema *Schema) error) error {
-	for _, schema := range r.db {
+	for _, schema := range r.snapshot() {
 		if err := callback(schema); err != nil {
 			return err
 		}
@@ -129,10 +359,52 @@ func (r *schemaRegistry) mustGet(schemaId string) *Schema {
 	return schema
 }
 
+func (r *schemaRegistry) snapshot() map[string]*Schema {
+	db, _ := r.db.Load().(map[string]*Schema)
+	return db
+}
+
+// Checks the schema to be registered against the registered schemas.
+func (r *schemaRegistry) checkRegistration(registered map[string]*Schema, schema *Schema) error {
+	attributes := collectAttributes(schema.attributes, map[string]*Attribute{})
+
+	for id, other := range registered {
+		if id == schema.id {
+			for attrId, old := range collectAttributes(other.attributes, map[string]*Attribute{}) {
+				attr, ok := attributes[attrId]
+				if !ok || attr.typ != old.typ || attr.multiValued != old.multiValued {
+					return fmt.Errorf("%w: schema '%s' cannot drop or change registered attribute '%s'",
+						ErrInvalidValue, schema.id, old.id)
+				}
+			}
+			continue
+		}
+
+		for attrId := range collectAttributes(other.attributes, map[string]*Attribute{}) {
+			if attr, ok := attributes[attrId]; ok {
+				return fmt.Errorf("%w: attribute '%s' of schema '%s' conflicts with registered schema '%s'",
+					ErrInvalidValue, attr.id, schema.id, other.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Collects the attributes and their sub attributes into the map, keyed by their lower cased ids.
+func collectAttributes(attributes []*Attribute, collector map[string]*Attribute) map[string]*Attribute {
+	for _, attr := range attributes {
+		collector[strings.ToLower(attr.id)] = attr
+		collectAttributes(attr.subAttributes, collector)
+	}
+	return collector
+}
+
 // Schemas return the schema registry that holds all registered schemas. Use Get and Register to operate the registry.
 func Schemas() *schemaRegistry {
 	schemaRegistryOnce.Do(func() {
-		schemaReg = &schemaRegistry{db: map[string]*Schema{}}
+		schemaReg = &schemaRegistry{}
+		schemaReg.db.Store(map[string]*Schema{})
 	})
 	return schemaReg
 }
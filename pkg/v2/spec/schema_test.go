@@ -2,8 +2,13 @@ package spec
 
 import (
 	"encoding/json"
+	"errors"
+	"github.com/imulab/go-scim/pkg/v2/annotation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"io/fs"
+	"path/filepath"
 	"testing"
 )
 
@@ -22,7 +27,7 @@ func (s *SchemaTestSuite) TestMarshal() {
 		name:        "User",
 		description: "User schema",
 		attributes: []*Attribute{
-			{name: "foobar", typ: TypeString},
+			{id: "urn:ietf:params:scim:schemas:core:2.0:User:foobar", name: "foobar", typ: TypeString, path: "foobar"},
 		},
 	}
 
@@ -36,6 +41,7 @@ func (s *SchemaTestSuite) TestMarshal() {
   "description": "User schema",
   "attributes": [
     {
+      "id": "urn:ietf:params:scim:schemas:core:2.0:User:foobar",
       "name": "foobar",
       "type": "string",
       "multiValued": false,
@@ -43,7 +49,9 @@ func (s *SchemaTestSuite) TestMarshal() {
       "caseExact": false,
       "mutability": "readWrite",
       "returned": "default",
-      "uniqueness": "none"
+      "uniqueness": "none",
+      "_index": 0,
+      "_path": "foobar"
     }
   ]
 }
@@ -51,6 +59,50 @@ func (s *SchemaTestSuite) TestMarshal() {
 	assert.JSONEq(s.T(), expect, string(raw))
 }
 
+func (s *SchemaTestSuite) TestMarshalRoundTrip() {
+	for _, path := range []string{
+		standardUserSchema,
+		standardGroupSchema,
+		standardEnterpriseUserSchema,
+	} {
+		s.T().Run(filepath.Base(path), func(t *testing.T) {
+			raw, err := fs.ReadFile(standardFS, path)
+			require.Nil(t, err)
+
+			schema := new(Schema)
+			require.Nil(t, json.Unmarshal(raw, schema))
+			first, err := json.Marshal(schema)
+			require.Nil(t, err)
+
+			reloaded := new(Schema)
+			require.Nil(t, json.Unmarshal(first, reloaded))
+			second, err := json.Marshal(reloaded)
+			require.Nil(t, err)
+
+			assert.Equal(t, string(first), string(second))
+			// annotations, and the order of sub attributes, are kept
+			assert.Equal(t, schema, reloaded)
+		})
+	}
+
+	s.T().Run("builder", func(t *testing.T) {
+		schema, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Test", "Test").
+			Annotation(annotation.StandardSubAttributes, nil).
+			Attribute(StringAttr("nickName").Annotation(annotation.Redact, map[string]interface{}{"keepLast": 2})).
+			Attribute(ComplexAttr("tags", StringAttr("label")).MultiValued()).
+			Build()
+		require.Nil(t, err)
+
+		first, err := json.Marshal(schema)
+		require.Nil(t, err)
+		reloaded := new(Schema)
+		require.Nil(t, json.Unmarshal(first, reloaded))
+		second, err := json.Marshal(reloaded)
+		require.Nil(t, err)
+		assert.Equal(t, string(first), string(second))
+	})
+}
+
 func (s *SchemaTestSuite) TestUnmarshal() {
 	raw := `
 {
@@ -80,3 +132,715 @@ func (s *SchemaTestSuite) TestUnmarshal() {
 	assert.Equal(s.T(), "User", schema.Name())
 	assert.Len(s.T(), schema.attributes, 1)
 }
+
+func (s *SchemaTestSuite) TestUnmarshalInvalidAttributeNames() {
+	tests := []struct {
+		name   string
+		raw    string
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "duplicate top level attributes",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:userName", "name": "userName", "type": "string"},
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:username", "name": "username", "type": "string"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "duplicate attribute 'username' under 'urn:ietf:params:scim:schemas:test:2.0:Test'")
+			},
+		},
+		{
+			name: "duplicate sub attributes",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:name",
+      "name": "name",
+      "type": "complex",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:name.givenName", "name": "givenName", "type": "string"},
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:name.familyName", "name": "familyName", "type": "string"},
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:name.GivenName", "name": "GivenName", "type": "string"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "duplicate attribute 'GivenName' under 'urn:ietf:params:scim:schemas:test:2.0:Test:name'")
+			},
+		},
+		{
+			name: "same name under different parents",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:value", "name": "value", "type": "string"},
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:emails",
+      "name": "emails",
+      "type": "complex",
+      "multiValued": true,
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:emails.value", "name": "value", "type": "string"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "reserved name",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:emails",
+      "name": "emails",
+      "type": "complex",
+      "multiValued": true,
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:emails.pr", "name": "PR", "type": "string"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'PR' under 'urn:ietf:params:scim:schemas:test:2.0:Test:emails' uses a reserved name")
+			},
+		},
+		{
+			name: "name not conforming to ATTRNAME",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:cost center", "name": "cost center", "type": "string"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'cost center' under 'urn:ietf:params:scim:schemas:test:2.0:Test' is not a valid attribute name")
+			},
+		},
+		{
+			name: "name starting with digit",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:2fa", "name": "2fa", "type": "boolean"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute '2fa' under 'urn:ietf:params:scim:schemas:test:2.0:Test' is not a valid attribute name")
+			},
+		},
+		{
+			name: "dollar prefix on non reference sub attribute",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:manager",
+      "name": "manager",
+      "type": "complex",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:manager.$value", "name": "$value", "type": "string"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute '$value' under 'urn:ietf:params:scim:schemas:test:2.0:Test:manager' is not a valid attribute name")
+			},
+		},
+		{
+			name: "names conforming to ATTRNAME",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:cost-center_2", "name": "cost-center_2", "type": "string"},
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:manager",
+      "name": "manager",
+      "type": "complex",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:manager.$ref", "name": "$ref", "type": "reference"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+			},
+		},
+		{
+			name: "illegal type",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:age", "name": "age", "type": "number"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:age' has illegal type 'number'")
+			},
+		},
+		{
+			name: "illegal sub attribute characteristics",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:name",
+      "name": "name",
+      "type": "complex",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:name.givenName", "name": "givenName", "mutability": "readonly"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:name.givenName' has illegal mutability 'readonly'")
+			},
+		},
+		{
+			name: "illegal mutability",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:nickName", "name": "nickName", "type": "string", "mutability": "read-only"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "attribute 'urn:ietf:params:scim:schemas:test:2.0:Test:nickName' has illegal mutability 'read-only'")
+			},
+		},
+		{
+			name: "illegal returned",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:secret", "name": "secret", "returned": "sometimes"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has illegal returned 'sometimes'")
+			},
+		},
+		{
+			name: "illegal uniqueness",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:code", "name": "code", "uniqueness": "client"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "has illegal uniqueness 'client'")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			test.expect(t, json.Unmarshal([]byte(test.raw), new(Schema)))
+		})
+	}
+}
+
+func (s *SchemaTestSuite) TestRegister() {
+	parse := func(t *testing.T, raw string) *Schema {
+		schema := new(Schema)
+		assert.Nil(t, json.Unmarshal([]byte(raw), schema))
+		return schema
+	}
+
+	var notified []string
+	Schemas().Subscribe(func(schema *Schema) {
+		notified = append(notified, schema.ID())
+	})
+
+	assert.Nil(s.T(), Schemas().Register(parse(s.T(), `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Tenant",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:badge", "name": "badge", "type": "string"}
+  ]
+}
+`)))
+
+	tests := []struct {
+		name   string
+		raw    string
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "attribute id conflicting with another schema",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Other",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:Badge", "name": "Badge", "type": "string"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "conflicts with registered schema 'urn:ietf:params:scim:schemas:test:2.0:Tenant'")
+			},
+		},
+		{
+			name: "replacement dropping a registered attribute",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Tenant",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:floor", "name": "floor", "type": "integer"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				assert.Contains(t, err.Error(), "cannot drop or change registered attribute")
+			},
+		},
+		{
+			name: "replacement changing the type of a registered attribute",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Tenant",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:badge", "name": "badge", "type": "string", "multiValued": true}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+			},
+		},
+		{
+			name: "replacement adding an attribute",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Tenant",
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:badge", "name": "badge", "type": "string"},
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Tenant:floor", "name": "floor", "type": "integer"}
+  ]
+}
+`,
+			expect: func(t *testing.T, err error) {
+				assert.Nil(t, err)
+				schema, ok := Schemas().Get("urn:ietf:params:scim:schemas:test:2.0:Tenant")
+				assert.True(t, ok)
+				assert.Len(t, schema.attributes, 2)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			test.expect(t, Schemas().Register(parse(t, test.raw)))
+		})
+	}
+
+	// only successful registrations are notified
+	assert.Equal(s.T(), []string{
+		"urn:ietf:params:scim:schemas:test:2.0:Tenant",
+		"urn:ietf:params:scim:schemas:test:2.0:Tenant",
+	}, notified)
+}
+
+func (s *SchemaTestSuite) TestRegisterWhileIterating() {
+	// registration while iterating does not affect the iteration, which works on a snapshot of the registry
+	n := 0
+	assert.Nil(s.T(), Schemas().ForEachSchema(func(schema *Schema) error {
+		n++
+		return Schemas().Register(&Schema{id: "urn:ietf:params:scim:schemas:test:2.0:Iterating" + schema.id})
+	}))
+
+	m := 0
+	_ = Schemas().ForEachSchema(func(schema *Schema) error {
+		m++
+		return nil
+	})
+	assert.Equal(s.T(), 2*n, m)
+}
+
+func (s *SchemaTestSuite) TestUnmarshalStandardSubAttributes() {
+	names := func(attr *Attribute) []string {
+		var result []string
+		_ = attr.ForEachSubAttribute(func(subAttribute *Attribute) error {
+			result = append(result, subAttribute.Name())
+			return nil
+		})
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		raw    string
+		expect func(t *testing.T, schema *Schema)
+	}{
+		{
+			name: "annotated attribute",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "attributes": [
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:badges",
+      "name": "badges",
+      "type": "complex",
+      "multiValued": true,
+      "_path": "badges",
+      "_annotations": {"@StandardSubAttributes": {}},
+      "subAttributes": [
+        {
+          "id": "urn:ietf:params:scim:schemas:test:2.0:Test:badges.value",
+          "name": "value",
+          "type": "integer",
+          "_index": 0,
+          "_path": "badges.value"
+        },
+        {
+          "id": "urn:ietf:params:scim:schemas:test:2.0:Test:badges.issuer",
+          "name": "issuer",
+          "type": "string",
+          "_index": 1,
+          "_path": "badges.issuer"
+        }
+      ]
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:tags",
+      "name": "tags",
+      "type": "complex",
+      "multiValued": true,
+      "_path": "tags",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:tags.label", "name": "label", "type": "string", "_path": "tags.label"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, schema *Schema) {
+				badges, tags := schema.attributes[0], schema.attributes[1]
+				assert.Equal(t, []string{"value", "issuer", "type", "primary", "display", "$ref"}, names(badges))
+				assert.Equal(t, []string{"label"}, names(tags))
+
+				// declared sub attribute takes precedence
+				assert.Equal(t, TypeInteger, badges.SubAttributeForName("value").Type())
+
+				primary := badges.SubAttributeForName("primary")
+				assert.Equal(t, TypeBoolean, primary.Type())
+				assert.Equal(t, "urn:ietf:params:scim:schemas:test:2.0:Test:badges.primary", primary.ID())
+				assert.Equal(t, "badges.primary", primary.Path())
+				assert.Equal(t, 3, primary.index)
+				_, ok := primary.Annotation(annotation.Primary)
+				assert.True(t, ok)
+			},
+		},
+		{
+			name: "annotated schema",
+			raw: `
+{
+  "id": "urn:ietf:params:scim:schemas:test:2.0:Test",
+  "_annotations": {"@StandardSubAttributes": {}},
+  "attributes": [
+    {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:nickName", "name": "nickName", "type": "string", "_path": "nickName"},
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:tags",
+      "name": "tags",
+      "type": "complex",
+      "multiValued": true,
+      "_path": "tags",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:tags.label", "name": "label", "type": "string", "_path": "tags.label"}
+      ]
+    },
+    {
+      "id": "urn:ietf:params:scim:schemas:test:2.0:Test:name",
+      "name": "name",
+      "type": "complex",
+      "_path": "name",
+      "subAttributes": [
+        {"id": "urn:ietf:params:scim:schemas:test:2.0:Test:name.givenName", "name": "givenName", "type": "string", "_path": "name.givenName"}
+      ]
+    }
+  ]
+}
+`,
+			expect: func(t *testing.T, schema *Schema) {
+				assert.Equal(t, []string{"label", "type", "primary", "display", "value", "$ref"}, names(schema.attributes[1]))
+				assert.Equal(t, []string{"givenName"}, names(schema.attributes[2]))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			schema := new(Schema)
+			assert.Nil(t, json.Unmarshal([]byte(test.raw), schema))
+			test.expect(t, schema)
+		})
+	}
+}
+
+func (s *SchemaTestSuite) TestLint() {
+	tests := []struct {
+		name     string
+		builder  *SchemaBuilder
+		findings []string
+	}{
+		{
+			name: "consistent attributes",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(StringAttr("serial").Required().Mutability(MutabilityReadOnly).Annotation(annotation.UUID, nil)).
+				Attribute(StringAttr("code").CaseExact().Uniqueness(UniquenessServer)).
+				Attribute(ComplexAttr("emails", StringAttr("value")).MultiValued()),
+		},
+		{
+			name: "required and readOnly",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(StringAttr("serial").Required().Mutability(MutabilityReadOnly)),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:serial' is required but readOnly",
+			},
+		},
+		{
+			name: "caseExact on non string",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(IntegerAttr("rank").CaseExact()).
+				Attribute(ComplexAttr("name", StringAttr("givenName").CaseExact(), BooleanAttr("verified").CaseExact())),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:rank' is caseExact, but only string, reference and binary values have case, not integer values",
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:name.verified' is caseExact",
+			},
+		},
+		{
+			name: "primary not boolean",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(ComplexAttr("phones", StringAttr("value"), StringAttr("primary")).MultiValued()).
+				Attribute(ComplexAttr("emails", StringAttr("value"), IntegerAttr("preferred").Annotation(annotation.Primary, nil)).MultiValued()),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:phones.primary' is a primary sub attribute, but is string instead of boolean",
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:emails.preferred' is a primary sub attribute, but is integer instead of boolean",
+			},
+		},
+		{
+			name: "uniqueness on multiValued complex",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(ComplexAttr("emails", StringAttr("value")).MultiValued().Uniqueness(UniquenessServer)),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:emails' is a multiValued complex attribute with uniqueness 'server'",
+			},
+		},
+		{
+			name: "string constraints on non string",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(StringAttr("code").Annotation(annotation.Constraints, map[string]interface{}{"maxLength": 8})).
+				Attribute(IntegerAttr("rank").Annotation(annotation.Constraints, map[string]interface{}{"pattern": "^[0-9]+$"})),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:rank' has the @Constraints parameter 'pattern', which only applies to string values, not integer values",
+			},
+		},
+		{
+			name: "numeric constraints on non numeric",
+			builder: NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Lint", "Lint").
+				Attribute(IntegerAttr("seatCount").Annotation(annotation.Constraints, map[string]interface{}{"minimum": 1})).
+				Attribute(StringAttr("code").Annotation(annotation.Constraints, map[string]interface{}{"maximum": 10})),
+			findings: []string{
+				"attribute 'urn:ietf:params:scim:schemas:test:2.0:Lint:code' has the @Constraints parameter 'maximum', which only applies to integer and decimal values, not string values",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			schema, err := test.builder.Build()
+			require.Nil(t, err)
+
+			findings := LintSchema(schema)
+			if assert.Len(t, findings, len(test.findings)) {
+				for i, finding := range findings {
+					assert.True(t, errors.Is(finding, ErrInvalidValue))
+					assert.Contains(t, finding.Error(), test.findings[i])
+				}
+			}
+		})
+	}
+
+	s.T().Run("registration", func(t *testing.T) {
+		defer SetLintPolicy(LintPolicyWarn)
+
+		schema, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Linted", "Linted").
+			Attribute(IntegerAttr("rank").CaseExact()).
+			Build()
+		require.Nil(t, err)
+
+		SetLintPolicy(LintPolicyReject)
+		err = Schemas().Register(schema)
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		_, ok := Schemas().Get("urn:ietf:params:scim:schemas:test:2.0:Linted")
+		assert.False(t, ok)
+
+		var warnings []error
+		SetWarningHandler(func(warning error) {
+			warnings = append(warnings, warning)
+		})
+		defer SetWarningHandler(nil)
+		SetLintPolicy(LintPolicyWarn)
+		assert.Nil(t, Schemas().Register(schema))
+		if assert.Len(t, warnings, 1) {
+			assert.True(t, errors.Is(warnings[0], ErrInvalidValue))
+			assert.Contains(t, warnings[0].Error(), "schema 'urn:ietf:params:scim:schemas:test:2.0:Linted'")
+		}
+	})
+}
+
+func (s *SchemaTestSuite) TestOverrideAttribute() {
+	const schemaId = "urn:ietf:params:scim:schemas:test:2.0:Override"
+
+	OverrideAttribute(schemaId+":groups", ReturnedRequest, DescriptionOverride("Only upon request."))
+	OverrideAttribute(schemaId+":emails.display", ReturnedRequest)
+
+	schema, err := NewSchemaBuilder(schemaId, "Override").
+		Attribute(StringAttr("groups").MultiValued().Mutability(MutabilityReadOnly)).
+		Attribute(ComplexAttr("emails", StringAttr("value"), StringAttr("display")).MultiValued()).
+		Build()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), Schemas().Register(schema))
+
+	registered, ok := Schemas().Get(schemaId)
+	require.True(s.T(), ok)
+	groups := registered.attributes[0]
+	assert.Equal(s.T(), ReturnedRequest, groups.Returned())
+	assert.Equal(s.T(), "Only upon request.", groups.Description())
+	assert.Equal(s.T(), ReturnedRequest, registered.attributes[1].SubAttributeForName("display").Returned())
+	assert.Equal(s.T(), ReturnedDefault, registered.attributes[1].SubAttributeForName("value").Returned())
+
+	raw, err := json.Marshal(registered)
+	require.Nil(s.T(), err)
+	assert.Contains(s.T(), string(raw), `"returned":"request"`)
+
+	s.T().Run("unsafe", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			returned Returned
+			override Returned
+		}{
+			{name: "always attribute", returned: ReturnedAlways, override: ReturnedRequest},
+			{name: "never attribute", returned: ReturnedNever, override: ReturnedDefault},
+			{name: "override to never", returned: ReturnedDefault, override: ReturnedNever},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				const unsafeId = "urn:ietf:params:scim:schemas:test:2.0:UnsafeOverride"
+				OverrideAttribute(unsafeId+":secret", test.override)
+
+				schema, err := NewSchemaBuilder(unsafeId, "UnsafeOverride").
+					Attribute(StringAttr("secret").Returned(test.returned)).
+					Build()
+				require.Nil(t, err)
+
+				err = Schemas().Register(schema)
+				assert.True(t, errors.Is(err, ErrInvalidValue))
+				_, ok := Schemas().Get(unsafeId)
+				assert.False(t, ok)
+			})
+		}
+	})
+}
+
+func (s *SchemaTestSuite) TestMergeExtensions() {
+	const enterpriseId = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+	userResourceType, err := RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	user := userResourceType.Schema()
+	enterprise, ok := Schemas().Get(enterpriseId)
+	require.True(s.T(), ok)
+
+	s.T().Run("core User with enterprise extension", func(t *testing.T) {
+		merged := user.MergeExtensions(enterprise, enterprise, user, nil)
+		assert.Equal(t, user.ID(), merged.ID())
+		assert.Equal(t, user.Name(), merged.Name())
+
+		attrs := map[string]*Attribute{}
+		_ = merged.ForEachAttribute(func(attr *Attribute) error {
+			attrs[attr.Name()] = attr
+			return nil
+		})
+		assert.Len(t, attrs, len(user.attributes)+1)
+		assert.NotNil(t, attrs["userName"])
+
+		container := attrs[enterpriseId]
+		require.NotNil(t, container)
+		assert.Equal(t, TypeComplex, container.Type())
+		assert.NotNil(t, container.SubAttributeForName("employeeNumber"))
+		assert.NotNil(t, container.SubAttributeForName("manager").SubAttributeForName("value"))
+
+		// the original schema is untouched
+		assert.Len(t, user.attributes, len(attrs)-1)
+	})
+
+	s.T().Run("name collision qualified by URN", func(t *testing.T) {
+		base, err := NewSchemaBuilder("urn:ietf:params:scim:schemas:test:2.0:Base", "Base").
+			Attribute(StringAttr("manager")).
+			Build()
+		require.Nil(t, err)
+
+		merged := base.MergeExtensions(enterprise)
+		var names []string
+		_ = merged.ForEachAttribute(func(attr *Attribute) error {
+			names = append(names, attr.Name())
+			return nil
+		})
+		assert.Equal(t, []string{"manager", enterpriseId}, names)
+		assert.Equal(t, TypeString, merged.attributes[0].Type())
+		assert.Equal(t, TypeComplex, merged.attributes[1].SubAttributeForName("manager").Type())
+	})
+}
@@ -0,0 +1,68 @@
+package spec
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// The schemas and resource types defined by RFC 7643, with the annotations of this project. They are compiled into the
+// binary, so that every consumer registers the same definitions.
+//
+//go:embed standard
+var standardFS embed.FS
+
+const (
+	standardCoreSchema           = "standard/schemas/core_schema.json"
+	standardUserSchema           = "standard/schemas/user_schema.json"
+	standardEnterpriseUserSchema = "standard/schemas/user_enterprise_extension_schema.json"
+	standardGroupSchema          = "standard/schemas/group_schema.json"
+	standardUserResourceType     = "standard/resource_types/user_resource_type.json"
+	standardGroupResourceType    = "standard/resource_types/group_resource_type.json"
+)
+
+// RegisterStandardUserResourceType registers the core schema, the User schema and the enterprise User extension schema,
+// as defined by RFC 7643, and returns the User resource type served at "/Users", with the enterprise User extension as
+// an optional schema extension. Custom schema extensions can then be overlaid onto the returned resource type with
+// AddExtension, after registering them.
+//
+// Each call registers the schemas again, which replaces the schemas registered by the previous call with identical
+// ones, and returns a new resource type.
+func RegisterStandardUserResourceType() (*ResourceType, error) {
+	return registerStandardResourceType(standardUserResourceType, standardCoreSchema, standardUserSchema, standardEnterpriseUserSchema)
+}
+
+// RegisterStandardGroupResourceType registers the core schema and the Group schema, as defined by RFC 7643, and returns
+// the Group resource type served at "/Groups". Like RegisterStandardUserResourceType, custom schema extensions can then
+// be overlaid onto the returned resource type with AddExtension.
+func RegisterStandardGroupResourceType() (*ResourceType, error) {
+	return registerStandardResourceType(standardGroupResourceType, standardCoreSchema, standardGroupSchema)
+}
+
+// Registers the schemas, in order, and parses the resource type, from the embedded standard definitions.
+func registerStandardResourceType(resourceTypeFile string, schemaFiles ...string) (*ResourceType, error) {
+	for _, path := range schemaFiles {
+		raw, err := fs.ReadFile(standardFS, path)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := ParseSchema(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%w (file:'%s')", err, path)
+		}
+		if err := Schemas().Register(schema); err != nil {
+			return nil, fmt.Errorf("%w (file:'%s')", err, path)
+		}
+	}
+
+	raw, err := fs.ReadFile(standardFS, resourceTypeFile)
+	if err != nil {
+		return nil, err
+	}
+	resourceType, err := ParseResourceType(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w (file:'%s')", err, resourceTypeFile)
+	}
+	return resourceType, nil
+}
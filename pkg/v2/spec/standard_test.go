@@ -0,0 +1,38 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterStandardResourceTypes(t *testing.T) {
+	user, err := RegisterStandardUserResourceType()
+	require.Nil(t, err)
+	assert.Equal(t, "User", user.ID())
+	assert.Equal(t, "/Users", user.Endpoint())
+	assert.Equal(t, "urn:ietf:params:scim:schemas:core:2.0:User", user.Schema().ID())
+	assert.Equal(t, 1, user.CountExtensions())
+	_, ok := Schemas().Get(CoreSchemaId)
+	assert.True(t, ok)
+
+	group, err := RegisterStandardGroupResourceType()
+	require.Nil(t, err)
+	assert.Equal(t, "/Groups", group.Endpoint())
+	assert.Equal(t, 0, group.CountExtensions())
+
+	// registering again yields identical definitions, so custom extensions can be overlaid onto each
+	again, err := RegisterStandardUserResourceType()
+	require.Nil(t, err)
+	assert.True(t, user != again)
+
+	ext := &Schema{id: "urn:example:params:scim:schemas:extension:2.0:Standard", attributes: []*Attribute{
+		{id: "urn:example:params:scim:schemas:extension:2.0:Standard:badge", name: "badge",
+			path: "urn:example:params:scim:schemas:extension:2.0:Standard:badge", typ: TypeString},
+	}}
+	require.Nil(t, Schemas().Register(ext))
+	require.Nil(t, again.AddExtension(ext, false))
+	assert.Equal(t, 2, again.CountExtensions())
+	assert.Equal(t, 1, user.CountExtensions())
+}
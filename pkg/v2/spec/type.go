@@ -1,5 +1,10 @@
 package spec
 
+import (
+	"reflect"
+	"time"
+)
+
 // A SCIM data type
 type Type int
 
@@ -15,26 +20,28 @@ const (
 	TypeComplex
 )
 
-func mustParseType(value string) Type {
+// Parses the type value of the JSON definition, where an empty value stands for the default. The returned boolean
+// is false if the value is not a legal type value.
+func parseType(value string) (Type, bool) {
 	switch value {
 	case "string", "":
-		return TypeString
+		return TypeString, true
 	case "integer":
-		return TypeInteger
+		return TypeInteger, true
 	case "decimal":
-		return TypeDecimal
+		return TypeDecimal, true
 	case "boolean":
-		return TypeBoolean
+		return TypeBoolean, true
 	case "dateTime":
-		return TypeDateTime
+		return TypeDateTime, true
 	case "reference":
-		return TypeReference
+		return TypeReference, true
 	case "binary":
-		return TypeBinary
+		return TypeBinary, true
 	case "complex":
-		return TypeComplex
+		return TypeComplex, true
 	default:
-		panic("invalid type value")
+		return TypeString, false
 	}
 }
 
@@ -60,3 +67,35 @@ func (t Type) String() string {
 		panic("invalid type")
 	}
 }
+
+// Go types that SCIM data types are decoded into
+var (
+	goTypeString  = reflect.TypeOf("")
+	goTypeInteger = reflect.TypeOf(int64(0))
+	goTypeDecimal = reflect.TypeOf(float64(0))
+	goTypeBoolean = reflect.TypeOf(false)
+	goTypeTime    = reflect.TypeOf(time.Time{})
+	goTypeBinary  = reflect.TypeOf([]byte{})
+	goTypeComplex = reflect.TypeOf(map[string]interface{}{})
+)
+
+func (t Type) goType() reflect.Type {
+	switch t {
+	case TypeString, TypeReference:
+		return goTypeString
+	case TypeInteger:
+		return goTypeInteger
+	case TypeDecimal:
+		return goTypeDecimal
+	case TypeBoolean:
+		return goTypeBoolean
+	case TypeDateTime:
+		return goTypeTime
+	case TypeBinary:
+		return goTypeBinary
+	case TypeComplex:
+		return goTypeComplex
+	default:
+		panic("invalid type")
+	}
+}
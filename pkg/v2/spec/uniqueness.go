@@ -1,5 +1,7 @@
 package spec
 
+import "fmt"
+
 // SCIM uniqueness definition
 type Uniqueness int
 
@@ -10,16 +12,19 @@ const (
 	UniquenessGlobal
 )
 
-func mustParseUniqueness(value string) Uniqueness {
+// ParseUniqueness parses the uniqueness characteristic of an attribute from its string form defined in RFC7643, which is
+// case sensitive. An empty value stands for the default uniqueness, as it does in the JSON definition of a schema. Any
+// other value is rejected with ErrInvalidValue.
+func ParseUniqueness(value string) (Uniqueness, error) {
 	switch value {
 	case "none", "":
-		return UniquenessNone
+		return UniquenessNone, nil
 	case "server":
-		return UniquenessServer
+		return UniquenessServer, nil
 	case "global":
-		return UniquenessGlobal
+		return UniquenessGlobal, nil
 	default:
-		panic("invalid uniqueness value")
+		return UniquenessNone, fmt.Errorf("%w: '%s' is not a valid uniqueness", ErrInvalidValue, value)
 	}
 }
 
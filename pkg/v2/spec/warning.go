@@ -0,0 +1,23 @@
+package spec
+
+import "sync/atomic"
+
+// WarningHandler receives the warnings raised while loading or registering schemas, i.e. for unknown annotations
+// under AnnotationPolicyWarn, or the findings of LintSchema under LintPolicyWarn. Each warning is an error wrapping ErrInvalidValue, as it would be returned under the
+// rejecting policy.
+type WarningHandler func(warning error)
+
+var warningHandler atomic.Value
+
+// SetWarningHandler sets the handler of the warnings raised by schemas loaded or registered afterwards. Without a
+// handler, which is the default, warnings are discarded.
+func SetWarningHandler(handler WarningHandler) {
+	warningHandler.Store(handler)
+}
+
+// Passes the warning to the handler set by SetWarningHandler, if any.
+func warn(warning error) {
+	if handler, _ := warningHandler.Load().(WarningHandler); handler != nil {
+		handler(warning)
+	}
+}
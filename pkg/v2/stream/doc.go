@@ -0,0 +1,2 @@
+// This package processes streams of resources, i.e. exports in newline delimited JSON (NDJSON), one resource at a time.
+package stream
@@ -0,0 +1,56 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/crud/expr"
+	scimjson "github.com/imulab/go-scim/pkg/v2/json"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+)
+
+// Filter reads newline delimited JSON (NDJSON) resources of the given resource type from the reader, and calls
+// out with each resource that satisfies the compiled filter. A nil filter matches all resources. Resources are decoded
+// and evaluated one line at a time, so that memory usage is bounded by the size of a single resource regardless of the
+// size of the stream. Blank lines are skipped.
+//
+// Errors from decoding or evaluating a resource are reported with the 1-based line number, i.e.
+// "invalidSyntax: ... (line:3)". The first error, including the one returned by out, stops the processing.
+func Filter(r io.Reader, rt *spec.ResourceType, filter *expr.Expression, out func(*prop.Resource) error) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("%w: failed to read stream (line:%d)", spec.ErrInternal, line)
+		}
+
+		if raw = bytes.TrimSpace(raw); len(raw) > 0 {
+			resource := prop.NewResource(rt)
+			if err := scimjson.Deserialize(raw, resource); err != nil {
+				return fmt.Errorf("%w (line:%d)", err, line)
+			}
+
+			match := true
+			if filter != nil {
+				var err error
+				if match, err = crud.EvaluateExpressionOnProperty(resource.RootProperty(), filter); err != nil {
+					return fmt.Errorf("%w (line:%d)", err, line)
+				}
+			}
+
+			if match {
+				if err := out(resource); err != nil {
+					return err
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/imulab/go-scim/pkg/v2/crud"
+	"github.com/imulab/go-scim/pkg/v2/crud/expr"
+	"github.com/imulab/go-scim/pkg/v2/prop"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFilter(t *testing.T) {
+	s := new(FilterTestSuite)
+	suite.Run(t, s)
+}
+
+type FilterTestSuite struct {
+	suite.Suite
+	resourceType *spec.ResourceType
+}
+
+func (s *FilterTestSuite) TestFilter() {
+	const stream = `{"id": "a1", "emails": [{"value": "a1@foo.com", "type": "work"}]}
+{"id": "b1", "emails": [{"value": "b1@foo.com", "type": "home"}]}
+
+{"id": "a2"}
+{"id": "b2"}`
+
+	tests := []struct {
+		name   string
+		stream string
+		filter string
+		out    func(ids *[]string) func(*prop.Resource) error
+		expect func(t *testing.T, ids []string, err error)
+	}{
+		{
+			name:   "no filter",
+			stream: stream,
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"a1", "b1", "a2", "b2"}, ids)
+			},
+		},
+		{
+			name:   "filter",
+			stream: stream,
+			filter: `id sw "a"`,
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"a1", "a2"}, ids)
+			},
+		},
+		{
+			name:   "filter on multiValued attribute",
+			stream: stream,
+			filter: `emails.type eq "home"`,
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"b1"}, ids)
+			},
+		},
+		{
+			name:   "trailing newline",
+			stream: stream + "\n",
+			filter: `id sw "b"`,
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"b1", "b2"}, ids)
+			},
+		},
+		{
+			name:   "decode error reports line number",
+			stream: "{\"id\": \"a1\"}\n\n{\"id\": \"a2\"\n{\"id\": \"a3\"}",
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.True(t, errors.Is(err, spec.ErrInvalidSyntax))
+				assert.True(t, strings.HasSuffix(err.Error(), "(line:3)"))
+				assert.Equal(t, []string{"a1"}, ids)
+			},
+		},
+		{
+			name:   "error from out stops processing",
+			stream: stream,
+			out: func(ids *[]string) func(*prop.Resource) error {
+				return func(r *prop.Resource) error {
+					*ids = append(*ids, r.IdOrEmpty())
+					return spec.ErrTooMany
+				}
+			},
+			expect: func(t *testing.T, ids []string, err error) {
+				assert.True(t, errors.Is(err, spec.ErrTooMany))
+				assert.Equal(t, []string{"a1"}, ids)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			var filter *expr.Expression
+			if len(test.filter) > 0 {
+				var err error
+				filter, err = expr.CompileFilter(test.filter)
+				require.Nil(t, err)
+			}
+
+			ids := make([]string, 0)
+			out := func(r *prop.Resource) error {
+				ids = append(ids, r.IdOrEmpty())
+				return nil
+			}
+			if test.out != nil {
+				out = test.out(&ids)
+			}
+
+			err := Filter(strings.NewReader(test.stream), s.resourceType, filter, out)
+			test.expect(t, ids, err)
+		})
+	}
+}
+
+func (s *FilterTestSuite) SetupSuite() {
+	var err error
+	s.resourceType, err = spec.RegisterStandardUserResourceType()
+	require.Nil(s.T(), err)
+	crud.Register(s.resourceType)
+}